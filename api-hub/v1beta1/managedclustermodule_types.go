@@ -33,7 +33,14 @@ type ManagedClusterModuleSpec struct {
 	JobNamespace string `json:"jobNamespace,omitempty"`
 
 	// Selector describes on which managed clusters the ModuleSpec should be applied.
+	// +optional
 	Selector map[string]string `json:"selector"`
+
+	// LabelSelector is an optional set-based selector that managed clusters must also match for the ModuleSpec to
+	// be applied on them.
+	// It is combined with Selector: managed clusters must match both.
+	// +optional
+	LabelSelector *metav1.LabelSelector `json:"labelSelector,omitempty"`
 }
 
 // ManagedClusterModuleStatus defines the observed state of ManagedClusterModule.
@@ -19,12 +19,33 @@ package v1beta1
 import (
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // BuildArg represents a build argument used when building a container image.
+// Value and ValueFrom are mutually exclusive.
 type BuildArg struct {
-	Name  string `json:"name"`
-	Value string `json:"value"`
+	Name string `json:"name"`
+
+	// +optional
+	Value string `json:"value,omitempty"`
+
+	// +optional
+	// ValueFrom reads the value of the build argument from a ConfigMap or a Secret.
+	ValueFrom *BuildArgSource `json:"valueFrom,omitempty"`
+}
+
+// BuildArgSource references the key of a ConfigMap or of a Secret.
+// Exactly one of ConfigMapKeyRef or SecretKeyRef must be set.
+type BuildArgSource struct {
+	// +optional
+	// ConfigMapKeyRef selects a key of a ConfigMap in the namespace of the Module.
+	ConfigMapKeyRef *v1.ConfigMapKeySelector `json:"configMapKeyRef,omitempty"`
+
+	// +optional
+	// SecretKeyRef selects a key of a Secret in the namespace of the Module.
+	// The value is passed to the build through an environment variable, so that it does not appear in the build Job.
+	SecretKeyRef *v1.SecretKeySelector `json:"secretKeyRef,omitempty"`
 }
 
 type TLSOptions struct {
@@ -42,8 +63,34 @@ type KanikoParams struct {
 	// +optional
 	// Kaniko image tag to use when creating the build Job
 	Tag string `json:"tag,omitempty"`
+
+	// +optional
+	// CacheRepo is the repository in which Kaniko caches the layers of the build.
+	// It is accessed with the credentials of the Module's imageRepoSecret and with the TLS options of the built image.
+	// Defaults to the cache repository of the operator configuration, if any.
+	CacheRepo string `json:"cacheRepo,omitempty"`
+
+	// +optional
+	// CacheTTL is the duration for which cached layers are used.
+	// Defaults to the Kaniko default of two weeks.
+	CacheTTL *metav1.Duration `json:"cacheTTL,omitempty"`
+}
+
+type BuildahParams struct {
+	// +optional
+	// Buildah image tag to use when creating the build Job
+	Tag string `json:"tag,omitempty"`
 }
 
+// BuildBackend is the tool that builds images.
+// +kubebuilder:validation:Enum=Kaniko;Buildah
+type BuildBackend string
+
+const (
+	BuildBackendKaniko  BuildBackend = "Kaniko"
+	BuildBackendBuildah BuildBackend = "Buildah"
+)
+
 type Build struct {
 	// +optional
 	// BuildArgs is an array of build variables that are provided to the image building backend.
@@ -62,9 +109,107 @@ type Build struct {
 	// For container registries auth use module.spec.imagePullSecret instead.
 	Secrets []v1.LocalObjectReference `json:"secrets"`
 
+	// +optional
+	// Backend is the tool that builds the image: Kaniko or Buildah.
+	// Defaults to Kaniko.
+	Backend BuildBackend `json:"backend,omitempty"`
+
 	// +optional
 	// KanikoParams is used to customize the building process of the image.
 	KanikoParams *KanikoParams `json:"kanikoParams,omitempty"`
+
+	// +optional
+	// BuildahParams is used to customize the building process of the image with the Buildah backend.
+	BuildahParams *BuildahParams `json:"buildahParams,omitempty"`
+
+	// +optional
+	// Context lists additional sources of the build context, next to the Dockerfile.
+	Context *BuildContext `json:"context,omitempty"`
+
+	// +optional
+	// PodPolicy controls where the build pods run, their resources and how long and how many times they are retried.
+	PodPolicy *BuildPodPolicy `json:"podPolicy,omitempty"`
+}
+
+// BuildPodPolicy holds the scheduling, resources and retry settings of build pods.
+// Those settings do not change the image, so changing them does not trigger a rebuild.
+type BuildPodPolicy struct {
+	// +optional
+	// NodeSelector selects the nodes that run the build pods.
+	// Defaults to the selector and labelSelector of the Module.
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// +optional
+	// Tolerations of the build pods.
+	Tolerations []v1.Toleration `json:"tolerations,omitempty"`
+
+	// +optional
+	// Resources of the container that builds the image.
+	Resources v1.ResourceRequirements `json:"resources,omitempty"`
+
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// ActiveDeadlineSeconds is the duration after which a build that has not completed is failed.
+	ActiveDeadlineSeconds *int64 `json:"activeDeadlineSeconds,omitempty"`
+
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// BackoffLimit is the number of retries before a build is failed.
+	// Defaults to the Kubernetes default of 6.
+	BackoffLimit *int32 `json:"backoffLimit,omitempty"`
+}
+
+// BuildContext holds the sources that are copied into the build context before the image is built.
+// The Dockerfile from DockerfileConfigMap is always used, even if a source contains its own.
+type BuildContext struct {
+	// +optional
+	// Git is a repository that is cloned at the root of the build context.
+	Git *GitBuildSource `json:"git,omitempty"`
+
+	// +optional
+	// ConfigMaps are mounted in the build context, for instance to provide patches or Kbuild fragments.
+	ConfigMaps []ConfigMapBuildSource `json:"configMaps,omitempty"`
+
+	// +optional
+	// Archive is a gzipped tarball that is extracted at the root of the build context, after Git is cloned.
+	Archive *ArchiveBuildSource `json:"archive,omitempty"`
+}
+
+type GitBuildSource struct {
+	// URL of the repository.
+	// Only http and https repositories are supported.
+	URL string `json:"url"`
+
+	// +optional
+	// Ref is the branch, tag or commit to build.
+	// Defaults to the HEAD of the repository.
+	Ref string `json:"ref,omitempty"`
+
+	// +optional
+	// CredentialsSecret is a kubernetes.io/basic-auth Secret holding the username and password used to access the
+	// repository.
+	CredentialsSecret *v1.LocalObjectReference `json:"credentialsSecret,omitempty"`
+}
+
+type ConfigMapBuildSource struct {
+	// Name of the ConfigMap.
+	Name string `json:"name"`
+
+	// +optional
+	// Path is the directory of the build context in which the keys of the ConfigMap are written.
+	// Defaults to the name of the ConfigMap.
+	Path string `json:"path,omitempty"`
+}
+
+type ArchiveBuildSource struct {
+	// URL of the archive, for instance a pre-signed object store URL.
+	// Only http and https URLs are supported.
+	URL string `json:"url"`
+
+	// +optional
+	// SHA256 is the expected checksum of the archive.
+	// If not set, the ETag or Last-Modified header of the archive is used to detect changes.
+	SHA256 string `json:"sha256,omitempty"`
 }
 
 type Sign struct {
@@ -113,6 +258,45 @@ type KernelMapping struct {
 	// +optional
 	// Regexp is a regular expression to be match against node kernels.
 	Regexp string `json:"regexp"`
+
+	// +optional
+	// VersionRange matches node kernels whose version is within the range.
+	VersionRange *KernelVersionRange `json:"versionRange,omitempty"`
+
+	// +optional
+	// Exclude is a list of kernel versions that never match this mapping, even if they match Literal, Regexp,
+	// VersionRange or Fallback.
+	Exclude []string `json:"exclude,omitempty"`
+
+	// +optional
+	// Fallback makes this mapping match the node kernels that no other mapping matches.
+	// At most one mapping can be a fallback for each Architecture and OSImage.
+	Fallback bool `json:"fallback,omitempty"`
+
+	// +optional
+	// Architecture restricts this mapping to the nodes of an architecture, such as amd64 or arm64.
+	// The kernel names x86_64 and aarch64 are also accepted.
+	Architecture string `json:"architecture,omitempty"`
+
+	// +optional
+	// OSImage is a regular expression restricting this mapping to the nodes whose OS image matches it, such as
+	// "^Red Hat Enterprise Linux" or "^Ubuntu".
+	// Nodes with the same kernel version and architecture must resolve to the same ContainerImage; if they do not, the
+	// module is not loaded on any of them.
+	OSImage string `json:"osImage,omitempty"`
+}
+
+// KernelVersionRange is a range of kernel versions.
+// Versions are compared segment by segment, including the distribution's release, so that
+// 5.14.0-284.11.1.el9_2 is newer than 5.14.0-284 and older than 5.14.0-362.
+type KernelVersionRange struct {
+	// +optional
+	// Min is the oldest kernel version in the range (inclusive).
+	Min string `json:"min,omitempty"`
+
+	// +optional
+	// Max is the first kernel version after the range (exclusive).
+	Max string `json:"max,omitempty"`
 }
 
 type ModprobeArgs struct {
@@ -125,9 +309,28 @@ type ModprobeArgs struct {
 	Unload []string `json:"unload,omitempty"`
 }
 
+// ModprobeModule is a kernel module loaded by modprobe along with its own parameters.
+type ModprobeModule struct {
+	// Name is the name of the kernel module to be loaded.
+	Name string `json:"name"`
+
+	// Parameters is an optional list of kernel module parameters to be provided to modprobe.
+	// They should be in the form of key=value.
+	// +optional
+	Parameters []string `json:"parameters,omitempty"`
+}
+
 type ModprobeSpec struct {
 	// ModuleName is the name of the Module to be loaded.
-	ModuleName string `json:"moduleName"`
+	// Exactly one of ModuleName or Modules must be set, unless RawArgs is used.
+	// +optional
+	ModuleName string `json:"moduleName,omitempty"`
+
+	// Modules is an optional list of kernel modules to be loaded, in order, by separate modprobe invocations.
+	// The kernel modules are unloaded in the reverse order.
+	// Parameters is ignored when Modules is set; each kernel module carries its own parameters instead.
+	// +optional
+	Modules []ModprobeModule `json:"modules,omitempty"`
 
 	// Parameters is an optional list of kernel module parameters to be provided to modprobe.
 	// They should be in the form of key=value and will be separated by spaces in the modprobe command.
@@ -156,6 +359,34 @@ type ModprobeSpec struct {
 	FirmwarePath string `json:"firmwarePath,omitempty"`
 }
 
+// KernelMappingResolver looks up kernel mappings outside of the Module.
+// Exactly one of ConfigMap and HTTP must be set.
+type KernelMappingResolver struct {
+	// ConfigMap is a catalog of kernel mappings, stored as a YAML list under the kernelMappings key.
+	// It is matched like KernelMappings.
+	// +optional
+	ConfigMap *v1.LocalObjectReference `json:"configMap,omitempty"`
+
+	// HTTP is an endpoint that resolves the kernel mapping of a node.
+	// +optional
+	HTTP *HTTPKernelMappingResolver `json:"http,omitempty"`
+}
+
+type HTTPKernelMappingResolver struct {
+	// URL receives GET requests with the kernelVersion, architecture, osImage, namespace and module query parameters.
+	// It must reply with a JSON KernelMapping, or with a 404 status if it has no mapping for the node.
+	URL string `json:"url"`
+
+	// CacheTTL is how long the replies of the endpoint are cached.
+	// Defaults to 10 minutes.
+	// +optional
+	CacheTTL *metav1.Duration `json:"cacheTTL,omitempty"`
+
+	// +optional
+	// If InsecureSkipTLSVerify, the operator will accept any certificate provided by the endpoint.
+	InsecureSkipTLSVerify bool `json:"insecureSkipTLSVerify,omitempty"`
+}
+
 type ModuleLoaderContainerSpec struct {
 	// Build contains build instructions.
 	// +optional
@@ -169,6 +400,12 @@ type ModuleLoaderContainerSpec struct {
 	// +optional
 	ContainerImage string `json:"containerImage,omitempty"`
 
+	// List of environment variables to set in the container.
+	// +optional
+	// +patchMergeKey=name
+	// +patchStrategy=merge
+	Env []v1.EnvVar `json:"env,omitempty" patchStrategy:"merge" patchMergeKey:"name"`
+
 	// Image pull policy.
 	// One of Always, Never, IfNotPresent.
 	// Defaults to Always if :latest tag is specified, or IfNotPresent otherwise.
@@ -180,8 +417,13 @@ type ModuleLoaderContainerSpec struct {
 	// KernelMappings is a list of kernel mappings.
 	// When a node's labels match Selector, then the KMM Operator will look for the first mapping that matches its
 	// kernel version, and use the corresponding container image to run the DriverContainer.
-	// +kubebuilder:validation:MinItems=1
-	KernelMappings []KernelMapping `json:"kernelMappings"`
+	// At least one of KernelMappings and KernelMappingResolver must be set.
+	// +optional
+	KernelMappings []KernelMapping `json:"kernelMappings,omitempty"`
+
+	// KernelMappingResolver is queried for the nodes that match none of KernelMappings.
+	// +optional
+	KernelMappingResolver *KernelMappingResolver `json:"kernelMappingResolver,omitempty"`
 
 	// Modprobe is a set of properties to customize which module modprobe loads and with which properties.
 	Modprobe ModprobeSpec `json:"modprobe"`
@@ -189,16 +431,84 @@ type ModuleLoaderContainerSpec struct {
 	// +optional
 	// RegistryTLS set the TLS configs for accessing the registry of the module-loader's image.
 	RegistryTLS TLSOptions `json:"registryTLS"`
+
+	// Compute Resources required by this container.
+	// More info: https://kubernetes.io/docs/concepts/configuration/manage-resources-containers/
+	// +optional
+	Resources v1.ResourceRequirements `json:"resources,omitempty"`
+
+	// Version is the version of the kernel module.
+	// When set, the module loader only runs on nodes labeled with
+	// kmm.node.kubernetes.io/version-module.<namespace>.<name>=<version>.
+	// Changing the label of a node unloads the previous version of the kernel module before loading the new one.
+	// As label names are limited to 63 characters, the Module's namespace and name must not exceed 40 characters
+	// combined.
+	// +optional
+	Version string `json:"version,omitempty"`
+
+	// VolumeMounts is a list of volume mounts that are appended to the default ones.
+	// +optional
+	VolumeMounts []v1.VolumeMount `json:"volumeMounts,omitempty"`
 }
 
 type ModuleLoaderSpec struct {
 	// Container holds the properties for the module loader container that runs modprobe.
 	Container ModuleLoaderContainerSpec `json:"container"`
 
+	// Affinity is the scheduling constraints of the module loader pods.
+	// Any node affinity is combined with the Module's selectors.
+	// +optional
+	Affinity *v1.Affinity `json:"affinity,omitempty"`
+
+	// PriorityClassName is the priority class of the module loader pods.
+	// Defaults to system-node-critical.
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+
 	// +optional
 	// ServiceAccountName is the name of the ServiceAccount to use to run this pod.
 	// More info: https://kubernetes.io/docs/tasks/configure-pod-container/configure-service-account/
 	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+
+	// Tolerations are applied to the module loader pods.
+	// Nodes with NoSchedule taints are only targeted if those taints are tolerated.
+	// +optional
+	Tolerations []v1.Toleration `json:"tolerations,omitempty"`
+
+	// UpgradePolicy controls how the module loader pods are replaced when the Module changes.
+	// If it is not set, the DaemonSet's default rolling update is used.
+	// +optional
+	UpgradePolicy *UpgradePolicy `json:"upgradePolicy,omitempty"`
+
+	// Volumes is a list of volumes that are appended to the default ones.
+	// +optional
+	Volumes []v1.Volume `json:"volumes,omitempty"`
+}
+
+// UpgradePolicy describes how the KMM Operator replaces module loader pods, node by node.
+type UpgradePolicy struct {
+	// MaxUnavailable is the maximum number of nodes on which the kernel module can be unavailable during an upgrade.
+	// Value can be an absolute number (ex: 5) or a percentage of the targeted nodes (ex: 10%).
+	// Defaults to 1.
+	// +optional
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+
+	// Cordon marks the node unschedulable before the kernel module is unloaded, and schedulable again once the new
+	// kernel module is loaded.
+	// +optional
+	Cordon bool `json:"cordon,omitempty"`
+
+	// Drain evicts the pods running on the node before the kernel module is unloaded.
+	// Pods managed by DaemonSets are not evicted.
+	// Drain implies Cordon.
+	// +optional
+	Drain bool `json:"drain,omitempty"`
+
+	// VerifyLoad only considers a node upgraded once the kernel modules loaded on it are those of the new image,
+	// according to their srcversion.
+	// It is checked by a startup probe, so the node's ready label is not removed if the check fails later on.
+	// +optional
+	VerifyLoad bool `json:"verifyLoad,omitempty"`
 }
 
 type DevicePluginContainerSpec struct {
@@ -281,7 +591,34 @@ type ModuleSpec struct {
 	ImageRepoSecret *v1.LocalObjectReference `json:"imageRepoSecret,omitempty"`
 
 	// Selector describes on which nodes the Module should be loaded and optionally built.
+	// At least one of Selector and LabelSelector is required.
+	// +optional
 	Selector map[string]string `json:"selector"`
+
+	// LabelSelector is an optional set-based selector that nodes must also match for the Module to be loaded on
+	// them.
+	// It is combined with Selector: nodes must match both.
+	// +optional
+	LabelSelector *metav1.LabelSelector `json:"labelSelector,omitempty"`
+
+	// Dependencies is an optional list of names of Modules, in the same namespace, that must be loaded on a node
+	// before this Module can be loaded there.
+	// This Module is unloaded before its dependencies.
+	// +optional
+	// +listType=set
+	Dependencies []string `json:"dependencies,omitempty"`
+
+	// Paused stops the reconciliation of the Module.
+	// Existing DaemonSets, build and signing jobs are left as they are until Paused is unset.
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+
+	// RebuildTrigger is an arbitrary value.
+	// Changing it rebuilds and re-signs the images of all kernel versions, even if they already exist, and then
+	// restarts the module loader pods.
+	// While it is set, the module loader pods run their image pinned by its digest.
+	// +optional
+	RebuildTrigger string `json:"rebuildTrigger,omitempty"`
 }
 
 // DaemonSetStatus contains the status for a daemonset deployed during
@@ -295,6 +632,97 @@ type DaemonSetStatus struct {
 	AvailableNumber int32 `json:"availableNumber"`
 }
 
+const (
+	// ModuleConditionReady is True when the kernel module is loaded on all targeted nodes and the device plugin,
+	// if any, is running on all of them.
+	ModuleConditionReady string = "Ready"
+	// ModuleConditionProgressing is True when at least one kernel version is still being built, signed or loaded.
+	ModuleConditionProgressing string = "Progressing"
+	// ModuleConditionDegraded is True when the reconciliation of at least one kernel version failed.
+	ModuleConditionDegraded string = "Degraded"
+	// ModuleConditionPaused is True when the reconciliation of the Module is paused.
+	ModuleConditionPaused string = "Paused"
+	// ModuleConditionReferencesValid is False when a ConfigMap or Secret referenced by the Module is missing or
+	// invalid.
+	ModuleConditionReferencesValid string = "ReferencesValid"
+)
+
+// InvalidReference describes a ConfigMap or Secret referenced by the Module that is missing or invalid.
+type InvalidReference struct {
+	// Kind is the kind of the referenced object: ConfigMap or Secret.
+	// +kubebuilder:validation:Enum=ConfigMap;Secret
+	Kind string `json:"kind"`
+
+	// Name is the name of the referenced object, in the namespace of the Module.
+	Name string `json:"name"`
+
+	// Message explains why the reference is invalid.
+	Message string `json:"message"`
+}
+
+type KernelVersionStage string
+
+const (
+	KernelVersionStageBuild        KernelVersionStage = "Build"
+	KernelVersionStageSign         KernelVersionStage = "Sign"
+	KernelVersionStageModuleLoader KernelVersionStage = "ModuleLoader"
+	KernelVersionStageLoaded       KernelVersionStage = "Loaded"
+)
+
+// KernelVersionStatus contains the progress of the Module for a single kernel version.
+type KernelVersionStatus struct {
+	// KernelVersion is the node kernel version this status applies to.
+	KernelVersion string `json:"kernelVersion"`
+
+	// Architecture is the node architecture this status applies to.
+	// +kubebuilder:default=""
+	// +optional
+	Architecture string `json:"architecture"`
+
+	// Mapping describes the KernelMapping that matched KernelVersion: its Literal, Regexp, VersionRange or
+	// "fallback".
+	// +optional
+	Mapping string `json:"mapping,omitempty"`
+
+	// MappingSource is where the mapping was found: "KernelMappings", "ConfigMap/<name>" for a resolver ConfigMap or
+	// the URL of a resolver endpoint.
+	// +optional
+	MappingSource string `json:"mappingSource,omitempty"`
+
+	// ContainerImage is the module-loader image resolved for KernelVersion.
+	// +optional
+	ContainerImage string `json:"containerImage,omitempty"`
+
+	// Stage is the current stage of KernelVersion: Build, Sign, ModuleLoader (loader DaemonSet not yet available
+	// on all nodes) or Loaded.
+	// +kubebuilder:validation:Enum=Build;Sign;ModuleLoader;Loaded
+	Stage KernelVersionStage `json:"stage"`
+
+	// LastError is the last error that occurred while handling Stage, if any.
+	// +optional
+	LastError string `json:"lastError,omitempty"`
+
+	// FailedJobLogs is the name of the ConfigMap holding the last lines of the logs of the failed containers of the
+	// build or signing job, if it has failed.
+	// +optional
+	FailedJobLogs string `json:"failedJobLogs,omitempty"`
+
+	// FailedJobHash identifies the inputs of the build or signing job, if it has failed. No job is run again for
+	// the same inputs, even once the failed job has been garbage-collected.
+	// +optional
+	FailedJobHash string `json:"failedJobHash,omitempty"`
+
+	// RebuildTrigger is the value of spec.rebuildTrigger for which the image of KernelVersion was last rebuilt
+	// and re-signed.
+	// +optional
+	RebuildTrigger string `json:"rebuildTrigger,omitempty"`
+
+	// LastTransitionTime is the last time Stage or LastError changed.
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Format=date-time
+	LastTransitionTime metav1.Time `json:"lastTransitionTime"`
+}
+
 // ModuleStatus defines the observed state of Module.
 type ModuleStatus struct {
 	// DevicePlugin contains the status of the Device Plugin daemonset
@@ -302,6 +730,23 @@ type ModuleStatus struct {
 	DevicePlugin DaemonSetStatus `json:"devicePlugin,omitempty"`
 	// ModuleLoader contains the status of the ModuleLoader daemonset
 	ModuleLoader DaemonSetStatus `json:"moduleLoader"`
+
+	// Conditions contains the Ready, Progressing, Degraded, Paused and ReferencesValid conditions of the Module.
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// KernelVersions contains the progress of each kernel version and architecture targeted by the Module.
+	// +listType=map
+	// +listMapKey=kernelVersion
+	// +listMapKey=architecture
+	// +optional
+	KernelVersions []KernelVersionStatus `json:"kernelVersions,omitempty"`
+
+	// InvalidReferences lists the ConfigMaps and Secrets referenced by the Module that are missing or invalid.
+	// +optional
+	InvalidReferences []InvalidReference `json:"invalidReferences,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -39,6 +39,11 @@ type PreflightValidationSpec struct {
 	// +kubebuilder:validation:Required
 	KernelVersion string `json:"kernelVersion"`
 
+	// Architecture is the architecture of the nodes that will run KernelVersion, such as amd64 or arm64.
+	// It selects the image to check in multi-platform images. Defaults to the architecture of the operator.
+	// +optional
+	Architecture string `json:"architecture,omitempty"`
+
 	// Boolean flag that determines whether images build during preflight must also
 	// be pushed to a defined repository
 	// +optional
@@ -23,16 +23,35 @@ package v1beta1
 
 import (
 	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ArchiveBuildSource) DeepCopyInto(out *ArchiveBuildSource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArchiveBuildSource.
+func (in *ArchiveBuildSource) DeepCopy() *ArchiveBuildSource {
+	if in == nil {
+		return nil
+	}
+	out := new(ArchiveBuildSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Build) DeepCopyInto(out *Build) {
 	*out = *in
 	if in.BuildArgs != nil {
 		in, out := &in.BuildArgs, &out.BuildArgs
 		*out = make([]BuildArg, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	if in.DockerfileConfigMap != nil {
 		in, out := &in.DockerfileConfigMap, &out.DockerfileConfigMap
@@ -48,8 +67,23 @@ func (in *Build) DeepCopyInto(out *Build) {
 	if in.KanikoParams != nil {
 		in, out := &in.KanikoParams, &out.KanikoParams
 		*out = new(KanikoParams)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.BuildahParams != nil {
+		in, out := &in.BuildahParams, &out.BuildahParams
+		*out = new(BuildahParams)
 		**out = **in
 	}
+	if in.Context != nil {
+		in, out := &in.Context, &out.Context
+		*out = new(BuildContext)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PodPolicy != nil {
+		in, out := &in.PodPolicy, &out.PodPolicy
+		*out = new(BuildPodPolicy)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Build.
@@ -65,6 +99,11 @@ func (in *Build) DeepCopy() *Build {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BuildArg) DeepCopyInto(out *BuildArg) {
 	*out = *in
+	if in.ValueFrom != nil {
+		in, out := &in.ValueFrom, &out.ValueFrom
+		*out = new(BuildArgSource)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BuildArg.
@@ -77,6 +116,116 @@ func (in *BuildArg) DeepCopy() *BuildArg {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BuildArgSource) DeepCopyInto(out *BuildArgSource) {
+	*out = *in
+	if in.ConfigMapKeyRef != nil {
+		in, out := &in.ConfigMapKeyRef, &out.ConfigMapKeyRef
+		*out = new(v1.ConfigMapKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SecretKeyRef != nil {
+		in, out := &in.SecretKeyRef, &out.SecretKeyRef
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BuildArgSource.
+func (in *BuildArgSource) DeepCopy() *BuildArgSource {
+	if in == nil {
+		return nil
+	}
+	out := new(BuildArgSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BuildContext) DeepCopyInto(out *BuildContext) {
+	*out = *in
+	if in.Git != nil {
+		in, out := &in.Git, &out.Git
+		*out = new(GitBuildSource)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ConfigMaps != nil {
+		in, out := &in.ConfigMaps, &out.ConfigMaps
+		*out = make([]ConfigMapBuildSource, len(*in))
+		copy(*out, *in)
+	}
+	if in.Archive != nil {
+		in, out := &in.Archive, &out.Archive
+		*out = new(ArchiveBuildSource)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BuildContext.
+func (in *BuildContext) DeepCopy() *BuildContext {
+	if in == nil {
+		return nil
+	}
+	out := new(BuildContext)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BuildPodPolicy) DeepCopyInto(out *BuildPodPolicy) {
+	*out = *in
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]v1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.Resources.DeepCopyInto(&out.Resources)
+	if in.ActiveDeadlineSeconds != nil {
+		in, out := &in.ActiveDeadlineSeconds, &out.ActiveDeadlineSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.BackoffLimit != nil {
+		in, out := &in.BackoffLimit, &out.BackoffLimit
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BuildPodPolicy.
+func (in *BuildPodPolicy) DeepCopy() *BuildPodPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(BuildPodPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BuildahParams) DeepCopyInto(out *BuildahParams) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BuildahParams.
+func (in *BuildahParams) DeepCopy() *BuildahParams {
+	if in == nil {
+		return nil
+	}
+	out := new(BuildahParams)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CRStatus) DeepCopyInto(out *CRStatus) {
 	*out = *in
@@ -93,6 +242,21 @@ func (in *CRStatus) DeepCopy() *CRStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConfigMapBuildSource) DeepCopyInto(out *ConfigMapBuildSource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigMapBuildSource.
+func (in *ConfigMapBuildSource) DeepCopy() *ConfigMapBuildSource {
+	if in == nil {
+		return nil
+	}
+	out := new(ConfigMapBuildSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DaemonSetStatus) DeepCopyInto(out *DaemonSetStatus) {
 	*out = *in
@@ -171,9 +335,69 @@ func (in *DevicePluginSpec) DeepCopy() *DevicePluginSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitBuildSource) DeepCopyInto(out *GitBuildSource) {
+	*out = *in
+	if in.CredentialsSecret != nil {
+		in, out := &in.CredentialsSecret, &out.CredentialsSecret
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitBuildSource.
+func (in *GitBuildSource) DeepCopy() *GitBuildSource {
+	if in == nil {
+		return nil
+	}
+	out := new(GitBuildSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPKernelMappingResolver) DeepCopyInto(out *HTTPKernelMappingResolver) {
+	*out = *in
+	if in.CacheTTL != nil {
+		in, out := &in.CacheTTL, &out.CacheTTL
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPKernelMappingResolver.
+func (in *HTTPKernelMappingResolver) DeepCopy() *HTTPKernelMappingResolver {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPKernelMappingResolver)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InvalidReference) DeepCopyInto(out *InvalidReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InvalidReference.
+func (in *InvalidReference) DeepCopy() *InvalidReference {
+	if in == nil {
+		return nil
+	}
+	out := new(InvalidReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KanikoParams) DeepCopyInto(out *KanikoParams) {
 	*out = *in
+	if in.CacheTTL != nil {
+		in, out := &in.CacheTTL, &out.CacheTTL
+		*out = new(metav1.Duration)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KanikoParams.
@@ -204,6 +428,16 @@ func (in *KernelMapping) DeepCopyInto(out *KernelMapping) {
 		*out = new(TLSOptions)
 		**out = **in
 	}
+	if in.VersionRange != nil {
+		in, out := &in.VersionRange, &out.VersionRange
+		*out = new(KernelVersionRange)
+		**out = **in
+	}
+	if in.Exclude != nil {
+		in, out := &in.Exclude, &out.Exclude
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KernelMapping.
@@ -216,6 +450,62 @@ func (in *KernelMapping) DeepCopy() *KernelMapping {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KernelMappingResolver) DeepCopyInto(out *KernelMappingResolver) {
+	*out = *in
+	if in.ConfigMap != nil {
+		in, out := &in.ConfigMap, &out.ConfigMap
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	if in.HTTP != nil {
+		in, out := &in.HTTP, &out.HTTP
+		*out = new(HTTPKernelMappingResolver)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KernelMappingResolver.
+func (in *KernelMappingResolver) DeepCopy() *KernelMappingResolver {
+	if in == nil {
+		return nil
+	}
+	out := new(KernelMappingResolver)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KernelVersionRange) DeepCopyInto(out *KernelVersionRange) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KernelVersionRange.
+func (in *KernelVersionRange) DeepCopy() *KernelVersionRange {
+	if in == nil {
+		return nil
+	}
+	out := new(KernelVersionRange)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KernelVersionStatus) DeepCopyInto(out *KernelVersionStatus) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KernelVersionStatus.
+func (in *KernelVersionStatus) DeepCopy() *KernelVersionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KernelVersionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ModprobeArgs) DeepCopyInto(out *ModprobeArgs) {
 	*out = *in
@@ -241,6 +531,26 @@ func (in *ModprobeArgs) DeepCopy() *ModprobeArgs {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ModprobeModule) DeepCopyInto(out *ModprobeModule) {
+	*out = *in
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ModprobeModule.
+func (in *ModprobeModule) DeepCopy() *ModprobeModule {
+	if in == nil {
+		return nil
+	}
+	out := new(ModprobeModule)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ModprobeSpec) DeepCopyInto(out *ModprobeSpec) {
 	*out = *in
@@ -249,6 +559,13 @@ func (in *ModprobeSpec) DeepCopyInto(out *ModprobeSpec) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Modules != nil {
+		in, out := &in.Modules, &out.Modules
+		*out = make([]ModprobeModule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.Args != nil {
 		in, out := &in.Args, &out.Args
 		*out = new(ModprobeArgs)
@@ -277,7 +594,7 @@ func (in *Module) DeepCopyInto(out *Module) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Module.
@@ -343,6 +660,13 @@ func (in *ModuleLoaderContainerSpec) DeepCopyInto(out *ModuleLoaderContainerSpec
 		*out = new(Sign)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]v1.EnvVar, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.KernelMappings != nil {
 		in, out := &in.KernelMappings, &out.KernelMappings
 		*out = make([]KernelMapping, len(*in))
@@ -350,8 +674,21 @@ func (in *ModuleLoaderContainerSpec) DeepCopyInto(out *ModuleLoaderContainerSpec
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.KernelMappingResolver != nil {
+		in, out := &in.KernelMappingResolver, &out.KernelMappingResolver
+		*out = new(KernelMappingResolver)
+		(*in).DeepCopyInto(*out)
+	}
 	in.Modprobe.DeepCopyInto(&out.Modprobe)
 	out.RegistryTLS = in.RegistryTLS
+	in.Resources.DeepCopyInto(&out.Resources)
+	if in.VolumeMounts != nil {
+		in, out := &in.VolumeMounts, &out.VolumeMounts
+		*out = make([]v1.VolumeMount, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ModuleLoaderContainerSpec.
@@ -368,6 +705,30 @@ func (in *ModuleLoaderContainerSpec) DeepCopy() *ModuleLoaderContainerSpec {
 func (in *ModuleLoaderSpec) DeepCopyInto(out *ModuleLoaderSpec) {
 	*out = *in
 	in.Container.DeepCopyInto(&out.Container)
+	if in.Affinity != nil {
+		in, out := &in.Affinity, &out.Affinity
+		*out = new(v1.Affinity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]v1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.UpgradePolicy != nil {
+		in, out := &in.UpgradePolicy, &out.UpgradePolicy
+		*out = new(UpgradePolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Volumes != nil {
+		in, out := &in.Volumes, &out.Volumes
+		*out = make([]v1.Volume, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ModuleLoaderSpec.
@@ -401,6 +762,16 @@ func (in *ModuleSpec) DeepCopyInto(out *ModuleSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.LabelSelector != nil {
+		in, out := &in.LabelSelector, &out.LabelSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Dependencies != nil {
+		in, out := &in.Dependencies, &out.Dependencies
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ModuleSpec.
@@ -418,6 +789,25 @@ func (in *ModuleStatus) DeepCopyInto(out *ModuleStatus) {
 	*out = *in
 	out.DevicePlugin = in.DevicePlugin
 	out.ModuleLoader = in.ModuleLoader
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.KernelVersions != nil {
+		in, out := &in.KernelVersions, &out.KernelVersions
+		*out = make([]KernelVersionStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.InvalidReferences != nil {
+		in, out := &in.InvalidReferences, &out.InvalidReferences
+		*out = make([]InvalidReference, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ModuleStatus.
@@ -579,3 +969,23 @@ func (in *TLSOptions) DeepCopy() *TLSOptions {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradePolicy) DeepCopyInto(out *UpgradePolicy) {
+	*out = *in
+	if in.MaxUnavailable != nil {
+		in, out := &in.MaxUnavailable, &out.MaxUnavailable
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradePolicy.
+func (in *UpgradePolicy) DeepCopy() *UpgradePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
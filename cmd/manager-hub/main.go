@@ -22,15 +22,19 @@ import (
 	"os"
 
 	"github.com/kubernetes-sigs/kernel-module-management/api-hub/v1beta1"
+	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
 	"github.com/kubernetes-sigs/kernel-module-management/controllers/hub"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/cmd"
+	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/klog/v2"
 	"k8s.io/klog/v2/klogr"
 	workv1 "open-cluster-management.io/api/work/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
@@ -40,7 +44,9 @@ import (
 	"github.com/kubernetes-sigs/kernel-module-management/internal/build"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/build/job"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/cluster"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/dtk"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/filter"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/joblogs"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/manifestwork"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/metrics"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/module"
@@ -90,11 +96,30 @@ func main() {
 		cmd.FatalError(setupLogger, err, "unable to load the config file")
 	}
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), options)
+	// pods are only read to collect the logs of build and signing Jobs, so that the hub does not cache all of its pods
+	jobPods, err := joblogs.JobPodsSelector()
+	if err != nil {
+		cmd.FatalError(setupLogger, err, "unable to create the selector of the pods of Jobs")
+	}
+
+	options.NewCache = cache.BuilderWithOptions(cache.Options{
+		SelectorsByObject: cache.SelectorsByObject{
+			&v1.Pod{}: {Label: jobPods},
+		},
+	})
+
+	restConfig := ctrl.GetConfigOrDie()
+
+	mgr, err := ctrl.NewManager(restConfig, options)
 	if err != nil {
 		cmd.FatalError(setupLogger, err, "unable to create manager")
 	}
 
+	clientset, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		cmd.FatalError(setupLogger, err, "unable to create the Kubernetes clientset")
+	}
+
 	client := mgr.GetClient()
 
 	filterAPI := filter.New(client, mgr.GetLogger())
@@ -105,18 +130,61 @@ func main() {
 	registryAPI := registry.NewRegistry()
 	jobHelperAPI := utils.NewJobHelper(client)
 
-	buildAPI := job.NewBuildManager(
+	dtkCatalog, err := dtk.NewConfigMapCatalogFromEnv(client)
+	if err != nil {
+		cmd.FatalError(setupLogger, err, "unable to create the Driver Toolkit catalog")
+	}
+
+	buildContextImage, err := job.BuildContextImageFromEnv()
+	if err != nil {
+		cmd.FatalError(setupLogger, err, "unable to determine the build context image")
+	}
+
+	kanikoCacheRepo, err := job.KanikoCacheRepoFromEnv()
+	if err != nil {
+		cmd.FatalError(setupLogger, err, "unable to determine the Kaniko cache repository")
+	}
+
+	failedJobRetention, err := joblogs.RetentionFromEnv()
+	if err != nil {
+		cmd.FatalError(setupLogger, err, "unable to determine the retention of failed jobs")
+	}
+
+	jobLogsAPI := joblogs.NewCollector(client, clientset.CoreV1(), mgr.GetEventRecorderFor("kmm-hub"), scheme)
+
+	kanikoBuildAPI := job.NewBuildManager(
 		client,
-		job.NewMaker(client, build.NewHelper(), jobHelperAPI, scheme),
+		job.NewKanikoMaker(client, build.NewHelper(), jobHelperAPI, dtkCatalog, buildContextImage, kanikoCacheRepo, scheme),
 		jobHelperAPI,
 		registryAPI,
+		jobLogsAPI,
+		failedJobRetention,
+	)
+
+	buildahBuildAPI := job.NewBuildManager(
+		client,
+		job.NewBuildahMaker(client, build.NewHelper(), jobHelperAPI, dtkCatalog, buildContextImage, scheme),
+		jobHelperAPI,
+		registryAPI,
+		jobLogsAPI,
+		failedJobRetention,
+	)
+
+	buildAPI := build.NewBackendManager(
+		build.NewHelper(),
+		map[kmmv1beta1.BuildBackend]build.Manager{
+			kmmv1beta1.BuildBackendKaniko:  kanikoBuildAPI,
+			kmmv1beta1.BuildBackendBuildah: buildahBuildAPI,
+		},
 	)
 
 	signAPI := signjob.NewSignJobManager(
 		client,
-		signjob.NewSigner(client, scheme, sign.NewSignerHelper(), jobHelperAPI),
+		signjob.NewSigner(client, scheme, jobHelperAPI),
 		jobHelperAPI,
 		registryAPI,
+		jobLogsAPI,
+		failedJobRetention,
 	)
 
 	ctrlLogger := setupLogger.WithValues("name", hub.ManagedClusterModuleReconcilerName)
@@ -132,7 +200,7 @@ func main() {
 	mcmr := hub.NewManagedClusterModuleReconciler(
 		client,
 		manifestwork.NewCreator(client, scheme),
-		cluster.NewClusterAPI(client, module.NewKernelMapper(), buildAPI, signAPI, operatorNamespace),
+		cluster.NewClusterAPI(client, module.NewKernelMapper(build.NewHelper(), sign.NewSignerHelper()), buildAPI, signAPI, operatorNamespace),
 		filterAPI,
 	)
 
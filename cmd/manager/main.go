@@ -26,6 +26,7 @@ import (
 	"github.com/kubernetes-sigs/kernel-module-management/internal/cmd"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/klog/v2"
 	"k8s.io/klog/v2/klogr"
@@ -42,7 +43,9 @@ import (
 	"github.com/kubernetes-sigs/kernel-module-management/internal/build/job"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/constants"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/daemonset"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/dtk"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/filter"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/joblogs"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/metrics"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/module"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/preflight"
@@ -51,7 +54,9 @@ import (
 	"github.com/kubernetes-sigs/kernel-module-management/internal/sign"
 	signjob "github.com/kubernetes-sigs/kernel-module-management/internal/sign/job"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/statusupdater"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/upgrade"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/utils"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/webhook"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -90,6 +95,12 @@ func main() {
 		managed = false
 	}
 
+	enableWebhooks, err := GetBoolEnv("ENABLE_WEBHOOKS")
+	if err != nil {
+		setupLogger.Error(err, "could not determine if webhooks should be enabled; disabling")
+		enableWebhooks = false
+	}
+
 	setupLogger.Info("Creating manager", "git commit", commit)
 
 	options := ctrl.Options{Scheme: scheme}
@@ -99,11 +110,18 @@ func main() {
 		cmd.FatalError(setupLogger, err, "unable to load the config file")
 	}
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), options)
+	restConfig := ctrl.GetConfigOrDie()
+
+	mgr, err := ctrl.NewManager(restConfig, options)
 	if err != nil {
 		cmd.FatalError(setupLogger, err, "unable to create manager")
 	}
 
+	clientset, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		cmd.FatalError(setupLogger, err, "unable to create the Kubernetes clientset")
+	}
+
 	client := mgr.GetClient()
 
 	filterAPI := filter.New(client, mgr.GetLogger())
@@ -114,22 +132,66 @@ func main() {
 	registryAPI := registry.NewRegistry()
 	jobHelperAPI := utils.NewJobHelper(client)
 
-	buildAPI := job.NewBuildManager(
+	dtkCatalog, err := dtk.NewConfigMapCatalogFromEnv(client)
+	if err != nil {
+		cmd.FatalError(setupLogger, err, "unable to create the Driver Toolkit catalog")
+	}
+
+	buildContextImage, err := job.BuildContextImageFromEnv()
+	if err != nil {
+		cmd.FatalError(setupLogger, err, "unable to determine the build context image")
+	}
+
+	kanikoCacheRepo, err := job.KanikoCacheRepoFromEnv()
+	if err != nil {
+		cmd.FatalError(setupLogger, err, "unable to determine the Kaniko cache repository")
+	}
+
+	failedJobRetention, err := joblogs.RetentionFromEnv()
+	if err != nil {
+		cmd.FatalError(setupLogger, err, "unable to determine the retention of failed jobs")
+	}
+
+	jobLogsAPI := joblogs.NewCollector(client, clientset.CoreV1(), mgr.GetEventRecorderFor("kmm"), scheme)
+
+	kanikoBuildAPI := job.NewBuildManager(
 		client,
-		job.NewMaker(client, build.NewHelper(), jobHelperAPI, scheme),
+		job.NewKanikoMaker(client, build.NewHelper(), jobHelperAPI, dtkCatalog, buildContextImage, kanikoCacheRepo, scheme),
 		jobHelperAPI,
 		registryAPI,
+		jobLogsAPI,
+		failedJobRetention,
+	)
+
+	buildahBuildAPI := job.NewBuildManager(
+		client,
+		job.NewBuildahMaker(client, build.NewHelper(), jobHelperAPI, dtkCatalog, buildContextImage, scheme),
+		jobHelperAPI,
+		registryAPI,
+		jobLogsAPI,
+		failedJobRetention,
+	)
+
+	buildAPI := build.NewBackendManager(
+		build.NewHelper(),
+		map[v1beta12.BuildBackend]build.Manager{
+			v1beta12.BuildBackendKaniko:  kanikoBuildAPI,
+			v1beta12.BuildBackendBuildah: buildahBuildAPI,
+		},
 	)
 
 	signAPI := signjob.NewSignJobManager(
 		client,
-		signjob.NewSigner(client, scheme, sign.NewSignerHelper(), jobHelperAPI),
+		signjob.NewSigner(client, scheme, jobHelperAPI),
 		jobHelperAPI,
 		registryAPI,
+		jobLogsAPI,
+		failedJobRetention,
 	)
 
 	daemonAPI := daemonset.NewCreator(client, constants.KernelLabel, scheme)
-	kernelAPI := module.NewKernelMapper()
+	kernelAPI := module.NewKernelMapper(build.NewHelper(), sign.NewSignerHelper())
+	upgradeAPI := upgrade.NewManager(client, upgrade.NewNodeDrainer(client, clientset.CoreV1()))
 
 	mc := controllers.NewModuleReconciler(
 		client,
@@ -137,10 +199,14 @@ func main() {
 		signAPI,
 		rbac.NewCreator(client, scheme),
 		daemonAPI,
+		upgradeAPI,
 		kernelAPI,
+		module.NewMappingResolver(client, kernelAPI),
+		module.NewReferenceValidator(client),
 		metricsAPI,
 		filterAPI,
 		statusupdater.NewModuleStatusUpdater(client, metricsAPI),
+		registryAPI,
 	)
 
 	if err = mc.SetupWithManager(mgr, constants.KernelLabel); err != nil {
@@ -164,6 +230,12 @@ func main() {
 		cmd.FatalError(setupLogger, err, "unable to create controller", "name", controllers.PreflightValidationReconcilerName)
 	}
 
+	if enableWebhooks {
+		if err = webhook.NewModuleWebhook(client, build.NewHelper(), sign.NewSignerHelper()).SetupWebhookWithManager(mgr); err != nil {
+			cmd.FatalError(setupLogger, err, "unable to create webhook", "webhook", "Module")
+		}
+	}
+
 	if managed {
 		setupLogger.Info("Starting as managed")
 
@@ -9,6 +9,8 @@ import (
 	dockertypes "github.com/docker/cli/cli/config/types"
 	"github.com/go-logr/logr"
 	"github.com/google/go-containerregistry/pkg/authn"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/registry"
 	"io"
 	"k8s.io/klog/v2/klogr"
@@ -92,6 +94,24 @@ func die(exitval int, message string, err error) {
 	os.Exit(exitval)
 }
 
+// setImageLabels returns a copy of image with the given labels added to its config
+func setImageLabels(image v1.Image, labels map[string]string) (v1.Image, error) {
+	configFile, err := image.ConfigFile()
+	if err != nil {
+		return nil, fmt.Errorf("could not get the image config: %v", err)
+	}
+
+	cfg := configFile.Config.DeepCopy()
+	if cfg.Labels == nil {
+		cfg.Labels = make(map[string]string, len(labels))
+	}
+	for k, v := range labels {
+		cfg.Labels[k] = v
+	}
+
+	return mutate.Config(image, *cfg)
+}
+
 func processFile(filename string, header *tar.Header, tarreader io.Reader, data []interface{}) error {
 
 	registryObj := data[0].(registry.Registry)
@@ -178,6 +198,8 @@ func main() {
 	var privKeyFile string
 	var pubKeyFile string
 	var nopush bool
+	var platform string
+	labels := make(map[string]string)
 
 	logger = klogr.New()
 
@@ -189,6 +211,15 @@ func main() {
 	flag.StringVar(&pullSecret, "pullsecret", "", "path to file containing credentials for pulling images")
 	flag.StringVar(&pullSecret, "pushsecret", "", "path to file containing credentials for pushing images")
 	flag.BoolVar(&nopush, "no-push", false, "do not push the resulting image")
+	flag.StringVar(&platform, "platform", "", "architecture of the image to sign in a multi-arch unsigned image; defaults to the one of this host")
+	flag.Func("label", "key=value label to set on the signed image, can be repeated", func(s string) error {
+		k, v, ok := strings.Cut(s, "=")
+		if !ok {
+			return fmt.Errorf("%s is not in the key=value format", s)
+		}
+		labels[k] = v
+		return nil
+	})
 
 	flag.Parse()
 
@@ -229,7 +260,7 @@ func main() {
 
 	r := registry.NewRegistry()
 
-	img, err := r.GetImageByName(unsignedImageName, a)
+	img, err := r.GetImageByName(unsignedImageName, a, registry.PlatformForArchitecture(platform))
 	if err != nil {
 		die(3, "could not Image()", err)
 	}
@@ -279,6 +310,13 @@ func main() {
 
 	logger.Info("Appended new layer to image", "image", signedImageName)
 
+	if len(labels) > 0 {
+		signedImage, err = setImageLabels(signedImage, labels)
+		if err != nil {
+			die(6, "failed to set labels on image", err)
+		}
+	}
+
 	if !nopush {
 		a, err = getAuthFromFile(pushSecret, strings.Split(signedImageName, "/")[0])
 		if err != nil {
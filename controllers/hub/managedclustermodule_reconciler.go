@@ -22,6 +22,7 @@ import (
 
 	hubv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api-hub/v1beta1"
 	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
@@ -37,6 +38,7 @@ import (
 
 	"github.com/kubernetes-sigs/kernel-module-management/internal/cluster"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/filter"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/joblogs"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/manifestwork"
 )
 
@@ -58,6 +60,10 @@ type ManagedClusterModuleReconciler struct {
 //+kubebuilder:rbac:groups=work.open-cluster-management.io,resources=manifestworks,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=cluster.open-cluster-management.io,resources=managedclusters,verbs=get;list;watch
 //+kubebuilder:rbac:groups=batch,resources=jobs,verbs=create;list;watch;delete
+//+kubebuilder:rbac:groups="core",resources=pods,verbs=list;watch
+//+kubebuilder:rbac:groups="core",resources=pods/log,verbs=get
+//+kubebuilder:rbac:groups="core",resources=configmaps,verbs=create;delete;get;list;update;watch
+//+kubebuilder:rbac:groups="core",resources=events,verbs=create;patch
 
 func NewManagedClusterModuleReconciler(
 	client client.Client,
@@ -145,6 +151,10 @@ func (r *ManagedClusterModuleReconciler) Reconcile(ctx context.Context, req ctrl
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *ManagedClusterModuleReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &v1.Pod{}, joblogs.JobNameIndexKey, joblogs.IndexJobName); err != nil {
+		return fmt.Errorf("could not index the pods by Job: %v", err)
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&hubv1beta1.ManagedClusterModule{}).
 		Owns(&workv1.ManifestWork{}).
@@ -18,18 +18,23 @@ package controllers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 
 	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/build"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/constants"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/daemonset"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/filter"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/joblogs"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/metrics"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/module"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/rbac"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/registry"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/sign"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/statusupdater"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/upgrade"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/utils"
 	appsv1 "k8s.io/api/apps/v1"
 	batchv1 "k8s.io/api/batch/v1"
@@ -38,6 +43,7 @@ import (
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/sets"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
@@ -50,6 +56,25 @@ import (
 
 const ModuleReconcilerName = "Module"
 
+// jobFailedError is returned for build and signing jobs that exhausted their retries; they are not retried until
+// their inputs change.
+type jobFailedError struct {
+	stage         string
+	reason        string
+	logsConfigMap string
+	failedJobHash string
+}
+
+func (e *jobFailedError) Error() string {
+	msg := fmt.Sprintf("%s failed: %s", e.stage, e.reason)
+
+	if e.logsConfigMap != "" {
+		msg += fmt.Sprintf("; logs are in ConfigMap %s", e.logsConfigMap)
+	}
+
+	return msg
+}
+
 // ModuleReconciler reconciles a Module object
 type ModuleReconciler struct {
 	client.Client
@@ -58,10 +83,14 @@ type ModuleReconciler struct {
 	signAPI          sign.SignManager
 	rbacAPI          rbac.RBACCreator
 	daemonAPI        daemonset.DaemonSetCreator
+	upgradeAPI       upgrade.Manager
 	kernelAPI        module.KernelMapper
+	resolverAPI      module.MappingResolver
+	referenceAPI     module.ReferenceValidator
 	metricsAPI       metrics.Metrics
 	filter           *filter.Filter
 	statusUpdaterAPI statusupdater.ModuleStatusUpdater
+	registryAPI      registry.Registry
 }
 
 func NewModuleReconciler(
@@ -70,29 +99,41 @@ func NewModuleReconciler(
 	signAPI sign.SignManager,
 	rbacAPI rbac.RBACCreator,
 	daemonAPI daemonset.DaemonSetCreator,
+	upgradeAPI upgrade.Manager,
 	kernelAPI module.KernelMapper,
+	resolverAPI module.MappingResolver,
+	referenceAPI module.ReferenceValidator,
 	metricsAPI metrics.Metrics,
 	filter *filter.Filter,
-	statusUpdaterAPI statusupdater.ModuleStatusUpdater) *ModuleReconciler {
+	statusUpdaterAPI statusupdater.ModuleStatusUpdater,
+	registryAPI registry.Registry) *ModuleReconciler {
 	return &ModuleReconciler{
 		Client:           client,
 		buildAPI:         buildAPI,
 		signAPI:          signAPI,
 		rbacAPI:          rbacAPI,
 		daemonAPI:        daemonAPI,
+		upgradeAPI:       upgradeAPI,
 		kernelAPI:        kernelAPI,
+		resolverAPI:      resolverAPI,
+		referenceAPI:     referenceAPI,
 		metricsAPI:       metricsAPI,
 		filter:           filter,
 		statusUpdaterAPI: statusUpdaterAPI,
+		registryAPI:      registryAPI,
 	}
 }
 
 //+kubebuilder:rbac:groups=kmm.sigs.x-k8s.io,resources=modules,verbs=get;list;watch;update;patch
 //+kubebuilder:rbac:groups=kmm.sigs.x-k8s.io,resources=modules/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=apps,resources=daemonsets,verbs=create;delete;get;list;patch;watch
-//+kubebuilder:rbac:groups="core",resources=nodes,verbs=get;list;watch
+//+kubebuilder:rbac:groups="core",resources=nodes,verbs=get;list;patch;watch
+//+kubebuilder:rbac:groups="core",resources=pods,verbs=delete;list
+//+kubebuilder:rbac:groups="core",resources=pods/eviction,verbs=create
+//+kubebuilder:rbac:groups="core",resources=pods/log,verbs=get
 //+kubebuilder:rbac:groups="core",resources=secrets,verbs=get;list;watch
-//+kubebuilder:rbac:groups="core",resources=configmaps,verbs=get;list;watch
+//+kubebuilder:rbac:groups="core",resources=configmaps,verbs=create;delete;get;list;update;watch
+//+kubebuilder:rbac:groups="core",resources=events,verbs=create;patch
 //+kubebuilder:rbac:groups="core",resources=serviceaccounts,verbs=create;delete;get;list;patch;watch
 //+kubebuilder:rbac:groups="batch",resources=jobs,verbs=create;list;watch;delete
 
@@ -108,6 +149,11 @@ func (r *ModuleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 	if err != nil {
 		if k8serrors.IsNotFound(err) {
 			logger.Info("Module deleted")
+
+			if err = r.upgradeAPI.ReleaseNodes(ctx, req.Name, sets.NewString()); err != nil {
+				return res, fmt.Errorf("could not release the nodes upgraded for module %s: %v", req.Name, err)
+			}
+
 			return ctrl.Result{}, nil
 		}
 
@@ -116,6 +162,16 @@ func (r *ModuleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 
 	r.setKMMOMetrics(ctx)
 
+	if mod.Spec.Paused {
+		logger.Info("Module is paused; skipping reconciliation")
+
+		if err = r.statusUpdaterAPI.ModuleSetPaused(ctx, mod); err != nil {
+			return res, fmt.Errorf("failed to update status of the paused module: %w", err)
+		}
+
+		return res, nil
+	}
+
 	if mod.Spec.ModuleLoader.ServiceAccountName == "" {
 		if err := r.rbacAPI.CreateModuleLoaderServiceAccount(ctx, *mod); err != nil {
 			return res, fmt.Errorf("could not create module-loader's ServiceAccount: %w", err)
@@ -132,58 +188,91 @@ func (r *ModuleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		return res, fmt.Errorf("could get targeted nodes for module %s: %w", mod.Name, err)
 	}
 
-	mappings, nodesWithMapping, err := r.getRelevantKernelMappingsAndNodes(ctx, mod, targetedNodes)
+	mappings, sources, osConfigs, nodesWithMapping, err := r.getRelevantKernelMappingsAndNodes(ctx, mod, targetedNodes)
 	if err != nil {
 		return res, fmt.Errorf("could get kernel mappings and nodes for modules %s: %w", mod.Name, err)
 	}
 
-	dsByKernelVersion, err := r.daemonAPI.ModuleDaemonSetsByKernelVersion(ctx, mod.Name, mod.Namespace)
+	invalidReferences, err := r.referenceAPI.ValidateReferences(ctx, mod, mappings)
+	if err != nil {
+		return res, fmt.Errorf("could not validate the ConfigMaps and Secrets referenced by module %s: %w", mod.Name, err)
+	}
+	if len(invalidReferences) > 0 {
+		logger.Info("Some referenced ConfigMaps or Secrets are invalid", "invalid", invalidReferences)
+	}
+	mod.Status.InvalidReferences = invalidReferences
+
+	dsByKey, err := r.daemonAPI.ModuleDaemonSetsByKey(ctx, mod.Name, mod.Namespace)
 	if err != nil {
 		return res, fmt.Errorf("could get DaemonSets for module %s: %v", mod.Name, err)
 	}
 
-	for kernelVersion, m := range mappings {
-		requeue, err := r.handleBuild(ctx, mod, m, kernelVersion)
-		if err != nil {
-			return res, fmt.Errorf("failed to handle build for kernel version %s: %v", kernelVersion, err)
-		}
-		if requeue {
-			logger.Info("Build requires a requeue; skipping handling driver container for now", "kernelVersion", kernelVersion, "image", m)
-			res.Requeue = true
+	kernelVersionStatuses := make(map[module.KernelTarget]*kmmv1beta1.KernelVersionStatus, len(mappings))
+	errs := make([]error, 0)
+
+	for target, m := range mappings {
+		kvs := newKernelVersionStatus(target, m)
+		kvs.MappingSource = sources[target]
+		kvs.RebuildTrigger = lastRebuildTrigger(mod, target)
+		kernelVersionStatuses[target] = kvs
+
+		requeue, err := r.handleKernelVersion(ctx, mod, m, osConfigs[target], dsByKey, target, kvs)
+
+		var jfe *jobFailedError
+		if errors.As(err, &jfe) {
+			logger.Info(
+				"The job has failed; it will be retried once its inputs change",
+				"kernelVersion", target.KernelVersion,
+				"architecture", target.Architecture,
+				"reason", err.Error(),
+			)
+			kvs.LastError = err.Error()
+			kvs.FailedJobLogs = jfe.logsConfigMap
+			kvs.FailedJobHash = jfe.failedJobHash
 			continue
 		}
-
-		signrequeue, err := r.handleSigning(ctx, mod, m, kernelVersion)
 		if err != nil {
-			return res, fmt.Errorf("failed to handle signing for kernel version %s: %v", kernelVersion, err)
+			logger.Error(
+				err,
+				"Failed to handle kernel version; continuing with the other ones",
+				"kernelVersion", target.KernelVersion,
+				"architecture", target.Architecture,
+			)
+			kvs.LastError = err.Error()
+			errs = append(errs, err)
+			continue
 		}
-		if signrequeue {
-			logger.Info("Signing requires a requeue; skipping handling driver container for now", "kernelVersion", kernelVersion, "image", m)
+		if requeue {
 			res.Requeue = true
-			continue
 		}
+	}
 
-		err = r.handleDriverContainer(ctx, mod, m, dsByKernelVersion, kernelVersion)
-		if err != nil {
-			return res, fmt.Errorf("failed to handle driver container for kernel version %s: %v", kernelVersion, err)
-		}
+	logger.Info("Handle module versions")
+	requeue, err := r.handleModuleVersion(ctx, mod, targetedNodes)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("could not handle module versions: %v", err))
+	}
+	if requeue {
+		res.Requeue = true
 	}
 
 	logger.Info("Handle device plugin")
-	err = r.handleDevicePlugin(ctx, mod)
-	if err != nil {
-		return res, fmt.Errorf("could handle device plugin: %w", err)
+	if err = r.handleDevicePlugin(ctx, mod); err != nil {
+		errs = append(errs, fmt.Errorf("could handle device plugin: %w", err))
 	}
 
 	logger.Info("Run garbage collection")
-	err = r.garbageCollect(ctx, mod, mappings, dsByKernelVersion)
-	if err != nil {
-		return res, fmt.Errorf("failed to run garbage collection: %v", err)
+	if err = r.garbageCollect(ctx, mod, mappings, targetedNodes, dsByKey, kernelVersionStatuses); err != nil {
+		errs = append(errs, fmt.Errorf("failed to run garbage collection: %v", err))
 	}
 
-	err = r.statusUpdaterAPI.ModuleUpdateStatus(ctx, mod, nodesWithMapping, targetedNodes, dsByKernelVersion)
+	err = r.statusUpdaterAPI.ModuleUpdateStatus(ctx, mod, nodesWithMapping, targetedNodes, dsByKey, kernelVersionStatuses)
 	if err != nil {
-		return res, fmt.Errorf("failed to update status of the module: %w", err)
+		errs = append(errs, fmt.Errorf("failed to update status of the module: %w", err))
+	}
+
+	if len(errs) > 0 {
+		return res, utilerrors.NewAggregate(errs)
 	}
 
 	logger.Info("Reconcile loop finished successfully")
@@ -191,60 +280,257 @@ func (r *ModuleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 	return res, nil
 }
 
+// getRelevantKernelMappingsAndNodes returns the mapping of each kernel version and architecture found on
+// targetedNodes, where it was found, the OS configuration it was prepared with, and the nodes for which a mapping was
+// found.
+// Mappings are cached by kernel version, architecture and OS image, as those are the only node properties they can
+// match. Kernel versions and architectures whose nodes resolve to different images are left out, along with their nodes,
+// and so are the architectures sharing an image that is built or signed.
 func (r *ModuleReconciler) getRelevantKernelMappingsAndNodes(ctx context.Context,
 	mod *kmmv1beta1.Module,
-	targetedNodes []v1.Node) (map[string]*kmmv1beta1.KernelMapping, []v1.Node, error) {
+	targetedNodes []v1.Node) (map[module.KernelTarget]*kmmv1beta1.KernelMapping, map[module.KernelTarget]string, map[module.KernelTarget]*module.NodeOSConfig, []v1.Node, error) {
 
-	mappings := make(map[string]*kmmv1beta1.KernelMapping)
+	type cacheKey struct {
+		target  module.KernelTarget
+		osImage string
+	}
+
+	mappings := make(map[module.KernelTarget]*kmmv1beta1.KernelMapping)
+	sources := make(map[module.KernelTarget]string)
+	osConfigs := make(map[module.KernelTarget]*module.NodeOSConfig)
+	cache := make(map[cacheKey]*kmmv1beta1.KernelMapping)
+	conflicts := make(map[module.KernelTarget]bool)
+	imageTargets := make(map[string]module.KernelTarget)
 	logger := log.FromContext(ctx)
 
 	nodes := make([]v1.Node, 0, len(targetedNodes))
 
 	for _, node := range targetedNodes {
-		osConfig := r.kernelAPI.GetNodeOSConfig(&node)
-		kernelVersion := strings.TrimSuffix(node.Status.NodeInfo.KernelVersion, "+")
+		target := nodeKernelTarget(&node)
+
+		ck := cacheKey{target: target, osImage: node.Status.NodeInfo.OSImage}
 
 		nodeLogger := logger.WithValues(
 			"node", node.Name,
-			"kernel version", kernelVersion,
+			"kernel version", target.KernelVersion,
+			"architecture", target.Architecture,
 		)
 
-		if image, ok := mappings[kernelVersion]; ok {
+		if image, ok := cache[ck]; ok {
 			nodes = append(nodes, node)
 			nodeLogger.V(1).Info("Using cached image", "image", image)
 			continue
 		}
 
-		m, err := r.kernelAPI.FindMappingForKernel(mod.Spec.ModuleLoader.Container.KernelMappings, kernelVersion)
+		osConfig := r.kernelAPI.GetNodeOSConfig(&node)
+
+		m, source, err := r.resolverAPI.ResolveMapping(ctx, mod, target.KernelVersion, &node)
 		if err != nil {
-			nodeLogger.Info("no suitable container image found; skipping node")
+			if errors.Is(err, module.ErrNoMapping) {
+				nodeLogger.Info("no suitable container image found; skipping node")
+			} else {
+				nodeLogger.Info(utils.WarnString("could not resolve the kernel mapping; skipping node"), "error", err)
+			}
 			continue
 		}
 
-		m, err = r.kernelAPI.PrepareKernelMapping(m, osConfig)
+		m, err = r.kernelAPI.PrepareKernelMapping(mod.Spec, m, osConfig)
 		if err != nil {
 			nodes = append(nodes, node)
 			nodeLogger.Info("failed to substitute the template variables in the mapping", "error", err)
 			continue
 		}
 
+		// all nodes of a kernel version and architecture are served by the same DaemonSet, which cannot tell their OS
+		// images apart; whichever image it ran, some nodes would load the wrong module
+		if existing, ok := mappings[target]; ok && existing.ContainerImage != m.ContainerImage {
+			nodeLogger.Info(
+				utils.WarnString("the OS image of the node resolves to another image than other nodes with the same kernel and architecture; skipping all of them"),
+				"image", m.ContainerImage,
+				"existing image", existing.ContainerImage,
+			)
+			conflicts[target] = true
+			continue
+		}
+
+		// the images built or signed by KMM only hold the architecture of their job; if several architectures shared
+		// one, each job would overwrite the image of the others
+		if other, ok := imageTargets[m.ContainerImage]; ok && other.Architecture != target.Architecture &&
+			(producesImage(mod, m) || producesImage(mod, mappings[other])) {
+			nodeLogger.Info(
+				utils.WarnString("the image is built or signed for several architectures; skipping all their nodes"),
+				"image", m.ContainerImage,
+				"other architecture", other.Architecture,
+			)
+			conflicts[target] = true
+			conflicts[other] = true
+			continue
+		}
+
 		nodeLogger.V(1).Info("Found a valid mapping",
 			"image", m.ContainerImage,
 			"build", m.Build != nil,
 		)
 
-		mappings[kernelVersion] = m
+		cache[ck] = m
+		mappings[target] = m
+		if _, ok := imageTargets[m.ContainerImage]; !ok {
+			imageTargets[m.ContainerImage] = target
+		}
+		sources[target] = source
+		osConfigs[target] = osConfig
 		nodes = append(nodes, node)
 	}
-	return mappings, nodes, nil
+
+	if len(conflicts) == 0 {
+		return mappings, sources, osConfigs, nodes, nil
+	}
+
+	nodesWithoutConflict := make([]v1.Node, 0, len(nodes))
+
+	for _, node := range nodes {
+		if !conflicts[nodeKernelTarget(&node)] {
+			nodesWithoutConflict = append(nodesWithoutConflict, node)
+		}
+	}
+
+	for target := range conflicts {
+		delete(mappings, target)
+		delete(sources, target)
+		delete(osConfigs, target)
+	}
+
+	return mappings, sources, osConfigs, nodesWithoutConflict, nil
+}
+
+// producesImage returns true if KMM builds or signs the image of m.
+func producesImage(mod *kmmv1beta1.Module, m *kmmv1beta1.KernelMapping) bool {
+	return m != nil && (module.ShouldBeBuilt(mod.Spec, *m) || module.ShouldBeSigned(mod.Spec, *m))
+}
+
+func nodeKernelTarget(node *v1.Node) module.KernelTarget {
+	return module.KernelTarget{
+		KernelVersion: strings.TrimSuffix(node.Status.NodeInfo.KernelVersion, "+"),
+		Architecture:  module.NodeArchitecture(node),
+	}
+}
+
+// handleKernelVersion builds, signs and loads the module for a single kernel version and architecture.
+// It updates the stage of kvs and returns true if the kernel version needs to be requeued.
+func (r *ModuleReconciler) handleKernelVersion(ctx context.Context,
+	mod *kmmv1beta1.Module,
+	m *kmmv1beta1.KernelMapping,
+	osConfig *module.NodeOSConfig,
+	dsByKey map[daemonset.Key]*appsv1.DaemonSet,
+	target module.KernelTarget,
+	kvs *kmmv1beta1.KernelVersionStatus) (bool, error) {
+
+	logger := log.FromContext(ctx)
+	kernelVersion := target.KernelVersion
+
+	force := kvs.RebuildTrigger != mod.Spec.RebuildTrigger
+	if force {
+		logger.Info("Rebuild requested", "kernelVersion", kernelVersion, "trigger", mod.Spec.RebuildTrigger)
+	}
+
+	kvs.Stage = kmmv1beta1.KernelVersionStageBuild
+
+	requeue, err := r.handleBuild(ctx, mod, m, target, osConfig, force)
+	if err != nil {
+		return false, fmt.Errorf("failed to handle build for kernel version %s: %w", kernelVersion, err)
+	}
+	if requeue {
+		logger.Info("Build requires a requeue; skipping handling driver container for now", "kernelVersion", kernelVersion, "image", m)
+		return true, nil
+	}
+
+	kvs.Stage = kmmv1beta1.KernelVersionStageSign
+
+	signrequeue, err := r.handleSigning(ctx, mod, m, target, force)
+	if err != nil {
+		return false, fmt.Errorf("failed to handle signing for kernel version %s: %w", kernelVersion, err)
+	}
+	if signrequeue {
+		logger.Info("Signing requires a requeue; skipping handling driver container for now", "kernelVersion", kernelVersion, "image", m)
+		return true, nil
+	}
+
+	kvs.Stage = kmmv1beta1.KernelVersionStageModuleLoader
+	kvs.RebuildTrigger = mod.Spec.RebuildTrigger
+
+	upgrading, err := r.handleDriverContainer(ctx, mod, m, dsByKey, target)
+	if err != nil {
+		return false, fmt.Errorf("failed to handle driver container for kernel version %s: %v", kernelVersion, err)
+	}
+
+	return upgrading, nil
+}
+
+// moduleLoaderImage returns the image the module loader pods of km run.
+// Once the Module can be rebuilt, its images reuse their tag, so they are pinned by digest: nodes then run the image
+// the pods were rolled out with, without pulling it each time a pod starts.
+func (r *ModuleReconciler) moduleLoaderImage(ctx context.Context, mod *kmmv1beta1.Module, km *kmmv1beta1.KernelMapping) (string, error) {
+	if mod.Spec.RebuildTrigger == "" || strings.Contains(km.ContainerImage, "@") {
+		return km.ContainerImage, nil
+	}
+
+	digest, err := module.ImageDigest(ctx, r.Client, r.registryAPI, mod.Spec, mod.Namespace, *km, km.ContainerImage)
+	if err != nil {
+		return "", fmt.Errorf("could not pin image %s: %v", km.ContainerImage, err)
+	}
+
+	return km.ContainerImage + "@" + digest, nil
+}
+
+// newKernelVersionStatus returns the initial status of target, assuming that its image is ready to be loaded.
+func newKernelVersionStatus(target module.KernelTarget, km *kmmv1beta1.KernelMapping) *kmmv1beta1.KernelVersionStatus {
+	var mapping string
+
+	switch {
+	case km.Literal != "":
+		mapping = km.Literal
+	case km.Regexp != "":
+		mapping = km.Regexp
+	case km.VersionRange != nil:
+		mapping = fmt.Sprintf("[%s, %s)", km.VersionRange.Min, km.VersionRange.Max)
+	case km.Fallback:
+		mapping = "fallback"
+	}
+
+	return &kmmv1beta1.KernelVersionStatus{
+		KernelVersion:  target.KernelVersion,
+		Architecture:   target.Architecture,
+		Mapping:        mapping,
+		ContainerImage: km.ContainerImage,
+		Stage:          kmmv1beta1.KernelVersionStageModuleLoader,
+	}
+}
+
+// lastRebuildTrigger returns the rebuild trigger for which the image of target was last rebuilt.
+// Kernel versions that were not targeted before do not need to be rebuilt, so the current trigger is returned for
+// them.
+func lastRebuildTrigger(mod *kmmv1beta1.Module, target module.KernelTarget) string {
+	for _, kvs := range mod.Status.KernelVersions {
+		if kvs.KernelVersion == target.KernelVersion && kvs.Architecture == target.Architecture {
+			return kvs.RebuildTrigger
+		}
+	}
+
+	return mod.Spec.RebuildTrigger
 }
 
 func (r *ModuleReconciler) getNodesListBySelector(ctx context.Context, mod *kmmv1beta1.Module) ([]v1.Node, error) {
 	logger := log.FromContext(ctx)
-	logger.V(1).Info("Listing nodes", "selector", mod.Spec.Selector)
+
+	sel, err := module.NodeSelector(mod.Spec)
+	if err != nil {
+		return nil, fmt.Errorf("could not get the node selector: %v", err)
+	}
+
+	logger.V(1).Info("Listing nodes", "selector", sel.String())
 
 	selectedNodes := v1.NodeList{}
-	opt := client.MatchingLabels(mod.Spec.Selector)
+	opt := client.MatchingLabelsSelector{Selector: sel}
 	if err := r.Client.List(ctx, &selectedNodes, opt); err != nil {
 		logger.Error(err, "Could not list nodes")
 		return nil, fmt.Errorf("could not list nodes: %v", err)
@@ -252,7 +538,16 @@ func (r *ModuleReconciler) getNodesListBySelector(ctx context.Context, mod *kmmv
 	nodes := make([]v1.Node, 0, len(selectedNodes.Items))
 
 	for _, node := range selectedNodes.Items {
-		if isNodeSchedulable(&node) {
+		if !isNodeSchedulable(&node, mod.Spec.ModuleLoader.Tolerations) {
+			continue
+		}
+
+		matches, err := module.MatchesNodeAffinity(&node, mod.Spec.ModuleLoader.Affinity)
+		if err != nil {
+			return nil, fmt.Errorf("could not match node %s against the module loader's affinity: %v", node.Name, err)
+		}
+
+		if matches {
 			nodes = append(nodes, node)
 		}
 	}
@@ -262,20 +557,30 @@ func (r *ModuleReconciler) getNodesListBySelector(ctx context.Context, mod *kmmv
 func (r *ModuleReconciler) handleBuild(ctx context.Context,
 	mod *kmmv1beta1.Module,
 	km *kmmv1beta1.KernelMapping,
-	kernelVersion string) (bool, error) {
+	target module.KernelTarget,
+	osConfig *module.NodeOSConfig,
+	force bool) (bool, error) {
 
-	shouldSync, err := r.buildAPI.ShouldSync(ctx, *mod, *km)
-	if err != nil {
-		return false, fmt.Errorf("could not check if build synchronization is needed: %w", err)
-	}
+	kernelVersion := target.KernelVersion
+
+	// a forced rebuild ignores the existing image
+	shouldSync := force && module.ShouldBeBuilt(mod.Spec, *km)
 	if !shouldSync {
-		return false, nil
+		var err error
+
+		shouldSync, err = r.buildAPI.ShouldSync(ctx, *mod, *km, kernelVersion, target.Architecture)
+		if err != nil {
+			return false, fmt.Errorf("could not check if build synchronization is needed: %w", err)
+		}
+		if !shouldSync {
+			return false, nil
+		}
 	}
 
 	logger := log.FromContext(ctx).WithValues("kernel version", kernelVersion, "image", km.ContainerImage)
 	buildCtx := log.IntoContext(ctx, logger)
 
-	buildRes, err := r.buildAPI.Sync(buildCtx, *mod, *km, kernelVersion, true, mod)
+	buildRes, err := r.buildAPI.Sync(buildCtx, *mod, *km, kernelVersion, target.Architecture, osConfig, true, mod)
 	if err != nil {
 		return false, fmt.Errorf("could not synchronize the build: %w", err)
 	}
@@ -285,6 +590,13 @@ func (r *ModuleReconciler) handleBuild(ctx context.Context,
 		r.metricsAPI.SetCompletedStage(mod.Name, mod.Namespace, kernelVersion, metrics.BuildStage, false)
 	case build.StatusCompleted:
 		r.metricsAPI.SetCompletedStage(mod.Name, mod.Namespace, kernelVersion, metrics.BuildStage, true)
+	case build.StatusFailed:
+		return false, &jobFailedError{
+			stage:         "build",
+			reason:        buildRes.Reason,
+			logsConfigMap: buildRes.LogsConfigMap,
+			failedJobHash: buildRes.FailedJobHash,
+		}
 	}
 
 	return buildRes.Requeue, nil
@@ -293,14 +605,23 @@ func (r *ModuleReconciler) handleBuild(ctx context.Context,
 func (r *ModuleReconciler) handleSigning(ctx context.Context,
 	mod *kmmv1beta1.Module,
 	km *kmmv1beta1.KernelMapping,
-	kernelVersion string) (bool, error) {
+	target module.KernelTarget,
+	force bool) (bool, error) {
 
-	shouldSync, err := r.signAPI.ShouldSync(ctx, *mod, *km)
-	if err != nil {
-		return false, fmt.Errorf("cound not check if synchronization is needed: %w", err)
-	}
+	kernelVersion := target.KernelVersion
+
+	// a forced re-sign ignores the existing image
+	shouldSync := force && module.ShouldBeSigned(mod.Spec, *km)
 	if !shouldSync {
-		return false, nil
+		var err error
+
+		shouldSync, err = r.signAPI.ShouldSync(ctx, *mod, *km, kernelVersion, target.Architecture)
+		if err != nil {
+			return false, fmt.Errorf("cound not check if synchronization is needed: %w", err)
+		}
+		if !shouldSync {
+			return false, nil
+		}
 	}
 
 	// if we need to sign AND we've built, then we must have built the intermediate image so must figure out its name
@@ -312,7 +633,7 @@ func (r *ModuleReconciler) handleSigning(ctx context.Context,
 	logger := log.FromContext(ctx).WithValues("kernel version", kernelVersion, "image", km.ContainerImage)
 	signCtx := log.IntoContext(ctx, logger)
 
-	signRes, err := r.signAPI.Sync(signCtx, *mod, *km, kernelVersion, previousImage, true, mod)
+	signRes, err := r.signAPI.Sync(signCtx, *mod, *km, kernelVersion, target.Architecture, previousImage, true, mod)
 	if err != nil {
 		return false, fmt.Errorf("could not synchronize the signing: %w", err)
 	}
@@ -322,6 +643,13 @@ func (r *ModuleReconciler) handleSigning(ctx context.Context,
 		r.metricsAPI.SetCompletedStage(mod.Name, mod.Namespace, kernelVersion, metrics.SignStage, false)
 	case utils.StatusCompleted:
 		r.metricsAPI.SetCompletedStage(mod.Name, mod.Namespace, kernelVersion, metrics.SignStage, true)
+	case utils.StatusFailed:
+		return false, &jobFailedError{
+			stage:         "signing",
+			reason:        signRes.Reason,
+			logsConfigMap: signRes.LogsConfigMap,
+			failedJobHash: signRes.FailedJobHash,
+		}
 	}
 
 	return signRes.Requeue, nil
@@ -330,33 +658,133 @@ func (r *ModuleReconciler) handleSigning(ctx context.Context,
 func (r *ModuleReconciler) handleDriverContainer(ctx context.Context,
 	mod *kmmv1beta1.Module,
 	km *kmmv1beta1.KernelMapping,
-	dsByKernelVersion map[string]*appsv1.DaemonSet,
-	kernelVersion string) error {
+	dsByKey map[daemonset.Key]*appsv1.DaemonSet,
+	target module.KernelTarget) (bool, error) {
 	ds := &appsv1.DaemonSet{
 		ObjectMeta: metav1.ObjectMeta{Namespace: mod.Namespace},
 	}
 
+	kernelVersion := target.KernelVersion
+
+	key := daemonset.Key{
+		KernelVersion: kernelVersion,
+		Architecture:  target.Architecture,
+		ModuleVersion: mod.Spec.ModuleLoader.Container.Version,
+	}
+
 	logger := log.FromContext(ctx)
-	if existingDS := dsByKernelVersion[kernelVersion]; existingDS != nil {
-		logger.Info("updating existing driver container DS", "kernel version", kernelVersion, "image", km, "name", ds.Name)
+	if existingDS := dsByKey[key]; existingDS != nil {
+		logger.Info("updating existing driver container DS", "kernel version", kernelVersion, "architecture", target.Architecture, "image", km, "name", ds.Name)
 		ds = existingDS
 	} else {
-		logger.Info("creating new driver container DS", "kernel version", kernelVersion, "image", km)
+		logger.Info("creating new driver container DS", "kernel version", kernelVersion, "architecture", target.Architecture, "image", km)
 		ds.GenerateName = mod.Name + "-"
 	}
 
+	image, err := r.moduleLoaderImage(ctx, mod, km)
+	if err != nil {
+		return false, err
+	}
+
 	opRes, err := controllerutil.CreateOrPatch(ctx, r.Client, ds, func() error {
-		return r.daemonAPI.SetDriverContainerAsDesired(ctx, ds, km.ContainerImage, *mod, kernelVersion)
+		return r.daemonAPI.SetDriverContainerAsDesired(ctx, ds, image, *mod, kernelVersion, target.Architecture)
 	})
 
-	if err == nil {
-		if opRes == controllerutil.OperationResultCreated {
-			r.metricsAPI.SetCompletedStage(mod.Name, mod.Namespace, kernelVersion, metrics.ModuleLoaderStage, false)
+	if err != nil {
+		return false, err
+	}
+
+	if opRes == controllerutil.OperationResultCreated {
+		r.metricsAPI.SetCompletedStage(mod.Name, mod.Namespace, kernelVersion, metrics.ModuleLoaderStage, false)
+	}
+	logger.Info("Reconciled Driver Container", "name", ds.Name, "result", opRes)
+
+	if mod.Spec.ModuleLoader.UpgradePolicy == nil {
+		return false, nil
+	}
+
+	upgrading, err := r.upgradeAPI.Upgrade(ctx, mod, ds)
+	if err != nil {
+		return false, fmt.Errorf("could not upgrade DaemonSet %s: %v", ds.Name, err)
+	}
+
+	if upgrading {
+		logger.Info("Module loader upgrade in progress", "name", ds.Name)
+	}
+
+	return upgrading, nil
+}
+
+// handleModuleVersion moves each node targeted by mod towards the version requested by its version label.
+// KMM first removes the label allowing the previous version on the node, which unloads it. Once no module-loader pod
+// is left on the node, the label is set to the requested version so that it can be loaded.
+// It returns true while at least one node is transitioning between versions.
+func (r *ModuleReconciler) handleModuleVersion(ctx context.Context, mod *kmmv1beta1.Module, targetedNodes []v1.Node) (bool, error) {
+	if mod.Spec.ModuleLoader.Container.Version == "" {
+		return false, nil
+	}
+
+	logger := log.FromContext(ctx)
+
+	pods := v1.PodList{}
+
+	opts := []client.ListOption{
+		client.InNamespace(mod.Namespace),
+		client.MatchingLabels{
+			constants.ModuleNameLabel: mod.Name,
+			constants.DaemonSetRole:   "module-loader",
+		},
+	}
+
+	if err := r.Client.List(ctx, &pods, opts...); err != nil {
+		return false, fmt.Errorf("could not list module-loader pods: %v", err)
+	}
+
+	nodesWithLoader := sets.NewString()
+
+	for _, pod := range pods.Items {
+		nodesWithLoader.Insert(pod.Spec.NodeName)
+	}
+
+	requestedLabel := daemonset.GetModuleVersionNodeLabel(mod.Namespace, mod.Name)
+	loaderLabel := daemonset.GetModuleLoaderVersionNodeLabel(mod.Namespace, mod.Name)
+
+	transitioning := false
+	errs := make([]error, 0)
+
+	for i := range targetedNodes {
+		node := &targetedNodes[i]
+
+		requested := node.Labels[requestedLabel]
+		current := node.Labels[loaderLabel]
+
+		if requested == current {
+			continue
+		}
+
+		transitioning = true
+		nodeLogger := logger.WithValues("node", node.Name, "current", current, "requested", requested)
+
+		patchFrom := client.MergeFrom(node.DeepCopy())
+
+		switch {
+		case current != "":
+			nodeLogger.Info("Unloading the current version of the module")
+			delete(node.Labels, loaderLabel)
+		case nodesWithLoader.Has(node.Name):
+			nodeLogger.Info("Waiting for the previous version of the module to be unloaded")
+			continue
+		default:
+			nodeLogger.Info("Allowing the requested version of the module")
+			node.Labels[loaderLabel] = requested
+		}
+
+		if err := r.Client.Patch(ctx, node, patchFrom); err != nil {
+			errs = append(errs, fmt.Errorf("could not patch node %s: %v", node.Name, err))
 		}
-		logger.Info("Reconciled Driver Container", "name", ds.Name, "result", opRes)
 	}
 
-	return err
+	return transitioning, utilerrors.NewAggregate(errs)
 }
 
 func (r *ModuleReconciler) handleDevicePlugin(ctx context.Context, mod *kmmv1beta1.Module) error {
@@ -391,27 +819,96 @@ func (r *ModuleReconciler) handleDevicePlugin(ctx context.Context, mod *kmmv1bet
 
 func (r *ModuleReconciler) garbageCollect(ctx context.Context,
 	mod *kmmv1beta1.Module,
-	mappings map[string]*kmmv1beta1.KernelMapping,
-	existingDS map[string]*appsv1.DaemonSet) error {
+	mappings map[module.KernelTarget]*kmmv1beta1.KernelMapping,
+	targetedNodes []v1.Node,
+	existingDS map[daemonset.Key]*appsv1.DaemonSet,
+	kernelVersionStatuses map[module.KernelTarget]*kmmv1beta1.KernelVersionStatus) error {
 	logger := log.FromContext(ctx)
 	// Garbage collect old DaemonSets for which there are no nodes.
-	validKernels := sets.StringKeySet(mappings)
+	version := mod.Spec.ModuleLoader.Container.Version
+	validKeys := make(map[daemonset.Key]bool, len(mappings))
+
+	for target := range mappings {
+		key := daemonset.Key{
+			KernelVersion: target.KernelVersion,
+			Architecture:  target.Architecture,
+			ModuleVersion: version,
+		}
+
+		validKeys[key] = true
+	}
+
+	// Keep the DaemonSets of previous versions that are still allowed on some nodes.
+	if version != "" {
+		loaderLabel := daemonset.GetModuleLoaderVersionNodeLabel(mod.Namespace, mod.Name)
+		loadedVersions := sets.NewString()
+
+		for _, node := range targetedNodes {
+			if v := node.Labels[loaderLabel]; v != "" {
+				loadedVersions.Insert(v)
+			}
+		}
+
+		for key := range existingDS {
+			target := module.KernelTarget{KernelVersion: key.KernelVersion, Architecture: key.Architecture}
 
-	deleted, err := r.daemonAPI.GarbageCollect(ctx, existingDS, validKernels)
+			if mappings[target] != nil && loadedVersions.Has(key.ModuleVersion) {
+				validKeys[key] = true
+			}
+		}
+	}
+
+	deleted, err := r.daemonAPI.GarbageCollect(ctx, existingDS, validKeys)
 	if err != nil {
 		return fmt.Errorf("could not garbage collect DaemonSets: %v", err)
 	}
 
 	logger.Info("Garbage-collected DaemonSets", "names", deleted)
 
+	// Nodes are released when the upgrade that labeled them cannot run anymore
+	activeDS := sets.NewString()
+
+	if mod.Spec.ModuleLoader.UpgradePolicy != nil {
+		for _, ds := range existingDS {
+			activeDS.Insert(ds.Name)
+		}
+
+		activeDS.Delete(deleted...)
+	}
+
+	if err = r.upgradeAPI.ReleaseNodes(ctx, mod.Name, activeDS); err != nil {
+		return fmt.Errorf("could not release upgraded nodes: %v", err)
+	}
+
+	// Completed build jobs are kept until the rebuilt image has been signed, so that they are not run again
+	rebuilding := make(map[module.KernelTarget]bool)
+
+	for target, kvs := range kernelVersionStatuses {
+		if kvs.RebuildTrigger != mod.Spec.RebuildTrigger {
+			logger.Info(
+				"Rebuild in progress; not garbage-collecting its build objects",
+				"kernelVersion", target.KernelVersion,
+				"architecture", target.Architecture,
+			)
+			rebuilding[target] = true
+		}
+	}
+
 	// Garbage collect for successfully finished build jobs
-	deleted, err = r.buildAPI.GarbageCollect(ctx, mod.Name, mod.Namespace, mod)
+	deleted, err = r.buildAPI.GarbageCollect(ctx, mod.Name, mod.Namespace, mod, rebuilding)
 	if err != nil {
 		return fmt.Errorf("could not garbage collect build objects: %v", err)
 	}
 
 	logger.Info("Garbage-collected Build objects", "names", deleted)
 
+	deleted, err = r.signAPI.GarbageCollect(ctx, mod.Name, mod.Namespace, mod)
+	if err != nil {
+		return fmt.Errorf("could not garbage collect signing objects: %v", err)
+	}
+
+	logger.Info("Garbage-collected signing objects", "names", deleted)
+
 	return nil
 }
 
@@ -438,6 +935,20 @@ func (r *ModuleReconciler) getRequestedModule(ctx context.Context, namespacedNam
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *ModuleReconciler) SetupWithManager(mgr ctrl.Manager, kernelLabel string) error {
+	ctx := context.Background()
+
+	if err := mgr.GetFieldIndexer().IndexField(ctx, &kmmv1beta1.Module{}, module.ConfigMapsIndexKey, module.IndexConfigMaps); err != nil {
+		return fmt.Errorf("could not index the ConfigMaps referenced by Modules: %v", err)
+	}
+
+	if err := mgr.GetFieldIndexer().IndexField(ctx, &kmmv1beta1.Module{}, module.SecretsIndexKey, module.IndexSecrets); err != nil {
+		return fmt.Errorf("could not index the Secrets referenced by Modules: %v", err)
+	}
+
+	if err := mgr.GetFieldIndexer().IndexField(ctx, &v1.Pod{}, joblogs.JobNameIndexKey, joblogs.IndexJobName); err != nil {
+		return fmt.Errorf("could not index the pods by Job: %v", err)
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&kmmv1beta1.Module{}).
 		Owns(&appsv1.DaemonSet{}).
@@ -450,15 +961,33 @@ func (r *ModuleReconciler) SetupWithManager(mgr ctrl.Manager, kernelLabel string
 				r.filter.ModuleReconcilerNodePredicate(kernelLabel),
 			),
 		).
+		Watches(
+			&source.Kind{Type: &v1.ConfigMap{}},
+			handler.EnqueueRequestsFromMapFunc(r.filter.FindModulesForConfigMap),
+		).
+		Watches(
+			&source.Kind{Type: &v1.Secret{}},
+			handler.EnqueueRequestsFromMapFunc(r.filter.FindModulesForSecret),
+		).
 		Named(ModuleReconcilerName).
 		Complete(r)
 }
 
-func isNodeSchedulable(node *v1.Node) bool {
+// isNodeSchedulable returns true if all the node's NoSchedule taints are tolerated.
+func isNodeSchedulable(node *v1.Node, tolerations []v1.Toleration) bool {
 	for _, taint := range node.Spec.Taints {
-		if taint.Effect == v1.TaintEffectNoSchedule {
+		if taint.Effect == v1.TaintEffectNoSchedule && !isTaintTolerated(&taint, tolerations) {
 			return false
 		}
 	}
 	return true
 }
+
+func isTaintTolerated(taint *v1.Taint, tolerations []v1.Toleration) bool {
+	for _, t := range tolerations {
+		if t.ToleratesTaint(taint) {
+			return true
+		}
+	}
+	return false
+}
@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"context"
+	"errors"
 
 	"github.com/golang/mock/gomock"
 	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
@@ -11,8 +12,10 @@ import (
 	"github.com/kubernetes-sigs/kernel-module-management/internal/metrics"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/module"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/rbac"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/registry"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/sign"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/statusupdater"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/upgrade"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/utils"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -39,7 +42,10 @@ var _ = Describe("ModuleReconciler_Reconcile", func() {
 		mockSM      *sign.MockSignManager
 		mockRC      *rbac.MockRBACCreator
 		mockDC      *daemonset.MockDaemonSetCreator
+		mockUM      *upgrade.MockManager
 		mockKM      *module.MockKernelMapper
+		mockMR      *module.MockMappingResolver
+		mockRV      *module.MockReferenceValidator
 		mockMetrics *metrics.MockMetrics
 		mockSU      *statusupdater.MockModuleStatusUpdater
 	)
@@ -51,7 +57,10 @@ var _ = Describe("ModuleReconciler_Reconcile", func() {
 		mockSM = sign.NewMockSignManager(ctrl)
 		mockRC = rbac.NewMockRBACCreator(ctrl)
 		mockDC = daemonset.NewMockDaemonSetCreator(ctrl)
+		mockUM = upgrade.NewMockManager(ctrl)
 		mockKM = module.NewMockKernelMapper(ctrl)
+		mockMR = module.NewMockMappingResolver(ctrl)
+		mockRV = module.NewMockReferenceValidator(ctrl)
 		mockMetrics = metrics.NewMockMetrics(ctrl)
 		mockSU = statusupdater.NewMockModuleStatusUpdater(ctrl)
 	})
@@ -74,8 +83,9 @@ var _ = Describe("ModuleReconciler_Reconcile", func() {
 			Return(
 				apierrors.NewNotFound(schema.GroupResource{}, moduleName),
 			)
+		mockUM.EXPECT().ReleaseNodes(ctx, moduleName, sets.NewString())
 
-		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockRC, mockDC, mockKM, mockMetrics, nil, mockSU)
+		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockRC, mockDC, mockUM, mockKM, mockMR, mockRV, mockMetrics, nil, mockSU, nil)
 		Expect(
 			mr.Reconcile(ctx, req),
 		).To(
@@ -83,6 +93,32 @@ var _ = Describe("ModuleReconciler_Reconcile", func() {
 		)
 	})
 
+	It("should only update the status if the Module is paused", func() {
+		mod := kmmv1beta1.Module{
+			ObjectMeta: metav1.ObjectMeta{Name: moduleName, Namespace: namespace},
+			Spec:       kmmv1beta1.ModuleSpec{Paused: true},
+		}
+
+		gomock.InOrder(
+			clnt.EXPECT().Get(ctx, req.NamespacedName, gomock.Any()).DoAndReturn(
+				func(_ interface{}, _ interface{}, m *kmmv1beta1.Module, _ ...ctrlclient.GetOption) error {
+					m.ObjectMeta = mod.ObjectMeta
+					m.Spec = mod.Spec
+					return nil
+				},
+			),
+			clnt.EXPECT().List(ctx, gomock.Any(), gomock.Any()),
+			mockMetrics.EXPECT().SetExistingKMMOModules(0),
+			mockSU.EXPECT().ModuleSetPaused(ctx, &mod),
+		)
+
+		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockRC, mockDC, mockUM, mockKM, mockMR, mockRV, mockMetrics, nil, mockSU, nil)
+
+		res, err := mr.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(Equal(reconcile.Result{}))
+	})
+
 	It("should add the module loader and device plugin ServiceAccounts if they are not set", func() {
 		mod := kmmv1beta1.Module{
 			ObjectMeta: metav1.ObjectMeta{
@@ -136,15 +172,18 @@ var _ = Describe("ModuleReconciler_Reconcile", func() {
 			mockMetrics.EXPECT().SetCompletedStage(moduleName, namespace, "", metrics.DevicePluginStage, false),
 		)
 
-		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockRC, mockDC, mockKM, mockMetrics, nil, mockSU)
+		mockRV.EXPECT().ValidateReferences(ctx, gomock.Any(), gomock.Any())
+		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockRC, mockDC, mockUM, mockKM, mockMR, mockRV, mockMetrics, nil, mockSU, nil)
 
-		dsByKernelVersion := make(map[string]*appsv1.DaemonSet)
+		dsByKey := make(map[daemonset.Key]*appsv1.DaemonSet)
 
 		gomock.InOrder(
-			mockDC.EXPECT().ModuleDaemonSetsByKernelVersion(ctx, moduleName, namespace).Return(dsByKernelVersion, nil),
-			mockDC.EXPECT().GarbageCollect(ctx, dsByKernelVersion, sets.NewString()),
-			mockBM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, &mod),
-			mockSU.EXPECT().ModuleUpdateStatus(ctx, &mod, []v1.Node{}, []v1.Node{}, dsByKernelVersion).Return(nil),
+			mockDC.EXPECT().ModuleDaemonSetsByKey(ctx, moduleName, namespace).Return(dsByKey, nil),
+			mockDC.EXPECT().GarbageCollect(ctx, dsByKey, map[daemonset.Key]bool{}),
+			mockUM.EXPECT().ReleaseNodes(ctx, mod.Name, sets.NewString()),
+			mockBM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, &mod, map[module.KernelTarget]bool{}),
+			mockSM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, &mod),
+			mockSU.EXPECT().ModuleUpdateStatus(ctx, &mod, []v1.Node{}, []v1.Node{}, dsByKey, map[module.KernelTarget]*kmmv1beta1.KernelVersionStatus{}).Return(nil),
 		)
 
 		res, err := mr.Reconcile(context.Background(), req)
@@ -191,22 +230,113 @@ var _ = Describe("ModuleReconciler_Reconcile", func() {
 			),
 		)
 
-		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockRC, mockDC, mockKM, mockMetrics, nil, mockSU)
+		mockRV.EXPECT().ValidateReferences(ctx, gomock.Any(), gomock.Any())
+		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockRC, mockDC, mockUM, mockKM, mockMR, mockRV, mockMetrics, nil, mockSU, nil)
+
+		dsByKey := make(map[daemonset.Key]*appsv1.DaemonSet)
+
+		gomock.InOrder(
+			mockDC.EXPECT().ModuleDaemonSetsByKey(ctx, moduleName, namespace).Return(dsByKey, nil),
+			mockDC.EXPECT().GarbageCollect(ctx, dsByKey, map[daemonset.Key]bool{}),
+			mockUM.EXPECT().ReleaseNodes(ctx, mod.Name, sets.NewString()),
+			mockBM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, &mod, map[module.KernelTarget]bool{}),
+			mockSM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, &mod),
+			mockSU.EXPECT().ModuleUpdateStatus(ctx, &mod, []v1.Node{}, []v1.Node{}, dsByKey, map[module.KernelTarget]*kmmv1beta1.KernelVersionStatus{}).Return(nil),
+		)
+
+		res, err := mr.Reconcile(context.Background(), req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(Equal(reconcile.Result{}))
+	})
+
+	It("should report the invalid references in the status", func() {
+		const serviceAccountName = "module-loader-service-account"
+
+		mod := kmmv1beta1.Module{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      moduleName,
+				Namespace: namespace,
+			},
+			Spec: kmmv1beta1.ModuleSpec{
+				Selector: map[string]string{"key": "value"},
+				ModuleLoader: kmmv1beta1.ModuleLoaderSpec{
+					ServiceAccountName: serviceAccountName,
+				},
+			},
+		}
+
+		invalidReferences := []kmmv1beta1.InvalidReference{
+			{Kind: "Secret", Name: "some-secret", Message: "not found"},
+		}
+
+		expectedMod := mod.DeepCopy()
+		expectedMod.Status.InvalidReferences = invalidReferences
 
-		dsByKernelVersion := make(map[string]*appsv1.DaemonSet)
+		dsByKey := make(map[daemonset.Key]*appsv1.DaemonSet)
 
 		gomock.InOrder(
-			mockDC.EXPECT().ModuleDaemonSetsByKernelVersion(ctx, moduleName, namespace).Return(dsByKernelVersion, nil),
-			mockDC.EXPECT().GarbageCollect(ctx, dsByKernelVersion, sets.NewString()),
-			mockBM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, &mod),
-			mockSU.EXPECT().ModuleUpdateStatus(ctx, &mod, []v1.Node{}, []v1.Node{}, dsByKernelVersion).Return(nil),
+			clnt.EXPECT().Get(ctx, req.NamespacedName, gomock.Any()).DoAndReturn(
+				func(_ interface{}, _ interface{}, m *kmmv1beta1.Module, _ ...ctrlclient.GetOption) error {
+					m.ObjectMeta = mod.ObjectMeta
+					m.Spec = mod.Spec
+					return nil
+				},
+			),
+			clnt.EXPECT().List(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ interface{}, list *kmmv1beta1.ModuleList, _ ...interface{}) error {
+					list.Items = []kmmv1beta1.Module{mod}
+					return nil
+				},
+			),
+			mockMetrics.EXPECT().SetExistingKMMOModules(1),
+			clnt.EXPECT().List(ctx, gomock.Any(), gomock.Any()),
+			mockRV.EXPECT().ValidateReferences(ctx, &mod, map[module.KernelTarget]*kmmv1beta1.KernelMapping{}).Return(invalidReferences, nil),
+			mockDC.EXPECT().ModuleDaemonSetsByKey(ctx, moduleName, namespace).Return(dsByKey, nil),
+			mockDC.EXPECT().GarbageCollect(ctx, dsByKey, map[daemonset.Key]bool{}),
+			mockUM.EXPECT().ReleaseNodes(ctx, mod.Name, sets.NewString()),
+			mockBM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, expectedMod, map[module.KernelTarget]bool{}),
+			mockSM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, expectedMod),
+			mockSU.EXPECT().ModuleUpdateStatus(ctx, expectedMod, []v1.Node{}, []v1.Node{}, dsByKey, map[module.KernelTarget]*kmmv1beta1.KernelVersionStatus{}).Return(nil),
 		)
 
+		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockRC, mockDC, mockUM, mockKM, mockMR, mockRV, mockMetrics, nil, mockSU, nil)
+
 		res, err := mr.Reconcile(context.Background(), req)
 		Expect(err).NotTo(HaveOccurred())
 		Expect(res).To(Equal(reconcile.Result{}))
 	})
 
+	It("should return an error if the references could not be validated", func() {
+		mod := kmmv1beta1.Module{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      moduleName,
+				Namespace: namespace,
+			},
+			Spec: kmmv1beta1.ModuleSpec{
+				ModuleLoader: kmmv1beta1.ModuleLoaderSpec{ServiceAccountName: "some-sa"},
+			},
+		}
+
+		gomock.InOrder(
+			clnt.EXPECT().Get(ctx, req.NamespacedName, gomock.Any()).DoAndReturn(
+				func(_ interface{}, _ interface{}, m *kmmv1beta1.Module, _ ...ctrlclient.GetOption) error {
+					m.ObjectMeta = mod.ObjectMeta
+					m.Spec = mod.Spec
+					return nil
+				},
+			),
+			clnt.EXPECT().List(ctx, gomock.Any(), gomock.Any()),
+			mockMetrics.EXPECT().SetExistingKMMOModules(0),
+			clnt.EXPECT().List(ctx, gomock.Any(), gomock.Any()),
+			mockRV.EXPECT().ValidateReferences(ctx, &mod, map[module.KernelTarget]*kmmv1beta1.KernelMapping{}).Return(nil, errors.New("some error")),
+		)
+
+		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockRC, mockDC, mockUM, mockKM, mockMR, mockRV, mockMetrics, nil, mockSU, nil)
+
+		_, err := mr.Reconcile(context.Background(), req)
+		Expect(err).To(HaveOccurred())
+	})
+
 	It("should remove obsolete DaemonSets when no nodes match the selector", func() {
 		const (
 			kernelVersion      = "1.2.3"
@@ -256,15 +386,18 @@ var _ = Describe("ModuleReconciler_Reconcile", func() {
 			),
 		)
 
-		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockRC, mockDC, mockKM, mockMetrics, nil, mockSU)
+		mockRV.EXPECT().ValidateReferences(ctx, gomock.Any(), gomock.Any())
+		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockRC, mockDC, mockUM, mockKM, mockMR, mockRV, mockMetrics, nil, mockSU, nil)
 
-		dsByKernelVersion := map[string]*appsv1.DaemonSet{kernelVersion: &ds}
+		dsByKey := map[daemonset.Key]*appsv1.DaemonSet{{KernelVersion: kernelVersion}: &ds}
 
 		gomock.InOrder(
-			mockDC.EXPECT().ModuleDaemonSetsByKernelVersion(ctx, moduleName, namespace).Return(dsByKernelVersion, nil),
-			mockDC.EXPECT().GarbageCollect(ctx, dsByKernelVersion, sets.NewString()),
-			mockBM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, &mod),
-			mockSU.EXPECT().ModuleUpdateStatus(ctx, &mod, []v1.Node{}, []v1.Node{}, dsByKernelVersion).Return(nil),
+			mockDC.EXPECT().ModuleDaemonSetsByKey(ctx, moduleName, namespace).Return(dsByKey, nil),
+			mockDC.EXPECT().GarbageCollect(ctx, dsByKey, map[daemonset.Key]bool{}),
+			mockUM.EXPECT().ReleaseNodes(ctx, mod.Name, sets.NewString()),
+			mockBM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, &mod, map[module.KernelTarget]bool{}),
+			mockSM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, &mod),
+			mockSU.EXPECT().ModuleUpdateStatus(ctx, &mod, []v1.Node{}, []v1.Node{}, dsByKey, map[module.KernelTarget]*kmmv1beta1.KernelVersionStatus{}).Return(nil),
 		)
 
 		res, err := mr.Reconcile(context.Background(), req)
@@ -318,9 +451,20 @@ var _ = Describe("ModuleReconciler_Reconcile", func() {
 			},
 		}
 
-		dsByKernelVersion := make(map[string]*appsv1.DaemonSet)
+		dsByKey := make(map[daemonset.Key]*appsv1.DaemonSet)
+
+		kernelVersionStatuses := map[module.KernelTarget]*kmmv1beta1.KernelVersionStatus{
+			{KernelVersion: kernelVersion}: {
+				KernelVersion:  kernelVersion,
+				Mapping:        kernelVersion,
+				MappingSource:  module.MappingSourceModule,
+				ContainerImage: imageName,
+				Stage:          kmmv1beta1.KernelVersionStageModuleLoader,
+			},
+		}
 
-		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockRC, mockDC, mockKM, mockMetrics, nil, mockSU)
+		mockRV.EXPECT().ValidateReferences(ctx, gomock.Any(), gomock.Any())
+		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockRC, mockDC, mockUM, mockKM, mockMR, mockRV, mockMetrics, nil, mockSU, nil)
 
 		ds := appsv1.DaemonSet{
 			ObjectMeta: metav1.ObjectMeta{
@@ -350,20 +494,22 @@ var _ = Describe("ModuleReconciler_Reconcile", func() {
 				},
 			),
 			mockKM.EXPECT().GetNodeOSConfig(&nodeList.Items[0]).Return(&osConfig),
-			mockKM.EXPECT().FindMappingForKernel(mappings, kernelVersion).Return(&mappings[0], nil),
-			mockKM.EXPECT().PrepareKernelMapping(&mappings[0], &osConfig).Return(&mappings[0], nil),
-			mockDC.EXPECT().ModuleDaemonSetsByKernelVersion(ctx, moduleName, namespace).Return(dsByKernelVersion, nil),
-			mockBM.EXPECT().ShouldSync(gomock.Any(), mod, mappings[0]).Return(true, nil),
-			mockBM.EXPECT().Sync(gomock.Any(), mod, mappings[0], kernelVersion, true, &mod),
-			mockSM.EXPECT().ShouldSync(gomock.Any(), mod, mappings[0]).Return(true, nil),
-			mockSM.EXPECT().Sync(gomock.Any(), mod, mappings[0], kernelVersion, "", true, &mod),
+			mockMR.EXPECT().ResolveMapping(ctx, &mod, kernelVersion, &nodeList.Items[0]).Return(&mappings[0], module.MappingSourceModule, nil),
+			mockKM.EXPECT().PrepareKernelMapping(mod.Spec, &mappings[0], &osConfig).Return(&mappings[0], nil),
+			mockDC.EXPECT().ModuleDaemonSetsByKey(ctx, moduleName, namespace).Return(dsByKey, nil),
+			mockBM.EXPECT().ShouldSync(gomock.Any(), mod, mappings[0], kernelVersion, "").Return(true, nil),
+			mockBM.EXPECT().Sync(gomock.Any(), mod, mappings[0], kernelVersion, "", &osConfig, true, &mod),
+			mockSM.EXPECT().ShouldSync(gomock.Any(), mod, mappings[0], kernelVersion, "").Return(true, nil),
+			mockSM.EXPECT().Sync(gomock.Any(), mod, mappings[0], kernelVersion, "", "", true, &mod),
 			clnt.EXPECT().Get(ctx, gomock.Any(), gomock.Any()).Return(apierrors.NewNotFound(schema.GroupResource{}, "whatever")),
-			mockDC.EXPECT().SetDriverContainerAsDesired(context.Background(), &ds, imageName, gomock.AssignableToTypeOf(mod), kernelVersion),
+			mockDC.EXPECT().SetDriverContainerAsDesired(context.Background(), &ds, imageName, gomock.AssignableToTypeOf(mod), kernelVersion, ""),
 			clnt.EXPECT().Create(ctx, gomock.Any()).Return(nil),
 			mockMetrics.EXPECT().SetCompletedStage(moduleName, namespace, kernelVersion, metrics.ModuleLoaderStage, false),
-			mockDC.EXPECT().GarbageCollect(ctx, dsByKernelVersion, sets.NewString(kernelVersion)),
-			mockBM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, &mod),
-			mockSU.EXPECT().ModuleUpdateStatus(ctx, &mod, nodeList.Items, nodeList.Items, dsByKernelVersion).Return(nil),
+			mockDC.EXPECT().GarbageCollect(ctx, dsByKey, map[daemonset.Key]bool{{KernelVersion: kernelVersion}: true}),
+			mockUM.EXPECT().ReleaseNodes(ctx, mod.Name, sets.NewString()),
+			mockBM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, &mod, map[module.KernelTarget]bool{}),
+			mockSM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, &mod),
+			mockSU.EXPECT().ModuleUpdateStatus(ctx, &mod, nodeList.Items, nodeList.Items, dsByKey, kernelVersionStatuses).Return(nil),
 		)
 
 		res, err := mr.Reconcile(context.Background(), req)
@@ -456,26 +602,39 @@ var _ = Describe("ModuleReconciler_Reconcile", func() {
 			clnt.EXPECT().Patch(ctx, gomock.Any(), gomock.Any()),
 		)
 
-		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockRC, mockDC, mockKM, mockMetrics, nil, mockSU)
+		mockRV.EXPECT().ValidateReferences(ctx, gomock.Any(), gomock.Any())
+		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockRC, mockDC, mockUM, mockKM, mockMR, mockRV, mockMetrics, nil, mockSU, nil)
 
-		dsByKernelVersion := map[string]*appsv1.DaemonSet{kernelVersion: &ds}
+		dsByKey := map[daemonset.Key]*appsv1.DaemonSet{{KernelVersion: kernelVersion}: &ds}
+
+		kernelVersionStatuses := map[module.KernelTarget]*kmmv1beta1.KernelVersionStatus{
+			{KernelVersion: kernelVersion}: {
+				KernelVersion:  kernelVersion,
+				Mapping:        kernelVersion,
+				MappingSource:  module.MappingSourceModule,
+				ContainerImage: imageName,
+				Stage:          kmmv1beta1.KernelVersionStageModuleLoader,
+			},
+		}
 
 		gomock.InOrder(
 			mockKM.EXPECT().GetNodeOSConfig(&nodeList.Items[0]).Return(&osConfig),
-			mockKM.EXPECT().FindMappingForKernel(mappings, kernelVersion).Return(&mappings[0], nil),
-			mockKM.EXPECT().PrepareKernelMapping(&mappings[0], &osConfig).Return(&mappings[0], nil),
-			mockDC.EXPECT().ModuleDaemonSetsByKernelVersion(ctx, moduleName, namespace).Return(dsByKernelVersion, nil),
-			mockBM.EXPECT().ShouldSync(gomock.Any(), mod, mappings[0]).Return(true, nil),
-			mockBM.EXPECT().Sync(gomock.Any(), mod, mappings[0], kernelVersion, true, &mod),
-			mockSM.EXPECT().ShouldSync(gomock.Any(), mod, mappings[0]).Return(true, nil),
-			mockSM.EXPECT().Sync(gomock.Any(), mod, mappings[0], kernelVersion, "", true, &mod),
-			mockDC.EXPECT().SetDriverContainerAsDesired(context.Background(), &ds, imageName, gomock.AssignableToTypeOf(mod), kernelVersion).Do(
-				func(ctx context.Context, d *appsv1.DaemonSet, _ string, _ kmmv1beta1.Module, _ string) {
+			mockMR.EXPECT().ResolveMapping(ctx, &mod, kernelVersion, &nodeList.Items[0]).Return(&mappings[0], module.MappingSourceModule, nil),
+			mockKM.EXPECT().PrepareKernelMapping(mod.Spec, &mappings[0], &osConfig).Return(&mappings[0], nil),
+			mockDC.EXPECT().ModuleDaemonSetsByKey(ctx, moduleName, namespace).Return(dsByKey, nil),
+			mockBM.EXPECT().ShouldSync(gomock.Any(), mod, mappings[0], kernelVersion, "").Return(true, nil),
+			mockBM.EXPECT().Sync(gomock.Any(), mod, mappings[0], kernelVersion, "", &osConfig, true, &mod),
+			mockSM.EXPECT().ShouldSync(gomock.Any(), mod, mappings[0], kernelVersion, "").Return(true, nil),
+			mockSM.EXPECT().Sync(gomock.Any(), mod, mappings[0], kernelVersion, "", "", true, &mod),
+			mockDC.EXPECT().SetDriverContainerAsDesired(context.Background(), &ds, imageName, gomock.AssignableToTypeOf(mod), kernelVersion, "").Do(
+				func(ctx context.Context, d *appsv1.DaemonSet, _ string, _ kmmv1beta1.Module, _, _ string) {
 					d.SetLabels(map[string]string{"test": "test"})
 				}),
-			mockDC.EXPECT().GarbageCollect(ctx, dsByKernelVersion, sets.NewString(kernelVersion)),
-			mockBM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, &mod),
-			mockSU.EXPECT().ModuleUpdateStatus(ctx, &mod, nodeList.Items, nodeList.Items, dsByKernelVersion).Return(nil),
+			mockDC.EXPECT().GarbageCollect(ctx, dsByKey, map[daemonset.Key]bool{{KernelVersion: kernelVersion}: true}),
+			mockUM.EXPECT().ReleaseNodes(ctx, mod.Name, sets.NewString()),
+			mockBM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, &mod, map[module.KernelTarget]bool{}),
+			mockSM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, &mod),
+			mockSU.EXPECT().ModuleUpdateStatus(ctx, &mod, nodeList.Items, nodeList.Items, dsByKey, kernelVersionStatuses).Return(nil),
 		)
 
 		res, err := mr.Reconcile(context.Background(), req)
@@ -483,6 +642,140 @@ var _ = Describe("ModuleReconciler_Reconcile", func() {
 		Expect(res).To(Equal(reconcile.Result{}))
 	})
 
+	It("should keep handling the other kernel versions when one of them fails", func() {
+		const (
+			failingKernelVersion = "1.2.3"
+			kernelVersion        = "4.5.6"
+			imageName            = "test-image"
+			serviceAccountName   = "module-loader-service-account"
+		)
+
+		mappings := []kmmv1beta1.KernelMapping{
+			{
+				ContainerImage: imageName,
+				Literal:        failingKernelVersion,
+			},
+			{
+				ContainerImage: imageName,
+				Literal:        kernelVersion,
+			},
+		}
+
+		osConfig := module.NodeOSConfig{}
+
+		mod := kmmv1beta1.Module{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      moduleName,
+				Namespace: namespace,
+			},
+			Spec: kmmv1beta1.ModuleSpec{
+				ModuleLoader: kmmv1beta1.ModuleLoaderSpec{
+					ServiceAccountName: serviceAccountName,
+					Container: kmmv1beta1.ModuleLoaderContainerSpec{
+						KernelMappings: mappings,
+					},
+				},
+				Selector: map[string]string{"key": "value"},
+			},
+		}
+
+		nodeList := v1.NodeList{
+			Items: []v1.Node{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "node1",
+						Labels: map[string]string{"key": "value"},
+					},
+					Status: v1.NodeStatus{
+						NodeInfo: v1.NodeSystemInfo{KernelVersion: failingKernelVersion},
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "node2",
+						Labels: map[string]string{"key": "value"},
+					},
+					Status: v1.NodeStatus{
+						NodeInfo: v1.NodeSystemInfo{KernelVersion: kernelVersion},
+					},
+				},
+			},
+		}
+
+		dsByKey := make(map[daemonset.Key]*appsv1.DaemonSet)
+
+		kernelVersionStatuses := map[module.KernelTarget]*kmmv1beta1.KernelVersionStatus{
+			{KernelVersion: failingKernelVersion}: {
+				KernelVersion:  failingKernelVersion,
+				Mapping:        failingKernelVersion,
+				MappingSource:  module.MappingSourceModule,
+				ContainerImage: imageName,
+				Stage:          kmmv1beta1.KernelVersionStageBuild,
+				LastError:      "failed to handle build for kernel version 1.2.3: could not check if build synchronization is needed: some error",
+			},
+			{KernelVersion: kernelVersion}: {
+				KernelVersion:  kernelVersion,
+				Mapping:        kernelVersion,
+				MappingSource:  module.MappingSourceModule,
+				ContainerImage: imageName,
+				Stage:          kmmv1beta1.KernelVersionStageModuleLoader,
+			},
+		}
+
+		mockRV.EXPECT().ValidateReferences(ctx, gomock.Any(), gomock.Any())
+		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockRC, mockDC, mockUM, mockKM, mockMR, mockRV, mockMetrics, nil, mockSU, nil)
+
+		gomock.InOrder(
+			clnt.EXPECT().Get(ctx, req.NamespacedName, gomock.Any()).DoAndReturn(
+				func(_ interface{}, _ interface{}, m *kmmv1beta1.Module, _ ...ctrlclient.GetOption) error {
+					m.ObjectMeta = mod.ObjectMeta
+					m.Spec = mod.Spec
+					return nil
+				},
+			),
+			clnt.EXPECT().List(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ interface{}, list *kmmv1beta1.ModuleList, _ ...interface{}) error {
+					return nil
+				},
+			),
+			mockMetrics.EXPECT().SetExistingKMMOModules(0),
+			clnt.EXPECT().List(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ interface{}, list *v1.NodeList, _ ...interface{}) error {
+					list.Items = nodeList.Items
+					return nil
+				},
+			),
+			mockKM.EXPECT().GetNodeOSConfig(&nodeList.Items[0]).Return(&osConfig),
+			mockMR.EXPECT().ResolveMapping(ctx, &mod, failingKernelVersion, &nodeList.Items[0]).Return(&mappings[0], module.MappingSourceModule, nil),
+			mockKM.EXPECT().PrepareKernelMapping(mod.Spec, &mappings[0], &osConfig).Return(&mappings[0], nil),
+			mockKM.EXPECT().GetNodeOSConfig(&nodeList.Items[1]).Return(&osConfig),
+			mockMR.EXPECT().ResolveMapping(ctx, &mod, kernelVersion, &nodeList.Items[1]).Return(&mappings[1], module.MappingSourceModule, nil),
+			mockKM.EXPECT().PrepareKernelMapping(mod.Spec, &mappings[1], &osConfig).Return(&mappings[1], nil),
+			mockDC.EXPECT().ModuleDaemonSetsByKey(ctx, moduleName, namespace).Return(dsByKey, nil),
+		)
+
+		// kernel versions are handled in no particular order
+		mockBM.EXPECT().ShouldSync(gomock.Any(), mod, mappings[0], failingKernelVersion, "").Return(false, errors.New("some error"))
+		mockBM.EXPECT().ShouldSync(gomock.Any(), mod, mappings[1], kernelVersion, "").Return(false, nil)
+		mockSM.EXPECT().ShouldSync(gomock.Any(), mod, mappings[1], kernelVersion, "").Return(false, nil)
+		clnt.EXPECT().Get(ctx, gomock.Any(), gomock.Any()).Return(apierrors.NewNotFound(schema.GroupResource{}, "whatever"))
+		mockDC.EXPECT().SetDriverContainerAsDesired(context.Background(), gomock.Any(), imageName, gomock.AssignableToTypeOf(mod), kernelVersion, "")
+		clnt.EXPECT().Create(ctx, gomock.Any()).Return(nil)
+		mockMetrics.EXPECT().SetCompletedStage(moduleName, namespace, kernelVersion, metrics.ModuleLoaderStage, false)
+
+		gomock.InOrder(
+			mockDC.EXPECT().GarbageCollect(ctx, dsByKey, map[daemonset.Key]bool{{KernelVersion: failingKernelVersion}: true, {KernelVersion: kernelVersion}: true}),
+			mockUM.EXPECT().ReleaseNodes(ctx, mod.Name, sets.NewString()),
+			mockBM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, &mod, map[module.KernelTarget]bool{}),
+			mockSM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, &mod),
+			mockSU.EXPECT().ModuleUpdateStatus(ctx, &mod, nodeList.Items, nodeList.Items, dsByKey, kernelVersionStatuses).Return(nil),
+		)
+
+		_, err := mr.Reconcile(context.Background(), req)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("some error"))
+	})
+
 	It("should create a Device plugin if defined in the module", func() {
 		const (
 			imageName     = "test-image"
@@ -518,7 +811,8 @@ var _ = Describe("ModuleReconciler_Reconcile", func() {
 			},
 		}
 
-		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockRC, mockDC, mockKM, mockMetrics, nil, mockSU)
+		mockRV.EXPECT().ValidateReferences(ctx, gomock.Any(), gomock.Any())
+		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockRC, mockDC, mockUM, mockKM, mockMR, mockRV, mockMetrics, nil, mockSU, nil)
 
 		ds := appsv1.DaemonSet{
 			ObjectMeta: metav1.ObjectMeta{
@@ -547,15 +841,17 @@ var _ = Describe("ModuleReconciler_Reconcile", func() {
 					return nil
 				},
 			),
-			mockDC.EXPECT().ModuleDaemonSetsByKernelVersion(ctx, moduleName, namespace).Return(nil, nil),
+			mockDC.EXPECT().ModuleDaemonSetsByKey(ctx, moduleName, namespace).Return(nil, nil),
 			clnt.EXPECT().Get(ctx, gomock.Any(), gomock.Any()).Return(apierrors.NewNotFound(schema.GroupResource{}, "whatever")),
 			clnt.EXPECT().Get(ctx, gomock.Any(), gomock.Any()).Return(apierrors.NewNotFound(schema.GroupResource{}, "whatever")),
 			mockDC.EXPECT().SetDevicePluginAsDesired(context.Background(), &ds, gomock.AssignableToTypeOf(&mod)),
 			clnt.EXPECT().Create(ctx, gomock.Any()).Return(nil),
 			mockMetrics.EXPECT().SetCompletedStage(moduleName, namespace, "", metrics.DevicePluginStage, false),
-			mockDC.EXPECT().GarbageCollect(ctx, nil, sets.NewString()),
-			mockBM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, &mod),
-			mockSU.EXPECT().ModuleUpdateStatus(ctx, &mod, []v1.Node{}, []v1.Node{}, nil).Return(nil),
+			mockDC.EXPECT().GarbageCollect(ctx, nil, map[daemonset.Key]bool{}),
+			mockUM.EXPECT().ReleaseNodes(ctx, mod.Name, sets.NewString()),
+			mockBM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, &mod, map[module.KernelTarget]bool{}),
+			mockSM.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, &mod),
+			mockSU.EXPECT().ModuleUpdateStatus(ctx, &mod, []v1.Node{}, []v1.Node{}, nil, map[module.KernelTarget]*kmmv1beta1.KernelVersionStatus{}).Return(nil),
 		)
 
 		res, err := mr.Reconcile(context.Background(), req)
@@ -564,6 +860,110 @@ var _ = Describe("ModuleReconciler_Reconcile", func() {
 	})
 })
 
+var _ = Describe("ModuleReconciler_getRelevantKernelMappingsAndNodes", func() {
+	const kernelVersion = "5.15.0-76-generic"
+
+	node := func(name, arch, osImage string) v1.Node {
+		return v1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status: v1.NodeStatus{
+				NodeInfo: v1.NodeSystemInfo{
+					Architecture:  arch,
+					KernelVersion: kernelVersion,
+					OSImage:       osImage,
+				},
+			},
+		}
+	}
+
+	mod := kmmv1beta1.Module{
+		Spec: kmmv1beta1.ModuleSpec{
+			ModuleLoader: kmmv1beta1.ModuleLoaderSpec{
+				Container: kmmv1beta1.ModuleLoaderContainerSpec{
+					KernelMappings: []kmmv1beta1.KernelMapping{
+						{ContainerImage: "ubuntu-arm", Literal: kernelVersion, Architecture: "aarch64", OSImage: "^Ubuntu"},
+						{ContainerImage: "x86", Literal: kernelVersion, Architecture: "x86_64"},
+						{ContainerImage: "rhel-arm", Literal: kernelVersion, Architecture: "aarch64"},
+					},
+				},
+			},
+		},
+	}
+
+	It("should split the mappings by kernel version and architecture", func() {
+		kernelAPI := module.NewKernelMapper(build.NewHelper(), sign.NewSignerHelper())
+		mr := NewModuleReconciler(nil, nil, nil, nil, nil, nil, kernelAPI, module.NewMappingResolver(nil, kernelAPI), nil, nil, nil, nil, nil)
+
+		nodes := []v1.Node{
+			node("ubuntu-arm-1", "arm64", "Ubuntu 22.04.2 LTS"),
+			node("ubuntu-arm-2", "arm64", "Ubuntu 22.04.2 LTS"),
+			node("ubuntu-x86", "amd64", "Ubuntu 22.04.2 LTS"),
+			node("rhel-x86", "amd64", "Red Hat Enterprise Linux 9.2 (Plow)"),
+			node("rhel-arm", "arm64", "Red Hat Enterprise Linux 9.2 (Plow)"),
+			node("s390x", "s390x", "Ubuntu 22.04.2 LTS"),
+		}
+
+		mappings, sources, osConfigs, nodesWithMapping, err := mr.getRelevantKernelMappingsAndNodes(context.Background(), &mod, nodes)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(mappings).To(HaveLen(1))
+		Expect(sources).To(HaveKeyWithValue(module.KernelTarget{KernelVersion: kernelVersion, Architecture: "amd64"}, module.MappingSourceModule))
+		Expect(osConfigs).To(HaveLen(1))
+		Expect(osConfigs[module.KernelTarget{KernelVersion: kernelVersion, Architecture: "amd64"}].Architecture).To(Equal("amd64"))
+		Expect(mappings[module.KernelTarget{KernelVersion: kernelVersion, Architecture: "amd64"}].ContainerImage).To(Equal("x86"))
+
+		// rhel-arm resolves to another image than the Ubuntu arm64 nodes, so none of them is served, and no mapping
+		// matches s390x
+		Expect(nodesWithMapping).To(Equal(nodes[2:4]))
+	})
+
+	It("should not depend on the order of the nodes", func() {
+		kernelAPI := module.NewKernelMapper(build.NewHelper(), sign.NewSignerHelper())
+		mr := NewModuleReconciler(nil, nil, nil, nil, nil, nil, kernelAPI, module.NewMappingResolver(nil, kernelAPI), nil, nil, nil, nil, nil)
+
+		nodes := []v1.Node{
+			node("rhel-arm", "arm64", "Red Hat Enterprise Linux 9.2 (Plow)"),
+			node("ubuntu-arm-1", "arm64", "Ubuntu 22.04.2 LTS"),
+			node("ubuntu-arm-2", "arm64", "Ubuntu 22.04.2 LTS"),
+		}
+
+		mappings, _, _, nodesWithMapping, err := mr.getRelevantKernelMappingsAndNodes(context.Background(), &mod, nodes)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(mappings).To(BeEmpty())
+		Expect(nodesWithMapping).To(BeEmpty())
+	})
+	DescribeTable("should not build the same image for several architectures",
+		func(containerImage string, b *kmmv1beta1.Build, expectedMappings int) {
+			kernelAPI := module.NewKernelMapper(build.NewHelper(), sign.NewSignerHelper())
+			mr := NewModuleReconciler(nil, nil, nil, nil, nil, nil, kernelAPI, module.NewMappingResolver(nil, kernelAPI), nil, nil, nil, nil, nil)
+
+			mod := kmmv1beta1.Module{
+				Spec: kmmv1beta1.ModuleSpec{
+					ModuleLoader: kmmv1beta1.ModuleLoaderSpec{
+						Container: kmmv1beta1.ModuleLoaderContainerSpec{
+							KernelMappings: []kmmv1beta1.KernelMapping{
+								{ContainerImage: containerImage, Literal: kernelVersion, Build: b},
+							},
+						},
+					},
+				},
+			}
+
+			nodes := []v1.Node{
+				node("x86", "amd64", "Ubuntu 22.04.2 LTS"),
+				node("arm", "arm64", "Ubuntu 22.04.2 LTS"),
+			}
+
+			mappings, _, _, nodesWithMapping, err := mr.getRelevantKernelMappingsAndNodes(context.Background(), &mod, nodes)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(mappings).To(HaveLen(expectedMappings))
+			Expect(nodesWithMapping).To(HaveLen(expectedMappings))
+		},
+		Entry("pulled image", "image", nil, 2),
+		Entry("built image", "image", &kmmv1beta1.Build{}, 0),
+		Entry("built image of each architecture", "image:${ARCH}", &kmmv1beta1.Build{}, 2),
+	)
+})
+
 var _ = Describe("ModuleReconciler_handleBuild", func() {
 	var (
 		ctrl        *gomock.Controller
@@ -573,6 +973,7 @@ var _ = Describe("ModuleReconciler_handleBuild", func() {
 		mockRC      *rbac.MockRBACCreator
 		mockDC      *daemonset.MockDaemonSetCreator
 		mockKM      *module.MockKernelMapper
+		mockMR      *module.MockMappingResolver
 		mockMetrics *metrics.MockMetrics
 		mockSU      *statusupdater.MockModuleStatusUpdater
 	)
@@ -585,6 +986,7 @@ var _ = Describe("ModuleReconciler_handleBuild", func() {
 		mockRC = rbac.NewMockRBACCreator(ctrl)
 		mockDC = daemonset.NewMockDaemonSetCreator(ctrl)
 		mockKM = module.NewMockKernelMapper(ctrl)
+		mockMR = module.NewMockMappingResolver(ctrl)
 		mockMetrics = metrics.NewMockMetrics(ctrl)
 		mockSU = statusupdater.NewMockModuleStatusUpdater(ctrl)
 	})
@@ -604,12 +1006,48 @@ var _ = Describe("ModuleReconciler_handleBuild", func() {
 		mod := &kmmv1beta1.Module{}
 
 		gomock.InOrder(
-			mockBM.EXPECT().ShouldSync(gomock.Any(), *mod, *km).Return(false, nil),
+			mockBM.EXPECT().ShouldSync(gomock.Any(), *mod, *km, kernelVersion, "").Return(false, nil),
 		)
 
-		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockRC, mockDC, mockKM, mockMetrics, nil, mockSU)
+		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockRC, mockDC, nil, mockKM, mockMR, nil, mockMetrics, nil, mockSU, nil)
+
+		res, err := mr.handleBuild(context.Background(), mod, km, module.KernelTarget{KernelVersion: kernelVersion}, nil, false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(BeFalse())
+	})
+
+	It("should build even if the image exists when a rebuild is forced", func() {
+		km := &kmmv1beta1.KernelMapping{
+			ContainerImage: imageName,
+			Literal:        kernelVersion,
+			Build:          &kmmv1beta1.Build{},
+		}
+		mod := &kmmv1beta1.Module{
+			ObjectMeta: metav1.ObjectMeta{Name: moduleName, Namespace: namespace},
+		}
+		buildRes := build.Result{Requeue: true, Status: build.StatusInProgress}
+
+		mockBM.EXPECT().Sync(gomock.Any(), *mod, *km, kernelVersion, "", nil, true, mod).Return(buildRes, nil)
+
+		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockRC, mockDC, nil, mockKM, mockMR, nil, mockMetrics, nil, mockSU, nil)
+
+		res, err := mr.handleBuild(context.Background(), mod, km, module.KernelTarget{KernelVersion: kernelVersion}, nil, true)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(BeTrue())
+	})
+
+	It("should not force a build if the mapping has no build", func() {
+		km := &kmmv1beta1.KernelMapping{
+			ContainerImage: imageName,
+			Literal:        kernelVersion,
+		}
+		mod := &kmmv1beta1.Module{}
+
+		mockBM.EXPECT().ShouldSync(gomock.Any(), *mod, *km, kernelVersion, "").Return(false, nil)
+
+		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockRC, mockDC, nil, mockKM, mockMR, nil, mockMetrics, nil, mockSU, nil)
 
-		res, err := mr.handleBuild(context.Background(), mod, km, kernelVersion)
+		res, err := mr.handleBuild(context.Background(), mod, km, module.KernelTarget{KernelVersion: kernelVersion}, nil, true)
 		Expect(err).NotTo(HaveOccurred())
 		Expect(res).To(BeFalse())
 	})
@@ -629,13 +1067,13 @@ var _ = Describe("ModuleReconciler_handleBuild", func() {
 		}
 		buildRes := build.Result{Requeue: true, Status: build.StatusCreated}
 		gomock.InOrder(
-			mockBM.EXPECT().ShouldSync(gomock.Any(), *mod, *km).Return(true, nil),
-			mockBM.EXPECT().Sync(gomock.Any(), *mod, *km, gomock.Any(), true, mod).Return(buildRes, nil),
+			mockBM.EXPECT().ShouldSync(gomock.Any(), *mod, *km, kernelVersion, "").Return(true, nil),
+			mockBM.EXPECT().Sync(gomock.Any(), *mod, *km, gomock.Any(), "", nil, true, mod).Return(buildRes, nil),
 			mockMetrics.EXPECT().SetCompletedStage(mod.Name, mod.Namespace, kernelVersion, metrics.BuildStage, false),
 		)
 
-		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockRC, mockDC, mockKM, mockMetrics, nil, mockSU)
-		res, err := mr.handleBuild(context.Background(), mod, km, kernelVersion)
+		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockRC, mockDC, nil, mockKM, mockMR, nil, mockMetrics, nil, mockSU, nil)
+		res, err := mr.handleBuild(context.Background(), mod, km, module.KernelTarget{KernelVersion: kernelVersion}, nil, false)
 		Expect(err).NotTo(HaveOccurred())
 		Expect(res).To(BeTrue())
 	})
@@ -655,16 +1093,51 @@ var _ = Describe("ModuleReconciler_handleBuild", func() {
 		}
 		buildRes := build.Result{Requeue: false, Status: build.StatusCompleted}
 		gomock.InOrder(
-			mockBM.EXPECT().ShouldSync(gomock.Any(), *mod, *km).Return(true, nil),
-			mockBM.EXPECT().Sync(gomock.Any(), *mod, *km, gomock.Any(), true, mod).Return(buildRes, nil),
+			mockBM.EXPECT().ShouldSync(gomock.Any(), *mod, *km, kernelVersion, "").Return(true, nil),
+			mockBM.EXPECT().Sync(gomock.Any(), *mod, *km, gomock.Any(), "", nil, true, mod).Return(buildRes, nil),
 			mockMetrics.EXPECT().SetCompletedStage(mod.Name, mod.Namespace, kernelVersion, metrics.BuildStage, true),
 		)
 
-		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockRC, mockDC, mockKM, mockMetrics, nil, mockSU)
-		res, err := mr.handleBuild(context.Background(), mod, km, kernelVersion)
+		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockRC, mockDC, nil, mockKM, mockMR, nil, mockMetrics, nil, mockSU, nil)
+		res, err := mr.handleBuild(context.Background(), mod, km, module.KernelTarget{KernelVersion: kernelVersion}, nil, false)
 		Expect(err).NotTo(HaveOccurred())
 		Expect(res).To(BeFalse())
 	})
+
+	It("should return a jobFailedError with the reason and the logs when the build has failed", func() {
+		km := &kmmv1beta1.KernelMapping{
+			ContainerImage: imageName,
+			Literal:        kernelVersion,
+			Build:          &kmmv1beta1.Build{},
+		}
+		mod := &kmmv1beta1.Module{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      moduleName,
+				Namespace: namespace,
+			},
+		}
+		buildRes := build.Result{
+			Status:        build.StatusFailed,
+			Reason:        "BackoffLimitExceeded: some message",
+			LogsConfigMap: "some-job-logs",
+			FailedJobHash: "some-hash",
+		}
+		gomock.InOrder(
+			mockBM.EXPECT().ShouldSync(gomock.Any(), *mod, *km, kernelVersion, "").Return(true, nil),
+			mockBM.EXPECT().Sync(gomock.Any(), *mod, *km, gomock.Any(), "", nil, true, mod).Return(buildRes, nil),
+		)
+
+		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockRC, mockDC, nil, mockKM, mockMR, nil, mockMetrics, nil, mockSU, nil)
+		res, err := mr.handleBuild(context.Background(), mod, km, module.KernelTarget{KernelVersion: kernelVersion}, nil, false)
+		Expect(err).To(MatchError(&jobFailedError{
+			stage:         "build",
+			reason:        "BackoffLimitExceeded: some message",
+			logsConfigMap: "some-job-logs",
+			failedJobHash: "some-hash",
+		}))
+		Expect(err.Error()).To(Equal("build failed: BackoffLimitExceeded: some message; logs are in ConfigMap some-job-logs"))
+		Expect(res).To(BeFalse())
+	})
 })
 
 /***************** signing ***********************/
@@ -677,6 +1150,7 @@ var _ = Describe("ModuleReconciler_handleSigning", func() {
 		mockRC      *rbac.MockRBACCreator
 		mockDC      *daemonset.MockDaemonSetCreator
 		mockKM      *module.MockKernelMapper
+		mockMR      *module.MockMappingResolver
 		mockMetrics *metrics.MockMetrics
 		mockSU      *statusupdater.MockModuleStatusUpdater
 	)
@@ -689,6 +1163,7 @@ var _ = Describe("ModuleReconciler_handleSigning", func() {
 		mockRC = rbac.NewMockRBACCreator(ctrl)
 		mockDC = daemonset.NewMockDaemonSetCreator(ctrl)
 		mockKM = module.NewMockKernelMapper(ctrl)
+		mockMR = module.NewMockMappingResolver(ctrl)
 		mockMetrics = metrics.NewMockMetrics(ctrl)
 		mockSU = statusupdater.NewMockModuleStatusUpdater(ctrl)
 	})
@@ -708,12 +1183,12 @@ var _ = Describe("ModuleReconciler_handleSigning", func() {
 		mod := &kmmv1beta1.Module{}
 
 		gomock.InOrder(
-			mockSM.EXPECT().ShouldSync(gomock.Any(), *mod, *km).Return(false, nil),
+			mockSM.EXPECT().ShouldSync(gomock.Any(), *mod, *km, kernelVersion, "").Return(false, nil),
 		)
 
-		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockRC, mockDC, mockKM, mockMetrics, nil, mockSU)
+		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockRC, mockDC, nil, mockKM, mockMR, nil, mockMetrics, nil, mockSU, nil)
 
-		res, err := mr.handleSigning(context.Background(), mod, km, kernelVersion)
+		res, err := mr.handleSigning(context.Background(), mod, km, module.KernelTarget{KernelVersion: kernelVersion}, false)
 		Expect(err).NotTo(HaveOccurred())
 		Expect(res).To(BeFalse())
 	})
@@ -734,14 +1209,14 @@ var _ = Describe("ModuleReconciler_handleSigning", func() {
 
 		signRes := utils.Result{Requeue: true, Status: utils.StatusCreated}
 		gomock.InOrder(
-			mockSM.EXPECT().ShouldSync(gomock.Any(), *mod, *km).Return(true, nil),
-			mockSM.EXPECT().Sync(gomock.Any(), *mod, *km, gomock.Any(), "", true, mod).Return(signRes, nil),
+			mockSM.EXPECT().ShouldSync(gomock.Any(), *mod, *km, kernelVersion, "").Return(true, nil),
+			mockSM.EXPECT().Sync(gomock.Any(), *mod, *km, gomock.Any(), "", "", true, mod).Return(signRes, nil),
 			mockMetrics.EXPECT().SetCompletedStage(mod.Name, mod.Namespace, kernelVersion, metrics.SignStage, false),
 		)
 
-		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockRC, mockDC, mockKM, mockMetrics, nil, mockSU)
+		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockRC, mockDC, nil, mockKM, mockMR, nil, mockMetrics, nil, mockSU, nil)
 
-		res, err := mr.handleSigning(context.Background(), mod, km, kernelVersion)
+		res, err := mr.handleSigning(context.Background(), mod, km, module.KernelTarget{KernelVersion: kernelVersion}, false)
 
 		Expect(err).NotTo(HaveOccurred())
 		Expect(res).To(BeTrue())
@@ -763,19 +1238,40 @@ var _ = Describe("ModuleReconciler_handleSigning", func() {
 
 		signRes := utils.Result{Requeue: false, Status: build.StatusCompleted}
 		gomock.InOrder(
-			mockSM.EXPECT().ShouldSync(gomock.Any(), *mod, *km).Return(true, nil),
-			mockSM.EXPECT().Sync(gomock.Any(), *mod, *km, gomock.Any(), "", true, mod).Return(signRes, nil),
+			mockSM.EXPECT().ShouldSync(gomock.Any(), *mod, *km, kernelVersion, "").Return(true, nil),
+			mockSM.EXPECT().Sync(gomock.Any(), *mod, *km, gomock.Any(), "", "", true, mod).Return(signRes, nil),
 			mockMetrics.EXPECT().SetCompletedStage(mod.Name, mod.Namespace, kernelVersion, metrics.SignStage, true),
 		)
 
-		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockRC, mockDC, mockKM, mockMetrics, nil, mockSU)
+		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockRC, mockDC, nil, mockKM, mockMR, nil, mockMetrics, nil, mockSU, nil)
 
-		res, err := mr.handleSigning(context.Background(), mod, km, kernelVersion)
+		res, err := mr.handleSigning(context.Background(), mod, km, module.KernelTarget{KernelVersion: kernelVersion}, false)
 
 		Expect(err).NotTo(HaveOccurred())
 		Expect(res).To(BeFalse())
 	})
 
+	It("should sign even if the image exists when a rebuild is forced", func() {
+		km := &kmmv1beta1.KernelMapping{
+			ContainerImage: imageName,
+			Literal:        kernelVersion,
+			Sign:           &kmmv1beta1.Sign{},
+		}
+		mod := &kmmv1beta1.Module{
+			ObjectMeta: metav1.ObjectMeta{Name: moduleName, Namespace: namespace},
+		}
+
+		signRes := utils.Result{Requeue: true, Status: utils.StatusInProgress}
+		mockSM.EXPECT().Sync(gomock.Any(), *mod, *km, kernelVersion, "", "", true, mod).Return(signRes, nil)
+
+		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockRC, mockDC, nil, mockKM, mockMR, nil, mockMetrics, nil, mockSU, nil)
+
+		res, err := mr.handleSigning(context.Background(), mod, km, module.KernelTarget{KernelVersion: kernelVersion}, true)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(BeTrue())
+	})
+
 	It("should run sign sync with the previous image as well when module build and sign are specified", func() {
 		km := &kmmv1beta1.KernelMapping{
 			ContainerImage: imageName,
@@ -793,22 +1289,423 @@ var _ = Describe("ModuleReconciler_handleSigning", func() {
 
 		signRes := utils.Result{Requeue: false, Status: build.StatusCompleted}
 		gomock.InOrder(
-			mockSM.EXPECT().ShouldSync(gomock.Any(), *mod, *km).Return(true, nil),
-			mockSM.EXPECT().Sync(gomock.Any(), *mod, *km, gomock.Any(), imageName+":"+namespace+"_"+moduleName+"_kmm_unsigned", true, mod).Return(signRes, nil),
+			mockSM.EXPECT().ShouldSync(gomock.Any(), *mod, *km, kernelVersion, "").Return(true, nil),
+			mockSM.EXPECT().Sync(gomock.Any(), *mod, *km, gomock.Any(), "", imageName+":"+namespace+"_"+moduleName+"_kmm_unsigned", true, mod).Return(signRes, nil),
 			mockMetrics.EXPECT().SetCompletedStage(mod.Name, mod.Namespace, kernelVersion, metrics.SignStage, true),
 		)
 
-		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockRC, mockDC, mockKM, mockMetrics, nil, mockSU)
+		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockRC, mockDC, nil, mockKM, mockMR, nil, mockMetrics, nil, mockSU, nil)
 
-		res, err := mr.handleSigning(context.Background(), mod, km, kernelVersion)
+		res, err := mr.handleSigning(context.Background(), mod, km, module.KernelTarget{KernelVersion: kernelVersion}, false)
 
 		Expect(err).NotTo(HaveOccurred())
 		Expect(res).To(BeFalse())
 	})
+
+	It("should return a jobFailedError when the signing has failed", func() {
+		km := &kmmv1beta1.KernelMapping{
+			ContainerImage: imageName,
+			Literal:        kernelVersion,
+			Sign:           &kmmv1beta1.Sign{},
+		}
+		mod := &kmmv1beta1.Module{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      moduleName,
+				Namespace: namespace,
+			},
+		}
+
+		signRes := utils.Result{Status: utils.StatusFailed, Reason: "BackoffLimitExceeded: some message"}
+		gomock.InOrder(
+			mockSM.EXPECT().ShouldSync(gomock.Any(), *mod, *km, kernelVersion, "").Return(true, nil),
+			mockSM.EXPECT().Sync(gomock.Any(), *mod, *km, gomock.Any(), "", "", true, mod).Return(signRes, nil),
+		)
+
+		mr := NewModuleReconciler(clnt, mockBM, mockSM, mockRC, mockDC, nil, mockKM, mockMR, nil, mockMetrics, nil, mockSU, nil)
+
+		res, err := mr.handleSigning(context.Background(), mod, km, module.KernelTarget{KernelVersion: kernelVersion}, false)
+
+		Expect(err).To(MatchError(&jobFailedError{stage: "signing", reason: "BackoffLimitExceeded: some message"}))
+		Expect(err.Error()).To(Equal("signing failed: BackoffLimitExceeded: some message"))
+		Expect(res).To(BeFalse())
+	})
 })
 
 /***************** end signing ***********************/
 
+var _ = Describe("ModuleReconciler_handleDriverContainer", func() {
+	var (
+		ctrl   *gomock.Controller
+		clnt   *client.MockClient
+		mockDC *daemonset.MockDaemonSetCreator
+		mockUM *upgrade.MockManager
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		clnt = client.NewMockClient(ctrl)
+		mockDC = daemonset.NewMockDaemonSetCreator(ctrl)
+		mockUM = upgrade.NewMockManager(ctrl)
+	})
+
+	const kernelVersion = "1.2.3"
+
+	ctx := context.Background()
+	km := &kmmv1beta1.KernelMapping{ContainerImage: "test-image", Literal: kernelVersion}
+
+	ds := appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "some-daemonset", Namespace: namespace},
+	}
+
+	It("should not upgrade the DaemonSet node by node if there is no upgrade policy", func() {
+		mod := kmmv1beta1.Module{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-module", Namespace: namespace},
+		}
+
+		dsByKey := map[daemonset.Key]*appsv1.DaemonSet{{KernelVersion: kernelVersion}: ds.DeepCopy()}
+
+		gomock.InOrder(
+			clnt.EXPECT().Get(ctx, gomock.Any(), gomock.Any()),
+			mockDC.EXPECT().SetDriverContainerAsDesired(ctx, gomock.Any(), km.ContainerImage, mod, kernelVersion, ""),
+		)
+
+		mr := NewModuleReconciler(clnt, nil, nil, nil, mockDC, mockUM, nil, nil, nil, nil, nil, nil, nil)
+
+		upgrading, err := mr.handleDriverContainer(ctx, &mod, km, dsByKey, module.KernelTarget{KernelVersion: kernelVersion})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(upgrading).To(BeFalse())
+	})
+
+	It("should pin the image by digest if the Module can be rebuilt", func() {
+		mod := kmmv1beta1.Module{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-module", Namespace: namespace},
+			Spec:       kmmv1beta1.ModuleSpec{RebuildTrigger: "1"},
+		}
+
+		dsByKey := map[daemonset.Key]*appsv1.DaemonSet{{KernelVersion: kernelVersion}: ds.DeepCopy()}
+
+		mockReg := registry.NewMockRegistry(ctrl)
+
+		gomock.InOrder(
+			mockReg.EXPECT().GetDigest(ctx, km.ContainerImage, gomock.Any(), nil).Return("sha256:some-digest", nil),
+			clnt.EXPECT().Get(ctx, gomock.Any(), gomock.Any()),
+			mockDC.EXPECT().SetDriverContainerAsDesired(ctx, gomock.Any(), km.ContainerImage+"@sha256:some-digest", mod, kernelVersion, ""),
+		)
+
+		mr := NewModuleReconciler(clnt, nil, nil, nil, mockDC, mockUM, nil, nil, nil, nil, nil, nil, mockReg)
+
+		upgrading, err := mr.handleDriverContainer(ctx, &mod, km, dsByKey, module.KernelTarget{KernelVersion: kernelVersion})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(upgrading).To(BeFalse())
+	})
+
+	It("should upgrade the DaemonSet node by node if there is an upgrade policy", func() {
+		mod := kmmv1beta1.Module{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-module", Namespace: namespace},
+			Spec: kmmv1beta1.ModuleSpec{
+				ModuleLoader: kmmv1beta1.ModuleLoaderSpec{
+					UpgradePolicy: &kmmv1beta1.UpgradePolicy{Drain: true},
+				},
+			},
+		}
+
+		dsByKey := map[daemonset.Key]*appsv1.DaemonSet{{KernelVersion: kernelVersion}: ds.DeepCopy()}
+
+		gomock.InOrder(
+			clnt.EXPECT().Get(ctx, gomock.Any(), gomock.Any()),
+			mockDC.EXPECT().SetDriverContainerAsDesired(ctx, gomock.Any(), km.ContainerImage, mod, kernelVersion, ""),
+			mockUM.EXPECT().Upgrade(ctx, &mod, dsByKey[daemonset.Key{KernelVersion: kernelVersion}]).Return(true, nil),
+		)
+
+		mr := NewModuleReconciler(clnt, nil, nil, nil, mockDC, mockUM, nil, nil, nil, nil, nil, nil, nil)
+
+		upgrading, err := mr.handleDriverContainer(ctx, &mod, km, dsByKey, module.KernelTarget{KernelVersion: kernelVersion})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(upgrading).To(BeTrue())
+	})
+
+	It("should return an error if the upgrade failed", func() {
+		mod := kmmv1beta1.Module{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-module", Namespace: namespace},
+			Spec: kmmv1beta1.ModuleSpec{
+				ModuleLoader: kmmv1beta1.ModuleLoaderSpec{
+					UpgradePolicy: &kmmv1beta1.UpgradePolicy{},
+				},
+			},
+		}
+
+		dsByKey := map[daemonset.Key]*appsv1.DaemonSet{{KernelVersion: kernelVersion}: ds.DeepCopy()}
+
+		gomock.InOrder(
+			clnt.EXPECT().Get(ctx, gomock.Any(), gomock.Any()),
+			mockDC.EXPECT().SetDriverContainerAsDesired(ctx, gomock.Any(), km.ContainerImage, mod, kernelVersion, ""),
+			mockUM.EXPECT().Upgrade(ctx, &mod, gomock.Any()).Return(false, errors.New("some error")),
+		)
+
+		mr := NewModuleReconciler(clnt, nil, nil, nil, mockDC, mockUM, nil, nil, nil, nil, nil, nil, nil)
+
+		_, err := mr.handleDriverContainer(ctx, &mod, km, dsByKey, module.KernelTarget{KernelVersion: kernelVersion})
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("ModuleReconciler_handleModuleVersion", func() {
+	var (
+		ctrl *gomock.Controller
+		clnt *client.MockClient
+		mr   *ModuleReconciler
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		clnt = client.NewMockClient(ctrl)
+		mr = NewModuleReconciler(clnt, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	})
+
+	const moduleName = "test-module"
+
+	ctx := context.Background()
+
+	mod := kmmv1beta1.Module{
+		ObjectMeta: metav1.ObjectMeta{Name: moduleName, Namespace: namespace},
+		Spec: kmmv1beta1.ModuleSpec{
+			ModuleLoader: kmmv1beta1.ModuleLoaderSpec{
+				Container: kmmv1beta1.ModuleLoaderContainerSpec{Version: "v2"},
+			},
+		},
+	}
+
+	requestedLabel := daemonset.GetModuleVersionNodeLabel(namespace, moduleName)
+	loaderLabel := daemonset.GetModuleLoaderVersionNodeLabel(namespace, moduleName)
+
+	newNode := func(name string, nodeLabels map[string]string) v1.Node {
+		return v1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: nodeLabels},
+		}
+	}
+
+	expectPods := func(nodeNames ...string) {
+		clnt.EXPECT().List(ctx, &v1.PodList{}, gomock.Any()).DoAndReturn(
+			func(_ context.Context, list *v1.PodList, _ ...ctrlclient.ListOption) error {
+				for _, n := range nodeNames {
+					list.Items = append(list.Items, v1.Pod{Spec: v1.PodSpec{NodeName: n}})
+				}
+				return nil
+			},
+		)
+	}
+
+	It("should do nothing if the Module has no version", func() {
+		requeue, err := mr.handleModuleVersion(ctx, &kmmv1beta1.Module{}, []v1.Node{newNode("node", nil)})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(requeue).To(BeFalse())
+	})
+
+	It("should do nothing if all nodes run the requested version", func() {
+		expectPods("node-a")
+
+		nodes := []v1.Node{
+			newNode("node-a", map[string]string{requestedLabel: "v1", loaderLabel: "v1"}),
+			newNode("node-b", nil),
+		}
+
+		requeue, err := mr.handleModuleVersion(ctx, &mod, nodes)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(requeue).To(BeFalse())
+	})
+
+	It("should unload the previous version first", func() {
+		expectPods("node-a")
+
+		nodes := []v1.Node{
+			newNode("node-a", map[string]string{requestedLabel: "v2", loaderLabel: "v1"}),
+		}
+
+		clnt.EXPECT().Patch(ctx, gomock.Any(), gomock.Any()).Do(
+			func(_ context.Context, node *v1.Node, _ ctrlclient.Patch, _ ...ctrlclient.PatchOption) {
+				Expect(node.Labels).NotTo(HaveKey(loaderLabel))
+			},
+		)
+
+		requeue, err := mr.handleModuleVersion(ctx, &mod, nodes)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(requeue).To(BeTrue())
+	})
+
+	It("should wait for the previous version to be unloaded", func() {
+		expectPods("node-a")
+
+		nodes := []v1.Node{
+			newNode("node-a", map[string]string{requestedLabel: "v2"}),
+		}
+
+		requeue, err := mr.handleModuleVersion(ctx, &mod, nodes)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(requeue).To(BeTrue())
+	})
+
+	It("should allow the requested version once the previous one is unloaded", func() {
+		expectPods()
+
+		nodes := []v1.Node{
+			newNode("node-a", map[string]string{requestedLabel: "v2"}),
+		}
+
+		clnt.EXPECT().Patch(ctx, gomock.Any(), gomock.Any()).Do(
+			func(_ context.Context, node *v1.Node, _ ctrlclient.Patch, _ ...ctrlclient.PatchOption) {
+				Expect(node.Labels).To(HaveKeyWithValue(loaderLabel, "v2"))
+			},
+		)
+
+		requeue, err := mr.handleModuleVersion(ctx, &mod, nodes)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(requeue).To(BeTrue())
+	})
+
+	It("should return an error if a node could not be patched", func() {
+		expectPods()
+
+		nodes := []v1.Node{
+			newNode("node-a", map[string]string{requestedLabel: "v2"}),
+		}
+
+		clnt.EXPECT().Patch(ctx, gomock.Any(), gomock.Any()).Return(errors.New("some error"))
+
+		_, err := mr.handleModuleVersion(ctx, &mod, nodes)
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("ModuleReconciler_garbageCollect", func() {
+	var (
+		ctrl   *gomock.Controller
+		clnt   *client.MockClient
+		mockBM *build.MockManager
+		mockSM *sign.MockSignManager
+		mockDC *daemonset.MockDaemonSetCreator
+		mockUM *upgrade.MockManager
+		mr     *ModuleReconciler
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		clnt = client.NewMockClient(ctrl)
+		mockBM = build.NewMockManager(ctrl)
+		mockSM = sign.NewMockSignManager(ctrl)
+		mockDC = daemonset.NewMockDaemonSetCreator(ctrl)
+		mockUM = upgrade.NewMockManager(ctrl)
+		mr = NewModuleReconciler(clnt, mockBM, mockSM, nil, mockDC, mockUM, nil, nil, nil, nil, nil, nil, nil)
+	})
+
+	const (
+		kernelVersion = "1.2.3"
+		moduleName    = "test-module"
+	)
+
+	ctx := context.Background()
+
+	It("should keep the DaemonSets of previous versions still allowed on some nodes", func() {
+		mod := kmmv1beta1.Module{
+			ObjectMeta: metav1.ObjectMeta{Name: moduleName, Namespace: namespace},
+			Spec: kmmv1beta1.ModuleSpec{
+				ModuleLoader: kmmv1beta1.ModuleLoaderSpec{
+					Container: kmmv1beta1.ModuleLoaderContainerSpec{Version: "v3"},
+				},
+			},
+		}
+
+		mappings := map[module.KernelTarget]*kmmv1beta1.KernelMapping{{KernelVersion: kernelVersion}: {}}
+
+		nodes := []v1.Node{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{daemonset.GetModuleLoaderVersionNodeLabel(namespace, moduleName): "v2"},
+				},
+			},
+		}
+
+		existingDS := map[daemonset.Key]*appsv1.DaemonSet{
+			{KernelVersion: kernelVersion, ModuleVersion: "v1"}: {},
+			{KernelVersion: kernelVersion, ModuleVersion: "v2"}: {},
+			{KernelVersion: "4.5.6", ModuleVersion: "v2"}:       {},
+		}
+
+		expectedKeys := map[daemonset.Key]bool{
+			{KernelVersion: kernelVersion, ModuleVersion: "v2"}: true,
+			{KernelVersion: kernelVersion, ModuleVersion: "v3"}: true,
+		}
+
+		gomock.InOrder(
+			mockDC.EXPECT().GarbageCollect(ctx, existingDS, expectedKeys),
+			mockUM.EXPECT().ReleaseNodes(ctx, moduleName, sets.NewString()),
+			mockBM.EXPECT().GarbageCollect(ctx, moduleName, namespace, &mod, map[module.KernelTarget]bool{}),
+			mockSM.EXPECT().GarbageCollect(ctx, moduleName, namespace, &mod),
+		)
+
+		Expect(
+			mr.garbageCollect(ctx, &mod, mappings, nodes, existingDS, nil),
+		).To(
+			Succeed(),
+		)
+	})
+
+	It("should release the nodes upgraded by garbage-collected DaemonSets", func() {
+		mod := kmmv1beta1.Module{
+			ObjectMeta: metav1.ObjectMeta{Name: moduleName, Namespace: namespace},
+			Spec: kmmv1beta1.ModuleSpec{
+				ModuleLoader: kmmv1beta1.ModuleLoaderSpec{UpgradePolicy: &kmmv1beta1.UpgradePolicy{}},
+			},
+		}
+
+		mappings := map[module.KernelTarget]*kmmv1beta1.KernelMapping{{KernelVersion: kernelVersion}: {}}
+
+		existingDS := map[daemonset.Key]*appsv1.DaemonSet{
+			{KernelVersion: kernelVersion}: {ObjectMeta: metav1.ObjectMeta{Name: "ds-current"}},
+			{KernelVersion: "4.5.6"}:       {ObjectMeta: metav1.ObjectMeta{Name: "ds-old"}},
+		}
+
+		gomock.InOrder(
+			mockDC.EXPECT().GarbageCollect(ctx, existingDS, gomock.Any()).Return([]string{"ds-old"}, nil),
+			mockUM.EXPECT().ReleaseNodes(ctx, moduleName, sets.NewString("ds-current")),
+			mockBM.EXPECT().GarbageCollect(ctx, moduleName, namespace, &mod, map[module.KernelTarget]bool{}),
+			mockSM.EXPECT().GarbageCollect(ctx, moduleName, namespace, &mod),
+		)
+
+		Expect(
+			mr.garbageCollect(ctx, &mod, mappings, nil, existingDS, nil),
+		).To(
+			Succeed(),
+		)
+	})
+
+	It("should keep the build objects of the targets being rebuilt", func() {
+		mod := kmmv1beta1.Module{
+			ObjectMeta: metav1.ObjectMeta{Name: moduleName, Namespace: namespace},
+			Spec:       kmmv1beta1.ModuleSpec{RebuildTrigger: "2"},
+		}
+
+		rebuilt := module.KernelTarget{KernelVersion: "4.5.6"}
+
+		mappings := map[module.KernelTarget]*kmmv1beta1.KernelMapping{{KernelVersion: kernelVersion}: {}, rebuilt: {}}
+
+		kernelVersionStatuses := map[module.KernelTarget]*kmmv1beta1.KernelVersionStatus{
+			{KernelVersion: kernelVersion}: {KernelVersion: kernelVersion, RebuildTrigger: "1"},
+			rebuilt:                        {KernelVersion: rebuilt.KernelVersion, RebuildTrigger: "2"},
+		}
+
+		mockDC.EXPECT().GarbageCollect(ctx, nil, map[daemonset.Key]bool{{KernelVersion: kernelVersion}: true, {KernelVersion: rebuilt.KernelVersion}: true})
+		mockUM.EXPECT().ReleaseNodes(ctx, moduleName, sets.NewString())
+		mockBM.EXPECT().GarbageCollect(ctx, moduleName, namespace, &mod, map[module.KernelTarget]bool{{KernelVersion: kernelVersion}: true})
+		mockSM.EXPECT().GarbageCollect(ctx, moduleName, namespace, &mod)
+
+		Expect(
+			mr.garbageCollect(ctx, &mod, mappings, nil, nil, kernelVersionStatuses),
+		).To(
+			Succeed(),
+		)
+	})
+})
+
 var _ = Describe("ModuleReconciler_getNodesListBySelector", func() {
 	var (
 		ctrl *gomock.Controller
@@ -834,7 +1731,7 @@ var _ = Describe("ModuleReconciler_getNodesListBySelector", func() {
 				return nil
 			},
 		)
-		mr := NewModuleReconciler(clnt, nil, nil, nil, nil, nil, nil, nil, nil)
+		mr := NewModuleReconciler(clnt, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
 		nodeList, err := mr.getNodesListBySelector(context.Background(), &mod)
 		Expect(err).NotTo(HaveOccurred())
 		Expect(len(nodeList)).To(Equal(0))
@@ -847,7 +1744,7 @@ var _ = Describe("ModuleReconciler_getNodesListBySelector", func() {
 				return nil
 			},
 		)
-		mr := NewModuleReconciler(clnt, nil, nil, nil, nil, nil, nil, nil, nil)
+		mr := NewModuleReconciler(clnt, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
 		nodeList, err := mr.getNodesListBySelector(context.Background(), &mod)
 		Expect(err).NotTo(HaveOccurred())
 		Expect(len(nodeList)).To(Equal(2))
@@ -869,9 +1766,113 @@ var _ = Describe("ModuleReconciler_getNodesListBySelector", func() {
 				return nil
 			},
 		)
-		mr := NewModuleReconciler(clnt, nil, nil, nil, nil, nil, nil, nil, nil)
+		mr := NewModuleReconciler(clnt, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
 		nodeList, err := mr.getNodesListBySelector(context.Background(), &mod)
 		Expect(err).NotTo(HaveOccurred())
 		Expect(len(nodeList)).To(Equal(1))
 	})
+
+	It("2 nodes with matching labels, 1 with a tolerated taint", func() {
+		taintedNode := v1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "tainted"},
+			Spec: v1.NodeSpec{
+				Taints: []v1.Taint{
+					{
+						Key:    "nvidia.com/gpu",
+						Effect: v1.TaintEffectNoSchedule,
+					},
+				},
+			},
+		}
+
+		otherTaintedNode := v1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "other-tainted"},
+			Spec: v1.NodeSpec{
+				Taints: []v1.Taint{
+					{
+						Key:    "other",
+						Effect: v1.TaintEffectNoSchedule,
+					},
+				},
+			},
+		}
+
+		tolerantMod := mod
+		tolerantMod.Spec.ModuleLoader.Tolerations = []v1.Toleration{
+			{Key: "nvidia.com/gpu", Operator: v1.TolerationOpExists, Effect: v1.TaintEffectNoSchedule},
+		}
+
+		clnt.EXPECT().List(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ interface{}, list *v1.NodeList, _ ...interface{}) error {
+				list.Items = []v1.Node{taintedNode, otherTaintedNode}
+				return nil
+			},
+		)
+		mr := NewModuleReconciler(clnt, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+		nodeList, err := mr.getNodesListBySelector(context.Background(), &tolerantMod)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(nodeList).To(Equal([]v1.Node{taintedNode}))
+	})
+
+	It("2 nodes with matching labels, 1 excluded by the module loader's affinity", func() {
+		gpuNode := v1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "gpu",
+				Labels: map[string]string{"gpu": "true"},
+			},
+		}
+
+		otherNode := v1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "other"},
+		}
+
+		affinityMod := mod
+		affinityMod.Spec.ModuleLoader.Affinity = &v1.Affinity{
+			NodeAffinity: &v1.NodeAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: &v1.NodeSelector{
+					NodeSelectorTerms: []v1.NodeSelectorTerm{
+						{
+							MatchExpressions: []v1.NodeSelectorRequirement{
+								{Key: "gpu", Operator: v1.NodeSelectorOpExists},
+							},
+						},
+					},
+				},
+			},
+		}
+
+		clnt.EXPECT().List(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ interface{}, list *v1.NodeList, _ ...interface{}) error {
+				list.Items = []v1.Node{gpuNode, otherNode}
+				return nil
+			},
+		)
+		mr := NewModuleReconciler(clnt, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+		nodeList, err := mr.getNodesListBySelector(context.Background(), &affinityMod)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(nodeList).To(Equal([]v1.Node{gpuNode}))
+	})
+})
+
+var _ = Describe("lastRebuildTrigger", func() {
+	mod := kmmv1beta1.Module{
+		Spec: kmmv1beta1.ModuleSpec{RebuildTrigger: "2"},
+		Status: kmmv1beta1.ModuleStatus{
+			KernelVersions: []kmmv1beta1.KernelVersionStatus{
+				{KernelVersion: "1.2.3", RebuildTrigger: "1"},
+			},
+		},
+	}
+
+	It("should return the trigger of a known kernel version", func() {
+		Expect(lastRebuildTrigger(&mod, module.KernelTarget{KernelVersion: "1.2.3"})).To(Equal("1"))
+	})
+
+	It("should return the current trigger for a known kernel version of another architecture", func() {
+		Expect(lastRebuildTrigger(&mod, module.KernelTarget{KernelVersion: "1.2.3", Architecture: "arm64"})).To(Equal("2"))
+	})
+
+	It("should return the current trigger for a new kernel version", func() {
+		Expect(lastRebuildTrigger(&mod, module.KernelTarget{KernelVersion: "4.5.6"})).To(Equal("2"))
+	})
 })
@@ -0,0 +1,98 @@
+package build
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/module"
+)
+
+// DefaultBackend is used by the builds that do not set a backend.
+const DefaultBackend = kmmv1beta1.BuildBackendKaniko
+
+type backendManager struct {
+	helper   Helper
+	backends map[kmmv1beta1.BuildBackend]Manager
+}
+
+// NewBackendManager returns a Manager that hands each build over to the Manager registered for its backend.
+func NewBackendManager(helper Helper, backends map[kmmv1beta1.BuildBackend]Manager) Manager {
+	return &backendManager{
+		helper:   helper,
+		backends: backends,
+	}
+}
+
+// GarbageCollect runs the garbage collection of every registered Manager, and returns the names of all deleted objects.
+func (bm *backendManager) GarbageCollect(ctx context.Context, modName, namespace string, owner metav1.Object, keep map[module.KernelTarget]bool) ([]string, error) {
+	names := make([]string, 0, len(bm.backends))
+	for b := range bm.backends {
+		names = append(names, string(b))
+	}
+	sort.Strings(names)
+
+	deleted := make([]string, 0)
+
+	for _, name := range names {
+		d, err := bm.backends[kmmv1beta1.BuildBackend(name)].GarbageCollect(ctx, modName, namespace, owner, keep)
+		if err != nil {
+			return nil, fmt.Errorf("could not garbage collect the %s builds: %v", name, err)
+		}
+
+		deleted = append(deleted, d...)
+	}
+
+	return deleted, nil
+}
+
+func (bm *backendManager) ShouldSync(
+	ctx context.Context,
+	mod kmmv1beta1.Module,
+	m kmmv1beta1.KernelMapping,
+	targetKernel string,
+	targetArch string) (bool, error) {
+
+	backend, err := bm.backend(mod.Spec, m)
+	if err != nil {
+		return false, err
+	}
+
+	return backend.ShouldSync(ctx, mod, m, targetKernel, targetArch)
+}
+
+func (bm *backendManager) Sync(
+	ctx context.Context,
+	mod kmmv1beta1.Module,
+	m kmmv1beta1.KernelMapping,
+	targetKernel string,
+	targetArch string,
+	osConfig *module.NodeOSConfig,
+	pushImage bool,
+	owner metav1.Object) (Result, error) {
+
+	backend, err := bm.backend(mod.Spec, m)
+	if err != nil {
+		return Result{}, err
+	}
+
+	return backend.Sync(ctx, mod, m, targetKernel, targetArch, osConfig, pushImage, owner)
+}
+
+func (bm *backendManager) backend(modSpec kmmv1beta1.ModuleSpec, m kmmv1beta1.KernelMapping) (Manager, error) {
+	name := DefaultBackend
+
+	if buildConfig := bm.helper.GetRelevantBuild(modSpec, m); buildConfig != nil && buildConfig.Backend != "" {
+		name = buildConfig.Backend
+	}
+
+	backend, ok := bm.backends[name]
+	if !ok {
+		return nil, fmt.Errorf("unsupported build backend %q", name)
+	}
+
+	return backend, nil
+}
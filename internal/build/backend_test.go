@@ -0,0 +1,128 @@
+package build
+
+import (
+	"context"
+	"errors"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/module"
+)
+
+var _ = Describe("BackendManager", func() {
+	const kernelVersion = "1.2.3"
+
+	var (
+		ctrl    *gomock.Controller
+		kaniko  *MockManager
+		buildah *MockManager
+		bm      Manager
+	)
+
+	ctx := context.Background()
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		kaniko = NewMockManager(ctrl)
+		buildah = NewMockManager(ctrl)
+		bm = NewBackendManager(
+			NewHelper(),
+			map[kmmv1beta1.BuildBackend]Manager{
+				kmmv1beta1.BuildBackendKaniko:  kaniko,
+				kmmv1beta1.BuildBackendBuildah: buildah,
+			},
+		)
+	})
+
+	makeModule := func(moduleBackend, mappingBackend kmmv1beta1.BuildBackend) (kmmv1beta1.Module, kmmv1beta1.KernelMapping) {
+		mod := kmmv1beta1.Module{
+			ObjectMeta: metav1.ObjectMeta{Name: "some-module", Namespace: "some-namespace"},
+			Spec: kmmv1beta1.ModuleSpec{
+				ModuleLoader: kmmv1beta1.ModuleLoaderSpec{
+					Container: kmmv1beta1.ModuleLoaderContainerSpec{
+						Build: &kmmv1beta1.Build{
+							Backend:             moduleBackend,
+							DockerfileConfigMap: &v1.LocalObjectReference{Name: "dockerfile"},
+						},
+					},
+				},
+			},
+		}
+
+		km := kmmv1beta1.KernelMapping{
+			Build:          &kmmv1beta1.Build{Backend: mappingBackend},
+			ContainerImage: "some-image",
+		}
+
+		return mod, km
+	}
+
+	It("should use Kaniko by default", func() {
+		mod, km := makeModule("", "")
+
+		kaniko.EXPECT().ShouldSync(ctx, mod, km, kernelVersion, "amd64").Return(true, nil)
+
+		res, err := bm.ShouldSync(ctx, mod, km, kernelVersion, "amd64")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(BeTrue())
+	})
+
+	It("should use the backend of the Module", func() {
+		mod, km := makeModule(kmmv1beta1.BuildBackendBuildah, "")
+
+		osConfig := module.NodeOSConfig{KernelFullVersion: kernelVersion}
+
+		buildah.EXPECT().Sync(ctx, mod, km, kernelVersion, "", &osConfig, true, &mod).Return(Result{Status: StatusCompleted}, nil)
+
+		res, err := bm.Sync(ctx, mod, km, kernelVersion, "", &osConfig, true, &mod)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(Equal(Result{Status: StatusCompleted}))
+	})
+
+	It("should prefer the backend of the kernel mapping", func() {
+		mod, km := makeModule(kmmv1beta1.BuildBackendBuildah, kmmv1beta1.BuildBackendKaniko)
+
+		kaniko.EXPECT().Sync(ctx, mod, km, kernelVersion, "", nil, false, &mod).Return(Result{Status: StatusInProgress}, nil)
+
+		res, err := bm.Sync(ctx, mod, km, kernelVersion, "", nil, false, &mod)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(Equal(Result{Status: StatusInProgress}))
+	})
+
+	It("should return an error for an unknown backend", func() {
+		mod, km := makeModule("Docker", "")
+
+		_, err := bm.ShouldSync(ctx, mod, km, kernelVersion, "amd64")
+		Expect(err).To(HaveOccurred())
+
+		_, err = bm.Sync(ctx, mod, km, kernelVersion, "", nil, true, &mod)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should garbage collect the builds of all backends", func() {
+		mod, _ := makeModule("", "")
+
+		gomock.InOrder(
+			buildah.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, &mod, nil).Return([]string{"buildah-job"}, nil),
+			kaniko.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, &mod, nil).Return([]string{"kaniko-job"}, nil),
+		)
+
+		deleted, err := bm.GarbageCollect(ctx, mod.Name, mod.Namespace, &mod, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(deleted).To(Equal([]string{"buildah-job", "kaniko-job"}))
+	})
+
+	It("should return an error if a backend fails to garbage collect", func() {
+		mod, _ := makeModule("", "")
+
+		buildah.EXPECT().GarbageCollect(ctx, mod.Name, mod.Namespace, &mod, nil).Return(nil, errors.New("some error"))
+
+		_, err := bm.GarbageCollect(ctx, mod.Name, mod.Namespace, &mod, nil)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -56,6 +56,10 @@ func (m *helper) GetRelevantBuild(modSpec kmmv1beta1.ModuleSpec, km kmmv1beta1.K
 	buildConfig := modSpec.ModuleLoader.Container.Build.DeepCopy()
 	buildConfig.DockerfileConfigMap = km.Build.DockerfileConfigMap
 
+	if km.Build.Backend != "" {
+		buildConfig.Backend = km.Build.Backend
+	}
+
 	buildConfig.BuildArgs = m.ApplyBuildArgOverrides(buildConfig.BuildArgs, km.Build.BuildArgs...)
 
 	// [TODO] once MGMT-10832 is consolidated, this code must be revisited. We will decide which
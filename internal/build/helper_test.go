@@ -81,6 +81,24 @@ var _ = Describe("GetRelevantBuild", func() {
 		Expect(res.DockerfileConfigMap).To(Equal(km.Build.DockerfileConfigMap))
 		Expect(res.BaseImageRegistryTLS).To(Equal(mod.Spec.ModuleLoader.Container.Build.BaseImageRegistryTLS))
 	})
+
+	It("should only override the backend of the module loader build if the kernel mapping sets one", func() {
+		mod := kmmv1beta1.Module{
+			Spec: kmmv1beta1.ModuleSpec{
+				ModuleLoader: kmmv1beta1.ModuleLoaderSpec{
+					Container: kmmv1beta1.ModuleLoaderContainerSpec{
+						Build: &kmmv1beta1.Build{Backend: kmmv1beta1.BuildBackendBuildah},
+					},
+				},
+			},
+		}
+
+		km := kmmv1beta1.KernelMapping{Build: &kmmv1beta1.Build{}}
+		Expect(nh.GetRelevantBuild(mod.Spec, km).Backend).To(Equal(kmmv1beta1.BuildBackendBuildah))
+
+		km.Build.Backend = kmmv1beta1.BuildBackendKaniko
+		Expect(nh.GetRelevantBuild(mod.Spec, km).Backend).To(Equal(kmmv1beta1.BuildBackendKaniko))
+	})
 })
 
 var _ = Describe("ApplyBuildArgOverrides", func() {
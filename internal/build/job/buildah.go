@@ -0,0 +1,128 @@
+package job
+
+import (
+	v1 "k8s.io/api/core/v1"
+
+	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
+)
+
+const (
+	buildahContainerName     = "buildah"
+	buildahStorageVolumeName = "buildah-storage"
+	buildahStoragePath       = "/var/lib/containers"
+	buildahDockerConfigPath  = "/run/buildah/auth"
+)
+
+type buildah struct{}
+
+func (b *buildah) backend() kmmv1beta1.BuildBackend {
+	return kmmv1beta1.BuildBackendBuildah
+}
+
+// podSpec returns a pod that builds the image with Buildah.
+// When the image is pushed, the build runs in an init container and the push in the main container; both share the
+// image storage through an emptyDir volume.
+func (b *buildah) podSpec(
+	modSpec kmmv1beta1.ModuleSpec,
+	buildConfig *kmmv1beta1.Build,
+	buildArgs []kmmv1beta1.BuildArg,
+	containerImage string,
+	registryTLS *kmmv1beta1.TLSOptions,
+	pushImage bool) v1.PodSpec {
+
+	buildahImageTag := "latest"
+	if buildConfig.BuildahParams != nil && buildConfig.BuildahParams.Tag != "" {
+		buildahImageTag = buildConfig.BuildahParams.Tag
+	}
+
+	image := "quay.io/buildah/stable:" + buildahImageTag
+
+	storageVolumeMount := v1.VolumeMount{
+		Name:      buildahStorageVolumeName,
+		MountPath: buildahStoragePath,
+	}
+
+	var env []v1.EnvVar
+
+	if modSpec.ImageRepoSecret != nil {
+		env = []v1.EnvVar{
+			{Name: "REGISTRY_AUTH_FILE", Value: buildahDockerConfigPath + "/config.json"},
+		}
+	}
+
+	build := v1.Container{
+		Name:         buildahContainerName,
+		Image:        image,
+		Command:      []string{"buildah"},
+		Args:         buildahBuildArgs(buildConfig, buildArgs, containerImage),
+		Env:          append(env, secretBuildArgsEnv(buildArgs)...),
+		VolumeMounts: append(volumeMounts(modSpec, buildConfig, buildahDockerConfigPath), storageVolumeMount),
+		WorkingDir:   "/workspace",
+	}
+
+	volumes := append(
+		volumes(modSpec, buildConfig),
+		v1.Volume{
+			Name:         buildahStorageVolumeName,
+			VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}},
+		},
+	)
+
+	spec := v1.PodSpec{
+		Containers:    []v1.Container{build},
+		RestartPolicy: v1.RestartPolicyNever,
+		Volumes:       volumes,
+	}
+
+	if !pushImage {
+		return spec
+	}
+
+	pushVolumeMounts := []v1.VolumeMount{storageVolumeMount}
+	if irs := modSpec.ImageRepoSecret; irs != nil {
+		pushVolumeMounts = append(pushVolumeMounts, makeImagePullSecretVolumeMount(irs, buildahDockerConfigPath))
+	}
+
+	pushArgs := []string{"push", "--storage-driver=vfs"}
+	if registryTLS.Insecure || registryTLS.InsecureSkipTLSVerify {
+		pushArgs = append(pushArgs, "--tls-verify=false")
+	}
+
+	spec.InitContainers = []v1.Container{build}
+	spec.Containers = []v1.Container{
+		{
+			Name:         "buildah-push",
+			Image:        image,
+			Command:      []string{"buildah"},
+			Args:         append(pushArgs, containerImage),
+			Env:          env,
+			VolumeMounts: pushVolumeMounts,
+		},
+	}
+
+	return spec
+}
+
+func buildahBuildArgs(buildConfig *kmmv1beta1.Build, buildArgs []kmmv1beta1.BuildArg, containerImage string) []string {
+	args := []string{
+		"bud",
+		"--storage-driver=vfs",
+		"--isolation=chroot",
+		"--file=/workspace/Dockerfile",
+		"--tag", containerImage,
+	}
+
+	args = append(args, buildArgFlags(buildArgs)...)
+
+	// Buildah falls back to plain HTTP for registries that are not verified
+	if buildConfig.BaseImageRegistryTLS.Insecure || buildConfig.BaseImageRegistryTLS.InsecureSkipTLSVerify {
+		args = append(args, "--tls-verify=false")
+	}
+
+	return args
+}
+
+// cacheArgs returns no arguments, as Buildah builds are not cached.
+func (b *buildah) cacheArgs(_ *kmmv1beta1.Build, _ string, _ *kmmv1beta1.TLSOptions) ([]string, error) {
+	return nil, nil
+}
@@ -0,0 +1,151 @@
+package job
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/module"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/utils"
+)
+
+// resolveBuildArgs replaces the build arguments read from ConfigMaps with their value.
+// Missing optional keys are dropped.
+// Arguments read from Secrets are returned as is: their value is passed to the build through secretBuildArgsEnv.
+func (m *maker) resolveBuildArgs(ctx context.Context, buildArgs []kmmv1beta1.BuildArg, namespace string) ([]kmmv1beta1.BuildArg, error) {
+	resolved := make([]kmmv1beta1.BuildArg, 0, len(buildArgs))
+
+	for _, ba := range buildArgs {
+		if ba.ValueFrom == nil || ba.ValueFrom.ConfigMapKeyRef == nil {
+			resolved = append(resolved, ba)
+			continue
+		}
+
+		ref := ba.ValueFrom.ConfigMapKeyRef
+		optional := ref.Optional != nil && *ref.Optional
+
+		cm := v1.ConfigMap{}
+		nsn := types.NamespacedName{Name: ref.Name, Namespace: namespace}
+
+		if err := m.client.Get(ctx, nsn, &cm); err != nil {
+			if k8serrors.IsNotFound(err) && optional {
+				continue
+			}
+
+			return nil, fmt.Errorf("could not get ConfigMap %s for build argument %s: %v", nsn, ba.Name, err)
+		}
+
+		value, ok := cm.Data[ref.Key]
+		if !ok {
+			if optional {
+				continue
+			}
+
+			return nil, fmt.Errorf("key %s of build argument %s not found in ConfigMap %s", ref.Key, ba.Name, nsn)
+		}
+
+		resolved = append(resolved, kmmv1beta1.BuildArg{Name: ba.Name, Value: value})
+	}
+
+	return resolved, nil
+}
+
+// secretBuildArgsVersions returns the versions of the Secrets the build arguments are read from, by Secret name, so
+// that their values can be hashed without appearing in the job nor on the image.
+func (m *maker) secretBuildArgsVersions(ctx context.Context, buildArgs []kmmv1beta1.BuildArg, namespace string) (map[string]string, error) {
+	versions := make(map[string]string)
+
+	for _, ba := range buildArgs {
+		if ba.ValueFrom == nil || ba.ValueFrom.SecretKeyRef == nil {
+			continue
+		}
+
+		ref := ba.ValueFrom.SecretKeyRef
+		optional := ref.Optional != nil && *ref.Optional
+
+		secret := v1.Secret{}
+		nsn := types.NamespacedName{Name: ref.Name, Namespace: namespace}
+
+		if err := m.client.Get(ctx, nsn, &secret); err != nil {
+			if k8serrors.IsNotFound(err) && optional {
+				continue
+			}
+
+			return nil, fmt.Errorf("could not get Secret %s for build argument %s: %v", nsn, ba.Name, err)
+		}
+
+		if _, ok := secret.Data[ref.Key]; !ok {
+			if optional {
+				continue
+			}
+
+			return nil, fmt.Errorf("key %s of build argument %s not found in Secret %s", ref.Key, ba.Name, nsn)
+		}
+
+		versions[ref.Name] = utils.SecretVersion(&secret)
+	}
+
+	return versions, nil
+}
+
+// buildArgFlags returns the --build-arg flags shared by Kaniko and Buildah.
+// Arguments read from Secrets are passed without a value, so that both tools read it from the environment variable
+// of the same name.
+func buildArgFlags(buildArgs []kmmv1beta1.BuildArg) []string {
+	flags := make([]string, 0, 2*len(buildArgs))
+
+	for _, ba := range buildArgs {
+		if isSecretBuildArg(ba) {
+			flags = append(flags, "--build-arg", ba.Name)
+			continue
+		}
+
+		flags = append(flags, "--build-arg", fmt.Sprintf("%s=%s", ba.Name, ba.Value))
+	}
+
+	return flags
+}
+
+// secretBuildArgsEnv returns the environment variables holding the values of the build arguments read from Secrets.
+func secretBuildArgsEnv(buildArgs []kmmv1beta1.BuildArg) []v1.EnvVar {
+	var env []v1.EnvVar
+
+	for _, ba := range buildArgs {
+		if isSecretBuildArg(ba) {
+			env = append(env, v1.EnvVar{
+				Name:      ba.Name,
+				ValueFrom: &v1.EnvVarSource{SecretKeyRef: ba.ValueFrom.SecretKeyRef},
+			})
+		}
+	}
+
+	return env
+}
+
+func isSecretBuildArg(ba kmmv1beta1.BuildArg) bool {
+	return ba.ValueFrom != nil && ba.ValueFrom.SecretKeyRef != nil
+}
+
+// targetBuildArgs returns the variables of osConfig that are not set in buildArgs as build arguments.
+func targetBuildArgs(osConfig *module.NodeOSConfig, buildArgs []kmmv1beta1.BuildArg) []kmmv1beta1.BuildArg {
+	names := sets.NewString()
+
+	for _, ba := range buildArgs {
+		names.Insert(ba.Name)
+	}
+
+	args := make([]kmmv1beta1.BuildArg, 0)
+
+	for _, ba := range osConfig.BuildArgs() {
+		if !names.Has(ba.Name) {
+			args = append(args, ba)
+		}
+	}
+
+	return args
+}
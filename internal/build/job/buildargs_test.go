@@ -0,0 +1,140 @@
+package job
+
+import (
+	"context"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/client"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/module"
+)
+
+var _ = Describe("resolveBuildArgs", func() {
+	const namespace = "some-namespace"
+
+	var (
+		ctrl *gomock.Controller
+		clnt *client.MockClient
+		m    *maker
+	)
+
+	ctx := context.Background()
+	nsn := types.NamespacedName{Name: "mirrors", Namespace: namespace}
+
+	configMapArg := func(key string, optional bool) kmmv1beta1.BuildArg {
+		return kmmv1beta1.BuildArg{
+			Name: "MIRROR_URL",
+			ValueFrom: &kmmv1beta1.BuildArgSource{
+				ConfigMapKeyRef: &v1.ConfigMapKeySelector{
+					LocalObjectReference: v1.LocalObjectReference{Name: nsn.Name},
+					Key:                  key,
+					Optional:             pointer.Bool(optional),
+				},
+			},
+		}
+	}
+
+	secretArg := kmmv1beta1.BuildArg{
+		Name: "LICENSE_KEY",
+		ValueFrom: &kmmv1beta1.BuildArgSource{
+			SecretKeyRef: &v1.SecretKeySelector{LocalObjectReference: v1.LocalObjectReference{Name: "license"}, Key: "key"},
+		},
+	}
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		clnt = client.NewMockClient(ctrl)
+		m = &maker{client: clnt}
+	})
+
+	mockConfigMap := func() {
+		clnt.
+			EXPECT().
+			Get(ctx, nsn, &v1.ConfigMap{}).
+			DoAndReturn(func(_ interface{}, _ interface{}, cm *v1.ConfigMap, _ ...ctrlclient.GetOption) error {
+				cm.Data = map[string]string{"url": "https://mirror.example.com"}
+				return nil
+			})
+	}
+
+	It("should only resolve the values of ConfigMaps", func() {
+		mockConfigMap()
+
+		literal := kmmv1beta1.BuildArg{Name: "SOME_ARG", Value: "some-value"}
+
+		args, err := m.resolveBuildArgs(ctx, []kmmv1beta1.BuildArg{literal, configMapArg("url", false), secretArg}, namespace)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(args).To(Equal([]kmmv1beta1.BuildArg{
+			literal,
+			{Name: "MIRROR_URL", Value: "https://mirror.example.com"},
+			secretArg,
+		}))
+	})
+
+	It("should return an error for a missing key", func() {
+		mockConfigMap()
+
+		_, err := m.resolveBuildArgs(ctx, []kmmv1beta1.BuildArg{configMapArg("other", false)}, namespace)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should drop missing optional keys", func() {
+		mockConfigMap()
+
+		args, err := m.resolveBuildArgs(ctx, []kmmv1beta1.BuildArg{configMapArg("other", true)}, namespace)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(args).To(BeEmpty())
+	})
+
+	It("should drop missing optional ConfigMaps", func() {
+		clnt.EXPECT().Get(ctx, nsn, &v1.ConfigMap{}).Return(k8serrors.NewNotFound(schema.GroupResource{}, nsn.Name))
+
+		args, err := m.resolveBuildArgs(ctx, []kmmv1beta1.BuildArg{configMapArg("url", true)}, namespace)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(args).To(BeEmpty())
+	})
+
+	It("should return an error for a missing ConfigMap", func() {
+		clnt.EXPECT().Get(ctx, nsn, &v1.ConfigMap{}).Return(k8serrors.NewNotFound(schema.GroupResource{}, nsn.Name))
+
+		_, err := m.resolveBuildArgs(ctx, []kmmv1beta1.BuildArg{configMapArg("url", false)}, namespace)
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("buildArgFlags", func() {
+	It("should not pass the values of Secrets", func() {
+		args := []kmmv1beta1.BuildArg{
+			{Name: "SOME_ARG", Value: "some-value"},
+			{
+				Name:      "LICENSE_KEY",
+				ValueFrom: &kmmv1beta1.BuildArgSource{SecretKeyRef: &v1.SecretKeySelector{Key: "key"}},
+			},
+		}
+
+		Expect(buildArgFlags(args)).To(Equal([]string{"--build-arg", "SOME_ARG=some-value", "--build-arg", "LICENSE_KEY"}))
+	})
+})
+
+var _ = Describe("targetBuildArgs", func() {
+	It("should skip the variables set by users", func() {
+		osConfig := module.NodeOSConfig{KernelVersionMajor: "6", Architecture: "x86_64"}
+		args := []kmmv1beta1.BuildArg{
+			{
+				Name:      "ARCH",
+				ValueFrom: &kmmv1beta1.BuildArgSource{SecretKeyRef: &v1.SecretKeySelector{Key: "key"}},
+			},
+		}
+
+		Expect(targetBuildArgs(&osConfig, args)).To(Equal([]kmmv1beta1.BuildArg{{Name: "KERNEL_X", Value: "6"}}))
+	})
+})
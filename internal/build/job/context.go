@@ -0,0 +1,414 @@
+package job
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/mitchellh/hashstructure"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
+)
+
+const (
+	buildContextVolumeName = "build-context"
+	buildContextPath       = "/workspace"
+
+	// BuildContextImageEnvVar holds the image of the init containers that fill the build context from Git repositories
+	// and archives.
+	// It must provide git, wget, sha256sum and tar, and be pinned by digest, as it is part of the hash of the builds.
+	BuildContextImageEnvVar = "BUILD_CONTEXT_IMAGE"
+
+	gitCloneScript = `set -e
+git clone --quiet --no-checkout "$GIT_URL" ` + buildContextPath + `
+git -C ` + buildContextPath + ` checkout --quiet "$GIT_REVISION"`
+
+	gitCloneWithCredentialsScript = `set -e
+git -c credential.helper='!f() { echo "username=$GIT_USERNAME"; echo "password=$GIT_PASSWORD"; }; f' clone --quiet --no-checkout "$GIT_URL" ` + buildContextPath + `
+git -C ` + buildContextPath + ` checkout --quiet "$GIT_REVISION"`
+
+	// without a checksum, the archive must still have the version that was hashed when the job was made
+	fetchArchiveScript = `set -e
+if [ -n "$ARCHIVE_SHA256" ]; then
+  wget -q -O /tmp/context.tar.gz "$ARCHIVE_URL"
+  echo "$ARCHIVE_SHA256  /tmp/context.tar.gz" | sha256sum -c -
+else
+  wget -S -O /tmp/context.tar.gz "$ARCHIVE_URL" 2>/tmp/headers || { cat /tmp/headers; exit 1; }
+  header() { tr -d '\r' </tmp/headers | grep -i "^ *$1:" | tail -n 1 | cut -d: -f2- | sed 's/^ *//'; }
+  version="etag:$(header ETag)"
+  [ "$version" != "etag:" ] || version="last-modified:$(header Last-Modified)"
+  if [ "$version" != "$ARCHIVE_VERSION" ]; then
+    echo "the archive has version $version instead of $ARCHIVE_VERSION"
+    exit 1
+  fi
+fi
+tar -xzf /tmp/context.tar.gz -C ` + buildContextPath
+)
+
+var commitRegexp = regexp.MustCompile("^[0-9a-f]{40}$")
+
+// BuildContextImageFromEnv returns the image set in BuildContextImageEnvVar, or an empty string if it is not set.
+func BuildContextImageFromEnv() (string, error) {
+	image := os.Getenv(BuildContextImageEnvVar)
+	if image == "" {
+		return "", nil
+	}
+
+	if _, err := name.NewDigest(image); err != nil {
+		return "", fmt.Errorf("invalid value %q for %s: the image must be pinned by digest: %v", image, BuildContextImageEnvVar, err)
+	}
+
+	return image, nil
+}
+
+type contextHashData struct {
+	GitRevision    string
+	ArchiveVersion string
+	ConfigMaps     map[string]map[string]string
+}
+
+// resolveContext returns what the sources of the build context resolve to and a hash of all of them, so that a change
+// in any of them changes the hash of the job.
+func (m *maker) resolveContext(ctx context.Context, bc *kmmv1beta1.BuildContext, namespace string) (contextHashData, string, error) {
+	if bc == nil {
+		return contextHashData{}, "", nil
+	}
+
+	data := contextHashData{
+		ConfigMaps: make(map[string]map[string]string, len(bc.ConfigMaps)),
+	}
+
+	if g := bc.Git; g != nil {
+		rev, err := m.gitRevision(ctx, g, namespace)
+		if err != nil {
+			return contextHashData{}, "", fmt.Errorf("could not resolve ref %q of %s: %v", g.Ref, g.URL, err)
+		}
+
+		data.GitRevision = rev
+	}
+
+	for _, src := range bc.ConfigMaps {
+		cm := v1.ConfigMap{}
+		nsn := types.NamespacedName{Name: src.Name, Namespace: namespace}
+
+		if err := m.client.Get(ctx, nsn, &cm); err != nil {
+			return contextHashData{}, "", fmt.Errorf("failed to get build context ConfigMap %s: %v", nsn, err)
+		}
+
+		files := make(map[string]string, len(cm.Data)+len(cm.BinaryData))
+		for k, v := range cm.Data {
+			files[k] = v
+		}
+		for k, v := range cm.BinaryData {
+			files[k] = string(v)
+		}
+
+		data.ConfigMaps[src.Name] = files
+	}
+
+	if a := bc.Archive; a != nil {
+		v, err := m.archiveVersion(ctx, a)
+		if err != nil {
+			return contextHashData{}, "", fmt.Errorf("could not get the version of archive %s: %v", a.URL, err)
+		}
+
+		data.ArchiveVersion = v
+	}
+
+	h, err := hashstructure.Hash(data, nil)
+	if err != nil {
+		return contextHashData{}, "", fmt.Errorf("could not hash the build context: %v", err)
+	}
+
+	return data, fmt.Sprintf("%d", h), nil
+}
+
+// gitRevision returns the commit of the ref of g, as advertised by the smart HTTP protocol of the repository.
+func (m *maker) gitRevision(ctx context.Context, g *kmmv1beta1.GitBuildSource, namespace string) (string, error) {
+	if commitRegexp.MatchString(g.Ref) {
+		return g.Ref, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(g.URL, "/")+"/info/refs?service=git-upload-pack", nil)
+	if err != nil {
+		return "", fmt.Errorf("could not create the request: %v", err)
+	}
+
+	req.Header.Set("User-Agent", "git/kmm")
+
+	if ref := g.CredentialsSecret; ref != nil {
+		secret := v1.Secret{}
+		nsn := types.NamespacedName{Name: ref.Name, Namespace: namespace}
+
+		if err = m.client.Get(ctx, nsn, &secret); err != nil {
+			return "", fmt.Errorf("failed to get Git credentials secret %s: %v", nsn, err)
+		}
+
+		req.SetBasicAuth(string(secret.Data[v1.BasicAuthUsernameKey]), string(secret.Data[v1.BasicAuthPasswordKey]))
+	}
+
+	res, err := m.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("repository replied with status %d", res.StatusCode)
+	}
+
+	if ct := res.Header.Get("Content-Type"); ct != "application/x-git-upload-pack-advertisement" {
+		return "", fmt.Errorf("unexpected content type %q: only the smart HTTP protocol is supported", ct)
+	}
+
+	refs, err := parseAdvertisedRefs(res.Body)
+	if err != nil {
+		return "", fmt.Errorf("could not parse the refs of the repository: %v", err)
+	}
+
+	names := []string{g.Ref}
+
+	switch {
+	case g.Ref == "":
+		names = []string{"HEAD"}
+	case g.Ref != "HEAD" && !strings.HasPrefix(g.Ref, "refs/"):
+		names = []string{"refs/tags/" + g.Ref, "refs/heads/" + g.Ref}
+	}
+
+	for _, n := range names {
+		// annotated tags are followed by the commit they point to
+		for _, candidate := range []string{n + "^{}", n} {
+			if rev, ok := refs[candidate]; ok {
+				return rev, nil
+			}
+		}
+	}
+
+	return "", errors.New("ref not found")
+}
+
+// parseAdvertisedRefs reads the pkt-lines of a git-upload-pack advertisement and returns commits by ref name.
+func parseAdvertisedRefs(r io.Reader) (map[string]string, error) {
+	br := bufio.NewReader(r)
+	refs := make(map[string]string)
+
+	for {
+		lenBuf := make([]byte, 4)
+
+		if _, err := io.ReadFull(br, lenBuf); err != nil {
+			if errors.Is(err, io.EOF) {
+				return refs, nil
+			}
+
+			return nil, err
+		}
+
+		n, err := strconv.ParseUint(string(lenBuf), 16, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pkt-line length %q", lenBuf)
+		}
+
+		// flush packet
+		if n == 0 {
+			continue
+		}
+
+		if n < 4 {
+			return nil, fmt.Errorf("invalid pkt-line length %d", n)
+		}
+
+		line := make([]byte, n-4)
+
+		if _, err = io.ReadFull(br, line); err != nil {
+			return nil, err
+		}
+
+		s := strings.TrimSuffix(string(line), "\n")
+
+		// service announcement
+		if strings.HasPrefix(s, "#") {
+			continue
+		}
+
+		// capabilities follow the first ref
+		s, _, _ = strings.Cut(s, "\x00")
+
+		if rev, name, ok := strings.Cut(s, " "); ok {
+			refs[name] = rev
+		}
+	}
+}
+
+// archiveVersion returns the checksum of a, or its ETag or Last-Modified header if no checksum is set.
+func (m *maker) archiveVersion(ctx context.Context, a *kmmv1beta1.ArchiveBuildSource) (string, error) {
+	if a.SHA256 != "" {
+		return "sha256:" + a.SHA256, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, a.URL, nil)
+	if err != nil {
+		return "", fmt.Errorf("could not create the request: %v", err)
+	}
+
+	res, err := m.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("server replied with status %d", res.StatusCode)
+	}
+
+	if etag := res.Header.Get("ETag"); etag != "" {
+		return "etag:" + etag, nil
+	}
+
+	if lm := res.Header.Get("Last-Modified"); lm != "" {
+		return "last-modified:" + lm, nil
+	}
+
+	return "", errors.New("the archive has no ETag or Last-Modified header; set its sha256")
+}
+
+// addBuildContext replaces the Dockerfile mounted in the build container with a build context that is filled by init
+// containers running image and ConfigMap mounts.
+// The Dockerfile is mounted on top of the build context, so that it wins over any Dockerfile from the sources.
+func addBuildContext(spec *v1.PodSpec, bc *kmmv1beta1.BuildContext, resolved contextHashData, image string) error {
+	if bc == nil {
+		return nil
+	}
+
+	if (bc.Git != nil || bc.Archive != nil) && image == "" {
+		return fmt.Errorf("the Git and archive sources need the image set in %s", BuildContextImageEnvVar)
+	}
+
+	contextMount := v1.VolumeMount{Name: buildContextVolumeName, MountPath: buildContextPath}
+
+	spec.Volumes = append(spec.Volumes, v1.Volume{
+		Name:         buildContextVolumeName,
+		VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}},
+	})
+
+	initContainers := make([]v1.Container, 0, 2)
+
+	if g := bc.Git; g != nil {
+		initContainers = append(initContainers, gitCloneContainer(g, resolved.GitRevision, image, contextMount))
+	}
+
+	if a := bc.Archive; a != nil {
+		env := []v1.EnvVar{
+			{Name: "ARCHIVE_URL", Value: a.URL},
+			{Name: "ARCHIVE_SHA256", Value: a.SHA256},
+		}
+
+		if a.SHA256 == "" {
+			env = append(env, v1.EnvVar{Name: "ARCHIVE_VERSION", Value: resolved.ArchiveVersion})
+		}
+
+		initContainers = append(initContainers, v1.Container{
+			Name:         "fetch-archive",
+			Image:        image,
+			Command:      []string{"/bin/sh", "-c"},
+			Args:         []string{fetchArchiveScript},
+			Env:          env,
+			VolumeMounts: []v1.VolumeMount{contextMount},
+		})
+	}
+
+	mounts := []v1.VolumeMount{
+		contextMount,
+		{
+			Name:      dockerfileVolumeName,
+			ReadOnly:  true,
+			MountPath: path.Join(buildContextPath, "Dockerfile"),
+			SubPath:   "Dockerfile",
+		},
+	}
+
+	for i, src := range bc.ConfigMaps {
+		volumeName := fmt.Sprintf("context-configmap-%d", i)
+
+		spec.Volumes = append(spec.Volumes, v1.Volume{
+			Name: volumeName,
+			VolumeSource: v1.VolumeSource{
+				ConfigMap: &v1.ConfigMapVolumeSource{
+					LocalObjectReference: v1.LocalObjectReference{Name: src.Name},
+				},
+			},
+		})
+
+		p := src.Path
+		if p == "" {
+			p = src.Name
+		}
+
+		mounts = append(mounts, v1.VolumeMount{
+			Name:      volumeName,
+			ReadOnly:  true,
+			MountPath: path.Join(buildContextPath, p),
+		})
+	}
+
+	c := buildContainer(spec)
+
+	for _, vm := range c.VolumeMounts {
+		if vm.Name != dockerfileVolumeName {
+			mounts = append(mounts, vm)
+		}
+	}
+
+	c.VolumeMounts = mounts
+
+	spec.InitContainers = append(initContainers, spec.InitContainers...)
+
+	return nil
+}
+
+func gitCloneContainer(g *kmmv1beta1.GitBuildSource, revision, image string, contextMount v1.VolumeMount) v1.Container {
+	script := gitCloneScript
+
+	env := []v1.EnvVar{
+		{Name: "GIT_URL", Value: g.URL},
+		{Name: "GIT_REVISION", Value: revision},
+	}
+
+	if ref := g.CredentialsSecret; ref != nil {
+		script = gitCloneWithCredentialsScript
+
+		env = append(
+			env,
+			secretKeyEnvVar("GIT_USERNAME", *ref, v1.BasicAuthUsernameKey),
+			secretKeyEnvVar("GIT_PASSWORD", *ref, v1.BasicAuthPasswordKey),
+		)
+	}
+
+	return v1.Container{
+		Name:         "git-clone",
+		Image:        image,
+		Command:      []string{"/bin/sh", "-c"},
+		Args:         []string{script},
+		Env:          env,
+		VolumeMounts: []v1.VolumeMount{contextMount},
+	}
+}
+
+func secretKeyEnvVar(name string, ref v1.LocalObjectReference, key string) v1.EnvVar {
+	return v1.EnvVar{
+		Name: name,
+		ValueFrom: &v1.EnvVarSource{
+			SecretKeyRef: &v1.SecretKeySelector{LocalObjectReference: ref, Key: key},
+		},
+	}
+}
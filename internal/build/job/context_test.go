@@ -0,0 +1,336 @@
+package job
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/client"
+)
+
+const (
+	headCommit   = "1111111111111111111111111111111111111111"
+	branchCommit = "2222222222222222222222222222222222222222"
+	tagObject    = "3333333333333333333333333333333333333333"
+	tagCommit    = "4444444444444444444444444444444444444444"
+)
+
+func pktLine(s string) string {
+	return fmt.Sprintf("%04x%s", len(s)+4, s)
+}
+
+var advertisement = pktLine("# service=git-upload-pack\n") +
+	"0000" +
+	pktLine(headCommit+" HEAD\x00multi_ack symref=HEAD:refs/heads/main\n") +
+	pktLine(headCommit+" refs/heads/main\n") +
+	pktLine(branchCommit+" refs/heads/release-1.0\n") +
+	pktLine(tagObject+" refs/tags/v1.0\n") +
+	pktLine(tagCommit+" refs/tags/v1.0^{}\n") +
+	"0000"
+
+var _ = Describe("parseAdvertisedRefs", func() {
+	It("should return the commits by ref name", func() {
+		refs, err := parseAdvertisedRefs(strings.NewReader(advertisement))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(refs).To(Equal(map[string]string{
+			"HEAD":                   headCommit,
+			"refs/heads/main":        headCommit,
+			"refs/heads/release-1.0": branchCommit,
+			"refs/tags/v1.0":         tagObject,
+			"refs/tags/v1.0^{}":      tagCommit,
+		}))
+	})
+
+	It("should return an error for an invalid length", func() {
+		_, err := parseAdvertisedRefs(strings.NewReader("zzzz"))
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("gitRevision", func() {
+	const namespace = "some-namespace"
+
+	var (
+		ctrl   *gomock.Controller
+		clnt   *client.MockClient
+		m      *maker
+		server *httptest.Server
+		auth   string
+	)
+
+	ctx := context.Background()
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		clnt = client.NewMockClient(ctrl)
+		m = &maker{client: clnt, httpClient: http.DefaultClient}
+		auth = ""
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/repo.git/info/refs" || r.URL.Query().Get("service") != "git-upload-pack" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+
+			if u, p, ok := r.BasicAuth(); ok {
+				auth = u + ":" + p
+			}
+
+			w.Header().Set("Content-Type", "application/x-git-upload-pack-advertisement")
+			_, _ = w.Write([]byte(advertisement))
+		}))
+		DeferCleanup(server.Close)
+	})
+
+	DescribeTable("should resolve refs",
+		func(ref, expected string) {
+			rev, err := m.gitRevision(ctx, &kmmv1beta1.GitBuildSource{URL: server.URL + "/repo.git", Ref: ref}, namespace)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rev).To(Equal(expected))
+		},
+		Entry("default", "", headCommit),
+		Entry("branch", "release-1.0", branchCommit),
+		Entry("annotated tag", "v1.0", tagCommit),
+		Entry("full ref", "refs/heads/main", headCommit),
+		Entry("commit", "5555555555555555555555555555555555555555", "5555555555555555555555555555555555555555"),
+	)
+
+	It("should return an error for an unknown ref", func() {
+		_, err := m.gitRevision(ctx, &kmmv1beta1.GitBuildSource{URL: server.URL + "/repo.git", Ref: "unknown"}, namespace)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should return an error if the repository is not found", func() {
+		_, err := m.gitRevision(ctx, &kmmv1beta1.GitBuildSource{URL: server.URL + "/other.git"}, namespace)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should use the credentials secret", func() {
+		clnt.
+			EXPECT().
+			Get(ctx, types.NamespacedName{Name: "git-credentials", Namespace: namespace}, &v1.Secret{}).
+			DoAndReturn(func(_ interface{}, _ interface{}, s *v1.Secret, _ ...ctrlclient.GetOption) error {
+				s.Data = map[string][]byte{
+					v1.BasicAuthUsernameKey: []byte("user"),
+					v1.BasicAuthPasswordKey: []byte("token"),
+				}
+				return nil
+			})
+
+		g := kmmv1beta1.GitBuildSource{
+			URL:               server.URL + "/repo.git",
+			CredentialsSecret: &v1.LocalObjectReference{Name: "git-credentials"},
+		}
+
+		_, err := m.gitRevision(ctx, &g, namespace)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(auth).To(Equal("user:token"))
+	})
+})
+
+var _ = Describe("archiveVersion", func() {
+	var (
+		m       *maker
+		headers map[string]string
+		server  *httptest.Server
+	)
+
+	ctx := context.Background()
+
+	BeforeEach(func() {
+		m = &maker{httpClient: http.DefaultClient}
+		headers = make(map[string]string)
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for k, v := range headers {
+				w.Header().Set(k, v)
+			}
+		}))
+		DeferCleanup(server.Close)
+	})
+
+	It("should use the checksum without querying the server", func() {
+		v, err := m.archiveVersion(ctx, &kmmv1beta1.ArchiveBuildSource{URL: "http://invalid.", SHA256: "abc"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(v).To(Equal("sha256:abc"))
+	})
+
+	It("should use the ETag header", func() {
+		headers["ETag"] = `"some-etag"`
+		headers["Last-Modified"] = "Wed, 21 Oct 2015 07:28:00 GMT"
+
+		v, err := m.archiveVersion(ctx, &kmmv1beta1.ArchiveBuildSource{URL: server.URL})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(v).To(Equal(`etag:"some-etag"`))
+	})
+
+	It("should use the Last-Modified header", func() {
+		headers["Last-Modified"] = "Wed, 21 Oct 2015 07:28:00 GMT"
+
+		v, err := m.archiveVersion(ctx, &kmmv1beta1.ArchiveBuildSource{URL: server.URL})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(v).To(Equal("last-modified:Wed, 21 Oct 2015 07:28:00 GMT"))
+	})
+
+	It("should return an error without version headers", func() {
+		_, err := m.archiveVersion(ctx, &kmmv1beta1.ArchiveBuildSource{URL: server.URL})
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("addBuildContext", func() {
+	const contextImage = "registry.example.com/git@sha256:0000000000000000000000000000000000000000000000000000000000000000"
+
+	dockerfileMount := v1.VolumeMount{Name: dockerfileVolumeName, ReadOnly: true, MountPath: "/workspace"}
+	secretMount := v1.VolumeMount{Name: "secret-build", ReadOnly: true, MountPath: "/run/secrets/build"}
+
+	newSpec := func() v1.PodSpec {
+		return v1.PodSpec{
+			Containers: []v1.Container{
+				{Name: kanikoContainerName, VolumeMounts: []v1.VolumeMount{dockerfileMount, secretMount}},
+			},
+			Volumes: []v1.Volume{{Name: dockerfileVolumeName}},
+		}
+	}
+
+	It("should not change the pod without a build context", func() {
+		spec := newSpec()
+		Expect(addBuildContext(&spec, nil, contextHashData{}, "")).To(Succeed())
+		Expect(spec).To(Equal(newSpec()))
+	})
+
+	It("should add the init containers and mounts of all sources", func() {
+		bc := kmmv1beta1.BuildContext{
+			Git: &kmmv1beta1.GitBuildSource{
+				URL:               "https://git.example.com/repo.git",
+				CredentialsSecret: &v1.LocalObjectReference{Name: "git-credentials"},
+			},
+			ConfigMaps: []kmmv1beta1.ConfigMapBuildSource{
+				{Name: "patches"},
+				{Name: "kbuild", Path: "src/kbuild"},
+			},
+			Archive: &kmmv1beta1.ArchiveBuildSource{URL: "https://example.com/src.tar.gz", SHA256: "abc"},
+		}
+
+		spec := newSpec()
+		Expect(addBuildContext(&spec, &bc, contextHashData{GitRevision: headCommit}, contextImage)).To(Succeed())
+
+		contextMount := v1.VolumeMount{Name: buildContextVolumeName, MountPath: "/workspace"}
+
+		Expect(spec.InitContainers).To(HaveLen(2))
+
+		git := spec.InitContainers[0]
+		Expect(git.Name).To(Equal("git-clone"))
+		Expect(git.Image).To(Equal(contextImage))
+		Expect(git.Args).To(Equal([]string{gitCloneWithCredentialsScript}))
+		Expect(git.VolumeMounts).To(Equal([]v1.VolumeMount{contextMount}))
+		Expect(git.Env).To(Equal([]v1.EnvVar{
+			{Name: "GIT_URL", Value: "https://git.example.com/repo.git"},
+			{Name: "GIT_REVISION", Value: headCommit},
+			secretKeyEnvVar("GIT_USERNAME", v1.LocalObjectReference{Name: "git-credentials"}, v1.BasicAuthUsernameKey),
+			secretKeyEnvVar("GIT_PASSWORD", v1.LocalObjectReference{Name: "git-credentials"}, v1.BasicAuthPasswordKey),
+		}))
+
+		archive := spec.InitContainers[1]
+		Expect(archive.Name).To(Equal("fetch-archive"))
+		Expect(archive.Env).To(Equal([]v1.EnvVar{
+			{Name: "ARCHIVE_URL", Value: "https://example.com/src.tar.gz"},
+			{Name: "ARCHIVE_SHA256", Value: "abc"},
+		}))
+
+		Expect(spec.Containers[0].VolumeMounts).To(Equal([]v1.VolumeMount{
+			contextMount,
+			{Name: dockerfileVolumeName, ReadOnly: true, MountPath: "/workspace/Dockerfile", SubPath: "Dockerfile"},
+			{Name: "context-configmap-0", ReadOnly: true, MountPath: "/workspace/patches"},
+			{Name: "context-configmap-1", ReadOnly: true, MountPath: "/workspace/src/kbuild"},
+			secretMount,
+		}))
+
+		Expect(spec.Volumes).To(ContainElements(
+			v1.Volume{
+				Name:         buildContextVolumeName,
+				VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}},
+			},
+			v1.Volume{
+				Name: "context-configmap-1",
+				VolumeSource: v1.VolumeSource{
+					ConfigMap: &v1.ConfigMapVolumeSource{LocalObjectReference: v1.LocalObjectReference{Name: "kbuild"}},
+				},
+			},
+		))
+	})
+
+	It("should run before a build in an init container", func() {
+		spec := v1.PodSpec{
+			InitContainers: []v1.Container{{Name: buildahContainerName}},
+			Containers:     []v1.Container{{Name: "buildah-push"}},
+		}
+
+		bc := kmmv1beta1.BuildContext{Git: &kmmv1beta1.GitBuildSource{URL: "https://git.example.com/repo.git"}}
+		Expect(addBuildContext(&spec, &bc, contextHashData{GitRevision: headCommit}, contextImage)).To(Succeed())
+
+		Expect(spec.InitContainers).To(HaveLen(2))
+		Expect(spec.InitContainers[0].Name).To(Equal("git-clone"))
+		Expect(spec.InitContainers[1].Name).To(Equal(buildahContainerName))
+		Expect(spec.InitContainers[1].VolumeMounts[0].Name).To(Equal(buildContextVolumeName))
+		Expect(spec.Containers[0].VolumeMounts).To(BeEmpty())
+	})
+
+	It("should check the version of archives without a checksum", func() {
+		bc := kmmv1beta1.BuildContext{
+			Archive: &kmmv1beta1.ArchiveBuildSource{URL: "https://example.com/src.tar.gz"},
+		}
+
+		spec := newSpec()
+		Expect(addBuildContext(&spec, &bc, contextHashData{ArchiveVersion: `etag:"some-etag"`}, contextImage)).To(Succeed())
+
+		Expect(spec.InitContainers).To(HaveLen(1))
+		Expect(spec.InitContainers[0].Env).To(Equal([]v1.EnvVar{
+			{Name: "ARCHIVE_URL", Value: "https://example.com/src.tar.gz"},
+			{Name: "ARCHIVE_SHA256", Value: ""},
+			{Name: "ARCHIVE_VERSION", Value: `etag:"some-etag"`},
+		}))
+	})
+
+	It("should only need an image for Git and archive sources", func() {
+		spec := newSpec()
+
+		bc := kmmv1beta1.BuildContext{ConfigMaps: []kmmv1beta1.ConfigMapBuildSource{{Name: "patches"}}}
+		Expect(addBuildContext(&spec, &bc, contextHashData{}, "")).To(Succeed())
+
+		bc = kmmv1beta1.BuildContext{Git: &kmmv1beta1.GitBuildSource{URL: "https://git.example.com/repo.git"}}
+		Expect(addBuildContext(&spec, &bc, contextHashData{GitRevision: headCommit}, "")).NotTo(Succeed())
+	})
+})
+
+var _ = Describe("BuildContextImageFromEnv", func() {
+	It("should return an empty image by default", func() {
+		GinkgoT().Setenv(BuildContextImageEnvVar, "")
+
+		Expect(BuildContextImageFromEnv()).To(BeEmpty())
+	})
+
+	It("should return an image pinned by digest", func() {
+		image := "docker.io/alpine/git@sha256:0000000000000000000000000000000000000000000000000000000000000000"
+		GinkgoT().Setenv(BuildContextImageEnvVar, image)
+
+		Expect(BuildContextImageFromEnv()).To(Equal(image))
+	})
+
+	It("should return an error for tagged images", func() {
+		GinkgoT().Setenv(BuildContextImageEnvVar, "docker.io/alpine/git:latest")
+
+		_, err := BuildContextImageFromEnv()
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,147 @@
+package job
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "k8s.io/api/core/v1"
+
+	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
+)
+
+const (
+	kanikoContainerName    = "kaniko"
+	kanikoDockerConfigPath = "/kaniko/.docker"
+
+	// KanikoCacheRepoEnvVar holds the default Kaniko cache repository of the operator.
+	// ${NAMESPACE} is replaced with the namespace of the Module, so that each namespace has its own cache.
+	KanikoCacheRepoEnvVar = "KANIKO_CACHE_REPO"
+
+	namespaceVariable = "${NAMESPACE}"
+)
+
+type kaniko struct {
+	cacheRepo string
+}
+
+// KanikoCacheRepoFromEnv returns the repository set in KanikoCacheRepoEnvVar, or an empty string if it is not set.
+func KanikoCacheRepoFromEnv() (string, error) {
+	repo := os.Getenv(KanikoCacheRepoEnvVar)
+	if repo == "" {
+		return "", nil
+	}
+
+	if _, err := name.NewRepository(strings.ReplaceAll(repo, namespaceVariable, "namespace")); err != nil {
+		return "", fmt.Errorf("invalid value %q for %s: %v", repo, KanikoCacheRepoEnvVar, err)
+	}
+
+	return repo, nil
+}
+
+func (k *kaniko) backend() kmmv1beta1.BuildBackend {
+	return kmmv1beta1.BuildBackendKaniko
+}
+
+func (k *kaniko) podSpec(
+	modSpec kmmv1beta1.ModuleSpec,
+	buildConfig *kmmv1beta1.Build,
+	buildArgs []kmmv1beta1.BuildArg,
+	containerImage string,
+	registryTLS *kmmv1beta1.TLSOptions,
+	pushImage bool) v1.PodSpec {
+
+	kanikoImageTag := "latest"
+	if buildConfig.KanikoParams != nil && buildConfig.KanikoParams.Tag != "" {
+		kanikoImageTag = buildConfig.KanikoParams.Tag
+	}
+
+	return v1.PodSpec{
+		Containers: []v1.Container{
+			{
+				Args:         kanikoArgs(buildConfig, buildArgs, containerImage, registryTLS, pushImage),
+				Env:          secretBuildArgsEnv(buildArgs),
+				Name:         kanikoContainerName,
+				Image:        "gcr.io/kaniko-project/executor:" + kanikoImageTag,
+				VolumeMounts: volumeMounts(modSpec, buildConfig, kanikoDockerConfigPath),
+			},
+		},
+		RestartPolicy: v1.RestartPolicyNever,
+		Volumes:       volumes(modSpec, buildConfig),
+	}
+}
+
+func kanikoArgs(
+	buildConfig *kmmv1beta1.Build,
+	buildArgs []kmmv1beta1.BuildArg,
+	containerImage string,
+	registryTLS *kmmv1beta1.TLSOptions,
+	pushImage bool) []string {
+
+	args := []string{}
+	if pushImage {
+		args = append(args, "--destination", containerImage)
+	} else {
+		args = append(args, "--no-push")
+	}
+
+	args = append(args, buildArgFlags(buildArgs)...)
+
+	if buildConfig.BaseImageRegistryTLS.Insecure {
+		args = append(args, "--insecure-pull")
+	}
+
+	if buildConfig.BaseImageRegistryTLS.InsecureSkipTLSVerify {
+		args = append(args, "--skip-tls-verify-pull")
+	}
+
+	if pushImage {
+		if registryTLS.Insecure {
+			args = append(args, "--insecure")
+		}
+
+		if registryTLS.InsecureSkipTLSVerify {
+			args = append(args, "--skip-tls-verify")
+		}
+	}
+
+	return args
+}
+
+// cacheArgs returns the arguments that make Kaniko cache layers in the repository of the build, or in the default
+// repository of the namespace.
+// The repository is accessed with the same credentials and TLS options as the built image.
+func (k *kaniko) cacheArgs(buildConfig *kmmv1beta1.Build, namespace string, registryTLS *kmmv1beta1.TLSOptions) ([]string, error) {
+	repo := strings.ReplaceAll(k.cacheRepo, namespaceVariable, namespace)
+	params := buildConfig.KanikoParams
+
+	if params != nil && params.CacheRepo != "" {
+		repo = params.CacheRepo
+	}
+
+	if repo == "" {
+		return nil, nil
+	}
+
+	ref, err := name.NewRepository(repo)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cache repository %q: %v", repo, err)
+	}
+
+	args := []string{"--cache=true", "--cache-repo", repo}
+
+	if params != nil && params.CacheTTL != nil {
+		args = append(args, "--cache-ttl", params.CacheTTL.Duration.String())
+	}
+
+	if registryTLS.Insecure {
+		args = append(args, "--insecure-registry", ref.RegistryStr())
+	}
+
+	if registryTLS.InsecureSkipTLSVerify {
+		args = append(args, "--skip-tls-verify-registry", ref.RegistryStr())
+	}
+
+	return args, nil
+}
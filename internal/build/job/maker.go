@@ -3,6 +3,9 @@ package job
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"strings"
+	"time"
 
 	"github.com/mitchellh/hashstructure"
 	batchv1 "k8s.io/api/batch/v1"
@@ -18,12 +21,15 @@ import (
 	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/build"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/constants"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/dtk"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/module"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/utils"
 )
 
 const (
 	dockerfileVolumeName = "dockerfile"
+
+	httpTimeout = 10 * time.Second
 )
 
 //go:generate mockgen -source=maker.go -package=job -destination=mock_maker.go
@@ -34,44 +40,113 @@ type Maker interface {
 		mod kmmv1beta1.Module,
 		km kmmv1beta1.KernelMapping,
 		targetKernel string,
+		targetArch string,
+		osConfig *module.NodeOSConfig,
 		owner metav1.Object,
 		pushImage bool) (*batchv1.Job, error)
+
+	// Backend returns the build backend of the jobs.
+	Backend() kmmv1beta1.BuildBackend
+}
+
+// builder makes the pods of the builds of one backend.
+type builder interface {
+	backend() kmmv1beta1.BuildBackend
+
+	podSpec(
+		modSpec kmmv1beta1.ModuleSpec,
+		buildConfig *kmmv1beta1.Build,
+		buildArgs []kmmv1beta1.BuildArg,
+		containerImage string,
+		registryTLS *kmmv1beta1.TLSOptions,
+		pushImage bool) v1.PodSpec
+
+	// cacheArgs returns the arguments that configure the cache of the build container; they do not change the image
+	// and are not hashed.
+	cacheArgs(buildConfig *kmmv1beta1.Build, namespace string, registryTLS *kmmv1beta1.TLSOptions) ([]string, error)
 }
 
 type maker struct {
-	client    client.Client
-	helper    build.Helper
-	jobHelper utils.JobHelper
-	scheme    *runtime.Scheme
+	client       client.Client
+	helper       build.Helper
+	jobHelper    utils.JobHelper
+	dtkCatalog   dtk.Catalog
+	httpClient   *http.Client
+	contextImage string
+	builder      builder
+	scheme       *runtime.Scheme
 }
 
+// hashData holds the inputs of a build.
+// Secrets are identified by their version rather than by their data, as the hash is published on the image.
 type hashData struct {
-	Dockerfile  string
-	PodTemplate *v1.PodTemplateSpec
+	Dockerfile     string
+	PodTemplate    *v1.PodTemplateSpec
+	SecretVersions map[string]string
 }
 
-func NewMaker(
+// NewKanikoMaker returns a Maker for Kaniko build jobs.
+// contextImage fills the build contexts from Git repositories and archives.
+// cacheRepo is the cache repository of the builds that do not set their own; caching is disabled if both are empty.
+func NewKanikoMaker(
 	client client.Client,
 	helper build.Helper,
 	jobHelper utils.JobHelper,
+	dtkCatalog dtk.Catalog,
+	contextImage string,
+	cacheRepo string,
 	scheme *runtime.Scheme) Maker {
+	return newMaker(client, helper, jobHelper, dtkCatalog, contextImage, &kaniko{cacheRepo: cacheRepo}, scheme)
+}
+
+// NewBuildahMaker returns a Maker for Buildah build jobs.
+// contextImage fills the build contexts from Git repositories and archives.
+func NewBuildahMaker(
+	client client.Client,
+	helper build.Helper,
+	jobHelper utils.JobHelper,
+	dtkCatalog dtk.Catalog,
+	contextImage string,
+	scheme *runtime.Scheme) Maker {
+	return newMaker(client, helper, jobHelper, dtkCatalog, contextImage, &buildah{}, scheme)
+}
+
+func newMaker(
+	client client.Client,
+	helper build.Helper,
+	jobHelper utils.JobHelper,
+	dtkCatalog dtk.Catalog,
+	contextImage string,
+	builder builder,
+	scheme *runtime.Scheme) *maker {
 	return &maker{
-		client:    client,
-		helper:    helper,
-		jobHelper: jobHelper,
-		scheme:    scheme,
+		client:       client,
+		helper:       helper,
+		jobHelper:    jobHelper,
+		dtkCatalog:   dtkCatalog,
+		httpClient:   &http.Client{Timeout: httpTimeout},
+		contextImage: contextImage,
+		builder:      builder,
+		scheme:       scheme,
 	}
 }
 
+func (m *maker) Backend() kmmv1beta1.BuildBackend {
+	return m.builder.backend()
+}
+
 func (m *maker) MakeJobTemplate(
 	ctx context.Context,
 	mod kmmv1beta1.Module,
 	km kmmv1beta1.KernelMapping,
 	targetKernel string,
+	targetArch string,
+	osConfig *module.NodeOSConfig,
 	owner metav1.Object,
 	pushImage bool) (*batchv1.Job, error) {
 
-	buildConfig := m.helper.GetRelevantBuild(mod.Spec, km)
+	// km is prepared: its build configuration is merged with the Module's
+	buildConfig := km.Build
 
 	containerImage := km.ContainerImage
 
@@ -81,25 +156,93 @@ func (m *maker) MakeJobTemplate(
 		containerImage = module.IntermediateImageName(mod.Name, mod.Namespace, containerImage)
 	}
 
+	dockerfile, err := m.getDockerfile(ctx, buildConfig, mod.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	overrides := []kmmv1beta1.BuildArg{{Name: "KERNEL_VERSION", Value: targetKernel}}
+
+	// only look the Driver Toolkit image up for the Dockerfiles that use it
+	if strings.Contains(dockerfile, dtk.BuildArg) {
+		entry, err := m.dtkCatalog.GetEntry(ctx, targetKernel)
+		if err != nil {
+			return nil, fmt.Errorf("could not get the Driver Toolkit image for kernel %s: %v", targetKernel, err)
+		}
+
+		overrides = append(overrides, kmmv1beta1.BuildArg{Name: dtk.BuildArg, Value: entry.ImageURL})
+	}
+
+	resolvedContext, contextHash, err := m.resolveContext(ctx, buildConfig.Context, mod.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("could not resolve the build context: %v", err)
+	}
+
+	userBuildArgs := m.helper.ApplyBuildArgOverrides(buildConfig.BuildArgs, overrides...)
+
+	buildArgs, err := m.resolveBuildArgs(ctx, userBuildArgs, mod.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("could not resolve the build arguments: %v", err)
+	}
+
 	registryTLS := module.TLSOptions(mod.Spec, km)
-	specTemplate := m.specTemplate(
-		mod.Spec,
-		buildConfig,
-		targetKernel,
-		containerImage,
-		registryTLS,
-		pushImage)
-
-	specTemplateHash, err := m.getHashAnnotationValue(ctx, buildConfig.DockerfileConfigMap.Name, mod.Namespace, &specTemplate)
+	specTemplate := v1.PodTemplateSpec{
+		Spec: m.builder.podSpec(mod.Spec, buildConfig, buildArgs, containerImage, registryTLS, pushImage),
+	}
+
+	if err = addBuildContext(&specTemplate.Spec, buildConfig.Context, resolvedContext, m.contextImage); err != nil {
+		return nil, err
+	}
+
+	annotations := make(map[string]string)
+
+	// a new trigger changes the hash of the job, so that the image is rebuilt
+	if t := mod.Spec.RebuildTrigger; t != "" {
+		annotations[constants.RebuildTriggerAnnotation] = t
+	}
+
+	// so does a change in the contents of the build context
+	if contextHash != "" {
+		annotations[constants.BuildContextAnnotation] = contextHash
+	}
+
+	// and the architecture, so that the image of another architecture is never taken for an up-to-date one
+	if targetArch != "" {
+		annotations[constants.ArchitectureLabel] = targetArch
+	}
+
+	if len(annotations) > 0 {
+		specTemplate.Annotations = annotations
+	}
+
+	specTemplateHash, err := m.getHashAnnotationValue(ctx, buildConfig, buildArgs, mod.Namespace, dockerfile, &specTemplate)
 	if err != nil {
 		return nil, fmt.Errorf("could not hash job's definitions: %v", err)
 	}
 
+	// stamp the hash of the build inputs on the image, so that we can detect when it needs to be rebuilt
+	c := buildContainer(&specTemplate.Spec)
+	c.Args = append(
+		c.Args,
+		"--label",
+		fmt.Sprintf("%s=%d", constants.BuildDigestLabel, specTemplateHash),
+	)
+
+	// the variables of the target derive from its kernel, which is already hashed, and from its nodes; they are not
+	// hashed so that the images built before they were passed are kept
+	if osConfig != nil {
+		c.Args = append(c.Args, buildArgFlags(targetBuildArgs(osConfig, userBuildArgs))...)
+	}
+
+	// the backend is labelled so that the Manager of each backend only garbage-collects its own jobs
+	labels := m.jobHelper.JobLabels(mod.Name, targetKernel, targetArch, utils.JobTypeBuild)
+	labels[constants.BuildBackendLabel] = string(m.builder.backend())
+
 	job := &batchv1.Job{
 		ObjectMeta: metav1.ObjectMeta{
 			GenerateName: mod.Name + "-build-",
 			Namespace:    mod.Namespace,
-			Labels:       m.jobHelper.JobLabels(mod.Name, targetKernel, utils.JobTypeBuild),
+			Labels:       labels,
 			Annotations:  map[string]string{constants.JobHashAnnotation: fmt.Sprintf("%d", specTemplateHash)},
 		},
 		Spec: batchv1.JobSpec{
@@ -108,99 +251,80 @@ func (m *maker) MakeJobTemplate(
 		},
 	}
 
-	if err := controllerutil.SetControllerReference(owner, job, m.scheme); err != nil {
-		return nil, fmt.Errorf("could not set the owner reference: %v", err)
-	}
-
-	return job, nil
-}
+	// neither the nodes the build runs on, which only follow the hashed architecture, nor the pod policy change the
+	// image, so they are applied after hashing
+	job.Spec.Template.Spec.NodeSelector = utils.JobNodeSelector(mod.Spec.Selector, targetArch)
+	job.Spec.Template.Spec.Affinity = module.Affinity(mod.Spec)
+	applyPodPolicy(&job.Spec, buildConfig.PodPolicy)
+
+	// the policy is hashed on its own, so that failed jobs can be retried once it changes
+	if p := buildConfig.PodPolicy; p != nil {
+		policyHash, err := hashstructure.Hash(p, nil)
+		if err != nil {
+			return nil, fmt.Errorf("could not hash the pod policy: %v", err)
+		}
 
-func (m *maker) specTemplate(
-	modSpec kmmv1beta1.ModuleSpec,
-	buildConfig *kmmv1beta1.Build,
-	targetKernel string,
-	containerImage string,
-	registryTLS *kmmv1beta1.TLSOptions,
-	pushImage bool) v1.PodTemplateSpec {
-
-	kanikoImageTag := "latest"
-	if buildConfig.KanikoParams != nil && buildConfig.KanikoParams.Tag != "" {
-		kanikoImageTag = buildConfig.KanikoParams.Tag
-	}
-
-	return v1.PodTemplateSpec{
-		Spec: v1.PodSpec{
-			Containers: []v1.Container{
-				{
-					Args:         m.containerArgs(buildConfig, targetKernel, containerImage, registryTLS, pushImage),
-					Name:         "kaniko",
-					Image:        "gcr.io/kaniko-project/executor:" + kanikoImageTag,
-					VolumeMounts: volumeMounts(modSpec, buildConfig),
-				},
-			},
-			NodeSelector:  modSpec.Selector,
-			RestartPolicy: v1.RestartPolicyOnFailure,
-			Volumes:       volumes(modSpec, buildConfig),
-		},
+		job.Annotations[constants.PodPolicyHashAnnotation] = fmt.Sprintf("%d", policyHash)
 	}
-}
-
-func (m *maker) containerArgs(
-	buildConfig *kmmv1beta1.Build,
-	targetKernel string,
-	containerImage string,
-	registryTLS *kmmv1beta1.TLSOptions,
-	pushImage bool) []string {
 
-	args := []string{}
-	if pushImage {
-		args = append(args, "--destination", containerImage)
-	} else {
-		args = append(args, "--no-push")
+	// neither does the cache
+	cacheArgs, err := m.builder.cacheArgs(buildConfig, mod.Namespace, registryTLS)
+	if err != nil {
+		return nil, fmt.Errorf("could not configure the build cache: %v", err)
 	}
 
-	buildArgs := m.helper.ApplyBuildArgOverrides(
-		buildConfig.BuildArgs,
-		kmmv1beta1.BuildArg{Name: "KERNEL_VERSION", Value: targetKernel},
-	)
+	c = buildContainer(&job.Spec.Template.Spec)
+	c.Args = append(c.Args, cacheArgs...)
 
-	for _, ba := range buildArgs {
-		args = append(args, "--build-arg", fmt.Sprintf("%s=%s", ba.Name, ba.Value))
+	if err := controllerutil.SetControllerReference(owner, job, m.scheme); err != nil {
+		return nil, fmt.Errorf("could not set the owner reference: %v", err)
 	}
 
-	if buildConfig.BaseImageRegistryTLS.Insecure {
-		args = append(args, "--insecure-pull")
-	}
+	return job, nil
+}
 
-	if buildConfig.BaseImageRegistryTLS.InsecureSkipTLSVerify {
-		args = append(args, "--skip-tls-verify-pull")
+func (m *maker) getDockerfile(ctx context.Context, buildConfig *kmmv1beta1.Build, namespace string) (string, error) {
+	dockerfileCM := &corev1.ConfigMap{}
+	namespacedName := types.NamespacedName{Name: buildConfig.DockerfileConfigMap.Name, Namespace: namespace}
+	if err := m.client.Get(ctx, namespacedName, dockerfileCM); err != nil {
+		return "", fmt.Errorf("failed to get dockerfile ConfigMap %s: %v", namespacedName, err)
+	}
+	data, ok := dockerfileCM.Data[constants.DockerfileCMKey]
+	if !ok {
+		return "", fmt.Errorf("invalid Dockerfile ConfigMap %s format, %s key is missing", namespacedName, constants.DockerfileCMKey)
 	}
 
-	if pushImage {
-		if registryTLS.Insecure {
-			args = append(args, "--insecure")
-		}
+	return data, nil
+}
 
-		if registryTLS.InsecureSkipTLSVerify {
-			args = append(args, "--skip-tls-verify")
+func (m *maker) getHashAnnotationValue(
+	ctx context.Context,
+	buildConfig *kmmv1beta1.Build,
+	buildArgs []kmmv1beta1.BuildArg,
+	namespace string,
+	dockerfile string,
+	podTemplate *v1.PodTemplateSpec) (uint64, error) {
+
+	secretVersions := make(map[string]string, len(buildConfig.Secrets))
+	for _, secretRef := range buildConfig.Secrets {
+		secret := &corev1.Secret{}
+		secretName := types.NamespacedName{Name: secretRef.Name, Namespace: namespace}
+		if err := m.client.Get(ctx, secretName, secret); err != nil {
+			return 0, fmt.Errorf("failed to get build secret %s: %v", secretName, err)
 		}
+		secretVersions[secretRef.Name] = utils.SecretVersion(secret)
 	}
 
-	return args
-}
-
-func (m *maker) getHashAnnotationValue(ctx context.Context, configMapName, namespace string, podTemplate *v1.PodTemplateSpec) (uint64, error) {
-	dockerfileCM := &corev1.ConfigMap{}
-	namespacedName := types.NamespacedName{Name: configMapName, Namespace: namespace}
-	if err := m.client.Get(ctx, namespacedName, dockerfileCM); err != nil {
-		return 0, fmt.Errorf("failed to get dockerfile ConfigMap %s: %v", namespacedName, err)
+	buildArgsVersions, err := m.secretBuildArgsVersions(ctx, buildArgs, namespace)
+	if err != nil {
+		return 0, err
 	}
-	data, ok := dockerfileCM.Data[constants.DockerfileCMKey]
-	if !ok {
-		return 0, fmt.Errorf("invalid Dockerfile ConfigMap %s format, %s key is missing", namespacedName, constants.DockerfileCMKey)
+
+	for secretName, version := range buildArgsVersions {
+		secretVersions[secretName] = version
 	}
 
-	return getHashValue(podTemplate, data)
+	return getHashValue(podTemplate, dockerfile, secretVersions)
 }
 
 func volumes(modSpec kmmv1beta1.ModuleSpec, buildConfig *kmmv1beta1.Build) []v1.Volume {
@@ -212,10 +336,10 @@ func volumes(modSpec kmmv1beta1.ModuleSpec, buildConfig *kmmv1beta1.Build) []v1.
 	return volumes
 }
 
-func volumeMounts(modSpec kmmv1beta1.ModuleSpec, buildConfig *kmmv1beta1.Build) []v1.VolumeMount {
+func volumeMounts(modSpec kmmv1beta1.ModuleSpec, buildConfig *kmmv1beta1.Build, dockerConfigPath string) []v1.VolumeMount {
 	volumeMounts := []v1.VolumeMount{dockerfileVolumeMount(dockerfileVolumeName)}
 	if irs := modSpec.ImageRepoSecret; irs != nil {
-		volumeMounts = append(volumeMounts, makeImagePullSecretVolumeMount(irs))
+		volumeMounts = append(volumeMounts, makeImagePullSecretVolumeMount(irs, dockerConfigPath))
 	}
 	volumeMounts = append(volumeMounts, makeBuildSecretVolumeMounts(buildConfig.Secrets)...)
 	return volumeMounts
@@ -246,10 +370,11 @@ func dockerfileVolumeMount(name string) v1.VolumeMount {
 	}
 }
 
-func getHashValue(podTemplate *v1.PodTemplateSpec, dockerfile string) (uint64, error) {
+func getHashValue(podTemplate *v1.PodTemplateSpec, dockerfile string, secretVersions map[string]string) (uint64, error) {
 	dataToHash := hashData{
-		Dockerfile:  dockerfile,
-		PodTemplate: podTemplate,
+		Dockerfile:     dockerfile,
+		PodTemplate:    podTemplate,
+		SecretVersions: secretVersions,
 	}
 	hashValue, err := hashstructure.Hash(dataToHash, nil)
 	if err != nil {
@@ -279,7 +404,7 @@ func makeImagePullSecretVolume(secretRef *v1.LocalObjectReference) v1.Volume {
 	}
 }
 
-func makeImagePullSecretVolumeMount(secretRef *v1.LocalObjectReference) v1.VolumeMount {
+func makeImagePullSecretVolumeMount(secretRef *v1.LocalObjectReference, mountPath string) v1.VolumeMount {
 	if secretRef == nil {
 		return v1.VolumeMount{}
 	}
@@ -287,7 +412,7 @@ func makeImagePullSecretVolumeMount(secretRef *v1.LocalObjectReference) v1.Volum
 	return v1.VolumeMount{
 		Name:      volumeNameFromSecretRef(*secretRef),
 		ReadOnly:  true,
-		MountPath: "/kaniko/.docker",
+		MountPath: mountPath,
 	}
 }
 
@@ -326,6 +451,39 @@ func makeBuildSecretVolumeMounts(secretRefs []v1.LocalObjectReference) []v1.Volu
 	return secretVolumeMounts
 }
 
+func applyPodPolicy(jobSpec *batchv1.JobSpec, policy *kmmv1beta1.BuildPodPolicy) {
+	if policy == nil {
+		return
+	}
+
+	podSpec := &jobSpec.Template.Spec
+
+	// the nodes of the policy replace those of the Module
+	if policy.NodeSelector != nil {
+		podSpec.NodeSelector = policy.NodeSelector
+		podSpec.Affinity = nil
+	}
+
+	podSpec.Tolerations = policy.Tolerations
+	buildContainer(podSpec).Resources = policy.Resources
+
+	jobSpec.ActiveDeadlineSeconds = policy.ActiveDeadlineSeconds
+	jobSpec.BackoffLimit = policy.BackoffLimit
+}
+
+// buildContainer returns the container that builds the image.
+func buildContainer(spec *v1.PodSpec) *v1.Container {
+	for _, containers := range [][]v1.Container{spec.InitContainers, spec.Containers} {
+		for i := range containers {
+			if n := containers[i].Name; n == kanikoContainerName || n == buildahContainerName {
+				return &containers[i]
+			}
+		}
+	}
+
+	return &spec.Containers[0]
+}
+
 func volumeNameFromSecretRef(ref v1.LocalObjectReference) string {
 	return "secret-" + ref.Name
 }
@@ -2,7 +2,9 @@ package job
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -12,6 +14,7 @@ import (
 	"golang.org/x/exp/slices"
 	batchv1 "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/utils/pointer"
@@ -21,6 +24,9 @@ import (
 	"github.com/kubernetes-sigs/kernel-module-management/internal/build"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/client"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/constants"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/dtk"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/module"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/registry"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/utils"
 )
 
@@ -39,6 +45,7 @@ var _ = Describe("MakeJobTemplate", func() {
 		m         Maker
 		mh        *build.MockHelper
 		jobhelper *utils.MockJobHelper
+		catalog   *dtk.MockCatalog
 	)
 
 	BeforeEach(func() {
@@ -46,7 +53,8 @@ var _ = Describe("MakeJobTemplate", func() {
 		clnt = client.NewMockClient(ctrl)
 		mh = build.NewMockHelper(ctrl)
 		jobhelper = utils.NewMockJobHelper(ctrl)
-		m = NewMaker(clnt, mh, jobhelper, scheme)
+		catalog = dtk.NewMockCatalog(ctrl)
+		m = NewKanikoMaker(clnt, mh, jobhelper, catalog, "", "", scheme)
 	})
 
 	AfterEach(func() {
@@ -67,6 +75,36 @@ var _ = Describe("MakeJobTemplate", func() {
 	dockerfileConfigMap := v1.LocalObjectReference{Name: "configMapName"}
 	dockerfileCMData := map[string]string{constants.DockerfileCMKey: dockerfile}
 
+	// expectJobTemplate expects the calls that MakeJobTemplate makes for every build: getting the Dockerfile,
+	// overriding the build arguments and labelling the job.
+	// inputs are the calls that resolve the other inputs of the build; they are expected between the first and the
+	// last of those calls.
+	expectJobTemplate := func(ctx context.Context, buildArgs []kmmv1beta1.BuildArg, targetArch string, inputs ...*gomock.Call) {
+		getDockerfile := clnt.EXPECT().Get(ctx, types.NamespacedName{Name: dockerfileConfigMap.Name, Namespace: mod.Namespace}, gomock.Any()).DoAndReturn(
+			func(_ interface{}, _ interface{}, cm *v1.ConfigMap, _ ...ctrlclient.GetOption) error {
+				cm.Data = dockerfileCMData
+				return nil
+			},
+		)
+
+		applyOverrides := mh.
+			EXPECT().
+			ApplyBuildArgOverrides(buildArgs, kmmv1beta1.BuildArg{Name: "KERNEL_VERSION", Value: kernelVersion}).
+			Return(buildArgs).
+			After(getDockerfile)
+
+		jobLabels := jobhelper.
+			EXPECT().
+			JobLabels(mod.Name, kernelVersion, targetArch, utils.JobTypeBuild).
+			Return(map[string]string{}).
+			After(applyOverrides)
+
+		for _, c := range inputs {
+			c.After(getDockerfile)
+			jobLabels.After(c)
+		}
+	}
+
 	DescribeTable("should set fields correctly", func(buildSecrets []v1.LocalObjectReference, imagePullSecret *v1.LocalObjectReference) {
 		ctx := context.Background()
 		nodeSelector := map[string]string{"arch": "x64"}
@@ -122,8 +160,7 @@ var _ = Describe("MakeJobTemplate", func() {
 								},
 							},
 						},
-						NodeSelector:  nodeSelector,
-						RestartPolicy: v1.RestartPolicyOnFailure,
+						RestartPolicy: v1.RestartPolicyNever,
 						Volumes: []v1.Volume{
 							{
 								Name: "dockerfile",
@@ -201,28 +238,53 @@ var _ = Describe("MakeJobTemplate", func() {
 					},
 				)
 		}
-		hash, err := getHashValue(&expected.Spec.Template, dockerfile)
+		secretVersions := make(map[string]string, len(buildSecrets))
+		for _, s := range buildSecrets {
+			secretVersions[s.Name] = "some-uid/1"
+		}
+
+		hash, err := getHashValue(&expected.Spec.Template, dockerfile, secretVersions)
 		Expect(err).NotTo(HaveOccurred())
 		annotations := map[string]string{constants.JobHashAnnotation: fmt.Sprintf("%d", hash)}
 		expected.SetAnnotations(annotations)
+		expected.Spec.Template.Spec.Containers[0].Args = append(
+			expected.Spec.Template.Spec.Containers[0].Args,
+			"--label", fmt.Sprintf("%s=%d", constants.BuildDigestLabel, hash),
+		)
+
+		// the nodes are not hashed
+		expected.Spec.Template.Spec.NodeSelector = nodeSelector
 
 		mod := mod.DeepCopy()
 		mod.Spec.Selector = nodeSelector
 
 		override := kmmv1beta1.BuildArg{Name: "KERNEL_VERSION", Value: kernelVersion}
-		gomock.InOrder(
-			mh.EXPECT().GetRelevantBuild(mod.Spec, km).Return(km.Build),
-			mh.EXPECT().ApplyBuildArgOverrides(buildArgs, override).Return(append(slices.Clone(buildArgs), override)),
+		calls := []*gomock.Call{
 			clnt.EXPECT().Get(ctx, types.NamespacedName{Name: dockerfileConfigMap.Name, Namespace: mod.Namespace}, gomock.Any()).DoAndReturn(
 				func(_ interface{}, _ interface{}, cm *v1.ConfigMap, _ ...ctrlclient.GetOption) error {
 					cm.Data = dockerfileCMData
 					return nil
 				},
 			),
-			jobhelper.EXPECT().JobLabels(mod.Name, kernelVersion, utils.JobTypeBuild).Return(labels),
-		)
+			mh.EXPECT().ApplyBuildArgOverrides(buildArgs, override).Return(append(slices.Clone(buildArgs), override)),
+		}
+		for _, s := range buildSecrets {
+			calls = append(
+				calls,
+				clnt.EXPECT().Get(ctx, types.NamespacedName{Name: s.Name, Namespace: mod.Namespace}, gomock.Any()).DoAndReturn(
+					func(_ interface{}, _ interface{}, secret *v1.Secret, _ ...ctrlclient.GetOption) error {
+						secret.UID = "some-uid"
+						secret.ResourceVersion = "1"
+						secret.Data = map[string][]byte{"some-key": []byte("some-value")}
+						return nil
+					},
+				),
+			)
+		}
+		calls = append(calls, jobhelper.EXPECT().JobLabels(mod.Name, kernelVersion, "", utils.JobTypeBuild).Return(labels))
+		gomock.InOrder(calls...)
 
-		actual, err := m.MakeJobTemplate(ctx, *mod, km, kernelVersion, mod, true)
+		actual, err := m.MakeJobTemplate(ctx, *mod, km, kernelVersion, "", nil, mod, true)
 		Expect(err).NotTo(HaveOccurred())
 
 		Expect(
@@ -262,19 +324,9 @@ var _ = Describe("MakeJobTemplate", func() {
 			RegistryTLS:    tls,
 		}
 
-		gomock.InOrder(
-			mh.EXPECT().GetRelevantBuild(mod.Spec, km).Return(km.Build),
-			mh.EXPECT().ApplyBuildArgOverrides(nil, kmmv1beta1.BuildArg{Name: "KERNEL_VERSION", Value: kernelVersion}),
-			clnt.EXPECT().Get(ctx, types.NamespacedName{Name: dockerfileConfigMap.Name, Namespace: mod.Namespace}, gomock.Any()).DoAndReturn(
-				func(_ interface{}, _ interface{}, cm *v1.ConfigMap, _ ...ctrlclient.GetOption) error {
-					cm.Data = dockerfileCMData
-					return nil
-				},
-			),
-			jobhelper.EXPECT().JobLabels(mod.Name, kernelVersion, utils.JobTypeBuild).Return(map[string]string{}),
-		)
+		expectJobTemplate(ctx, nil, "")
 
-		actual, err := m.MakeJobTemplate(ctx, mod, km, kernelVersion, &mod, pushImage)
+		actual, err := m.MakeJobTemplate(ctx, mod, km, kernelVersion, "", nil, &mod, pushImage)
 
 		Expect(err).NotTo(HaveOccurred())
 		Expect(actual.Spec.Template.Spec.Containers[0].Args).To(ContainElement(kanikoFlag))
@@ -334,20 +386,9 @@ var _ = Describe("MakeJobTemplate", func() {
 			ContainerImage: image,
 		}
 
-		override := kmmv1beta1.BuildArg{Name: "KERNEL_VERSION", Value: kernelVersion}
-		gomock.InOrder(
-			mh.EXPECT().GetRelevantBuild(mod.Spec, km).Return(km.Build),
-			mh.EXPECT().ApplyBuildArgOverrides(buildArgs, override),
-			clnt.EXPECT().Get(ctx, types.NamespacedName{Name: dockerfileConfigMap.Name, Namespace: mod.Namespace}, gomock.Any()).DoAndReturn(
-				func(_ interface{}, _ interface{}, cm *v1.ConfigMap, _ ...ctrlclient.GetOption) error {
-					cm.Data = dockerfileCMData
-					return nil
-				},
-			),
-			jobhelper.EXPECT().JobLabels(mod.Name, kernelVersion, utils.JobTypeBuild).Return(map[string]string{}),
-		)
+		expectJobTemplate(ctx, buildArgs, "")
 
-		actual, err := m.MakeJobTemplate(ctx, mod, km, kernelVersion, &mod, false)
+		actual, err := m.MakeJobTemplate(ctx, mod, km, kernelVersion, "", nil, &mod, false)
 
 		Expect(err).NotTo(HaveOccurred())
 		Expect(actual.Spec.Template.Spec.Containers[0].Image).To(Equal("gcr.io/kaniko-project/executor:" + customTag))
@@ -356,7 +397,6 @@ var _ = Describe("MakeJobTemplate", func() {
 	It("should add the kmm_unsigned suffix to the target image if sign is defined", func() {
 		ctx := context.Background()
 
-		override := kmmv1beta1.BuildArg{Name: "KERNEL_VERSION", Value: kernelVersion}
 		km := kmmv1beta1.KernelMapping{
 			Sign: &kmmv1beta1.Sign{},
 			Build: &kmmv1beta1.Build{
@@ -368,22 +408,535 @@ var _ = Describe("MakeJobTemplate", func() {
 
 		expectedImageName := km.ContainerImage + ":" + mod.Namespace + "_" + mod.Name + "_kmm_unsigned"
 
-		gomock.InOrder(
-			mh.EXPECT().GetRelevantBuild(mod.Spec, km).Return(km.Build),
-			mh.EXPECT().ApplyBuildArgOverrides(buildArgs, override),
-			clnt.EXPECT().Get(ctx, types.NamespacedName{Name: dockerfileConfigMap.Name, Namespace: mod.Namespace}, gomock.Any()).DoAndReturn(
-				func(_ interface{}, _ interface{}, cm *v1.ConfigMap, _ ...ctrlclient.GetOption) error {
-					cm.Data = dockerfileCMData
-					return nil
-				},
-			),
-			jobhelper.EXPECT().JobLabels(mod.Name, kernelVersion, utils.JobTypeBuild).Return(map[string]string{}),
-		)
+		expectJobTemplate(ctx, buildArgs, "")
 
-		actual, err := m.MakeJobTemplate(ctx, mod, km, kernelVersion, &mod, true)
+		actual, err := m.MakeJobTemplate(ctx, mod, km, kernelVersion, "", nil, &mod, true)
 
 		Expect(err).NotTo(HaveOccurred())
 		Expect(actual.Spec.Template.Spec.Containers[0].Args).To(ContainElement("--destination"))
 		Expect(actual.Spec.Template.Spec.Containers[0].Args).To(ContainElement(expectedImageName))
 	})
+
+	Context("Driver Toolkit", func() {
+		const dtkDockerfile = "ARG DTK_AUTO\nFROM ${DTK_AUTO}"
+
+		km := kmmv1beta1.KernelMapping{
+			Build:          &kmmv1beta1.Build{DockerfileConfigMap: &dockerfileConfigMap},
+			ContainerImage: image,
+		}
+
+		expectDockerfile := func(ctx context.Context) *gomock.Call {
+			return clnt.EXPECT().Get(ctx, types.NamespacedName{Name: dockerfileConfigMap.Name, Namespace: mod.Namespace}, gomock.Any()).DoAndReturn(
+				func(_ interface{}, _ interface{}, cm *v1.ConfigMap, _ ...ctrlclient.GetOption) error {
+					cm.Data = map[string]string{constants.DockerfileCMKey: dtkDockerfile}
+					return nil
+				},
+			)
+		}
+
+		It("should pass the Driver Toolkit image of the kernel as DTK_AUTO", func() {
+			ctx := context.Background()
+
+			overrides := []interface{}{
+				kmmv1beta1.BuildArg{Name: "KERNEL_VERSION", Value: kernelVersion},
+				kmmv1beta1.BuildArg{Name: dtk.BuildArg, Value: "dtk-image"},
+			}
+
+			gomock.InOrder(
+				expectDockerfile(ctx),
+				catalog.EXPECT().GetEntry(ctx, kernelVersion).Return(&registry.DriverToolkitEntry{ImageURL: "dtk-image"}, nil),
+				mh.EXPECT().ApplyBuildArgOverrides(nil, overrides...).DoAndReturn(
+					func(_ []kmmv1beta1.BuildArg, o ...kmmv1beta1.BuildArg) []kmmv1beta1.BuildArg {
+						return o
+					},
+				),
+				jobhelper.EXPECT().JobLabels(mod.Name, kernelVersion, "", utils.JobTypeBuild).Return(map[string]string{}),
+			)
+
+			actual, err := m.MakeJobTemplate(ctx, mod, km, kernelVersion, "", nil, &mod, true)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(actual.Spec.Template.Spec.Containers[0].Args).To(ContainElement("DTK_AUTO=dtk-image"))
+		})
+
+		It("should fail if the kernel has no Driver Toolkit image", func() {
+			ctx := context.Background()
+
+			gomock.InOrder(
+				expectDockerfile(ctx),
+				catalog.EXPECT().GetEntry(ctx, kernelVersion).Return(nil, dtk.ErrNoEntry),
+			)
+
+			_, err := m.MakeJobTemplate(ctx, mod, km, kernelVersion, "", nil, &mod, true)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("Buildah", func() {
+		km := kmmv1beta1.KernelMapping{
+			Build: &kmmv1beta1.Build{
+				Backend:             kmmv1beta1.BuildBackendBuildah,
+				BuildahParams:       &kmmv1beta1.BuildahParams{Tag: "v1.30"},
+				BuildArgs:           buildArgs,
+				DockerfileConfigMap: &dockerfileConfigMap,
+				BaseImageRegistryTLS: kmmv1beta1.TLSOptions{
+					InsecureSkipTLSVerify: true,
+				},
+			},
+			ContainerImage: image,
+		}
+
+		modWithSecret := mod
+		modWithSecret.Spec.ImageRepoSecret = &v1.LocalObjectReference{Name: "pull-secret"}
+
+		BeforeEach(func() {
+			m = NewBuildahMaker(clnt, mh, jobhelper, catalog, "", scheme)
+		})
+
+		It("should only build the image if it is not pushed", func() {
+			ctx := context.Background()
+
+			expectJobTemplate(ctx, buildArgs, "")
+
+			actual, err := m.MakeJobTemplate(ctx, modWithSecret, km, kernelVersion, "", nil, &mod, false)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(actual.Labels).To(HaveKeyWithValue(constants.BuildBackendLabel, string(kmmv1beta1.BuildBackendBuildah)))
+
+			spec := actual.Spec.Template.Spec
+			Expect(spec.InitContainers).To(BeEmpty())
+			Expect(spec.Containers).To(HaveLen(1))
+
+			c := spec.Containers[0]
+			Expect(c.Image).To(Equal("quay.io/buildah/stable:v1.30"))
+			Expect(c.Command).To(Equal([]string{"buildah"}))
+			Expect(c.Args[0]).To(Equal("bud"))
+			Expect(c.Args).To(ContainElements("--tag", image, "--build-arg", "name1=value1", "--tls-verify=false"))
+			Expect(c.Args[len(c.Args)-2]).To(Equal("--label"))
+			Expect(c.Args[len(c.Args)-1]).To(HavePrefix(constants.BuildDigestLabel + "="))
+			Expect(c.Env).To(ContainElement(v1.EnvVar{Name: "REGISTRY_AUTH_FILE", Value: "/run/buildah/auth/config.json"}))
+			Expect(c.VolumeMounts).To(ContainElement(v1.VolumeMount{Name: "secret-pull-secret", ReadOnly: true, MountPath: "/run/buildah/auth"}))
+			Expect(c.VolumeMounts).To(ContainElement(v1.VolumeMount{Name: "buildah-storage", MountPath: "/var/lib/containers"}))
+			Expect(spec.Volumes).To(ContainElement(v1.Volume{
+				Name:         "buildah-storage",
+				VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}},
+			}))
+		})
+
+		It("should build in an init container and push in the main container", func() {
+			ctx := context.Background()
+
+			expectJobTemplate(ctx, buildArgs, "")
+
+			actual, err := m.MakeJobTemplate(ctx, modWithSecret, km, kernelVersion, "", nil, &mod, true)
+			Expect(err).NotTo(HaveOccurred())
+
+			spec := actual.Spec.Template.Spec
+			Expect(spec.InitContainers).To(HaveLen(1))
+			Expect(spec.InitContainers[0].Args[0]).To(Equal("bud"))
+			Expect(spec.InitContainers[0].Args).To(ContainElement("--label"))
+
+			Expect(spec.Containers).To(HaveLen(1))
+			Expect(spec.Containers[0].Args).To(Equal([]string{"push", "--storage-driver=vfs", image}))
+			Expect(spec.Containers[0].VolumeMounts).To(ConsistOf(
+				v1.VolumeMount{Name: "buildah-storage", MountPath: "/var/lib/containers"},
+				v1.VolumeMount{Name: "secret-pull-secret", ReadOnly: true, MountPath: "/run/buildah/auth"},
+			))
+		})
+	})
+
+	Context("Build context", func() {
+		km := kmmv1beta1.KernelMapping{
+			Build: &kmmv1beta1.Build{
+				DockerfileConfigMap: &dockerfileConfigMap,
+				Context: &kmmv1beta1.BuildContext{
+					ConfigMaps: []kmmv1beta1.ConfigMapBuildSource{{Name: "patches"}},
+				},
+			},
+			ContainerImage: image,
+		}
+
+		makeJob := func(patch string) *batchv1.Job {
+			ctx := context.Background()
+
+			expectJobTemplate(
+				ctx,
+				nil,
+				"",
+				clnt.EXPECT().Get(ctx, types.NamespacedName{Name: "patches", Namespace: mod.Namespace}, gomock.Any()).DoAndReturn(
+					func(_ interface{}, _ interface{}, cm *v1.ConfigMap, _ ...ctrlclient.GetOption) error {
+						cm.Data = map[string]string{"fix.patch": patch}
+						return nil
+					},
+				),
+			)
+
+			job, err := m.MakeJobTemplate(ctx, mod, km, kernelVersion, "", nil, &mod, true)
+			Expect(err).NotTo(HaveOccurred())
+
+			return job
+		}
+
+		It("should mount the ConfigMaps in the build context", func() {
+			job := makeJob("some patch")
+
+			Expect(job.Spec.Template.Annotations).To(HaveKey(constants.BuildContextAnnotation))
+			Expect(job.Spec.Template.Spec.Containers[0].VolumeMounts).To(ContainElement(
+				v1.VolumeMount{Name: "context-configmap-0", ReadOnly: true, MountPath: "/workspace/patches"},
+			))
+		})
+
+		It("should change the hash of the job when a ConfigMap changes", func() {
+			job1 := makeJob("some patch")
+			job2 := makeJob("other patch")
+
+			Expect(job1.Annotations[constants.JobHashAnnotation]).NotTo(Equal(job2.Annotations[constants.JobHashAnnotation]))
+		})
+
+		It("should fail if a ConfigMap cannot be fetched", func() {
+			ctx := context.Background()
+
+			gomock.InOrder(
+				clnt.EXPECT().Get(ctx, types.NamespacedName{Name: dockerfileConfigMap.Name, Namespace: mod.Namespace}, gomock.Any()).DoAndReturn(
+					func(_ interface{}, _ interface{}, cm *v1.ConfigMap, _ ...ctrlclient.GetOption) error {
+						cm.Data = dockerfileCMData
+						return nil
+					},
+				),
+				clnt.EXPECT().Get(ctx, types.NamespacedName{Name: "patches", Namespace: mod.Namespace}, gomock.Any()).Return(errors.New("some error")),
+			)
+
+			_, err := m.MakeJobTemplate(ctx, mod, km, kernelVersion, "", nil, &mod, true)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("Pod policy", func() {
+		policy := kmmv1beta1.BuildPodPolicy{
+			NodeSelector: map[string]string{"node-role.kubernetes.io/build": ""},
+			Tolerations: []v1.Toleration{
+				{Key: "build", Operator: v1.TolerationOpExists, Effect: v1.TaintEffectNoSchedule},
+			},
+			Resources: v1.ResourceRequirements{
+				Limits: v1.ResourceList{v1.ResourceMemory: resource.MustParse("4Gi")},
+			},
+			ActiveDeadlineSeconds: pointer.Int64(3600),
+			BackoffLimit:          pointer.Int32(2),
+		}
+
+		BeforeEach(func() {
+			m = NewBuildahMaker(clnt, mh, jobhelper, catalog, "", scheme)
+		})
+
+		modWithSelector := mod
+		modWithSelector.Spec.Selector = map[string]string{"accelerator": "true"}
+		modWithSelector.Spec.LabelSelector = &metav1.LabelSelector{
+			MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: "zone", Operator: metav1.LabelSelectorOpIn, Values: []string{"a", "b"}},
+			},
+		}
+
+		makeJob := func(p *kmmv1beta1.BuildPodPolicy, pushImage bool, targetArch string) *batchv1.Job {
+			ctx := context.Background()
+
+			km := kmmv1beta1.KernelMapping{
+				Build: &kmmv1beta1.Build{
+					Backend:             kmmv1beta1.BuildBackendBuildah,
+					DockerfileConfigMap: &dockerfileConfigMap,
+					PodPolicy:           p,
+				},
+				ContainerImage: image,
+			}
+
+			expectJobTemplate(ctx, nil, targetArch)
+
+			job, err := m.MakeJobTemplate(ctx, modWithSelector, km, kernelVersion, targetArch, nil, &mod, pushImage)
+			Expect(err).NotTo(HaveOccurred())
+
+			return job
+		}
+
+		It("should apply the policy to the job and to the build container", func() {
+			job := makeJob(&policy, true, "")
+
+			Expect(job.Spec.ActiveDeadlineSeconds).To(Equal(pointer.Int64(3600)))
+			Expect(job.Spec.BackoffLimit).To(Equal(pointer.Int32(2)))
+
+			spec := job.Spec.Template.Spec
+			Expect(spec.NodeSelector).To(Equal(policy.NodeSelector))
+			Expect(spec.Affinity).To(BeNil())
+			Expect(spec.Tolerations).To(Equal(policy.Tolerations))
+			Expect(spec.InitContainers[0].Resources).To(Equal(policy.Resources))
+			Expect(spec.Containers[0].Resources).To(BeZero())
+		})
+
+		It("should default to the selectors of the Module", func() {
+			job := makeJob(&kmmv1beta1.BuildPodPolicy{BackoffLimit: pointer.Int32(0)}, false, "")
+
+			Expect(job.Spec.Template.Spec.NodeSelector).To(Equal(modWithSelector.Spec.Selector))
+			Expect(job.Spec.Template.Spec.Affinity).To(Equal(module.Affinity(modWithSelector.Spec)))
+			Expect(job.Spec.BackoffLimit).To(Equal(pointer.Int32(0)))
+		})
+
+		It("should not change the hash of the job", func() {
+			withPolicy := makeJob(&policy, true, "")
+			withoutPolicy := makeJob(nil, true, "")
+
+			Expect(withPolicy.Annotations[constants.JobHashAnnotation]).To(Equal(withoutPolicy.Annotations[constants.JobHashAnnotation]))
+		})
+
+		It("should annotate the job with the hash of the policy", func() {
+			withoutPolicy := makeJob(nil, true, "")
+			Expect(withoutPolicy.Annotations).NotTo(HaveKey(constants.PodPolicyHashAnnotation))
+
+			longerDeadline := policy
+			longerDeadline.ActiveDeadlineSeconds = pointer.Int64(7200)
+
+			withPolicy := makeJob(&policy, true, "")
+			Expect(withPolicy.Annotations).To(HaveKey(constants.PodPolicyHashAnnotation))
+			Expect(makeJob(&longerDeadline, true, "").Annotations[constants.PodPolicyHashAnnotation]).NotTo(
+				Equal(withPolicy.Annotations[constants.PodPolicyHashAnnotation]),
+			)
+		})
+
+		It("should build the image of the target architecture on its nodes", func() {
+			job := makeJob(nil, true, "arm64")
+
+			Expect(job.Spec.Template.Spec.NodeSelector).To(Equal(map[string]string{"accelerator": "true", v1.LabelArchStable: "arm64"}))
+			Expect(modWithSelector.Spec.Selector).NotTo(HaveKey(v1.LabelArchStable))
+			Expect(job.Spec.Template.Annotations).To(HaveKeyWithValue(constants.ArchitectureLabel, "arm64"))
+			Expect(job.Annotations[constants.JobHashAnnotation]).NotTo(Equal(makeJob(nil, true, "amd64").Annotations[constants.JobHashAnnotation]))
+		})
+	})
+
+	Context("Kaniko cache", func() {
+		makeJob := func(m Maker, b *kmmv1beta1.Build, registryTLS *kmmv1beta1.TLSOptions) *batchv1.Job {
+			ctx := context.Background()
+
+			km := kmmv1beta1.KernelMapping{
+				Build:          b,
+				ContainerImage: image,
+				RegistryTLS:    registryTLS,
+			}
+
+			expectJobTemplate(ctx, nil, "")
+
+			job, err := m.MakeJobTemplate(ctx, mod, km, kernelVersion, "", nil, &mod, true)
+			Expect(err).NotTo(HaveOccurred())
+
+			return job
+		}
+
+		It("should not cache layers by default", func() {
+			job := makeJob(m, &kmmv1beta1.Build{DockerfileConfigMap: &dockerfileConfigMap}, nil)
+
+			Expect(job.Spec.Template.Spec.Containers[0].Args).NotTo(ContainElement("--cache=true"))
+		})
+
+		It("should cache layers in the repository of the build", func() {
+			b := kmmv1beta1.Build{
+				DockerfileConfigMap: &dockerfileConfigMap,
+				KanikoParams: &kmmv1beta1.KanikoParams{
+					CacheRepo: "registry.example.com/cache",
+					CacheTTL:  &metav1.Duration{Duration: 48 * time.Hour},
+				},
+			}
+
+			job := makeJob(m, &b, &kmmv1beta1.TLSOptions{Insecure: true, InsecureSkipTLSVerify: true})
+
+			args := job.Spec.Template.Spec.Containers[0].Args
+			Expect(args[len(args)-9:]).To(Equal([]string{
+				"--cache=true",
+				"--cache-repo", "registry.example.com/cache",
+				"--cache-ttl", "48h0m0s",
+				"--insecure-registry", "registry.example.com",
+				"--skip-tls-verify-registry", "registry.example.com",
+			}))
+		})
+
+		It("should use the default repository of the namespace", func() {
+			m := NewKanikoMaker(clnt, mh, jobhelper, catalog, "", "registry.example.com/${NAMESPACE}/cache", scheme)
+
+			job := makeJob(m, &kmmv1beta1.Build{DockerfileConfigMap: &dockerfileConfigMap}, nil)
+
+			args := job.Spec.Template.Spec.Containers[0].Args
+			Expect(args[len(args)-3:]).To(Equal([]string{"--cache=true", "--cache-repo", "registry.example.com/" + namespace + "/cache"}))
+		})
+
+		It("should prefer the repository of the build to the default one", func() {
+			m := NewKanikoMaker(clnt, mh, jobhelper, catalog, "", "registry.example.com/${NAMESPACE}/cache", scheme)
+
+			b := kmmv1beta1.Build{
+				DockerfileConfigMap: &dockerfileConfigMap,
+				KanikoParams:        &kmmv1beta1.KanikoParams{CacheRepo: "other.example.com/cache"},
+			}
+
+			job := makeJob(m, &b, nil)
+
+			Expect(job.Spec.Template.Spec.Containers[0].Args).To(ContainElement("other.example.com/cache"))
+		})
+
+		It("should not cache Buildah builds", func() {
+			m := NewBuildahMaker(clnt, mh, jobhelper, catalog, "", scheme)
+
+			b := kmmv1beta1.Build{
+				Backend:             kmmv1beta1.BuildBackendBuildah,
+				DockerfileConfigMap: &dockerfileConfigMap,
+				KanikoParams:        &kmmv1beta1.KanikoParams{CacheRepo: "registry.example.com/cache"},
+			}
+
+			job := makeJob(m, &b, nil)
+
+			Expect(job.Spec.Template.Spec.InitContainers[0].Args).NotTo(ContainElement("--cache=true"))
+		})
+
+		It("should not change the hash of the job", func() {
+			b := kmmv1beta1.Build{
+				DockerfileConfigMap: &dockerfileConfigMap,
+				KanikoParams:        &kmmv1beta1.KanikoParams{CacheRepo: "registry.example.com/cache"},
+			}
+
+			withCache := makeJob(m, &b, nil)
+			withoutCache := makeJob(m, &kmmv1beta1.Build{DockerfileConfigMap: &dockerfileConfigMap}, nil)
+
+			Expect(withCache.Annotations).To(Equal(withoutCache.Annotations))
+		})
+	})
+
+	Context("Target build arguments", func() {
+		osConfig := module.NodeOSConfig{
+			KernelFullVersion:  kernelVersion,
+			KernelVersionMajor: "1",
+			Architecture:       "arm64",
+			OSID:               "rhel",
+		}
+
+		makeJob := func(buildArgs []kmmv1beta1.BuildArg, osConfig *module.NodeOSConfig) *batchv1.Job {
+			ctx := context.Background()
+
+			km := kmmv1beta1.KernelMapping{
+				Build:          &kmmv1beta1.Build{BuildArgs: buildArgs, DockerfileConfigMap: &dockerfileConfigMap},
+				ContainerImage: image,
+			}
+
+			expectJobTemplate(ctx, buildArgs, "")
+
+			job, err := m.MakeJobTemplate(ctx, mod, km, kernelVersion, "", osConfig, &mod, true)
+			Expect(err).NotTo(HaveOccurred())
+
+			return job
+		}
+
+		It("should pass the non-empty variables of the target", func() {
+			job := makeJob(nil, &osConfig)
+
+			args := job.Spec.Template.Spec.Containers[0].Args
+			Expect(args).To(ContainElements(
+				"KERNEL_FULL_VERSION="+kernelVersion,
+				"KERNEL_X=1",
+				"ARCH=arm64",
+				"OS_ID=rhel",
+			))
+			Expect(args).NotTo(ContainElement(HavePrefix("KERNEL_Y=")))
+		})
+
+		It("should let users override the variables of the target", func() {
+			job := makeJob([]kmmv1beta1.BuildArg{{Name: "ARCH", Value: "aarch64"}}, &osConfig)
+
+			args := job.Spec.Template.Spec.Containers[0].Args
+			Expect(args).To(ContainElement("ARCH=aarch64"))
+			Expect(args).NotTo(ContainElement("ARCH=arm64"))
+		})
+
+		It("should not change the hash of the job", func() {
+			withTarget := makeJob(nil, &osConfig)
+			withoutTarget := makeJob(nil, nil)
+
+			Expect(withTarget.Annotations).To(Equal(withoutTarget.Annotations))
+		})
+	})
+
+	Context("Build arguments from ConfigMaps and Secrets", func() {
+		km := kmmv1beta1.KernelMapping{
+			Build: &kmmv1beta1.Build{
+				BuildArgs: []kmmv1beta1.BuildArg{
+					{
+						Name: "MIRROR_URL",
+						ValueFrom: &kmmv1beta1.BuildArgSource{
+							ConfigMapKeyRef: &v1.ConfigMapKeySelector{LocalObjectReference: v1.LocalObjectReference{Name: "mirrors"}, Key: "url"},
+						},
+					},
+					{
+						Name: "LICENSE_KEY",
+						ValueFrom: &kmmv1beta1.BuildArgSource{
+							SecretKeyRef: &v1.SecretKeySelector{LocalObjectReference: v1.LocalObjectReference{Name: "license"}, Key: "key"},
+						},
+					},
+				},
+				DockerfileConfigMap: &dockerfileConfigMap,
+			},
+			ContainerImage: image,
+		}
+
+		makeJob := func(mirror, license, licenseVersion string) *batchv1.Job {
+			ctx := context.Background()
+
+			expectJobTemplate(
+				ctx,
+				km.Build.BuildArgs,
+				"",
+				clnt.EXPECT().Get(ctx, types.NamespacedName{Name: "mirrors", Namespace: mod.Namespace}, gomock.Any()).DoAndReturn(
+					func(_ interface{}, _ interface{}, cm *v1.ConfigMap, _ ...ctrlclient.GetOption) error {
+						cm.Data = map[string]string{"url": mirror}
+						return nil
+					},
+				),
+				clnt.EXPECT().Get(ctx, types.NamespacedName{Name: "license", Namespace: mod.Namespace}, gomock.Any()).DoAndReturn(
+					func(_ interface{}, _ interface{}, s *v1.Secret, _ ...ctrlclient.GetOption) error {
+						s.ResourceVersion = licenseVersion
+						s.Data = map[string][]byte{"key": []byte(license)}
+						return nil
+					},
+				),
+			)
+
+			job, err := m.MakeJobTemplate(ctx, mod, km, kernelVersion, "", nil, &mod, true)
+			Expect(err).NotTo(HaveOccurred())
+
+			return job
+		}
+
+		It("should pass ConfigMap values as arguments and Secret values through the environment", func() {
+			job := makeJob("https://mirror.example.com", "some-license", "1")
+
+			c := job.Spec.Template.Spec.Containers[0]
+			Expect(c.Args).To(ContainElements("MIRROR_URL=https://mirror.example.com", "LICENSE_KEY"))
+			Expect(c.Env).To(Equal([]v1.EnvVar{
+				{
+					Name:      "LICENSE_KEY",
+					ValueFrom: &v1.EnvVarSource{SecretKeyRef: km.Build.BuildArgs[1].ValueFrom.SecretKeyRef},
+				},
+			}))
+
+			for _, arg := range c.Args {
+				Expect(arg).NotTo(ContainSubstring("some-license"))
+			}
+		})
+
+		It("should change the hash of the job when a value changes", func() {
+			job := makeJob("https://mirror.example.com", "some-license", "1")
+			newMirror := makeJob("https://other.example.com", "some-license", "1")
+			newLicense := makeJob("https://mirror.example.com", "other-license", "2")
+
+			hash := job.Annotations[constants.JobHashAnnotation]
+			Expect(newMirror.Annotations[constants.JobHashAnnotation]).NotTo(Equal(hash))
+			Expect(newLicense.Annotations[constants.JobHashAnnotation]).NotTo(Equal(hash))
+		})
+
+		It("should hash the version of Secrets rather than their values", func() {
+			job := makeJob("https://mirror.example.com", "some-license", "1")
+			otherLicense := makeJob("https://mirror.example.com", "other-license", "1")
+
+			Expect(otherLicense.Annotations[constants.JobHashAnnotation]).To(Equal(job.Annotations[constants.JobHashAnnotation]))
+		})
+	})
 })
@@ -4,61 +4,115 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
+	batchv1 "k8s.io/api/batch/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/build"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/constants"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/joblogs"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/module"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/registry"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/utils"
 )
 
+// failedBeforeReason is the reason of the failure of the jobs that are not run again because they have failed before.
+const failedBeforeReason = "a job with the same inputs has failed and was garbage-collected"
+
+// upToDateTTL is how long an image found up to date with its build inputs is trusted before the inputs are resolved
+// again, if the Module and the objects it references have not changed.
+const upToDateTTL = 10 * time.Minute
+
 type jobManager struct {
-	client    client.Client
-	maker     Maker
-	jobHelper utils.JobHelper
-	registry  registry.Registry
+	client             client.Client
+	maker              Maker
+	jobHelper          utils.JobHelper
+	registry           registry.Registry
+	logs               joblogs.Collector
+	failedJobRetention time.Duration
+	upToDate           *utils.SyncCache
 }
 
+// NewBuildManager returns a Manager running builds in jobs.
+// Failed jobs and their logs are kept for failedJobRetention before being garbage collected.
 func NewBuildManager(
 	client client.Client,
 	maker Maker,
 	jobHelper utils.JobHelper,
-	registry registry.Registry) *jobManager {
+	registry registry.Registry,
+	logs joblogs.Collector,
+	failedJobRetention time.Duration) *jobManager {
 	return &jobManager{
-		client:    client,
-		maker:     maker,
-		jobHelper: jobHelper,
-		registry:  registry,
+		client:             client,
+		maker:              maker,
+		jobHelper:          jobHelper,
+		registry:           registry,
+		logs:               logs,
+		failedJobRetention: failedJobRetention,
+		upToDate:           utils.NewSyncCache(upToDateTTL),
 	}
 }
 
-func (jbm *jobManager) GarbageCollect(ctx context.Context, modName, namespace string, owner metav1.Object) ([]string, error) {
+func (jbm *jobManager) GarbageCollect(ctx context.Context, modName, namespace string, owner metav1.Object, keep map[module.KernelTarget]bool) ([]string, error) {
 	jobs, err := jbm.jobHelper.GetModuleJobs(ctx, modName, namespace, utils.JobTypeBuild, owner)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get build jobs for module %s: %v", modName, err)
 	}
 
+	failedBefore := time.Now().Add(-jbm.failedJobRetention)
+
 	deleteNames := make([]string, 0, len(jobs))
 	for _, job := range jobs {
-		if job.Status.Succeeded == 1 {
-			err = jbm.jobHelper.DeleteJob(ctx, &job)
-			if err != nil {
-				return nil, fmt.Errorf("failed to delete build job %s: %v", job.Name, err)
+		target := module.KernelTarget{
+			KernelVersion: job.Labels[constants.TargetKernelTarget],
+			Architecture:  job.Labels[constants.ArchitectureLabel],
+		}
+
+		if keep[target] || jobBackend(&job) != jbm.maker.Backend() {
+			continue
+		}
+
+		if job.Status.Succeeded != 1 {
+			c := utils.JobFailedCondition(&job)
+			if c == nil || !c.LastTransitionTime.Time.Before(failedBefore) {
+				continue
 			}
-			deleteNames = append(deleteNames, job.Name)
 		}
+
+		err = jbm.jobHelper.DeleteJob(ctx, &job)
+		if err != nil {
+			return nil, fmt.Errorf("failed to delete build job %s: %v", job.Name, err)
+		}
+
+		if err = jbm.logs.Delete(ctx, &job); err != nil {
+			return nil, fmt.Errorf("failed to delete the logs of build job %s: %v", job.Name, err)
+		}
+
+		deleteNames = append(deleteNames, job.Name)
 	}
 	return deleteNames, nil
 }
 
+// jobBackend returns the build backend of job.
+// Jobs created before the backend was labelled were all built with Kaniko.
+func jobBackend(job *batchv1.Job) kmmv1beta1.BuildBackend {
+	if b := job.Labels[constants.BuildBackendLabel]; b != "" {
+		return kmmv1beta1.BuildBackend(b)
+	}
+
+	return kmmv1beta1.BuildBackendKaniko
+}
+
 func (jbm *jobManager) ShouldSync(
 	ctx context.Context,
 	mod kmmv1beta1.Module,
-	m kmmv1beta1.KernelMapping) (bool, error) {
+	m kmmv1beta1.KernelMapping,
+	targetKernel string,
+	targetArch string) (bool, error) {
 
 	// if there is no build specified skip
 	if !module.ShouldBeBuilt(mod.Spec, m) {
@@ -74,14 +128,53 @@ func (jbm *jobManager) ShouldSync(
 	}
 
 	// build is specified and targetImage is either the final image or the intermediate image
-	// tag, depending on whether sign is specified or not. Either way, if targetImage exists
-	// we can skip building it
-	exists, err := module.ImageExists(ctx, jbm.client, jbm.registry, mod.Spec, mod.Namespace, m, targetImage)
+	// tag, depending on whether sign is specified or not. Either way, if targetImage is missing
+	// we need to build it
+	exists, err := module.ImageExists(ctx, jbm.client, jbm.registry, mod.Spec, mod.Namespace, m, targetImage, targetArch)
 	if err != nil {
 		return false, fmt.Errorf("failed to check existence of image %s: %w", targetImage, err)
 	}
+	if !exists {
+		return true, nil
+	}
 
-	return !exists, nil
+	// resolving the build inputs is expensive, so it is only done again once they may have changed
+	inputsVersion, err := module.InputsVersion(ctx, jbm.client, &mod, &m)
+	if err != nil {
+		return false, fmt.Errorf("could not get the version of the build inputs: %v", err)
+	}
+
+	cacheKey := fmt.Sprintf("%s/%s/%s/%s", mod.Namespace, mod.Name, targetKernel, targetArch)
+	if jbm.upToDate.IsUpToDate(cacheKey, inputsVersion) {
+		return false, nil
+	}
+
+	labels, err := module.ImageLabels(ctx, jbm.client, jbm.registry, mod.Spec, mod.Namespace, m, targetImage, targetArch)
+	if err != nil {
+		return false, fmt.Errorf("failed to get the labels of image %s: %w", targetImage, err)
+	}
+
+	// images that were not built by us (or by an older version) carry no digest; leave them alone
+	digest, ok := labels[constants.BuildDigestLabel]
+	if !ok {
+		jbm.upToDate.SetUpToDate(cacheKey, inputsVersion)
+		return false, nil
+	}
+
+	// the variables of the target are not hashed, so they are not needed to compare the build inputs
+	jobTemplate, err := jbm.maker.MakeJobTemplate(ctx, mod, m, targetKernel, targetArch, nil, &mod, true)
+	if err != nil {
+		return false, fmt.Errorf("could not make Job template: %v", err)
+	}
+
+	if digest != jobTemplate.Annotations[constants.JobHashAnnotation] {
+		log.FromContext(ctx).Info("The build inputs have changed since the image was built", "image", targetImage)
+		return true, nil
+	}
+
+	jbm.upToDate.SetUpToDate(cacheKey, inputsVersion)
+
+	return false, nil
 }
 
 func (jbm *jobManager) Sync(
@@ -89,6 +182,8 @@ func (jbm *jobManager) Sync(
 	mod kmmv1beta1.Module,
 	m kmmv1beta1.KernelMapping,
 	targetKernel string,
+	targetArch string,
+	osConfig *module.NodeOSConfig,
 	pushImage bool,
 	owner metav1.Object) (build.Result, error) {
 
@@ -96,17 +191,23 @@ func (jbm *jobManager) Sync(
 
 	logger.Info("Building in-cluster")
 
-	jobTemplate, err := jbm.maker.MakeJobTemplate(ctx, mod, m, targetKernel, owner, pushImage)
+	jobTemplate, err := jbm.maker.MakeJobTemplate(ctx, mod, m, targetKernel, targetArch, osConfig, owner, pushImage)
 	if err != nil {
 		return build.Result{}, fmt.Errorf("could not make Job template: %v", err)
 	}
 
-	job, err := jbm.jobHelper.GetModuleJobByKernel(ctx, mod.Name, mod.Namespace, targetKernel, utils.JobTypeBuild, owner)
+	job, err := jbm.jobHelper.GetModuleJobByKernel(ctx, mod.Name, mod.Namespace, targetKernel, targetArch, utils.JobTypeBuild, owner)
 	if err != nil {
 		if !errors.Is(err, utils.ErrNoMatchingJob) {
 			return build.Result{}, fmt.Errorf("error getting the build: %v", err)
 		}
 
+		// failed jobs are garbage-collected after a while; that does not make their inputs build
+		if h := module.FailedJobHash(&mod, targetKernel, targetArch); h != "" && h == utils.JobInputsHash(jobTemplate) {
+			logger.Info("A build job with the same inputs has already failed; not running it again")
+			return build.Result{Status: build.StatusFailed, Reason: failedBeforeReason, FailedJobHash: h}, nil
+		}
+
 		logger.Info("Creating job")
 		err = jbm.jobHelper.CreateJob(ctx, jobTemplate)
 		if err != nil {
@@ -123,23 +224,63 @@ func (jbm *jobManager) Sync(
 
 	if changed {
 		logger.Info("The module's build spec has been changed, deleting the current job so a new one can be created", "name", job.Name)
-		err = jbm.jobHelper.DeleteJob(ctx, job)
-		if err != nil {
-			logger.Info(utils.WarnString(fmt.Sprintf("failed to delete build job %s: %v", job.Name, err)))
-		}
+		jbm.deleteJob(ctx, job)
+		return build.Result{Status: build.StatusInProgress, Requeue: true}, nil
+	}
+
+	// the pod policy is not part of the hash of the job; raising the deadline, the backoff limit or the resources of
+	// a failed build retries it
+	if utils.JobFailedCondition(job) != nil &&
+		job.Annotations[constants.PodPolicyHashAnnotation] != jobTemplate.Annotations[constants.PodPolicyHashAnnotation] {
+		logger.Info("The pod policy of the failed build job has been changed, deleting it so a new one can be created", "name", job.Name)
+		jbm.deleteJob(ctx, job)
 		return build.Result{Status: build.StatusInProgress, Requeue: true}, nil
 	}
 
 	logger.Info("Returning job status", "name", job.Name, "namespace", job.Namespace)
 
-	switch {
-	case job.Status.Succeeded == 1:
+	if job.Status.Succeeded == 1 {
 		return build.Result{Status: build.StatusCompleted}, nil
+	}
+
+	// each attempt runs in its own pod, which is kept until the job is deleted, so the logs of all attempts can be
+	// collected, including those of the last one once the job has failed
+	logsConfigMap, err := jbm.logs.Collect(ctx, job, owner)
+	if err != nil {
+		logger.Info(utils.WarnString(fmt.Sprintf("failed to collect the logs of build job %s: %v", job.Name, err)))
+	}
+
+	if c := utils.JobFailedCondition(job); c != nil {
+		reason := fmt.Sprintf("%s: %s", c.Reason, c.Message)
+		logger.Info("The build job has failed", "name", job.Name, "reason", reason, "logs", logsConfigMap)
+		return build.Result{
+			Status:        build.StatusFailed,
+			Reason:        reason,
+			LogsConfigMap: logsConfigMap,
+			FailedJobHash: utils.JobInputsHash(job),
+		}, nil
+	}
+
+	switch {
 	case job.Status.Active == 1:
 		return build.Result{Status: build.StatusInProgress, Requeue: true}, nil
-	case job.Status.Failed == 1:
-		return build.Result{}, fmt.Errorf("job failed: %v", err)
+	case job.Status.Failed > 0:
+		// the Job controller retries failed pods until the backoff limit or the deadline is reached
+		return build.Result{Status: build.StatusInProgress, Requeue: true}, nil
 	default:
 		return build.Result{}, fmt.Errorf("unknown status: %v", job.Status)
 	}
 }
+
+// deleteJob deletes job and its logs, so that a new one can be created.
+// Errors are only logged: the job is looked up again on the next reconciliation.
+func (jbm *jobManager) deleteJob(ctx context.Context, job *batchv1.Job) {
+	logger := log.FromContext(ctx)
+
+	if err := jbm.jobHelper.DeleteJob(ctx, job); err != nil {
+		logger.Info(utils.WarnString(fmt.Sprintf("failed to delete build job %s: %v", job.Name, err)))
+	}
+	if err := jbm.logs.Delete(ctx, job); err != nil {
+		logger.Info(utils.WarnString(fmt.Sprintf("failed to delete the logs of build job %s: %v", job.Name, err)))
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	. "github.com/onsi/ginkgo/v2"
@@ -11,11 +12,15 @@ import (
 	batchv1 "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 
 	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/build"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/client"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/constants"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/joblogs"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/module"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/registry"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/utils"
 )
@@ -45,15 +50,15 @@ var _ = Describe("ShouldSync", func() {
 		mod := kmmv1beta1.Module{}
 		km := kmmv1beta1.KernelMapping{}
 
-		mgr := NewBuildManager(clnt, nil, nil, reg)
+		mgr := NewBuildManager(clnt, nil, nil, reg, nil, 0)
 
-		shouldSync, err := mgr.ShouldSync(ctx, mod, km)
+		shouldSync, err := mgr.ShouldSync(ctx, mod, km, kernelVersion, "")
 
 		Expect(err).ToNot(HaveOccurred())
 		Expect(shouldSync).To(BeFalse())
 	})
 
-	It("should return false if image already exists", func() {
+	It("should return false if image already exists without a build digest", func() {
 		ctx := context.Background()
 
 		km := kmmv1beta1.KernelMapping{
@@ -72,17 +77,102 @@ var _ = Describe("ShouldSync", func() {
 		}
 
 		gomock.InOrder(
-			reg.EXPECT().ImageExists(ctx, imageName, gomock.Any(), gomock.Any()).Return(true, nil),
+			reg.EXPECT().ImageExists(ctx, imageName, gomock.Any(), gomock.Any(), gomock.Any()).Return(true, nil),
+			clnt.EXPECT().Get(ctx, types.NamespacedName{Name: "pull-push-secret", Namespace: namespace}, &v1.Secret{}),
+			reg.EXPECT().GetImageLabels(ctx, imageName, gomock.Any(), gomock.Any(), gomock.Any()).Return(map[string]string{}, nil),
 		)
 
-		mgr := NewBuildManager(clnt, nil, nil, reg)
+		mgr := NewBuildManager(clnt, nil, nil, reg, nil, 0)
 
-		shouldSync, err := mgr.ShouldSync(ctx, mod, km)
+		shouldSync, err := mgr.ShouldSync(ctx, mod, km, kernelVersion, "")
 
 		Expect(err).ToNot(HaveOccurred())
 		Expect(shouldSync).To(BeFalse())
 	})
 
+	DescribeTable("should compare the build digest of the existing image",
+		func(imageDigest string, expected bool) {
+			ctx := context.Background()
+			maker := NewMockMaker(ctrl)
+
+			km := kmmv1beta1.KernelMapping{
+				Build:          &kmmv1beta1.Build{},
+				ContainerImage: imageName,
+			}
+
+			mod := kmmv1beta1.Module{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      moduleName,
+					Namespace: namespace,
+				},
+			}
+
+			job := batchv1.Job{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{constants.JobHashAnnotation: "some-hash"},
+				},
+			}
+
+			gomock.InOrder(
+				reg.EXPECT().ImageExists(ctx, imageName, gomock.Any(), gomock.Any(), gomock.Any()).Return(true, nil),
+				reg.EXPECT().
+					GetImageLabels(ctx, imageName, gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(map[string]string{constants.BuildDigestLabel: imageDigest}, nil),
+				maker.EXPECT().MakeJobTemplate(ctx, mod, km, kernelVersion, "", nil, &mod, true).Return(&job, nil),
+			)
+
+			mgr := NewBuildManager(clnt, maker, nil, reg, nil, 0)
+
+			shouldSync, err := mgr.ShouldSync(ctx, mod, km, kernelVersion, "")
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(shouldSync).To(Equal(expected))
+		},
+		Entry("digest is up to date", "some-hash", false),
+		Entry("build inputs have changed", "some-other-hash", true),
+	)
+
+	It("should not compare the build digest again while the inputs are unchanged", func() {
+		ctx := context.Background()
+		maker := NewMockMaker(ctrl)
+
+		km := kmmv1beta1.KernelMapping{
+			Build:          &kmmv1beta1.Build{},
+			ContainerImage: imageName,
+		}
+
+		mod := kmmv1beta1.Module{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      moduleName,
+				Namespace: namespace,
+			},
+		}
+
+		job := batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{constants.JobHashAnnotation: "some-hash"},
+			},
+		}
+
+		gomock.InOrder(
+			reg.EXPECT().ImageExists(ctx, imageName, gomock.Any(), gomock.Any(), gomock.Any()).Return(true, nil),
+			reg.EXPECT().
+				GetImageLabels(ctx, imageName, gomock.Any(), gomock.Any(), gomock.Any()).
+				Return(map[string]string{constants.BuildDigestLabel: "some-hash"}, nil),
+			maker.EXPECT().MakeJobTemplate(ctx, mod, km, kernelVersion, "", nil, &mod, true).Return(&job, nil),
+			reg.EXPECT().ImageExists(ctx, imageName, gomock.Any(), gomock.Any(), gomock.Any()).Return(true, nil),
+		)
+
+		mgr := NewBuildManager(clnt, maker, nil, reg, nil, 0)
+
+		for i := 0; i < 2; i++ {
+			shouldSync, err := mgr.ShouldSync(ctx, mod, km, kernelVersion, "")
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(shouldSync).To(BeFalse())
+		}
+	})
+
 	It("should return false and an error if image check fails", func() {
 		ctx := context.Background()
 
@@ -102,12 +192,12 @@ var _ = Describe("ShouldSync", func() {
 		}
 
 		gomock.InOrder(
-			reg.EXPECT().ImageExists(ctx, imageName, gomock.Any(), gomock.Any()).Return(false, errors.New("generic-registry-error")),
+			reg.EXPECT().ImageExists(ctx, imageName, gomock.Any(), gomock.Any(), gomock.Any()).Return(false, errors.New("generic-registry-error")),
 		)
 
-		mgr := NewBuildManager(clnt, nil, nil, reg)
+		mgr := NewBuildManager(clnt, nil, nil, reg, nil, 0)
 
-		shouldSync, err := mgr.ShouldSync(ctx, mod, km)
+		shouldSync, err := mgr.ShouldSync(ctx, mod, km, kernelVersion, "")
 
 		Expect(err).To(HaveOccurred())
 		Expect(err.Error()).To(ContainSubstring("generic-registry-error"))
@@ -133,12 +223,12 @@ var _ = Describe("ShouldSync", func() {
 		}
 
 		gomock.InOrder(
-			reg.EXPECT().ImageExists(ctx, imageName, gomock.Any(), gomock.Any()).Return(false, nil),
+			reg.EXPECT().ImageExists(ctx, imageName, gomock.Any(), gomock.Any(), gomock.Any()).Return(false, nil),
 		)
 
-		mgr := NewBuildManager(clnt, nil, nil, reg)
+		mgr := NewBuildManager(clnt, nil, nil, reg, nil, 0)
 
-		shouldSync, err := mgr.ShouldSync(ctx, mod, km)
+		shouldSync, err := mgr.ShouldSync(ctx, mod, km, kernelVersion, "")
 
 		Expect(err).ToNot(HaveOccurred())
 		Expect(shouldSync).To(BeTrue())
@@ -152,6 +242,7 @@ var _ = Describe("Sync", func() {
 		maker     *MockMaker
 		jobhelper *utils.MockJobHelper
 		reg       *registry.MockRegistry
+		logs      *joblogs.MockCollector
 	)
 
 	const (
@@ -165,6 +256,7 @@ var _ = Describe("Sync", func() {
 		maker = NewMockMaker(ctrl)
 		jobhelper = utils.NewMockJobHelper(ctrl)
 		reg = registry.NewMockRegistry(ctrl)
+		logs = joblogs.NewMockCollector(ctrl)
 	})
 
 	km := kmmv1beta1.KernelMapping{
@@ -182,6 +274,8 @@ var _ = Describe("Sync", func() {
 		ObjectMeta: metav1.ObjectMeta{Name: moduleName},
 	}
 
+	osConfig := &module.NodeOSConfig{KernelFullVersion: kernelVersion}
+
 	DescribeTable("should return the correct status depending on the job status",
 		func(s batchv1.JobStatus, r build.Result, expectsErr bool) {
 			j := batchv1.Job{
@@ -195,14 +289,18 @@ var _ = Describe("Sync", func() {
 			ctx := context.Background()
 
 			gomock.InOrder(
-				maker.EXPECT().MakeJobTemplate(ctx, mod, km, kernelVersion, &mod, true).Return(&j, nil),
-				jobhelper.EXPECT().GetModuleJobByKernel(ctx, mod.Name, mod.Namespace, kernelVersion, utils.JobTypeBuild, &mod).Return(&j, nil),
+				maker.EXPECT().MakeJobTemplate(ctx, mod, km, kernelVersion, "", osConfig, &mod, true).Return(&j, nil),
+				jobhelper.EXPECT().GetModuleJobByKernel(ctx, mod.Name, mod.Namespace, kernelVersion, "", utils.JobTypeBuild, &mod).Return(&j, nil),
 				jobhelper.EXPECT().IsJobChanged(&j, &j).Return(false, nil),
 			)
 
-			mgr := NewBuildManager(clnt, maker, jobhelper, reg)
+			if s.Succeeded != 1 {
+				logs.EXPECT().Collect(ctx, &j, &mod).Return(r.LogsConfigMap, nil)
+			}
+
+			mgr := NewBuildManager(clnt, maker, jobhelper, reg, logs, time.Hour)
 
-			res, err := mgr.Sync(ctx, mod, km, kernelVersion, true, &mod)
+			res, err := mgr.Sync(ctx, mod, km, kernelVersion, "", osConfig, true, &mod)
 
 			if expectsErr {
 				Expect(err).To(HaveOccurred())
@@ -213,20 +311,90 @@ var _ = Describe("Sync", func() {
 		},
 		Entry("active", batchv1.JobStatus{Active: 1}, build.Result{Requeue: true, Status: build.StatusInProgress}, false),
 		Entry("succeeded", batchv1.JobStatus{Succeeded: 1}, build.Result{Status: build.StatusCompleted}, false),
-		Entry("failed", batchv1.JobStatus{Failed: 1}, build.Result{}, true),
+		Entry("failed pod being retried", batchv1.JobStatus{Failed: 1}, build.Result{Requeue: true, Status: build.StatusInProgress}, false),
+		Entry(
+			"backoff limit exceeded",
+			batchv1.JobStatus{
+				Failed: 4,
+				Conditions: []batchv1.JobCondition{
+					{
+						Type:    batchv1.JobFailed,
+						Status:  v1.ConditionTrue,
+						Reason:  "BackoffLimitExceeded",
+						Message: "Job has reached the specified backoff limit",
+					},
+				},
+			},
+			build.Result{
+				Status:        build.StatusFailed,
+				Reason:        "BackoffLimitExceeded: Job has reached the specified backoff limit",
+				LogsConfigMap: "some-job-logs",
+				FailedJobHash: "some hash",
+			},
+			false,
+		),
+		Entry(
+			"deadline exceeded",
+			batchv1.JobStatus{
+				Active: 1,
+				Conditions: []batchv1.JobCondition{
+					{
+						Type:    batchv1.JobFailed,
+						Status:  v1.ConditionTrue,
+						Reason:  "DeadlineExceeded",
+						Message: "Job was active longer than specified deadline",
+					},
+				},
+			},
+			build.Result{
+				Status:        build.StatusFailed,
+				Reason:        "DeadlineExceeded: Job was active longer than specified deadline",
+				FailedJobHash: "some hash",
+			},
+			false,
+		),
+		Entry("unknown", batchv1.JobStatus{}, build.Result{}, true),
 	)
 
+	It("should return the status of the job if its logs could not be collected", func() {
+		ctx := context.Background()
+
+		j := batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        jobName,
+				Namespace:   namespace,
+				Annotations: map[string]string{constants.JobHashAnnotation: "some hash"},
+			},
+			Status: batchv1.JobStatus{Active: 1},
+		}
+
+		gomock.InOrder(
+			maker.EXPECT().MakeJobTemplate(ctx, mod, km, kernelVersion, "", osConfig, &mod, true).Return(&j, nil),
+			jobhelper.EXPECT().GetModuleJobByKernel(ctx, mod.Name, mod.Namespace, kernelVersion, "", utils.JobTypeBuild, &mod).Return(&j, nil),
+			jobhelper.EXPECT().IsJobChanged(&j, &j).Return(false, nil),
+			logs.EXPECT().Collect(ctx, &j, &mod).Return("", errors.New("some error")),
+		)
+
+		mgr := NewBuildManager(clnt, maker, jobhelper, reg, logs, time.Hour)
+
+		Expect(
+			mgr.Sync(ctx, mod, km, kernelVersion, "", osConfig, true, &mod),
+		).To(
+			Equal(build.Result{Requeue: true, Status: build.StatusInProgress}),
+		)
+	})
+
 	It("should return an error if there was an error creating the job template", func() {
 		ctx := context.Background()
 
 		gomock.InOrder(
-			maker.EXPECT().MakeJobTemplate(ctx, mod, km, kernelVersion, &mod, true).Return(nil, errors.New("random error")),
+			maker.EXPECT().MakeJobTemplate(ctx, mod, km, kernelVersion, "", osConfig, &mod, true).Return(nil, errors.New("random error")),
 		)
 
-		mgr := NewBuildManager(clnt, maker, jobhelper, reg)
+		mgr := NewBuildManager(clnt, maker, jobhelper, reg, logs, time.Hour)
 
 		Expect(
-			mgr.Sync(ctx, mod, km, kernelVersion, true, &mod),
+			mgr.Sync(ctx, mod, km, kernelVersion, "", osConfig, true, &mod),
 		).Error().To(
 			HaveOccurred(),
 		)
@@ -246,15 +414,15 @@ var _ = Describe("Sync", func() {
 		}
 
 		gomock.InOrder(
-			maker.EXPECT().MakeJobTemplate(ctx, mod, km, kernelVersion, &mod, true).Return(&j, nil),
-			jobhelper.EXPECT().GetModuleJobByKernel(ctx, mod.Name, mod.Namespace, kernelVersion, utils.JobTypeBuild, &mod).Return(nil, utils.ErrNoMatchingJob),
+			maker.EXPECT().MakeJobTemplate(ctx, mod, km, kernelVersion, "", osConfig, &mod, true).Return(&j, nil),
+			jobhelper.EXPECT().GetModuleJobByKernel(ctx, mod.Name, mod.Namespace, kernelVersion, "", utils.JobTypeBuild, &mod).Return(nil, utils.ErrNoMatchingJob),
 			jobhelper.EXPECT().CreateJob(ctx, &j).Return(errors.New("some error")),
 		)
 
-		mgr := NewBuildManager(clnt, maker, jobhelper, reg)
+		mgr := NewBuildManager(clnt, maker, jobhelper, reg, logs, time.Hour)
 
 		Expect(
-			mgr.Sync(ctx, mod, km, kernelVersion, true, &mod),
+			mgr.Sync(ctx, mod, km, kernelVersion, "", osConfig, true, &mod),
 		).Error().To(
 			HaveOccurred(),
 		)
@@ -275,20 +443,57 @@ var _ = Describe("Sync", func() {
 		}
 
 		gomock.InOrder(
-			maker.EXPECT().MakeJobTemplate(ctx, mod, km, kernelVersion, &mod, true).Return(&j, nil),
-			jobhelper.EXPECT().GetModuleJobByKernel(ctx, mod.Name, mod.Namespace, kernelVersion, utils.JobTypeBuild, &mod).Return(nil, utils.ErrNoMatchingJob),
+			maker.EXPECT().MakeJobTemplate(ctx, mod, km, kernelVersion, "", osConfig, &mod, true).Return(&j, nil),
+			jobhelper.EXPECT().GetModuleJobByKernel(ctx, mod.Name, mod.Namespace, kernelVersion, "", utils.JobTypeBuild, &mod).Return(nil, utils.ErrNoMatchingJob),
 			jobhelper.EXPECT().CreateJob(ctx, &j).Return(nil),
 		)
 
-		mgr := NewBuildManager(clnt, maker, jobhelper, reg)
+		mgr := NewBuildManager(clnt, maker, jobhelper, reg, logs, time.Hour)
 
 		Expect(
-			mgr.Sync(ctx, mod, km, kernelVersion, true, &mod),
+			mgr.Sync(ctx, mod, km, kernelVersion, "", osConfig, true, &mod),
 		).To(
 			Equal(build.Result{Requeue: true, Status: build.StatusCreated}),
 		)
 	})
 
+	It("should not create the job again if a job with the same inputs has failed", func() {
+		ctx := context.Background()
+
+		j := batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      jobName,
+				Namespace: namespace,
+				Annotations: map[string]string{
+					constants.JobHashAnnotation:       "some hash",
+					constants.PodPolicyHashAnnotation: "some policy hash",
+				},
+			},
+		}
+
+		failedMod := *mod.DeepCopy()
+		failedMod.Status.KernelVersions = []kmmv1beta1.KernelVersionStatus{
+			{KernelVersion: kernelVersion, FailedJobHash: "some hash-some policy hash"},
+		}
+
+		gomock.InOrder(
+			maker.EXPECT().MakeJobTemplate(ctx, failedMod, km, kernelVersion, "", osConfig, &failedMod, true).Return(&j, nil),
+			jobhelper.EXPECT().GetModuleJobByKernel(ctx, failedMod.Name, failedMod.Namespace, kernelVersion, "", utils.JobTypeBuild, &failedMod).Return(nil, utils.ErrNoMatchingJob),
+		)
+
+		mgr := NewBuildManager(clnt, maker, jobhelper, reg, logs, time.Hour)
+
+		Expect(
+			mgr.Sync(ctx, failedMod, km, kernelVersion, "", osConfig, true, &failedMod),
+		).To(
+			Equal(build.Result{
+				Status:        build.StatusFailed,
+				Reason:        failedBeforeReason,
+				FailedJobHash: "some hash-some policy hash",
+			}),
+		)
+	})
+
 	It("should delete the job if it was edited", func() {
 		ctx := context.Background()
 
@@ -317,16 +522,64 @@ var _ = Describe("Sync", func() {
 		}
 
 		gomock.InOrder(
-			maker.EXPECT().MakeJobTemplate(ctx, mod, km, kernelVersion, &mod, true).Return(&newJob, nil),
-			jobhelper.EXPECT().GetModuleJobByKernel(ctx, mod.Name, mod.Namespace, kernelVersion, utils.JobTypeBuild, &mod).Return(&j, nil),
+			maker.EXPECT().MakeJobTemplate(ctx, mod, km, kernelVersion, "", osConfig, &mod, true).Return(&newJob, nil),
+			jobhelper.EXPECT().GetModuleJobByKernel(ctx, mod.Name, mod.Namespace, kernelVersion, "", utils.JobTypeBuild, &mod).Return(&j, nil),
 			jobhelper.EXPECT().IsJobChanged(&j, &newJob).Return(true, nil),
 			jobhelper.EXPECT().DeleteJob(ctx, &j).Return(nil),
+			logs.EXPECT().Delete(ctx, &j).Return(nil),
 		)
 
-		mgr := NewBuildManager(clnt, maker, jobhelper, reg)
+		mgr := NewBuildManager(clnt, maker, jobhelper, reg, logs, time.Hour)
 
 		Expect(
-			mgr.Sync(ctx, mod, km, kernelVersion, true, &mod),
+			mgr.Sync(ctx, mod, km, kernelVersion, "", osConfig, true, &mod),
+		).To(
+			Equal(build.Result{Requeue: true, Status: build.StatusInProgress}),
+		)
+	})
+
+	It("should delete the failed job if its pod policy was changed", func() {
+		ctx := context.Background()
+
+		j := batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      jobName,
+				Namespace: namespace,
+				Annotations: map[string]string{
+					constants.JobHashAnnotation:       "some hash",
+					constants.PodPolicyHashAnnotation: "some policy hash",
+				},
+			},
+			Status: batchv1.JobStatus{
+				Conditions: []batchv1.JobCondition{
+					{Type: batchv1.JobFailed, Status: v1.ConditionTrue, Reason: "DeadlineExceeded"},
+				},
+			},
+		}
+
+		newJob := batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      jobName,
+				Namespace: namespace,
+				Annotations: map[string]string{
+					constants.JobHashAnnotation:       "some hash",
+					constants.PodPolicyHashAnnotation: "new policy hash",
+				},
+			},
+		}
+
+		gomock.InOrder(
+			maker.EXPECT().MakeJobTemplate(ctx, mod, km, kernelVersion, "", osConfig, &mod, true).Return(&newJob, nil),
+			jobhelper.EXPECT().GetModuleJobByKernel(ctx, mod.Name, mod.Namespace, kernelVersion, "", utils.JobTypeBuild, &mod).Return(&j, nil),
+			jobhelper.EXPECT().IsJobChanged(&j, &newJob).Return(false, nil),
+			jobhelper.EXPECT().DeleteJob(ctx, &j).Return(nil),
+			logs.EXPECT().Delete(ctx, &j).Return(nil),
+		)
+
+		mgr := NewBuildManager(clnt, maker, jobhelper, reg, logs, time.Hour)
+
+		Expect(
+			mgr.Sync(ctx, mod, km, kernelVersion, "", osConfig, true, &mod),
 		).To(
 			Equal(build.Result{Requeue: true, Status: build.StatusInProgress}),
 		)
@@ -340,6 +593,7 @@ var _ = Describe("GarbageCollect", func() {
 		maker     *MockMaker
 		jobhelper *utils.MockJobHelper
 		reg       *registry.MockRegistry
+		logs      *joblogs.MockCollector
 		mgr       *jobManager
 	)
 
@@ -349,15 +603,31 @@ var _ = Describe("GarbageCollect", func() {
 		maker = NewMockMaker(ctrl)
 		jobhelper = utils.NewMockJobHelper(ctrl)
 		reg = registry.NewMockRegistry(ctrl)
-		mgr = NewBuildManager(clnt, maker, jobhelper, reg)
+		logs = joblogs.NewMockCollector(ctrl)
+		mgr = NewBuildManager(clnt, maker, jobhelper, reg, logs, time.Hour)
+
+		maker.EXPECT().Backend().Return(kmmv1beta1.BuildBackendKaniko).AnyTimes()
 	})
 
 	mod := kmmv1beta1.Module{
 		ObjectMeta: metav1.ObjectMeta{Name: "moduleName"},
 	}
 
+	failedSince := func(d time.Duration) batchv1.JobStatus {
+		return batchv1.JobStatus{
+			Failed: 1,
+			Conditions: []batchv1.JobCondition{
+				{
+					Type:               batchv1.JobFailed,
+					Status:             v1.ConditionTrue,
+					LastTransitionTime: metav1.NewTime(time.Now().Add(-d)),
+				},
+			},
+		}
+	}
+
 	DescribeTable("should return the correct error and names of the collected jobs",
-		func(jobStatus1 batchv1.JobStatus, jobStatus2 batchv1.JobStatus, expectsErr bool) {
+		func(jobStatus1 batchv1.JobStatus, jobStatus2 batchv1.JobStatus, expectedNames []string, expectsErr bool) {
 			job1 := batchv1.Job{
 				ObjectMeta: metav1.ObjectMeta{
 					Name: "jobName1",
@@ -370,43 +640,104 @@ var _ = Describe("GarbageCollect", func() {
 				},
 				Status: jobStatus2,
 			}
-			expectedNames := []string{}
-			if !expectsErr {
-				if job1.Status.Succeeded == 1 {
-					expectedNames = append(expectedNames, "jobName1")
-				}
-				if job2.Status.Succeeded == 1 {
-					expectedNames = append(expectedNames, "jobName2")
-				}
-			}
 			returnedError := fmt.Errorf("some error")
 			if !expectsErr {
 				returnedError = nil
 			}
 
 			jobhelper.EXPECT().GetModuleJobs(context.Background(), mod.Name, mod.Namespace, utils.JobTypeBuild, &mod).Return([]batchv1.Job{job1, job2}, returnedError)
-			if !expectsErr {
-				if job1.Status.Succeeded == 1 {
-					jobhelper.EXPECT().DeleteJob(context.Background(), &job1).Return(nil)
-				}
-				if job2.Status.Succeeded == 1 {
-					jobhelper.EXPECT().DeleteJob(context.Background(), &job2).Return(nil)
+			for _, j := range []batchv1.Job{job1, job2} {
+				if sets.NewString(expectedNames...).Has(j.Name) {
+					job := j
+					jobhelper.EXPECT().DeleteJob(context.Background(), &job).Return(nil)
+					logs.EXPECT().Delete(context.Background(), &job).Return(nil)
 				}
 			}
 
-			names, err := mgr.GarbageCollect(context.Background(), mod.Name, mod.Namespace, &mod)
+			names, err := mgr.GarbageCollect(context.Background(), mod.Name, mod.Namespace, &mod, nil)
 
 			if expectsErr {
 				Expect(err).To(HaveOccurred())
 				Expect(names).To(BeNil())
 			} else {
 				Expect(err).NotTo(HaveOccurred())
-				Expect(expectedNames).To(Equal(names))
+				Expect(names).To(Equal(expectedNames))
 			}
 		},
-		Entry("all jobs succeeded", batchv1.JobStatus{Succeeded: 1}, batchv1.JobStatus{Succeeded: 1}, false),
-		Entry("1 job succeeded", batchv1.JobStatus{Succeeded: 1}, batchv1.JobStatus{Succeeded: 0}, false),
-		Entry("0 job succeeded", batchv1.JobStatus{Succeeded: 0}, batchv1.JobStatus{Succeeded: 0}, false),
-		Entry("error occured", batchv1.JobStatus{Succeeded: 0}, batchv1.JobStatus{Succeeded: 0}, true),
+		Entry("all jobs succeeded", batchv1.JobStatus{Succeeded: 1}, batchv1.JobStatus{Succeeded: 1}, []string{"jobName1", "jobName2"}, false),
+		Entry("1 job succeeded", batchv1.JobStatus{Succeeded: 1}, batchv1.JobStatus{Succeeded: 0}, []string{"jobName1"}, false),
+		Entry("0 job succeeded", batchv1.JobStatus{Succeeded: 0}, batchv1.JobStatus{Succeeded: 0}, []string{}, false),
+		Entry("failed jobs within the retention period", failedSince(time.Minute), batchv1.JobStatus{Active: 1}, []string{}, false),
+		Entry("failed job past the retention period", failedSince(2*time.Hour), failedSince(time.Minute), []string{"jobName1"}, false),
+		Entry("error occured", batchv1.JobStatus{Succeeded: 0}, batchv1.JobStatus{Succeeded: 0}, nil, true),
 	)
+
+	It("should keep the jobs of the targets to keep", func() {
+		kept := batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "kept",
+				Labels: map[string]string{
+					constants.TargetKernelTarget: "1.2.3",
+					constants.ArchitectureLabel:  "arm64",
+				},
+			},
+			Status: batchv1.JobStatus{Succeeded: 1},
+		}
+		deleted := batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "deleted",
+				Labels: map[string]string{constants.TargetKernelTarget: "1.2.3"},
+			},
+			Status: batchv1.JobStatus{Succeeded: 1},
+		}
+
+		gomock.InOrder(
+			jobhelper.EXPECT().GetModuleJobs(context.Background(), mod.Name, mod.Namespace, utils.JobTypeBuild, &mod).Return([]batchv1.Job{kept, deleted}, nil),
+			jobhelper.EXPECT().DeleteJob(context.Background(), &deleted),
+			logs.EXPECT().Delete(context.Background(), &deleted),
+		)
+
+		keep := map[module.KernelTarget]bool{{KernelVersion: "1.2.3", Architecture: "arm64"}: true}
+
+		Expect(
+			mgr.GarbageCollect(context.Background(), mod.Name, mod.Namespace, &mod, keep),
+		).To(
+			Equal([]string{"deleted"}),
+		)
+	})
+
+	It("should only collect the jobs of its backend", func() {
+		buildahJob := batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "buildah",
+				Labels: map[string]string{constants.BuildBackendLabel: string(kmmv1beta1.BuildBackendBuildah)},
+			},
+			Status: batchv1.JobStatus{Succeeded: 1},
+		}
+		kanikoJob := batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "kaniko",
+				Labels: map[string]string{constants.BuildBackendLabel: string(kmmv1beta1.BuildBackendKaniko)},
+			},
+			Status: batchv1.JobStatus{Succeeded: 1},
+		}
+		unlabelledJob := batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Name: "unlabelled"},
+			Status:     batchv1.JobStatus{Succeeded: 1},
+		}
+
+		gomock.InOrder(
+			jobhelper.EXPECT().GetModuleJobs(context.Background(), mod.Name, mod.Namespace, utils.JobTypeBuild, &mod).Return([]batchv1.Job{buildahJob, kanikoJob, unlabelledJob}, nil),
+			jobhelper.EXPECT().DeleteJob(context.Background(), &kanikoJob),
+			logs.EXPECT().Delete(context.Background(), &kanikoJob),
+			jobhelper.EXPECT().DeleteJob(context.Background(), &unlabelledJob),
+			logs.EXPECT().Delete(context.Background(), &unlabelledJob),
+		)
+
+		Expect(
+			mgr.GarbageCollect(context.Background(), mod.Name, mod.Namespace, &mod, nil),
+		).To(
+			Equal([]string{"kaniko", "unlabelled"}),
+		)
+	})
 })
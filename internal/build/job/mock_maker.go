@@ -10,8 +10,10 @@ import (
 
 	gomock "github.com/golang/mock/gomock"
 	v1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
+	module "github.com/kubernetes-sigs/kernel-module-management/internal/module"
 	v1 "k8s.io/api/batch/v1"
-	v10 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v10 "k8s.io/api/core/v1"
+	v11 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // MockMaker is a mock of Maker interface.
@@ -37,17 +39,97 @@ func (m *MockMaker) EXPECT() *MockMakerMockRecorder {
 	return m.recorder
 }
 
+// Backend mocks base method.
+func (m *MockMaker) Backend() v1beta1.BuildBackend {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Backend")
+	ret0, _ := ret[0].(v1beta1.BuildBackend)
+	return ret0
+}
+
+// Backend indicates an expected call of Backend.
+func (mr *MockMakerMockRecorder) Backend() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Backend", reflect.TypeOf((*MockMaker)(nil).Backend))
+}
+
 // MakeJobTemplate mocks base method.
-func (m *MockMaker) MakeJobTemplate(ctx context.Context, mod v1beta1.Module, km v1beta1.KernelMapping, targetKernel string, owner v10.Object, pushImage bool) (*v1.Job, error) {
+func (m *MockMaker) MakeJobTemplate(ctx context.Context, mod v1beta1.Module, km v1beta1.KernelMapping, targetKernel, targetArch string, osConfig *module.NodeOSConfig, owner v11.Object, pushImage bool) (*v1.Job, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "MakeJobTemplate", ctx, mod, km, targetKernel, owner, pushImage)
+	ret := m.ctrl.Call(m, "MakeJobTemplate", ctx, mod, km, targetKernel, targetArch, osConfig, owner, pushImage)
 	ret0, _ := ret[0].(*v1.Job)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // MakeJobTemplate indicates an expected call of MakeJobTemplate.
-func (mr *MockMakerMockRecorder) MakeJobTemplate(ctx, mod, km, targetKernel, owner, pushImage interface{}) *gomock.Call {
+func (mr *MockMakerMockRecorder) MakeJobTemplate(ctx, mod, km, targetKernel, targetArch, osConfig, owner, pushImage interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MakeJobTemplate", reflect.TypeOf((*MockMaker)(nil).MakeJobTemplate), ctx, mod, km, targetKernel, targetArch, osConfig, owner, pushImage)
+}
+
+// Mockbuilder is a mock of builder interface.
+type Mockbuilder struct {
+	ctrl     *gomock.Controller
+	recorder *MockbuilderMockRecorder
+}
+
+// MockbuilderMockRecorder is the mock recorder for Mockbuilder.
+type MockbuilderMockRecorder struct {
+	mock *Mockbuilder
+}
+
+// NewMockbuilder creates a new mock instance.
+func NewMockbuilder(ctrl *gomock.Controller) *Mockbuilder {
+	mock := &Mockbuilder{ctrl: ctrl}
+	mock.recorder = &MockbuilderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *Mockbuilder) EXPECT() *MockbuilderMockRecorder {
+	return m.recorder
+}
+
+// backend mocks base method.
+func (m *Mockbuilder) backend() v1beta1.BuildBackend {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "backend")
+	ret0, _ := ret[0].(v1beta1.BuildBackend)
+	return ret0
+}
+
+// backend indicates an expected call of backend.
+func (mr *MockbuilderMockRecorder) backend() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "backend", reflect.TypeOf((*Mockbuilder)(nil).backend))
+}
+
+// cacheArgs mocks base method.
+func (m *Mockbuilder) cacheArgs(buildConfig *v1beta1.Build, namespace string, registryTLS *v1beta1.TLSOptions) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "cacheArgs", buildConfig, namespace, registryTLS)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// cacheArgs indicates an expected call of cacheArgs.
+func (mr *MockbuilderMockRecorder) cacheArgs(buildConfig, namespace, registryTLS interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "cacheArgs", reflect.TypeOf((*Mockbuilder)(nil).cacheArgs), buildConfig, namespace, registryTLS)
+}
+
+// podSpec mocks base method.
+func (m *Mockbuilder) podSpec(modSpec v1beta1.ModuleSpec, buildConfig *v1beta1.Build, buildArgs []v1beta1.BuildArg, containerImage string, registryTLS *v1beta1.TLSOptions, pushImage bool) v10.PodSpec {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "podSpec", modSpec, buildConfig, buildArgs, containerImage, registryTLS, pushImage)
+	ret0, _ := ret[0].(v10.PodSpec)
+	return ret0
+}
+
+// podSpec indicates an expected call of podSpec.
+func (mr *MockbuilderMockRecorder) podSpec(modSpec, buildConfig, buildArgs, containerImage, registryTLS, pushImage interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MakeJobTemplate", reflect.TypeOf((*MockMaker)(nil).MakeJobTemplate), ctx, mod, km, targetKernel, owner, pushImage)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "podSpec", reflect.TypeOf((*Mockbuilder)(nil).podSpec), modSpec, buildConfig, buildArgs, containerImage, registryTLS, pushImage)
 }
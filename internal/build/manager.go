@@ -6,6 +6,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/module"
 )
 
 type Status string
@@ -13,29 +14,47 @@ type Status string
 const (
 	StatusCompleted  = "completed"
 	StatusCreated    = "created"
+	StatusFailed     = "failed"
 	StatusInProgress = "in progress"
 )
 
 type Result struct {
 	Requeue bool
 	Status  Status
+
+	// Reason explains why the build failed when Status is StatusFailed.
+	// A failed build is not retried until its inputs change.
+	Reason string
+
+	// LogsConfigMap is the name of the ConfigMap holding the logs of the failed containers of the build, if any.
+	LogsConfigMap string
+
+	// FailedJobHash identifies the inputs of the build when Status is StatusFailed.
+	FailedJobHash string
 }
 
 //go:generate mockgen -source=manager.go -package=build -destination=mock_manager.go
 
 type Manager interface {
-	GarbageCollect(ctx context.Context, modName, namespace string, owner metav1.Object) ([]string, error)
+	// GarbageCollect deletes the finished builds of the Module, except those of the targets in keep.
+	GarbageCollect(ctx context.Context, modName, namespace string, owner metav1.Object, keep map[module.KernelTarget]bool) ([]string, error)
 
 	ShouldSync(
 		ctx context.Context,
 		mod kmmv1beta1.Module,
-		m kmmv1beta1.KernelMapping) (bool, error)
+		m kmmv1beta1.KernelMapping,
+		targetKernel string,
+		targetArch string) (bool, error)
 
+	// Sync creates or checks the build of the image of m for targetKernel and targetArch.
+	// The variables of osConfig, if set, are passed to the build as build arguments.
 	Sync(
 		ctx context.Context,
 		mod kmmv1beta1.Module,
 		m kmmv1beta1.KernelMapping,
 		targetKernel string,
+		targetArch string,
+		osConfig *module.NodeOSConfig,
 		pushImage bool,
 		owner metav1.Object) (Result, error)
 }
@@ -8,7 +8,6 @@ import (
 
 	hubv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api-hub/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -73,13 +72,16 @@ func (c *clusterAPI) SelectedManagedClusters(
 
 	clusterList := &clusterv1.ManagedClusterList{}
 
+	sel, err := module.Selector(mcm.Spec.Selector, mcm.Spec.LabelSelector)
+	if err != nil {
+		return nil, fmt.Errorf("could not get the cluster selector: %v", err)
+	}
+
 	opts := []client.ListOption{
-		client.MatchingLabelsSelector{
-			Selector: labels.Set(mcm.Spec.Selector).AsSelector(),
-		},
+		client.MatchingLabelsSelector{Selector: sel},
 	}
 
-	err := c.client.List(ctx, clusterList, opts...)
+	err = c.client.List(ctx, clusterList, opts...)
 
 	return clusterList, err
 }
@@ -91,8 +93,7 @@ func (c *clusterAPI) BuildAndSign(
 
 	requeue := false
 
-	modKernelMappings := mcm.Spec.ModuleSpec.ModuleLoader.Container.KernelMappings
-	mappings, err := c.kernelMappingsByKernelVersion(ctx, modKernelMappings, cluster)
+	mappings, osConfigs, err := c.kernelMappingsByKernelVersion(ctx, mcm.Spec.ModuleSpec, cluster)
 	if err != nil {
 		return false, err
 	}
@@ -111,7 +112,7 @@ func (c *clusterAPI) BuildAndSign(
 	}
 
 	for kernelVersion, m := range mappings {
-		buildRequeue, err := c.build(ctx, mod, &mcm, m, kernelVersion)
+		buildRequeue, err := c.build(ctx, mod, &mcm, m, osConfigs[kernelVersion], kernelVersion)
 		if err != nil {
 			return false, err
 		}
@@ -134,20 +135,32 @@ func (c *clusterAPI) GarbageCollectBuilds(ctx context.Context, mcm hubv1beta1.Ma
 	if mcm.Spec.JobNamespace != "" {
 		namespace = mcm.Spec.JobNamespace
 	}
-	return c.buildAPI.GarbageCollect(ctx, mcm.Name, namespace, &mcm)
+
+	deleted, err := c.buildAPI.GarbageCollect(ctx, mcm.Name, namespace, &mcm, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	signDeleted, err := c.signAPI.GarbageCollect(ctx, mcm.Name, namespace, &mcm)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(deleted, signDeleted...), nil
 }
 
 func (c *clusterAPI) kernelMappingsByKernelVersion(
 	ctx context.Context,
-	modKernelMappings []kmmv1beta1.KernelMapping,
-	cluster clusterv1.ManagedCluster) (map[string]*kmmv1beta1.KernelMapping, error) {
+	modSpec kmmv1beta1.ModuleSpec,
+	cluster clusterv1.ManagedCluster) (map[string]*kmmv1beta1.KernelMapping, map[string]*module.NodeOSConfig, error) {
 
 	kernelVersions, err := c.kernelVersions(cluster)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	mappings := make(map[string]*kmmv1beta1.KernelMapping)
+	osConfigs := make(map[string]*module.NodeOSConfig)
 	logger := log.FromContext(ctx)
 
 	for _, kernelVersion := range kernelVersions {
@@ -163,13 +176,13 @@ func (c *clusterAPI) kernelMappingsByKernelVersion(
 			continue
 		}
 
-		m, err := c.kernelAPI.FindMappingForKernel(modKernelMappings, kernelVersion)
+		m, err := c.kernelAPI.FindMappingForKernel(modSpec.ModuleLoader.Container.KernelMappings, kernelVersion)
 		if err != nil {
 			kernelVersionLogger.Info("no suitable container image found; skipping kernel version")
 			continue
 		}
 
-		m, err = c.kernelAPI.PrepareKernelMapping(m, osConfig)
+		m, err = c.kernelAPI.PrepareKernelMapping(modSpec, m, osConfig)
 		if err != nil {
 			kernelVersionLogger.Info("failed to substitute the template variables in the mapping", "error", err)
 			continue
@@ -181,9 +194,10 @@ func (c *clusterAPI) kernelMappingsByKernelVersion(
 		)
 
 		mappings[kernelVersion] = m
+		osConfigs[kernelVersion] = osConfig
 	}
 
-	return mappings, nil
+	return mappings, osConfigs, nil
 }
 
 func (c *clusterAPI) kernelVersions(cluster clusterv1.ManagedCluster) ([]string, error) {
@@ -201,9 +215,11 @@ func (c *clusterAPI) build(
 	mod kmmv1beta1.Module,
 	mcm *hubv1beta1.ManagedClusterModule,
 	kernelMapping *kmmv1beta1.KernelMapping,
+	osConfig *module.NodeOSConfig,
 	kernelVersion string) (bool, error) {
 
-	shouldSync, err := c.buildAPI.ShouldSync(ctx, mod, *kernelMapping)
+	// the architecture of the managed cluster's nodes is not known on the hub
+	shouldSync, err := c.buildAPI.ShouldSync(ctx, mod, *kernelMapping, kernelVersion, "")
 	if err != nil {
 		return false, fmt.Errorf("could not check if build synchronization is needed: %v", err)
 	}
@@ -216,7 +232,7 @@ func (c *clusterAPI) build(
 		"image", kernelMapping.ContainerImage)
 	buildCtx := log.IntoContext(ctx, logger)
 
-	buildRes, err := c.buildAPI.Sync(buildCtx, mod, *kernelMapping, kernelVersion, true, mcm)
+	buildRes, err := c.buildAPI.Sync(buildCtx, mod, *kernelMapping, kernelVersion, "", osConfig, true, mcm)
 	if err != nil {
 		return false, fmt.Errorf("could not synchronize the build: %w", err)
 	}
@@ -231,7 +247,7 @@ func (c *clusterAPI) sign(
 	kernelMapping *kmmv1beta1.KernelMapping,
 	kernelVersion string) (bool, error) {
 
-	shouldSync, err := c.signAPI.ShouldSync(ctx, mod, *kernelMapping)
+	shouldSync, err := c.signAPI.ShouldSync(ctx, mod, *kernelMapping, kernelVersion, "")
 	if err != nil {
 		return false, fmt.Errorf("could not check if signing synchronization is needed: %v", err)
 	}
@@ -251,7 +267,7 @@ func (c *clusterAPI) sign(
 		"image", kernelMapping.ContainerImage)
 	signCtx := log.IntoContext(ctx, logger)
 
-	signRes, err := c.signAPI.Sync(signCtx, mod, *kernelMapping, kernelVersion, previousImage, true, mcm)
+	signRes, err := c.signAPI.Sync(signCtx, mod, *kernelMapping, kernelVersion, "", previousImage, true, mcm)
 	if err != nil {
 		return false, fmt.Errorf("could not synchronize the signing: %w", err)
 	}
@@ -322,9 +322,9 @@ var _ = Describe("ClusterAPI", func() {
 			gomock.InOrder(
 				mockKM.EXPECT().GetNodeOSConfigFromKernelVersion(kernelVersion).Return(&osConfig),
 				mockKM.EXPECT().FindMappingForKernel(mcm.Spec.ModuleSpec.ModuleLoader.Container.KernelMappings, kernelVersion).Return(&mappings[0], nil),
-				mockKM.EXPECT().PrepareKernelMapping(&mappings[0], &osConfig).Return(&mappings[0], nil),
-				mockBM.EXPECT().ShouldSync(gomock.Any(), mod, mappings[0]).Return(false, nil),
-				mockSM.EXPECT().ShouldSync(gomock.Any(), mod, mappings[0]).Return(false, nil),
+				mockKM.EXPECT().PrepareKernelMapping(mcm.Spec.ModuleSpec, &mappings[0], &osConfig).Return(&mappings[0], nil),
+				mockBM.EXPECT().ShouldSync(gomock.Any(), mod, mappings[0], kernelVersion, "").Return(false, nil),
+				mockSM.EXPECT().ShouldSync(gomock.Any(), mod, mappings[0], kernelVersion, "").Return(false, nil),
 			)
 
 			c := NewClusterAPI(clnt, mockKM, mockBM, mockSM, "")
@@ -393,10 +393,10 @@ var _ = Describe("ClusterAPI", func() {
 			gomock.InOrder(
 				mockKM.EXPECT().GetNodeOSConfigFromKernelVersion(kernelVersion).Return(&osConfig),
 				mockKM.EXPECT().FindMappingForKernel(mcm.Spec.ModuleSpec.ModuleLoader.Container.KernelMappings, kernelVersion).Return(&mappings[0], nil),
-				mockKM.EXPECT().PrepareKernelMapping(&mappings[0], &osConfig).Return(&mappings[0], nil),
-				mockBM.EXPECT().ShouldSync(gomock.Any(), mod, mappings[0]).Return(true, nil),
-				mockBM.EXPECT().Sync(gomock.Any(), mod, mappings[0], kernelVersion, true, mcm),
-				mockSM.EXPECT().ShouldSync(gomock.Any(), mod, mappings[0]).Return(false, nil),
+				mockKM.EXPECT().PrepareKernelMapping(mcm.Spec.ModuleSpec, &mappings[0], &osConfig).Return(&mappings[0], nil),
+				mockBM.EXPECT().ShouldSync(gomock.Any(), mod, mappings[0], kernelVersion, "").Return(true, nil),
+				mockBM.EXPECT().Sync(gomock.Any(), mod, mappings[0], kernelVersion, "", &osConfig, true, mcm),
+				mockSM.EXPECT().ShouldSync(gomock.Any(), mod, mappings[0], kernelVersion, "").Return(false, nil),
 			)
 
 			c := NewClusterAPI(clnt, mockKM, mockBM, mockSM, "")
@@ -465,9 +465,9 @@ var _ = Describe("ClusterAPI", func() {
 			gomock.InOrder(
 				mockKM.EXPECT().GetNodeOSConfigFromKernelVersion(kernelVersion).Return(&osConfig),
 				mockKM.EXPECT().FindMappingForKernel(mcm.Spec.ModuleSpec.ModuleLoader.Container.KernelMappings, kernelVersion).Return(&mappings[0], nil),
-				mockKM.EXPECT().PrepareKernelMapping(&mappings[0], &osConfig).Return(&mappings[0], nil),
-				mockBM.EXPECT().ShouldSync(gomock.Any(), mod, mappings[0]).Return(true, nil),
-				mockBM.EXPECT().Sync(gomock.Any(), mod, mappings[0], kernelVersion, true, mcm).Return(build.Result{}, errors.New("test-error")),
+				mockKM.EXPECT().PrepareKernelMapping(mcm.Spec.ModuleSpec, &mappings[0], &osConfig).Return(&mappings[0], nil),
+				mockBM.EXPECT().ShouldSync(gomock.Any(), mod, mappings[0], kernelVersion, "").Return(true, nil),
+				mockBM.EXPECT().Sync(gomock.Any(), mod, mappings[0], kernelVersion, "", &osConfig, true, mcm).Return(build.Result{}, errors.New("test-error")),
 			)
 
 			c := NewClusterAPI(clnt, mockKM, mockBM, mockSM, "")
@@ -536,10 +536,10 @@ var _ = Describe("ClusterAPI", func() {
 			gomock.InOrder(
 				mockKM.EXPECT().GetNodeOSConfigFromKernelVersion(kernelVersion).Return(&osConfig),
 				mockKM.EXPECT().FindMappingForKernel(mcm.Spec.ModuleSpec.ModuleLoader.Container.KernelMappings, kernelVersion).Return(&mappings[0], nil),
-				mockKM.EXPECT().PrepareKernelMapping(&mappings[0], &osConfig).Return(&mappings[0], nil),
-				mockBM.EXPECT().ShouldSync(gomock.Any(), mod, mappings[0]).Return(false, nil),
-				mockSM.EXPECT().ShouldSync(gomock.Any(), mod, mappings[0]).Return(true, nil),
-				mockSM.EXPECT().Sync(gomock.Any(), mod, mappings[0], kernelVersion, "", true, mcm),
+				mockKM.EXPECT().PrepareKernelMapping(mcm.Spec.ModuleSpec, &mappings[0], &osConfig).Return(&mappings[0], nil),
+				mockBM.EXPECT().ShouldSync(gomock.Any(), mod, mappings[0], kernelVersion, "").Return(false, nil),
+				mockSM.EXPECT().ShouldSync(gomock.Any(), mod, mappings[0], kernelVersion, "").Return(true, nil),
+				mockSM.EXPECT().Sync(gomock.Any(), mod, mappings[0], kernelVersion, "", "", true, mcm),
 			)
 
 			c := NewClusterAPI(clnt, mockKM, mockBM, mockSM, "")
@@ -608,10 +608,10 @@ var _ = Describe("ClusterAPI", func() {
 			gomock.InOrder(
 				mockKM.EXPECT().GetNodeOSConfigFromKernelVersion(kernelVersion).Return(&osConfig),
 				mockKM.EXPECT().FindMappingForKernel(mcm.Spec.ModuleSpec.ModuleLoader.Container.KernelMappings, kernelVersion).Return(&mappings[0], nil),
-				mockKM.EXPECT().PrepareKernelMapping(&mappings[0], &osConfig).Return(&mappings[0], nil),
-				mockBM.EXPECT().ShouldSync(gomock.Any(), mod, mappings[0]).Return(false, nil),
-				mockSM.EXPECT().ShouldSync(gomock.Any(), mod, mappings[0]).Return(true, nil),
-				mockSM.EXPECT().Sync(gomock.Any(), mod, mappings[0], kernelVersion, "", true, mcm).Return(utils.Result{}, errors.New("test-error")),
+				mockKM.EXPECT().PrepareKernelMapping(mcm.Spec.ModuleSpec, &mappings[0], &osConfig).Return(&mappings[0], nil),
+				mockBM.EXPECT().ShouldSync(gomock.Any(), mod, mappings[0], kernelVersion, "").Return(false, nil),
+				mockSM.EXPECT().ShouldSync(gomock.Any(), mod, mappings[0], kernelVersion, "").Return(true, nil),
+				mockSM.EXPECT().Sync(gomock.Any(), mod, mappings[0], kernelVersion, "", "", true, mcm).Return(utils.Result{}, errors.New("test-error")),
 			)
 
 			c := NewClusterAPI(clnt, mockKM, mockBM, mockSM, "")
@@ -680,11 +680,11 @@ var _ = Describe("ClusterAPI", func() {
 			gomock.InOrder(
 				mockKM.EXPECT().GetNodeOSConfigFromKernelVersion(kernelVersion).Return(&osConfig),
 				mockKM.EXPECT().FindMappingForKernel(mcm.Spec.ModuleSpec.ModuleLoader.Container.KernelMappings, kernelVersion).Return(&mappings[0], nil),
-				mockKM.EXPECT().PrepareKernelMapping(&mappings[0], &osConfig).Return(&mappings[0], nil),
-				mockBM.EXPECT().ShouldSync(gomock.Any(), mod, mappings[0]).Return(true, nil),
-				mockBM.EXPECT().Sync(gomock.Any(), mod, mappings[0], kernelVersion, true, mcm),
-				mockSM.EXPECT().ShouldSync(gomock.Any(), mod, mappings[0]).Return(true, nil),
-				mockSM.EXPECT().Sync(gomock.Any(), mod, mappings[0], kernelVersion, "", true, mcm),
+				mockKM.EXPECT().PrepareKernelMapping(mcm.Spec.ModuleSpec, &mappings[0], &osConfig).Return(&mappings[0], nil),
+				mockBM.EXPECT().ShouldSync(gomock.Any(), mod, mappings[0], kernelVersion, "").Return(true, nil),
+				mockBM.EXPECT().Sync(gomock.Any(), mod, mappings[0], kernelVersion, "", &osConfig, true, mcm),
+				mockSM.EXPECT().ShouldSync(gomock.Any(), mod, mappings[0], kernelVersion, "").Return(true, nil),
+				mockSM.EXPECT().Sync(gomock.Any(), mod, mappings[0], kernelVersion, "", "", true, mcm),
 			)
 
 			c := NewClusterAPI(clnt, mockKM, mockBM, mockSM, "")
@@ -754,11 +754,11 @@ var _ = Describe("ClusterAPI", func() {
 			gomock.InOrder(
 				mockKM.EXPECT().GetNodeOSConfigFromKernelVersion(kernelVersion).Return(&osConfig),
 				mockKM.EXPECT().FindMappingForKernel(mcm.Spec.ModuleSpec.ModuleLoader.Container.KernelMappings, kernelVersion).Return(&mappings[0], nil),
-				mockKM.EXPECT().PrepareKernelMapping(&mappings[0], &osConfig).Return(&mappings[0], nil),
-				mockBM.EXPECT().ShouldSync(gomock.Any(), mod, mappings[0]).Return(true, nil),
-				mockBM.EXPECT().Sync(gomock.Any(), mod, mappings[0], kernelVersion, true, mcm),
-				mockSM.EXPECT().ShouldSync(gomock.Any(), mod, mappings[0]).Return(true, nil),
-				mockSM.EXPECT().Sync(gomock.Any(), mod, mappings[0], kernelVersion, "", true, mcm),
+				mockKM.EXPECT().PrepareKernelMapping(mcm.Spec.ModuleSpec, &mappings[0], &osConfig).Return(&mappings[0], nil),
+				mockBM.EXPECT().ShouldSync(gomock.Any(), mod, mappings[0], kernelVersion, "").Return(true, nil),
+				mockBM.EXPECT().Sync(gomock.Any(), mod, mappings[0], kernelVersion, "", &osConfig, true, mcm),
+				mockSM.EXPECT().ShouldSync(gomock.Any(), mod, mappings[0], kernelVersion, "").Return(true, nil),
+				mockSM.EXPECT().Sync(gomock.Any(), mod, mappings[0], kernelVersion, "", "", true, mcm),
 			)
 
 			c := NewClusterAPI(clnt, mockKM, mockBM, mockSM, defaultJobNamespace)
@@ -780,20 +780,19 @@ var _ = Describe("ClusterAPI", func() {
 				},
 			}
 
-			collectedBuilds := []string{"test-build"}
-
 			ctx := context.Background()
 
 			gomock.InOrder(
-				mockBM.EXPECT().GarbageCollect(ctx, mcm.Name, mcm.Spec.JobNamespace, &mcm).Return(collectedBuilds, nil),
+				mockBM.EXPECT().GarbageCollect(ctx, mcm.Name, mcm.Spec.JobNamespace, &mcm, nil).Return([]string{"test-build"}, nil),
+				mockSM.EXPECT().GarbageCollect(ctx, mcm.Name, mcm.Spec.JobNamespace, &mcm).Return([]string{"test-sign"}, nil),
 			)
 
-			c := NewClusterAPI(clnt, nil, mockBM, nil, "")
+			c := NewClusterAPI(clnt, nil, mockBM, mockSM, "")
 
 			collected, err := c.GarbageCollectBuilds(ctx, mcm)
 
 			Expect(err).ToNot(HaveOccurred())
-			Expect(collected).To(Equal(collectedBuilds))
+			Expect(collected).To(Equal([]string{"test-build", "test-sign"}))
 		})
 
 		It("should return an error when garbage collection fails", func() {
@@ -808,7 +807,7 @@ var _ = Describe("ClusterAPI", func() {
 			ctx := context.Background()
 
 			gomock.InOrder(
-				mockBM.EXPECT().GarbageCollect(ctx, mcm.Name, mcm.Spec.JobNamespace, &mcm).Return(nil, errors.New("test")),
+				mockBM.EXPECT().GarbageCollect(ctx, mcm.Name, mcm.Spec.JobNamespace, &mcm, nil).Return(nil, errors.New("test")),
 			)
 
 			c := NewClusterAPI(clnt, nil, mockBM, nil, "")
@@ -832,10 +831,11 @@ var _ = Describe("ClusterAPI", func() {
 			ctx := context.Background()
 
 			gomock.InOrder(
-				mockBM.EXPECT().GarbageCollect(ctx, mcm.Name, defaultJobNamespace, &mcm).Return(collectedBuilds, nil),
+				mockBM.EXPECT().GarbageCollect(ctx, mcm.Name, defaultJobNamespace, &mcm, nil).Return(collectedBuilds, nil),
+				mockSM.EXPECT().GarbageCollect(ctx, mcm.Name, defaultJobNamespace, &mcm),
 			)
 
-			c := NewClusterAPI(clnt, nil, mockBM, nil, defaultJobNamespace)
+			c := NewClusterAPI(clnt, nil, mockBM, mockSM, defaultJobNamespace)
 
 			collected, err := c.GarbageCollectBuilds(ctx, mcm)
 
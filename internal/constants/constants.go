@@ -1,16 +1,27 @@
 package constants
 
 const (
-	ModuleNameLabel      = "kmm.node.kubernetes.io/module.name"
-	NodeLabelerFinalizer = "kmm.node.kubernetes.io/node-labeler"
-	TargetKernelTarget   = "kmm.node.kubernetes.io/target-kernel"
-	DaemonSetRole        = "kmm.node.kubernetes.io/role"
-	JobType              = "kmm.node.kubernetes.io/job-type"
-	JobHashAnnotation    = "kmm.node.kubernetes.io/last-hash"
-	KernelLabel          = "kmm.node.kubernetes.io/kernel-version.full"
+	ModuleNameLabel          = "kmm.node.kubernetes.io/module.name"
+	ModuleVersionLabel       = "kmm.node.kubernetes.io/module.version"
+	NodeLabelerFinalizer     = "kmm.node.kubernetes.io/node-labeler"
+	TargetKernelTarget       = "kmm.node.kubernetes.io/target-kernel"
+	DaemonSetRole            = "kmm.node.kubernetes.io/role"
+	JobType                  = "kmm.node.kubernetes.io/job-type"
+	JobHashAnnotation        = "kmm.node.kubernetes.io/last-hash"
+	KernelLabel              = "kmm.node.kubernetes.io/kernel-version.full"
+	ArchitectureLabel        = "kmm.node.kubernetes.io/architecture"
+	RebuildTriggerAnnotation = "kmm.node.kubernetes.io/rebuild-trigger"
+	BuildContextAnnotation   = "kmm.node.kubernetes.io/build-context-hash"
+	BuildDigestLabel         = "kmm.node.kubernetes.io/build-digest"
+	SignDigestLabel          = "kmm.node.kubernetes.io/sign-digest"
+	JobLogsAnnotation        = "kmm.node.kubernetes.io/collected-containers"
+	PodPolicyHashAnnotation  = "kmm.node.kubernetes.io/pod-policy-hash"
+	BuildBackendLabel        = "kmm.node.kubernetes.io/build-backend"
 
 	ManagedClusterModuleNameLabel = "kmm.node.kubernetes.io/managedclustermodule.name"
 	DockerfileCMKey               = "dockerfile"
+	KernelMappingsCMKey           = "kernelMappings"
+	DriverToolkitCMKey            = "driverToolkitEntries"
 	PublicSignDataKey             = "cert"
 	PrivateSignDataKey            = "key"
 )
@@ -8,12 +8,12 @@ import (
 
 	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/constants"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/module"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/rbac"
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/utils/pointer"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -26,14 +26,34 @@ const (
 	nodeVarLibFirmwarePath         = "/var/lib/firmware"
 	nodeVarLibFirmwareVolumeName   = "node-var-lib-firmware"
 	devicePluginKernelVersion      = ""
+	defaultPriorityClassName       = "system-node-critical"
+
+	// moduleLoaderTerminationGracePeriodSeconds leaves time for the kernel modules that depend on the module to be
+	// unloaded first.
+	moduleLoaderTerminationGracePeriodSeconds = 300
+
+	// moduleLoaderHoldersWaitSeconds bounds the wait for those kernel modules, so that the module is still unloaded,
+	// or its failure to unload logged, before the grace period is over.
+	moduleLoaderHoldersWaitSeconds = 240
 )
 
 //go:generate mockgen -source=daemonset.go -package=daemonset -destination=mock_daemonset.go
 
+// Key identifies a DaemonSet of a Module.
+// The module-loader DaemonSets are keyed by kernel version, architecture and module version, so that each of them
+// runs the image built for its nodes and several versions of a kernel module can coexist while nodes are being
+// migrated from one to another.
+// The device plugin DaemonSet has an empty KernelVersion.
+type Key struct {
+	KernelVersion string
+	Architecture  string
+	ModuleVersion string
+}
+
 type DaemonSetCreator interface {
-	GarbageCollect(ctx context.Context, existingDS map[string]*appsv1.DaemonSet, validKernels sets.String) ([]string, error)
-	ModuleDaemonSetsByKernelVersion(ctx context.Context, name, namespace string) (map[string]*appsv1.DaemonSet, error)
-	SetDriverContainerAsDesired(ctx context.Context, ds *appsv1.DaemonSet, image string, mod kmmv1beta1.Module, kernelVersion string) error
+	GarbageCollect(ctx context.Context, existingDS map[Key]*appsv1.DaemonSet, validKeys map[Key]bool) ([]string, error)
+	ModuleDaemonSetsByKey(ctx context.Context, name, namespace string) (map[Key]*appsv1.DaemonSet, error)
+	SetDriverContainerAsDesired(ctx context.Context, ds *appsv1.DaemonSet, image string, mod kmmv1beta1.Module, kernelVersion, arch string) error
 	SetDevicePluginAsDesired(ctx context.Context, ds *appsv1.DaemonSet, mod *kmmv1beta1.Module) error
 	GetNodeLabelFromPod(pod *v1.Pod, moduleName string) string
 }
@@ -52,11 +72,11 @@ func NewCreator(client client.Client, kernelLabel string, scheme *runtime.Scheme
 	}
 }
 
-func (dc *daemonSetGenerator) GarbageCollect(ctx context.Context, existingDS map[string]*appsv1.DaemonSet, validKernels sets.String) ([]string, error) {
+func (dc *daemonSetGenerator) GarbageCollect(ctx context.Context, existingDS map[Key]*appsv1.DaemonSet, validKeys map[Key]bool) ([]string, error) {
 	deleted := make([]string, 0)
 
-	for kernelVersion, ds := range existingDS {
-		if !dc.isDevicePluginDaemonSet(ds) && !validKernels.Has(kernelVersion) {
+	for key, ds := range existingDS {
+		if !dc.isDevicePluginDaemonSet(ds) && !validKeys[key] {
 			if err := dc.client.Delete(ctx, ds); err != nil {
 				return nil, fmt.Errorf("could not delete DaemonSet %s: %v", ds.Name, err)
 			}
@@ -68,29 +88,45 @@ func (dc *daemonSetGenerator) GarbageCollect(ctx context.Context, existingDS map
 	return deleted, nil
 }
 
-func (dc *daemonSetGenerator) ModuleDaemonSetsByKernelVersion(ctx context.Context, name, namespace string) (map[string]*appsv1.DaemonSet, error) {
+func (dc *daemonSetGenerator) ModuleDaemonSetsByKey(ctx context.Context, name, namespace string) (map[Key]*appsv1.DaemonSet, error) {
 	dsList, err := dc.moduleDaemonSets(ctx, name, namespace)
 	if err != nil {
 		return nil, fmt.Errorf("could not get all DaemonSets: %w", err)
 	}
 
-	dsByKernelVersion := make(map[string]*appsv1.DaemonSet, len(dsList))
+	dsByKey := make(map[Key]*appsv1.DaemonSet, len(dsList))
 
 	for i := 0; i < len(dsList); i++ {
 		ds := dsList[i]
 
-		kernelVersion := ds.Labels[dc.kernelLabel]
-		if dsByKernelVersion[kernelVersion] != nil {
-			return nil, fmt.Errorf("multiple DaemonSets found for kernel %q", kernelVersion)
+		key := Key{
+			KernelVersion: ds.Labels[dc.kernelLabel],
+			Architecture:  ds.Labels[constants.ArchitectureLabel],
+			ModuleVersion: ds.Labels[constants.ModuleVersionLabel],
 		}
 
-		dsByKernelVersion[kernelVersion] = &ds
+		if dsByKey[key] != nil {
+			return nil, fmt.Errorf(
+				"multiple DaemonSets found for kernel %q, architecture %q and version %q",
+				key.KernelVersion,
+				key.Architecture,
+				key.ModuleVersion,
+			)
+		}
+
+		dsByKey[key] = &ds
 	}
 
-	return dsByKernelVersion, nil
+	return dsByKey, nil
 }
 
-func (dc *daemonSetGenerator) SetDriverContainerAsDesired(ctx context.Context, ds *appsv1.DaemonSet, image string, mod kmmv1beta1.Module, kernelVersion string) error {
+// SetDriverContainerAsDesired sets the desired state of the module-loader DaemonSet for kernelVersion.
+// When arch is not empty, pods are only scheduled on the nodes of that architecture.
+// Pods are only scheduled on nodes where all the Module's dependencies are loaded.
+// Unloading waits until the kernel modules that depend on mod's, such as those of dependent Modules, have been
+// unloaded.
+// When the Module has a version, pods are only scheduled on nodes where KMM has allowed that version to be loaded.
+func (dc *daemonSetGenerator) SetDriverContainerAsDesired(ctx context.Context, ds *appsv1.DaemonSet, image string, mod kmmv1beta1.Module, kernelVersion, arch string) error {
 	if ds == nil {
 		return errors.New("ds cannot be nil")
 	}
@@ -109,6 +145,15 @@ func (dc *daemonSetGenerator) SetDriverContainerAsDesired(ctx context.Context, d
 		constants.DaemonSetRole:   "module-loader",
 	}
 
+	if arch != "" {
+		standardLabels[constants.ArchitectureLabel] = arch
+	}
+
+	version := mod.Spec.ModuleLoader.Container.Version
+	if version != "" {
+		standardLabels[constants.ModuleVersionLabel] = version
+	}
+
 	ds.SetLabels(
 		OverrideLabels(ds.GetLabels(), standardLabels),
 	)
@@ -116,6 +161,18 @@ func (dc *daemonSetGenerator) SetDriverContainerAsDesired(ctx context.Context, d
 	nodeSelector := CopyMapStringString(mod.Spec.Selector)
 	nodeSelector[dc.kernelLabel] = kernelVersion
 
+	if arch != "" {
+		nodeSelector[v1.LabelArchStable] = arch
+	}
+
+	for _, dep := range mod.Spec.Dependencies {
+		nodeSelector[getDriverContainerNodeLabel(dep)] = ""
+	}
+
+	if version != "" {
+		nodeSelector[GetModuleLoaderVersionNodeLabel(mod.Namespace, mod.Name)] = version
+	}
+
 	nodeLibModulesPath := "/lib/modules/" + kernelVersion
 
 	hostPathDirectory := v1.HostPathDirectory
@@ -134,7 +191,7 @@ func (dc *daemonSetGenerator) SetDriverContainerAsDesired(ctx context.Context, d
 			},
 			PreStop: &v1.LifecycleHandler{
 				Exec: &v1.ExecAction{
-					Command: MakeUnloadCommand(mod.Spec.ModuleLoader.Container.Modprobe, mod.Name),
+					Command: makeWaitingUnloadCommand(mod.Spec.ModuleLoader.Container.Modprobe, mod.Name),
 				},
 			},
 		},
@@ -194,22 +251,57 @@ func (dc *daemonSetGenerator) SetDriverContainerAsDesired(ctx context.Context, d
 		serviceAccountName = rbac.GenerateModuleLoaderServiceAccountName(mod)
 	}
 
+	container.Env = mod.Spec.ModuleLoader.Container.Env
+	container.Resources = mod.Spec.ModuleLoader.Container.Resources
+	container.VolumeMounts = append(container.VolumeMounts, mod.Spec.ModuleLoader.Container.VolumeMounts...)
+	volumes = append(volumes, mod.Spec.ModuleLoader.Volumes...)
+
+	priorityClassName := mod.Spec.ModuleLoader.PriorityClassName
+	if priorityClassName == "" {
+		priorityClassName = defaultPriorityClassName
+	}
+
+	updateStrategy := appsv1.DaemonSetUpdateStrategy{}
+
+	// pods are replaced node by node by the upgrade manager
+	if policy := mod.Spec.ModuleLoader.UpgradePolicy; policy != nil {
+		updateStrategy.Type = appsv1.OnDeleteDaemonSetStrategyType
+
+		// a startup probe only runs until it succeeds: a readiness probe failing later on would remove the node's
+		// ready label, and evict the pods of the Modules depending on this one
+		if policy.VerifyLoad {
+			container.StartupProbe = makeVerifyLoadProbe(mod.Spec.ModuleLoader.Container.Modprobe)
+		}
+	}
+
+	var podAnnotations map[string]string
+
+	// restart the pods so that they run the rebuilt image, which the caller pins by digest as it reuses the same tag
+	if t := mod.Spec.RebuildTrigger; t != "" {
+		podAnnotations = map[string]string{constants.RebuildTriggerAnnotation: t}
+	}
+
 	ds.Spec = appsv1.DaemonSetSpec{
 		Template: v1.PodTemplateSpec{
 			ObjectMeta: metav1.ObjectMeta{
-				Labels:     standardLabels,
-				Finalizers: []string{constants.NodeLabelerFinalizer},
+				Annotations: podAnnotations,
+				Labels:      standardLabels,
+				Finalizers:  []string{constants.NodeLabelerFinalizer},
 			},
 			Spec: v1.PodSpec{
-				Containers:         []v1.Container{container},
-				ImagePullSecrets:   GetPodPullSecrets(mod.Spec.ImageRepoSecret),
-				NodeSelector:       nodeSelector,
-				PriorityClassName:  "system-node-critical",
-				ServiceAccountName: serviceAccountName,
-				Volumes:            volumes,
+				Affinity:                      makeModuleLoaderAffinity(mod),
+				Containers:                    []v1.Container{container},
+				ImagePullSecrets:              GetPodPullSecrets(mod.Spec.ImageRepoSecret),
+				NodeSelector:                  nodeSelector,
+				PriorityClassName:             priorityClassName,
+				ServiceAccountName:            serviceAccountName,
+				TerminationGracePeriodSeconds: pointer.Int64(moduleLoaderTerminationGracePeriodSeconds),
+				Tolerations:                   mod.Spec.ModuleLoader.Tolerations,
+				Volumes:                       volumes,
 			},
 		},
-		Selector: &metav1.LabelSelector{MatchLabels: standardLabels},
+		Selector:       &metav1.LabelSelector{MatchLabels: standardLabels},
+		UpdateStrategy: updateStrategy,
 	}
 
 	return controllerutil.SetControllerReference(&mod, ds, dc.scheme)
@@ -278,7 +370,7 @@ func (dc *daemonSetGenerator) SetDevicePluginAsDesired(ctx context.Context, ds *
 						VolumeMounts:    append(mod.Spec.DevicePlugin.Container.VolumeMounts, containerVolumeMounts...),
 					},
 				},
-				PriorityClassName:  "system-node-critical",
+				PriorityClassName:  defaultPriorityClassName,
 				ImagePullSecrets:   GetPodPullSecrets(mod.Spec.ImageRepoSecret),
 				NodeSelector:       map[string]string{getDriverContainerNodeLabel(mod.Name): ""},
 				ServiceAccountName: serviceAccountName,
@@ -329,6 +421,17 @@ func getDriverContainerNodeLabel(moduleName string) string {
 	return fmt.Sprintf("kmm.node.kubernetes.io/%s.ready", moduleName)
 }
 
+// GetModuleVersionNodeLabel returns the node label through which users request a version of a Module on a node.
+func GetModuleVersionNodeLabel(namespace, moduleName string) string {
+	return fmt.Sprintf("kmm.node.kubernetes.io/version-module.%s.%s", namespace, moduleName)
+}
+
+// GetModuleLoaderVersionNodeLabel returns the node label set by KMM to the version of a Module that can be loaded
+// on a node.
+func GetModuleLoaderVersionNodeLabel(namespace, moduleName string) string {
+	return fmt.Sprintf("kmm.node.kubernetes.io/version-module-loader.%s.%s", namespace, moduleName)
+}
+
 func getDevicePluginNodeLabel(moduleName string) string {
 	return fmt.Sprintf("kmm.node.kubernetes.io/%s.device-plugin-ready", moduleName)
 }
@@ -373,9 +476,9 @@ func MakeLoadCommand(spec kmmv1beta1.ModprobeSpec, modName string) []string {
 		fmt.Fprintf(&loadCommand, "cp -r %s/* %s && ", fw, nodeVarLibFirmwarePath)
 	}
 
-	loadCommand.WriteString("modprobe")
-
 	if rawArgs := spec.RawArgs; rawArgs != nil && len(rawArgs.Load) > 0 {
+		loadCommand.WriteString("modprobe")
+
 		for _, arg := range rawArgs.Load {
 			loadCommand.WriteRune(' ')
 			loadCommand.WriteString(arg)
@@ -383,28 +486,35 @@ func MakeLoadCommand(spec kmmv1beta1.ModprobeSpec, modName string) []string {
 		return append(loadCommandShell, loadCommand.String())
 	}
 
-	if args := spec.Args; args != nil && len(args.Load) > 0 {
-		for _, arg := range args.Load {
-			loadCommand.WriteRune(' ')
-			loadCommand.WriteString(arg)
-		}
-	} else {
-		loadCommand.WriteString(" -v")
-	}
+	var args []string
 
-	if dirName := spec.DirName; dirName != "" {
-		loadCommand.WriteString(" -d " + dirName)
+	if spec.Args != nil {
+		args = spec.Args.Load
 	}
 
-	loadCommand.WriteString(" " + spec.ModuleName)
+	if len(spec.Modules) > 0 {
+		commands := make([]string, 0, len(spec.Modules))
 
-	if params := spec.Parameters; len(params) > 0 {
-		for _, param := range params {
-			loadCommand.WriteRune(' ')
-			loadCommand.WriteString(param)
+		for _, m := range spec.Modules {
+			commands = append(
+				commands,
+				fmt.Sprintf(
+					`%s || { echo "could not load kernel module %s"; exit 1; }`,
+					makeModprobeCommand(args, "-v", spec.DirName, m.Name, m.Parameters),
+					m.Name,
+				),
+			)
 		}
+
+		fmt.Fprintf(&loadCommand, "{ %s; }", strings.Join(commands, "; "))
+
+		return append(loadCommandShell, loadCommand.String())
 	}
 
+	loadCommand.WriteString(
+		makeModprobeCommand(args, "-v", spec.DirName, spec.ModuleName, spec.Parameters),
+	)
+
 	return append(loadCommandShell, loadCommand.String())
 }
 
@@ -415,7 +525,6 @@ func MakeUnloadCommand(spec kmmv1beta1.ModprobeSpec, modName string) []string {
 	}
 
 	var unloadCommand strings.Builder
-	unloadCommand.WriteString("modprobe")
 
 	fwUnloadCommand := ""
 	if fw := spec.FirmwarePath; fw != "" {
@@ -423,6 +532,8 @@ func MakeUnloadCommand(spec kmmv1beta1.ModprobeSpec, modName string) []string {
 	}
 
 	if rawArgs := spec.RawArgs; rawArgs != nil && len(rawArgs.Unload) > 0 {
+		unloadCommand.WriteString("modprobe")
+
 		for _, arg := range rawArgs.Unload {
 			unloadCommand.WriteRune(' ')
 			unloadCommand.WriteString(arg)
@@ -432,21 +543,215 @@ func MakeUnloadCommand(spec kmmv1beta1.ModprobeSpec, modName string) []string {
 		return append(unloadCommandShell, unloadCommand.String())
 	}
 
-	if args := spec.Args; args != nil && len(args.Unload) > 0 {
-		for _, arg := range args.Unload {
-			unloadCommand.WriteRune(' ')
-			unloadCommand.WriteString(arg)
-		}
-	} else {
-		unloadCommand.WriteString(" -rv")
+	var args []string
+
+	if spec.Args != nil {
+		args = spec.Args.Unload
 	}
 
-	if dirName := spec.DirName; dirName != "" {
-		unloadCommand.WriteString(" -d " + dirName)
+	if len(spec.Modules) > 0 {
+		commands := make([]string, 0, len(spec.Modules))
+
+		// unload the kernel modules in the reverse order
+		for i := len(spec.Modules) - 1; i >= 0; i-- {
+			m := spec.Modules[i]
+
+			commands = append(
+				commands,
+				fmt.Sprintf(
+					`%s || { echo "could not unload kernel module %s"; exit 1; }`,
+					makeModprobeCommand(args, "-rv", spec.DirName, m.Name, nil),
+					m.Name,
+				),
+			)
+		}
+
+		fmt.Fprintf(&unloadCommand, "{ %s; }%s", strings.Join(commands, "; "), fwUnloadCommand)
+
+		return append(unloadCommandShell, unloadCommand.String())
 	}
 
-	unloadCommand.WriteString(" " + spec.ModuleName)
+	unloadCommand.WriteString(
+		makeModprobeCommand(args, "-rv", spec.DirName, spec.ModuleName, nil),
+	)
 	unloadCommand.WriteString(fwUnloadCommand)
 
 	return append(unloadCommandShell, unloadCommand.String())
 }
+
+// makeModprobeCommand returns a single modprobe invocation for kernelModule.
+// defaultArg is used if args is empty.
+func makeModprobeCommand(args []string, defaultArg, dirName, kernelModule string, params []string) string {
+	var sb strings.Builder
+
+	sb.WriteString("modprobe")
+
+	if len(args) > 0 {
+		for _, arg := range args {
+			sb.WriteRune(' ')
+			sb.WriteString(arg)
+		}
+	} else {
+		sb.WriteString(" " + defaultArg)
+	}
+
+	if dirName != "" {
+		sb.WriteString(" -d " + dirName)
+	}
+
+	sb.WriteString(" " + kernelModule)
+
+	for _, param := range params {
+		sb.WriteRune(' ')
+		sb.WriteString(param)
+	}
+
+	return sb.String()
+}
+
+// makeWaitingUnloadCommand returns the command returned by MakeUnloadCommand, preceded by a wait for the kernel
+// modules in spec to have no holders outside of spec.
+// Other Modules depending on this one are unloaded when their pods are deleted, which happens as soon as this
+// Module's node label is removed; the kernel reports their modules as holders until then.
+// The wait lasts moduleLoaderHoldersWaitSeconds at most; the kernel modules are then unloaded anyway.
+// Kernel modules only passed as raw arguments are not waited for.
+func makeWaitingUnloadCommand(spec kmmv1beta1.ModprobeSpec, modName string) []string {
+	command := MakeUnloadCommand(spec, modName)
+
+	names := kernelModuleNames(spec)
+	if len(names) == 0 {
+		return command
+	}
+
+	// the kernel modules of spec may hold each other; they are unloaded in the right order by MakeUnloadCommand
+	own := make([]string, 0, len(names))
+
+	for _, n := range names {
+		own = append(own, "-e "+n)
+	}
+
+	// the seconds waited so far, for all kernel modules
+	waits := []string{"waited=0"}
+
+	for _, n := range names {
+		waits = append(
+			waits,
+			fmt.Sprintf(
+				"while ls /sys/module/%s/holders 2>/dev/null | grep -qvx %s; do "+
+					`[ $waited -lt %d ] || { echo "kernel module %s is still in use; unloading anyway"; break; }; `+
+					"sleep 1; waited=$((waited+1)); done",
+				n,
+				strings.Join(own, " "),
+				moduleLoaderHoldersWaitSeconds,
+				n,
+			),
+		)
+	}
+
+	last := len(command) - 1
+	command[last] = strings.Join(waits, "; ") + "; " + command[last]
+
+	return command
+}
+
+// makeVerifyLoadProbe returns a probe that succeeds once all kernel modules in spec are loaded from the image: they
+// must be listed in /proc/modules, with the srcversion of the kernel modules shipped in the image.
+// Kernel modules without a srcversion are only checked in /proc/modules.
+// The probe gives the kernel modules 5 minutes to be loaded before the container is restarted.
+// It returns nil if the kernel modules are only passed as raw arguments.
+func makeVerifyLoadProbe(spec kmmv1beta1.ModprobeSpec) *v1.Probe {
+	names := kernelModuleNames(spec)
+	if len(names) == 0 {
+		return nil
+	}
+
+	modinfo := "modinfo"
+	if spec.DirName != "" {
+		modinfo += " -b " + spec.DirName
+	}
+
+	checks := make([]string, 0, len(names))
+
+	for _, n := range names {
+		checks = append(
+			checks,
+			fmt.Sprintf(
+				`grep -q '^%s ' /proc/modules && [ "$(cat /sys/module/%s/srcversion 2>/dev/null)" = "$(%s -F srcversion %s)" ]`,
+				n,
+				n,
+				modinfo,
+				n,
+			),
+		)
+	}
+
+	return &v1.Probe{
+		ProbeHandler: v1.ProbeHandler{
+			Exec: &v1.ExecAction{
+				Command: []string{"/bin/sh", "-c", strings.Join(checks, " && ")},
+			},
+		},
+		PeriodSeconds:    5,
+		FailureThreshold: 60,
+	}
+}
+
+// kernelModuleNames returns the names of the kernel modules in spec, as reported by the kernel.
+func kernelModuleNames(spec kmmv1beta1.ModprobeSpec) []string {
+	names := make([]string, 0, len(spec.Modules)+1)
+
+	if spec.ModuleName != "" {
+		names = append(names, spec.ModuleName)
+	}
+
+	for _, m := range spec.Modules {
+		names = append(names, m.Name)
+	}
+
+	// the kernel reports module names with underscores
+	for i, n := range names {
+		names[i] = strings.ReplaceAll(n, "-", "_")
+	}
+
+	return names
+}
+
+// makeModuleLoaderAffinity merges the Module's set-based selector into the module loader's affinity.
+// The selector's requirements are added to every required node selector term, so that they apply whichever term
+// matches.
+func makeModuleLoaderAffinity(mod kmmv1beta1.Module) *v1.Affinity {
+	selectorAffinity := module.NodeAffinity(mod.Spec)
+
+	if mod.Spec.ModuleLoader.Affinity == nil {
+		if selectorAffinity == nil {
+			return nil
+		}
+
+		return &v1.Affinity{NodeAffinity: selectorAffinity}
+	}
+
+	affinity := mod.Spec.ModuleLoader.Affinity.DeepCopy()
+
+	if selectorAffinity == nil {
+		return affinity
+	}
+
+	if affinity.NodeAffinity == nil {
+		affinity.NodeAffinity = selectorAffinity
+		return affinity
+	}
+
+	required := affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+	if required == nil || len(required.NodeSelectorTerms) == 0 {
+		affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = selectorAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+		return affinity
+	}
+
+	selectorRequirements := selectorAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchExpressions
+
+	for i := range required.NodeSelectorTerms {
+		required.NodeSelectorTerms[i].MatchExpressions = append(required.NodeSelectorTerms[i].MatchExpressions, selectorRequirements...)
+	}
+
+	return affinity
+}
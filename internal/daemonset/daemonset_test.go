@@ -16,7 +16,6 @@ import (
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/utils/pointer"
 )
 
@@ -43,7 +42,7 @@ var _ = Describe("SetDriverContainerAsDesired", func() {
 
 	It("should return an error if the DaemonSet is nil", func() {
 		Expect(
-			dg.SetDriverContainerAsDesired(context.Background(), nil, "", kmmv1beta1.Module{}, ""),
+			dg.SetDriverContainerAsDesired(context.Background(), nil, "", kmmv1beta1.Module{}, "", ""),
 		).To(
 			HaveOccurred(),
 		)
@@ -51,7 +50,7 @@ var _ = Describe("SetDriverContainerAsDesired", func() {
 
 	It("should return an error if the image is empty", func() {
 		Expect(
-			dg.SetDriverContainerAsDesired(context.Background(), &appsv1.DaemonSet{}, "", kmmv1beta1.Module{}, ""),
+			dg.SetDriverContainerAsDesired(context.Background(), &appsv1.DaemonSet{}, "", kmmv1beta1.Module{}, "", ""),
 		).To(
 			HaveOccurred(),
 		)
@@ -59,7 +58,7 @@ var _ = Describe("SetDriverContainerAsDesired", func() {
 
 	It("should return an error if the kernel version is empty", func() {
 		Expect(
-			dg.SetDriverContainerAsDesired(context.Background(), &appsv1.DaemonSet{}, "", kmmv1beta1.Module{}, ""),
+			dg.SetDriverContainerAsDesired(context.Background(), &appsv1.DaemonSet{}, "", kmmv1beta1.Module{}, "", ""),
 		).To(
 			HaveOccurred(),
 		)
@@ -74,7 +73,7 @@ var _ = Describe("SetDriverContainerAsDesired", func() {
 
 		ds := appsv1.DaemonSet{}
 
-		err := dg.SetDriverContainerAsDesired(context.Background(), &ds, "test-image", mod, kernelVersion)
+		err := dg.SetDriverContainerAsDesired(context.Background(), &ds, "test-image", mod, kernelVersion, "")
 		Expect(err).NotTo(HaveOccurred())
 		Expect(ds.Spec.Template.Spec.Containers).To(HaveLen(1))
 		Expect(ds.Spec.Template.Spec.Volumes).To(HaveLen(1))
@@ -114,7 +113,7 @@ var _ = Describe("SetDriverContainerAsDesired", func() {
 
 		ds := appsv1.DaemonSet{}
 
-		err := dg.SetDriverContainerAsDesired(context.Background(), &ds, "test-image", mod, kernelVersion)
+		err := dg.SetDriverContainerAsDesired(context.Background(), &ds, "test-image", mod, kernelVersion, "")
 		Expect(err).NotTo(HaveOccurred())
 		Expect(ds.Spec.Template.Spec.Volumes).To(HaveLen(2))
 		Expect(ds.Spec.Template.Spec.Volumes[1]).To(Equal(vol))
@@ -122,6 +121,345 @@ var _ = Describe("SetDriverContainerAsDesired", func() {
 		Expect(ds.Spec.Template.Spec.Containers[0].VolumeMounts[1]).To(Equal(volm))
 	})
 
+	It("should only schedule pods on nodes where the dependencies are loaded", func() {
+		mod := kmmv1beta1.Module{
+			Spec: kmmv1beta1.ModuleSpec{
+				Selector:     map[string]string{"has-feature-x": "true"},
+				Dependencies: []string{"dep1", "dep2"},
+			},
+		}
+
+		ds := appsv1.DaemonSet{}
+
+		err := dg.SetDriverContainerAsDesired(context.Background(), &ds, "test-image", mod, kernelVersion, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ds.Spec.Template.Spec.NodeSelector).To(Equal(map[string]string{
+			"has-feature-x":                     "true",
+			kernelLabel:                         kernelVersion,
+			"kmm.node.kubernetes.io/dep1.ready": "",
+			"kmm.node.kubernetes.io/dep2.ready": "",
+		}))
+	})
+
+	It("should only schedule pods on nodes of the architecture", func() {
+		mod := kmmv1beta1.Module{
+			Spec: kmmv1beta1.ModuleSpec{
+				Selector: map[string]string{"has-feature-x": "true"},
+			},
+		}
+
+		ds := appsv1.DaemonSet{}
+
+		err := dg.SetDriverContainerAsDesired(context.Background(), &ds, "test-image", mod, kernelVersion, "arm64")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ds.Labels).To(HaveKeyWithValue(constants.ArchitectureLabel, "arm64"))
+		Expect(ds.Spec.Selector.MatchLabels).To(HaveKeyWithValue(constants.ArchitectureLabel, "arm64"))
+		Expect(ds.Spec.Template.Spec.NodeSelector).To(Equal(map[string]string{
+			"has-feature-x":    "true",
+			kernelLabel:        kernelVersion,
+			v1.LabelArchStable: "arm64",
+		}))
+	})
+
+	It("should only schedule pods on nodes where KMM allowed the module version", func() {
+		mod := kmmv1beta1.Module{
+			ObjectMeta: metav1.ObjectMeta{Name: moduleName, Namespace: namespace},
+			Spec: kmmv1beta1.ModuleSpec{
+				ModuleLoader: kmmv1beta1.ModuleLoaderSpec{
+					Container: kmmv1beta1.ModuleLoaderContainerSpec{Version: "v2"},
+				},
+				Selector: map[string]string{"has-feature-x": "true"},
+			},
+		}
+
+		ds := appsv1.DaemonSet{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace},
+		}
+
+		err := dg.SetDriverContainerAsDesired(context.Background(), &ds, "test-image", mod, kernelVersion, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ds.Labels).To(HaveKeyWithValue(constants.ModuleVersionLabel, "v2"))
+		Expect(ds.Spec.Selector.MatchLabels).To(HaveKeyWithValue(constants.ModuleVersionLabel, "v2"))
+		Expect(ds.Spec.Template.Spec.NodeSelector).To(Equal(map[string]string{
+			"has-feature-x": "true",
+			kernelLabel:     kernelVersion,
+			GetModuleLoaderVersionNodeLabel(namespace, moduleName): "v2",
+		}))
+	})
+
+	It("should not set an affinity if the Module has no set-based selector", func() {
+		mod := kmmv1beta1.Module{
+			Spec: kmmv1beta1.ModuleSpec{
+				Selector: map[string]string{"has-feature-x": "true"},
+			},
+		}
+
+		ds := appsv1.DaemonSet{}
+
+		err := dg.SetDriverContainerAsDesired(context.Background(), &ds, "test-image", mod, kernelVersion, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ds.Spec.Template.Spec.Affinity).To(BeNil())
+	})
+
+	It("should translate the set-based selector into a node affinity", func() {
+		mod := kmmv1beta1.Module{
+			Spec: kmmv1beta1.ModuleSpec{
+				Selector: map[string]string{"has-feature-x": "true"},
+				LabelSelector: &metav1.LabelSelector{
+					MatchExpressions: []metav1.LabelSelectorRequirement{
+						{Key: "zone", Operator: metav1.LabelSelectorOpIn, Values: []string{"a", "b"}},
+					},
+				},
+			},
+		}
+
+		ds := appsv1.DaemonSet{}
+
+		err := dg.SetDriverContainerAsDesired(context.Background(), &ds, "test-image", mod, kernelVersion, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ds.Spec.Template.Spec.NodeSelector).To(Equal(map[string]string{
+			"has-feature-x": "true",
+			kernelLabel:     kernelVersion,
+		}))
+		Expect(ds.Spec.Template.Spec.Affinity).To(Equal(&v1.Affinity{
+			NodeAffinity: &v1.NodeAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: &v1.NodeSelector{
+					NodeSelectorTerms: []v1.NodeSelectorTerm{
+						{
+							MatchExpressions: []v1.NodeSelectorRequirement{
+								{Key: "zone", Operator: v1.NodeSelectorOpIn, Values: []string{"a", "b"}},
+							},
+						},
+					},
+				},
+			},
+		}))
+	})
+
+	It("should apply the module loader's pod and container settings", func() {
+		tolerations := []v1.Toleration{
+			{Key: "nvidia.com/gpu", Operator: v1.TolerationOpExists, Effect: v1.TaintEffectNoSchedule},
+		}
+
+		resources := v1.ResourceRequirements{
+			Limits: v1.ResourceList{v1.ResourceMemory: resource.MustParse("64Mi")},
+		}
+
+		env := []v1.EnvVar{{Name: "SOME_VAR", Value: "some-value"}}
+		volm := v1.VolumeMount{Name: "some-volume", MountPath: "/some/path"}
+		vol := v1.Volume{
+			Name:         "some-volume",
+			VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}},
+		}
+
+		mod := kmmv1beta1.Module{
+			Spec: kmmv1beta1.ModuleSpec{
+				ModuleLoader: kmmv1beta1.ModuleLoaderSpec{
+					Container: kmmv1beta1.ModuleLoaderContainerSpec{
+						Env:          env,
+						Resources:    resources,
+						VolumeMounts: []v1.VolumeMount{volm},
+					},
+					PriorityClassName: "some-priority-class",
+					Tolerations:       tolerations,
+					Volumes:           []v1.Volume{vol},
+				},
+			},
+		}
+
+		ds := appsv1.DaemonSet{}
+
+		err := dg.SetDriverContainerAsDesired(context.Background(), &ds, "test-image", mod, kernelVersion, "")
+		Expect(err).NotTo(HaveOccurred())
+
+		podSpec := ds.Spec.Template.Spec
+		Expect(podSpec.PriorityClassName).To(Equal("some-priority-class"))
+		Expect(podSpec.Tolerations).To(Equal(tolerations))
+		Expect(podSpec.Volumes).To(HaveLen(2))
+		Expect(podSpec.Volumes[1]).To(Equal(vol))
+		Expect(podSpec.Containers[0].Env).To(Equal(env))
+		Expect(podSpec.Containers[0].Resources).To(Equal(resources))
+		Expect(podSpec.Containers[0].VolumeMounts).To(HaveLen(2))
+		Expect(podSpec.Containers[0].VolumeMounts[1]).To(Equal(volm))
+	})
+
+	It("should combine the module loader's affinity with the set-based selector", func() {
+		podAntiAffinity := &v1.PodAntiAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: []v1.PodAffinityTerm{
+				{TopologyKey: "kubernetes.io/hostname"},
+			},
+		}
+
+		mod := kmmv1beta1.Module{
+			Spec: kmmv1beta1.ModuleSpec{
+				LabelSelector: &metav1.LabelSelector{
+					MatchExpressions: []metav1.LabelSelectorRequirement{
+						{Key: "zone", Operator: metav1.LabelSelectorOpExists},
+					},
+				},
+				ModuleLoader: kmmv1beta1.ModuleLoaderSpec{
+					Affinity: &v1.Affinity{
+						NodeAffinity: &v1.NodeAffinity{
+							RequiredDuringSchedulingIgnoredDuringExecution: &v1.NodeSelector{
+								NodeSelectorTerms: []v1.NodeSelectorTerm{
+									{
+										MatchExpressions: []v1.NodeSelectorRequirement{
+											{Key: "pool", Operator: v1.NodeSelectorOpIn, Values: []string{"a"}},
+										},
+									},
+									{
+										MatchExpressions: []v1.NodeSelectorRequirement{
+											{Key: "pool", Operator: v1.NodeSelectorOpIn, Values: []string{"b"}},
+										},
+									},
+								},
+							},
+						},
+						PodAntiAffinity: podAntiAffinity,
+					},
+				},
+			},
+		}
+
+		ds := appsv1.DaemonSet{}
+
+		err := dg.SetDriverContainerAsDesired(context.Background(), &ds, "test-image", mod, kernelVersion, "")
+		Expect(err).NotTo(HaveOccurred())
+
+		zoneRequirement := v1.NodeSelectorRequirement{Key: "zone", Operator: v1.NodeSelectorOpExists}
+
+		Expect(ds.Spec.Template.Spec.Affinity).To(Equal(&v1.Affinity{
+			NodeAffinity: &v1.NodeAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: &v1.NodeSelector{
+					NodeSelectorTerms: []v1.NodeSelectorTerm{
+						{
+							MatchExpressions: []v1.NodeSelectorRequirement{
+								{Key: "pool", Operator: v1.NodeSelectorOpIn, Values: []string{"a"}},
+								zoneRequirement,
+							},
+						},
+						{
+							MatchExpressions: []v1.NodeSelectorRequirement{
+								{Key: "pool", Operator: v1.NodeSelectorOpIn, Values: []string{"b"}},
+								zoneRequirement,
+							},
+						},
+					},
+				},
+			},
+			PodAntiAffinity: podAntiAffinity,
+		}))
+
+		By("not modifying the Module's own affinity")
+
+		Expect(
+			mod.Spec.ModuleLoader.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchExpressions,
+		).To(
+			HaveLen(1),
+		)
+	})
+
+	It("should restart the pods when the rebuild trigger changes", func() {
+		mod := kmmv1beta1.Module{
+			Spec: kmmv1beta1.ModuleSpec{RebuildTrigger: "some-trigger"},
+		}
+
+		ds := appsv1.DaemonSet{}
+
+		err := dg.SetDriverContainerAsDesired(context.Background(), &ds, "test-image", mod, kernelVersion, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ds.Spec.Template.Annotations).To(Equal(map[string]string{constants.RebuildTriggerAnnotation: "some-trigger"}))
+		Expect(ds.Spec.Template.Spec.Containers[0].ImagePullPolicy).To(BeEmpty())
+	})
+
+	It("should use the default update strategy if there is no upgrade policy", func() {
+		mod := kmmv1beta1.Module{
+			Spec: kmmv1beta1.ModuleSpec{
+				ModuleLoader: kmmv1beta1.ModuleLoaderSpec{
+					Container: kmmv1beta1.ModuleLoaderContainerSpec{
+						Modprobe: kmmv1beta1.ModprobeSpec{ModuleName: "some-kmod"},
+					},
+				},
+			},
+		}
+
+		ds := appsv1.DaemonSet{}
+
+		err := dg.SetDriverContainerAsDesired(context.Background(), &ds, "test-image", mod, kernelVersion, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ds.Spec.UpdateStrategy).To(Equal(appsv1.DaemonSetUpdateStrategy{}))
+		Expect(ds.Spec.Template.Spec.Containers[0].StartupProbe).To(BeNil())
+	})
+
+	It("should let the upgrade manager replace pods and verify that the modules are loaded", func() {
+		mod := kmmv1beta1.Module{
+			Spec: kmmv1beta1.ModuleSpec{
+				ModuleLoader: kmmv1beta1.ModuleLoaderSpec{
+					Container: kmmv1beta1.ModuleLoaderContainerSpec{
+						Modprobe: kmmv1beta1.ModprobeSpec{
+							DirName: "/opt",
+							Modules: []kmmv1beta1.ModprobeModule{{Name: "kmod-a"}, {Name: "kmod_b"}},
+						},
+					},
+					UpgradePolicy: &kmmv1beta1.UpgradePolicy{VerifyLoad: true},
+				},
+			},
+		}
+
+		ds := appsv1.DaemonSet{}
+
+		err := dg.SetDriverContainerAsDesired(context.Background(), &ds, "test-image", mod, kernelVersion, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ds.Spec.UpdateStrategy.Type).To(Equal(appsv1.OnDeleteDaemonSetStrategyType))
+		Expect(ds.Spec.Template.Spec.Containers[0].ReadinessProbe).To(BeNil())
+		Expect(ds.Spec.Template.Spec.Containers[0].StartupProbe.Exec.Command).To(Equal([]string{
+			"/bin/sh",
+			"-c",
+			`grep -q '^kmod_a ' /proc/modules && [ "$(cat /sys/module/kmod_a/srcversion 2>/dev/null)" = "$(modinfo -b /opt -F srcversion kmod_a)" ] && ` +
+				`grep -q '^kmod_b ' /proc/modules && [ "$(cat /sys/module/kmod_b/srcversion 2>/dev/null)" = "$(modinfo -b /opt -F srcversion kmod_b)" ]`,
+		}))
+	})
+
+	It("should wait for the holders of the kernel modules outside of the Module before unloading them", func() {
+		mod := kmmv1beta1.Module{
+			Spec: kmmv1beta1.ModuleSpec{
+				ModuleLoader: kmmv1beta1.ModuleLoaderSpec{
+					Container: kmmv1beta1.ModuleLoaderContainerSpec{
+						Modprobe: kmmv1beta1.ModprobeSpec{
+							Modules: []kmmv1beta1.ModprobeModule{{Name: "kmod-a"}, {Name: "kmod_b"}},
+						},
+					},
+				},
+			},
+		}
+
+		ds := appsv1.DaemonSet{}
+
+		err := dg.SetDriverContainerAsDesired(context.Background(), &ds, "test-image", mod, kernelVersion, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ds.Spec.Template.Spec.Containers[0].Lifecycle.PreStop.Exec.Command).To(Equal([]string{
+			"/bin/sh",
+			"-c",
+			"waited=0; " +
+				"while ls /sys/module/kmod_a/holders 2>/dev/null | grep -qvx -e kmod_a -e kmod_b; do " +
+				`[ $waited -lt 240 ] || { echo "kernel module kmod_a is still in use; unloading anyway"; break; }; ` +
+				"sleep 1; waited=$((waited+1)); done; " +
+				"while ls /sys/module/kmod_b/holders 2>/dev/null | grep -qvx -e kmod_a -e kmod_b; do " +
+				`[ $waited -lt 240 ] || { echo "kernel module kmod_b is still in use; unloading anyway"; break; }; ` +
+				"sleep 1; waited=$((waited+1)); done; " +
+				`{ modprobe -rv kmod_b || { echo "could not unload kernel module kmod_b"; exit 1; }; ` +
+				`modprobe -rv kmod-a || { echo "could not unload kernel module kmod-a"; exit 1; }; }`,
+		}))
+	})
+
+	It("should not wait for kernel modules passed as raw arguments", func() {
+		spec := kmmv1beta1.ModprobeSpec{
+			RawArgs: &kmmv1beta1.ModprobeArgs{Load: []string{"some-kmod"}, Unload: []string{"-r", "some-kmod"}},
+		}
+
+		Expect(makeWaitingUnloadCommand(spec, "some-module")).To(Equal(MakeUnloadCommand(spec, "some-module")))
+	})
+
 	It("should add the default ServiceAccount to the module loader if it is not set in the spec", func() {
 		mod := kmmv1beta1.Module{
 			Spec: kmmv1beta1.ModuleSpec{
@@ -131,7 +469,7 @@ var _ = Describe("SetDriverContainerAsDesired", func() {
 
 		ds := appsv1.DaemonSet{}
 
-		err := dg.SetDriverContainerAsDesired(context.Background(), &ds, "test-image", mod, kernelVersion)
+		err := dg.SetDriverContainerAsDesired(context.Background(), &ds, "test-image", mod, kernelVersion, "")
 		Expect(err).NotTo(HaveOccurred())
 		Expect(ds.Spec.Template.Spec.ServiceAccountName).To(Equal(mod.Name + "-module-loader"))
 	})
@@ -172,7 +510,7 @@ var _ = Describe("SetDriverContainerAsDesired", func() {
 			},
 		}
 
-		err := dg.SetDriverContainerAsDesired(context.Background(), &ds, moduleLoaderImage, mod, kernelVersion)
+		err := dg.SetDriverContainerAsDesired(context.Background(), &ds, moduleLoaderImage, mod, kernelVersion, "")
 		Expect(err).NotTo(HaveOccurred())
 
 		podLabels := map[string]string{
@@ -219,7 +557,7 @@ var _ = Describe("SetDriverContainerAsDesired", func() {
 									},
 									PreStop: &v1.LifecycleHandler{
 										Exec: &v1.ExecAction{
-											Command: MakeUnloadCommand(mod.Spec.ModuleLoader.Container.Modprobe, moduleName),
+											Command: makeWaitingUnloadCommand(mod.Spec.ModuleLoader.Container.Modprobe, moduleName),
 										},
 									},
 								},
@@ -250,8 +588,9 @@ var _ = Describe("SetDriverContainerAsDesired", func() {
 							"has-feature-x": "true",
 							kernelLabel:     kernelVersion,
 						},
-						PriorityClassName:  "system-node-critical",
-						ServiceAccountName: serviceAccountName,
+						PriorityClassName:             "system-node-critical",
+						ServiceAccountName:            serviceAccountName,
+						TerminationGracePeriodSeconds: pointer.Int64(300),
 						Volumes: []v1.Volume{
 							{
 								Name: "node-lib-modules",
@@ -275,7 +614,7 @@ var _ = Describe("SetDriverContainerAsDesired", func() {
 		)
 	})
 
-	Describe("ModuleDaemonSetsByKernelVersion", func() {
+	Describe("ModuleDaemonSetsByKey", func() {
 		It("should return an empty map if no DaemonSets are present", func() {
 			clnt.EXPECT().List(context.Background(), gomock.Any(), gomock.Any())
 
@@ -288,7 +627,7 @@ var _ = Describe("SetDriverContainerAsDesired", func() {
 				},
 			}
 
-			m, err := dc.ModuleDaemonSetsByKernelVersion(context.Background(), mod.Name, mod.Namespace)
+			m, err := dc.ModuleDaemonSetsByKey(context.Background(), mod.Name, mod.Namespace)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(m).To(BeEmpty())
 		})
@@ -304,7 +643,7 @@ var _ = Describe("SetDriverContainerAsDesired", func() {
 				},
 			}
 
-			_, err := dc.ModuleDaemonSetsByKernelVersion(context.Background(), mod.Name, mod.Namespace)
+			_, err := dc.ModuleDaemonSetsByKey(context.Background(), mod.Name, mod.Namespace)
 			Expect(err).To(HaveOccurred())
 		})
 
@@ -349,11 +688,11 @@ var _ = Describe("SetDriverContainerAsDesired", func() {
 				},
 			}
 
-			m, err := dc.ModuleDaemonSetsByKernelVersion(context.Background(), mod.Name, mod.Namespace)
+			m, err := dc.ModuleDaemonSetsByKey(context.Background(), mod.Name, mod.Namespace)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(m).To(HaveLen(2))
-			Expect(m).To(HaveKeyWithValue(kernelVersion, &ds1))
-			Expect(m).To(HaveKeyWithValue(otherKernelVersion, &ds2))
+			Expect(m).To(HaveKeyWithValue(Key{KernelVersion: kernelVersion}, &ds1))
+			Expect(m).To(HaveKeyWithValue(Key{KernelVersion: otherKernelVersion}, &ds2))
 		})
 	})
 })
@@ -601,14 +940,14 @@ var _ = Describe("GarbageCollect", func() {
 
 		dc := NewCreator(clnt, kernelLabel, scheme)
 
-		existingDS := map[string]*appsv1.DaemonSet{
-			legitKernelVersion:    &dsLegit,
-			notLegitKernelVersion: &dsNotLegit,
+		existingDS := map[Key]*appsv1.DaemonSet{
+			{KernelVersion: legitKernelVersion}:    &dsLegit,
+			{KernelVersion: notLegitKernelVersion}: &dsNotLegit,
 		}
 
-		validKernels := sets.NewString(legitKernelVersion)
+		validKeys := map[Key]bool{{KernelVersion: legitKernelVersion}: true}
 
-		res, err := dc.GarbageCollect(context.Background(), existingDS, validKernels)
+		res, err := dc.GarbageCollect(context.Background(), existingDS, validKeys)
 		Expect(err).NotTo(HaveOccurred())
 		Expect(res).To(Equal([]string{notLegitName}))
 	})
@@ -624,16 +963,16 @@ var _ = Describe("GarbageCollect", func() {
 			ObjectMeta: metav1.ObjectMeta{Name: "name", Namespace: "namespace", Labels: map[string]string{kernelLabel: "kernel version"}},
 		}
 
-		existingDS := map[string]*appsv1.DaemonSet{
-			"some-kernel-version": &dsNotLegit,
+		existingDS := map[Key]*appsv1.DaemonSet{
+			{KernelVersion: "some-kernel-version"}: &dsNotLegit,
 		}
 
-		_, err := dc.GarbageCollect(context.Background(), existingDS, sets.NewString())
+		_, err := dc.GarbageCollect(context.Background(), existingDS, map[Key]bool{})
 		Expect(err).To(HaveOccurred())
 	})
 })
 
-var _ = Describe("ModuleDaemonSetsByKernelVersion", func() {
+var _ = Describe("ModuleDaemonSetsByKey", func() {
 	BeforeEach(func() {
 		ctrl = gomock.NewController(GinkgoT())
 		clnt = client.NewMockClient(ctrl)
@@ -644,7 +983,7 @@ var _ = Describe("ModuleDaemonSetsByKernelVersion", func() {
 
 		dc := NewCreator(clnt, kernelLabel, scheme)
 
-		m, err := dc.ModuleDaemonSetsByKernelVersion(context.Background(), moduleName, namespace)
+		m, err := dc.ModuleDaemonSetsByKey(context.Background(), moduleName, namespace)
 		Expect(err).NotTo(HaveOccurred())
 		Expect(m).To(BeEmpty())
 	})
@@ -680,7 +1019,7 @@ var _ = Describe("ModuleDaemonSetsByKernelVersion", func() {
 		)
 		dc := NewCreator(clnt, kernelLabel, scheme)
 
-		_, err := dc.ModuleDaemonSetsByKernelVersion(ctx, moduleName, namespace)
+		_, err := dc.ModuleDaemonSetsByKey(ctx, moduleName, namespace)
 		Expect(err).To(HaveOccurred())
 	})
 
@@ -720,11 +1059,81 @@ var _ = Describe("ModuleDaemonSetsByKernelVersion", func() {
 
 		dc := NewCreator(clnt, kernelLabel, scheme)
 
-		m, err := dc.ModuleDaemonSetsByKernelVersion(ctx, moduleName, namespace)
+		m, err := dc.ModuleDaemonSetsByKey(ctx, moduleName, namespace)
 		Expect(err).NotTo(HaveOccurred())
 		Expect(m).To(HaveLen(2))
-		Expect(m).To(HaveKeyWithValue(kernelVersion, &ds1))
-		Expect(m).To(HaveKeyWithValue(otherKernelVersion, &ds2))
+		Expect(m).To(HaveKeyWithValue(Key{KernelVersion: kernelVersion}, &ds1))
+		Expect(m).To(HaveKeyWithValue(Key{KernelVersion: otherKernelVersion}, &ds2))
+	})
+
+	It("should key DaemonSets by kernel and module version", func() {
+		dsLabels := func(version string) map[string]string {
+			return map[string]string{
+				"kmm.node.kubernetes.io/module.name":    moduleName,
+				"kmm.node.kubernetes.io/module.version": version,
+				kernelLabel:                             kernelVersion,
+			}
+		}
+
+		ds1 := appsv1.DaemonSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "ds1", Namespace: namespace, Labels: dsLabels("v1")},
+		}
+
+		ds2 := appsv1.DaemonSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "ds2", Namespace: namespace, Labels: dsLabels("v2")},
+		}
+
+		ctx := context.Background()
+
+		clnt.EXPECT().List(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ interface{}, list *appsv1.DaemonSetList, _ ...interface{}) error {
+				list.Items = []appsv1.DaemonSet{ds1, ds2}
+				return nil
+			},
+		)
+
+		dc := NewCreator(clnt, kernelLabel, scheme)
+
+		m, err := dc.ModuleDaemonSetsByKey(ctx, moduleName, namespace)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(m).To(HaveLen(2))
+		Expect(m).To(HaveKeyWithValue(Key{KernelVersion: kernelVersion, ModuleVersion: "v1"}, &ds1))
+		Expect(m).To(HaveKeyWithValue(Key{KernelVersion: kernelVersion, ModuleVersion: "v2"}, &ds2))
+	})
+
+	It("should key DaemonSets by architecture", func() {
+		dsLabels := func(arch string) map[string]string {
+			return map[string]string{
+				"kmm.node.kubernetes.io/module.name": moduleName,
+				constants.ArchitectureLabel:          arch,
+				kernelLabel:                          kernelVersion,
+			}
+		}
+
+		ds1 := appsv1.DaemonSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "ds1", Namespace: namespace, Labels: dsLabels("amd64")},
+		}
+
+		ds2 := appsv1.DaemonSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "ds2", Namespace: namespace, Labels: dsLabels("arm64")},
+		}
+
+		ctx := context.Background()
+
+		clnt.EXPECT().List(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ interface{}, list *appsv1.DaemonSetList, _ ...interface{}) error {
+				list.Items = []appsv1.DaemonSet{ds1, ds2}
+				return nil
+			},
+		)
+
+		dc := NewCreator(clnt, kernelLabel, scheme)
+
+		m, err := dc.ModuleDaemonSetsByKey(ctx, moduleName, namespace)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(m).To(HaveLen(2))
+		Expect(m).To(HaveKeyWithValue(Key{KernelVersion: kernelVersion, Architecture: "amd64"}, &ds1))
+		Expect(m).To(HaveKeyWithValue(Key{KernelVersion: kernelVersion, Architecture: "arm64"}, &ds2))
 	})
 
 	It("should include a map entry for device plugin", func() {
@@ -760,11 +1169,11 @@ var _ = Describe("ModuleDaemonSetsByKernelVersion", func() {
 
 		dc := NewCreator(clnt, kernelLabel, scheme)
 
-		m, err := dc.ModuleDaemonSetsByKernelVersion(context.Background(), moduleName, namespace)
+		m, err := dc.ModuleDaemonSetsByKey(context.Background(), moduleName, namespace)
 		Expect(err).NotTo(HaveOccurred())
 		Expect(m).To(HaveLen(2))
-		Expect(m).To(HaveKeyWithValue(kernelVersion, &ds1))
-		Expect(m).To(HaveKeyWithValue(devicePluginKernelVersion, &ds2))
+		Expect(m).To(HaveKeyWithValue(Key{KernelVersion: kernelVersion}, &ds1))
+		Expect(m).To(HaveKeyWithValue(Key{KernelVersion: devicePluginKernelVersion}, &ds2))
 	})
 })
 
@@ -928,6 +1337,29 @@ var _ = Describe("MakeLoadCommand", func() {
 			}),
 		)
 	})
+
+	It("should load several kernel modules in order", func() {
+		spec := kmmv1beta1.ModprobeSpec{
+			DirName:      "/some-dir",
+			FirmwarePath: "/kmm/firmware/mymodule",
+			Modules: []kmmv1beta1.ModprobeModule{
+				{Name: "kmod-a", Parameters: []string{"a=1", "b=2"}},
+				{Name: "kmod-b"},
+			},
+		}
+
+		Expect(
+			MakeLoadCommand(spec, moduleName),
+		).To(
+			Equal([]string{
+				"/bin/sh",
+				"-c",
+				"cp -r /kmm/firmware/mymodule/* /var/lib/firmware && { " +
+					`modprobe -v -d /some-dir kmod-a a=1 b=2 || { echo "could not load kernel module kmod-a"; exit 1; }; ` +
+					`modprobe -v -d /some-dir kmod-b || { echo "could not load kernel module kmod-b"; exit 1; }; }`,
+			}),
+		)
+	})
 })
 
 var _ = Describe("MakeUnloadCommand", func() {
@@ -1009,4 +1441,30 @@ var _ = Describe("MakeUnloadCommand", func() {
 			}),
 		)
 	})
+
+	It("should unload several kernel modules in the reverse order", func() {
+		spec := kmmv1beta1.ModprobeSpec{
+			Args: &kmmv1beta1.ModprobeArgs{
+				Unload: []string{"-r"},
+			},
+			FirmwarePath: "/kmm/firmware/mymodule",
+			Modules: []kmmv1beta1.ModprobeModule{
+				{Name: "kmod-a", Parameters: []string{"a=1"}},
+				{Name: "kmod-b"},
+			},
+		}
+
+		Expect(
+			MakeUnloadCommand(spec, moduleName),
+		).To(
+			Equal([]string{
+				"/bin/sh",
+				"-c",
+				"{ " +
+					`modprobe -r kmod-b || { echo "could not unload kernel module kmod-b"; exit 1; }; ` +
+					`modprobe -r kmod-a || { echo "could not unload kernel module kmod-a"; exit 1; }; }` +
+					" && cd /kmm/firmware/mymodule && find |sort -r |xargs -I{} rm -d /var/lib/firmware/{}",
+			}),
+		)
+	})
 })
@@ -0,0 +1,102 @@
+package dtk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/kubernetes-sigs/kernel-module-management/internal/constants"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/registry"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// BuildArg is the build argument that receives the Driver Toolkit image of the target kernel.
+	BuildArg = "DTK_AUTO"
+
+	// CatalogConfigMapEnvVar holds the namespace/name of the ConfigMap catalog used by the operator.
+	CatalogConfigMapEnvVar = "DTK_CATALOG_CONFIGMAP"
+)
+
+// ErrNoEntry is returned when the catalog has no entry for a kernel.
+var ErrNoEntry = errors.New("no Driver Toolkit entry found")
+
+//go:generate mockgen -source=dtk.go -package=dtk -destination=mock_dtk.go
+
+// Catalog looks up the Driver Toolkit entry of kernels.
+type Catalog interface {
+	GetEntry(ctx context.Context, kernelVersion string) (*registry.DriverToolkitEntry, error)
+}
+
+type configMapCatalog struct {
+	client client.Client
+	nsn    types.NamespacedName
+}
+
+// NewConfigMapCatalog returns a Catalog reading a YAML list of registry.DriverToolkitEntry from the
+// constants.DriverToolkitCMKey key of the ConfigMap namespace/name.
+// If name is empty, the catalog has no entries.
+func NewConfigMapCatalog(client client.Client, namespace, name string) Catalog {
+	return &configMapCatalog{
+		client: client,
+		nsn:    types.NamespacedName{Namespace: namespace, Name: name},
+	}
+}
+
+// NewConfigMapCatalogFromEnv returns the ConfigMap catalog named by the CatalogConfigMapEnvVar environment variable.
+// The catalog has no entries if the variable is not set.
+func NewConfigMapCatalogFromEnv(client client.Client) (Catalog, error) {
+	key := os.Getenv(CatalogConfigMapEnvVar)
+	if key == "" {
+		return NewConfigMapCatalog(client, "", ""), nil
+	}
+
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value %q for %s: %v", key, CatalogConfigMapEnvVar, err)
+	}
+
+	if namespace == "" {
+		return nil, fmt.Errorf("invalid value %q for %s: expected namespace/name", key, CatalogConfigMapEnvVar)
+	}
+
+	return NewConfigMapCatalog(client, namespace, name), nil
+}
+
+// GetEntry returns the entry whose kernel or RT kernel is kernelVersion.
+func (c *configMapCatalog) GetEntry(ctx context.Context, kernelVersion string) (*registry.DriverToolkitEntry, error) {
+	if c.nsn.Name == "" {
+		return nil, ErrNoEntry
+	}
+
+	cm := v1.ConfigMap{}
+
+	if err := c.client.Get(ctx, c.nsn, &cm); err != nil {
+		return nil, fmt.Errorf("could not get the Driver Toolkit ConfigMap %s: %v", c.nsn, err)
+	}
+
+	data, ok := cm.Data[constants.DriverToolkitCMKey]
+	if !ok {
+		return nil, fmt.Errorf("key %s is missing from ConfigMap %s", constants.DriverToolkitCMKey, c.nsn)
+	}
+
+	entries := make([]registry.DriverToolkitEntry, 0)
+
+	if err := yaml.NewYAMLOrJSONDecoder(strings.NewReader(data), len(data)).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("could not decode the entries of ConfigMap %s: %v", c.nsn, err)
+	}
+
+	for i, e := range entries {
+		if e.KernelFullVersion == kernelVersion || (e.RTKernelFullVersion != "" && e.RTKernelFullVersion == kernelVersion) {
+			return &entries[i], nil
+		}
+	}
+
+	return nil, ErrNoEntry
+}
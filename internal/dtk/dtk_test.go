@@ -0,0 +1,131 @@
+package dtk
+
+import (
+	"context"
+	"errors"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kubernetes-sigs/kernel-module-management/internal/client"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/constants"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/registry"
+)
+
+var _ = Describe("ConfigMapCatalog_GetEntry", func() {
+	const (
+		namespace = "kmm-operator"
+		name      = "dtk-catalog"
+	)
+
+	var (
+		ctrl    *gomock.Controller
+		clnt    *client.MockClient
+		catalog Catalog
+	)
+
+	ctx := context.Background()
+	nsn := types.NamespacedName{Namespace: namespace, Name: name}
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		clnt = client.NewMockClient(ctrl)
+		catalog = NewConfigMapCatalog(clnt, namespace, name)
+	})
+
+	expectConfigMap := func(data map[string]string) {
+		clnt.
+			EXPECT().
+			Get(ctx, nsn, &v1.ConfigMap{}).
+			DoAndReturn(func(_ interface{}, _ interface{}, cm *v1.ConfigMap, _ ...ctrlclient.GetOption) error {
+				cm.Data = data
+				return nil
+			})
+	}
+
+	const entries = `
+- imageURL: dtk@sha256:9.2
+  kernelFullVersion: 5.14.0-284.11.1.el9_2.x86_64
+  RTKernelFullVersion: 5.14.0-284.11.1.rt14.296.el9_2.x86_64
+  OSVersion: "9.2"
+- imageURL: dtk@sha256:8.6
+  kernelFullVersion: 4.18.0-372.52.1.el8_6.x86_64
+  OSVersion: "8.6"
+`
+
+	DescribeTable("should find the entry of a kernel",
+		func(kernelVersion, image string) {
+			expectConfigMap(map[string]string{constants.DriverToolkitCMKey: entries})
+
+			entry, err := catalog.GetEntry(ctx, kernelVersion)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entry.ImageURL).To(Equal(image))
+		},
+		Entry("regular kernel", "5.14.0-284.11.1.el9_2.x86_64", "dtk@sha256:9.2"),
+		Entry("RT kernel", "5.14.0-284.11.1.rt14.296.el9_2.x86_64", "dtk@sha256:9.2"),
+		Entry("other entry", "4.18.0-372.52.1.el8_6.x86_64", "dtk@sha256:8.6"),
+	)
+
+	It("should return ErrNoEntry for an unknown kernel", func() {
+		expectConfigMap(map[string]string{constants.DriverToolkitCMKey: entries})
+
+		_, err := catalog.GetEntry(ctx, "6.2.9-300.fc38.x86_64")
+		Expect(err).To(MatchError(ErrNoEntry))
+	})
+
+	It("should return the full entry", func() {
+		expectConfigMap(map[string]string{constants.DriverToolkitCMKey: entries})
+
+		entry, err := catalog.GetEntry(ctx, "4.18.0-372.52.1.el8_6.x86_64")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*entry).To(Equal(registry.DriverToolkitEntry{
+			ImageURL:          "dtk@sha256:8.6",
+			KernelFullVersion: "4.18.0-372.52.1.el8_6.x86_64",
+			OSVersion:         "8.6",
+		}))
+	})
+
+	It("should return an error if the key is missing", func() {
+		expectConfigMap(map[string]string{"some-key": entries})
+
+		_, err := catalog.GetEntry(ctx, "4.18.0-372.52.1.el8_6.x86_64")
+		Expect(err).To(HaveOccurred())
+		Expect(err).NotTo(MatchError(ErrNoEntry))
+	})
+
+	It("should return an error if the ConfigMap cannot be fetched", func() {
+		clnt.EXPECT().Get(ctx, nsn, &v1.ConfigMap{}).Return(errors.New("some error"))
+
+		_, err := catalog.GetEntry(ctx, "4.18.0-372.52.1.el8_6.x86_64")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should have no entries without a ConfigMap", func() {
+		_, err := NewConfigMapCatalog(clnt, "", "").GetEntry(ctx, "4.18.0-372.52.1.el8_6.x86_64")
+		Expect(err).To(MatchError(ErrNoEntry))
+	})
+})
+
+var _ = Describe("NewConfigMapCatalogFromEnv", func() {
+	DescribeTable("should parse the environment variable",
+		func(value string, expectError bool) {
+			GinkgoT().Setenv(CatalogConfigMapEnvVar, value)
+
+			_, err := NewConfigMapCatalogFromEnv(nil)
+
+			if expectError {
+				Expect(err).To(HaveOccurred())
+			} else {
+				Expect(err).NotTo(HaveOccurred())
+			}
+		},
+		Entry("unset", "", false),
+		Entry("namespace/name", "kmm-operator/dtk-catalog", false),
+		Entry("name only", "dtk-catalog", true),
+		Entry("too many slashes", "a/b/c", true),
+	)
+})
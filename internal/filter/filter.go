@@ -6,10 +6,11 @@ import (
 	"github.com/go-logr/logr"
 	hubv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api-hub/v1beta1"
 	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/module"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/labels"
-	"k8s.io/apimachinery/pkg/selection"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/kubectl/pkg/util/podutils"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/event"
@@ -96,20 +97,14 @@ func (f *Filter) FindModulesForNode(node client.Object) []reconcile.Request {
 
 		logger.V(1).Info("Processing module")
 
-		sel := labels.NewSelector()
-
-		for k, v := range mod.Spec.Selector {
-			logger.V(1).Info("Processing selector item", "key", k, "value", v)
-
-			requirement, err := labels.NewRequirement(k, selection.Equals, []string{v})
-			if err != nil {
-				logger.Error(err, "could not generate requirement")
-				return reqs
-			}
-
-			sel = sel.Add(*requirement)
+		sel, err := module.NodeSelector(mod.Spec)
+		if err != nil {
+			logger.Error(err, "could not generate the node selector")
+			continue
 		}
 
+		logger.V(1).Info("Processing selector", "selector", sel.String())
+
 		if !sel.Matches(nodeLabelsSet) {
 			logger.V(1).Info("Node labels do not match the module's selector; skipping")
 			continue
@@ -149,20 +144,14 @@ func (f *Filter) FindManagedClusterModulesForCluster(cluster client.Object) []re
 
 		logger.V(1).Info("Processing ManagedClusterModule")
 
-		sel := labels.NewSelector()
-
-		for k, v := range mod.Spec.Selector {
-			logger.V(1).Info("Processing selector item", "key", k, "value", v)
-
-			requirement, err := labels.NewRequirement(k, selection.Equals, []string{v})
-			if err != nil {
-				logger.Error(err, "could not generate requirement")
-				return reqs
-			}
-
-			sel = sel.Add(*requirement)
+		sel, err := module.Selector(mod.Spec.Selector, mod.Spec.LabelSelector)
+		if err != nil {
+			logger.Error(err, "could not generate the cluster selector")
+			continue
 		}
 
+		logger.V(1).Info("Processing selector", "selector", sel.String())
+
 		if !sel.Matches(clusterLabelsSet) {
 			logger.V(1).Info("Cluster labels do not match the ManagedClusterModule's selector; skipping")
 			continue
@@ -201,6 +190,53 @@ func (f *Filter) EnqueueAllPreflightValidations(mod client.Object) []reconcile.R
 	return reqs
 }
 
+// FindModulesForConfigMap returns reconciliation requests for all the Modules referencing cm.
+func (f *Filter) FindModulesForConfigMap(cm client.Object) []reconcile.Request {
+	return f.findModulesReferencing(cm, module.ConfigMapsIndexKey)
+}
+
+// FindModulesForSecret returns reconciliation requests for all the Modules referencing secret.
+func (f *Filter) FindModulesForSecret(secret client.Object) []reconcile.Request {
+	return f.findModulesReferencing(secret, module.SecretsIndexKey)
+}
+
+func (f *Filter) findModulesReferencing(obj client.Object, indexKey string) []reconcile.Request {
+	logger := f.logger.WithValues("name", obj.GetName(), "namespace", obj.GetNamespace())
+
+	reqs := make([]reconcile.Request, 0)
+	seen := sets.NewString()
+
+	// Modules referencing templated names may reference obj once the variables of the nodes are substituted.
+	for _, value := range []string{obj.GetName(), module.TemplatedReference} {
+		mods := kmmv1beta1.ModuleList{}
+
+		opts := []client.ListOption{
+			client.InNamespace(obj.GetNamespace()),
+			client.MatchingFields{indexKey: value},
+		}
+
+		if err := f.client.List(context.Background(), &mods, opts...); err != nil {
+			logger.Error(err, "could not list modules", "index", indexKey, "value", value)
+			continue
+		}
+
+		for _, mod := range mods.Items {
+			if seen.Has(mod.Name) {
+				continue
+			}
+
+			seen.Insert(mod.Name)
+
+			nsn := types.NamespacedName{Name: mod.Name, Namespace: mod.Namespace}
+			reqs = append(reqs, reconcile.Request{NamespacedName: nsn})
+		}
+	}
+
+	logger.V(1).Info("Modules referencing the object", "index", indexKey, "requests", reqs)
+
+	return reqs
+}
+
 // DeletingPredicate returns a predicate that returns true if the object is being deleted.
 func DeletingPredicate() predicate.Predicate {
 	return predicate.NewPredicateFuncs(func(object client.Object) bool {
@@ -2,12 +2,14 @@ package filter
 
 import (
 	"context"
+	"errors"
 
 	"github.com/go-logr/logr"
 	"github.com/golang/mock/gomock"
 	hubv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api-hub/v1beta1"
 	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
 	mockClient "github.com/kubernetes-sigs/kernel-module-management/internal/client"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/module"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	appsv1 "k8s.io/api/apps/v1"
@@ -339,6 +341,171 @@ var _ = Describe("FindModulesForNode", func() {
 		reqs := p.FindModulesForNode(&node)
 		Expect(reqs).To(Equal([]reconcile.Request{expectedReq}))
 	})
+
+	It("should use the set-based selector", func() {
+		node := v1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{"key": "value", "zone": "a"},
+			},
+		}
+
+		matchingMod := kmmv1beta1.Module{
+			ObjectMeta: metav1.ObjectMeta{Name: "matching-mod"},
+			Spec: kmmv1beta1.ModuleSpec{
+				Selector: map[string]string{"key": "value"},
+				LabelSelector: &metav1.LabelSelector{
+					MatchExpressions: []metav1.LabelSelectorRequirement{
+						{Key: "zone", Operator: metav1.LabelSelectorOpIn, Values: []string{"a", "b"}},
+					},
+				},
+			},
+		}
+
+		mod := kmmv1beta1.Module{
+			ObjectMeta: metav1.ObjectMeta{Name: "mod"},
+			Spec: kmmv1beta1.ModuleSpec{
+				LabelSelector: &metav1.LabelSelector{
+					MatchExpressions: []metav1.LabelSelectorRequirement{
+						{Key: "zone", Operator: metav1.LabelSelectorOpNotIn, Values: []string{"a"}},
+					},
+				},
+			},
+		}
+
+		invalidMod := kmmv1beta1.Module{
+			ObjectMeta: metav1.ObjectMeta{Name: "invalid-mod"},
+			Spec: kmmv1beta1.ModuleSpec{
+				LabelSelector: &metav1.LabelSelector{
+					MatchExpressions: []metav1.LabelSelectorRequirement{
+						{Key: "zone", Operator: metav1.LabelSelectorOpIn},
+					},
+				},
+			},
+		}
+
+		clnt.EXPECT().List(context.Background(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ interface{}, list *kmmv1beta1.ModuleList, _ ...interface{}) error {
+				list.Items = []kmmv1beta1.Module{invalidMod, matchingMod, mod}
+				return nil
+			},
+		)
+
+		p := New(clnt, logr.Discard())
+
+		expectedReq := reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: matchingMod.Name},
+		}
+
+		reqs := p.FindModulesForNode(&node)
+		Expect(reqs).To(Equal([]reconcile.Request{expectedReq}))
+	})
+})
+
+var _ = Describe("FindModulesForConfigMap", func() {
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		clnt = mockClient.NewMockClient(ctrl)
+	})
+
+	It("should return the Modules referencing the ConfigMap", func() {
+		const (
+			cmName    = "some-configmap"
+			namespace = "some-namespace"
+		)
+
+		cm := v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: cmName, Namespace: namespace},
+		}
+
+		clnt.EXPECT().List(
+			context.Background(),
+			&kmmv1beta1.ModuleList{},
+			client.InNamespace(namespace),
+			client.MatchingFields{module.ConfigMapsIndexKey: cmName},
+		).DoAndReturn(
+			func(_ interface{}, list *kmmv1beta1.ModuleList, _ ...interface{}) error {
+				list.Items = []kmmv1beta1.Module{
+					{ObjectMeta: metav1.ObjectMeta{Name: "mod", Namespace: namespace}},
+				}
+				return nil
+			},
+		)
+
+		clnt.EXPECT().List(
+			context.Background(),
+			&kmmv1beta1.ModuleList{},
+			client.InNamespace(namespace),
+			client.MatchingFields{module.ConfigMapsIndexKey: module.TemplatedReference},
+		).DoAndReturn(
+			func(_ interface{}, list *kmmv1beta1.ModuleList, _ ...interface{}) error {
+				list.Items = []kmmv1beta1.Module{
+					{ObjectMeta: metav1.ObjectMeta{Name: "mod", Namespace: namespace}},
+					{ObjectMeta: metav1.ObjectMeta{Name: "templated-mod", Namespace: namespace}},
+				}
+				return nil
+			},
+		)
+
+		p := New(clnt, logr.Discard())
+
+		Expect(
+			p.FindModulesForConfigMap(&cm),
+		).To(
+			Equal([]reconcile.Request{
+				{NamespacedName: types.NamespacedName{Name: "mod", Namespace: namespace}},
+				{NamespacedName: types.NamespacedName{Name: "templated-mod", Namespace: namespace}},
+			}),
+		)
+	})
+
+	It("should return nothing if the Modules could not be listed", func() {
+		clnt.EXPECT().List(context.Background(), gomock.Any(), gomock.Any()).Return(errors.New("some error")).Times(2)
+
+		p := New(clnt, logr.Discard())
+
+		Expect(
+			p.FindModulesForConfigMap(&v1.ConfigMap{}),
+		).To(
+			BeEmpty(),
+		)
+	})
+})
+
+var _ = Describe("FindModulesForSecret", func() {
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		clnt = mockClient.NewMockClient(ctrl)
+	})
+
+	It("should list the Modules using the Secrets index", func() {
+		const namespace = "some-namespace"
+
+		secret := v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "some-secret", Namespace: namespace},
+		}
+
+		clnt.EXPECT().List(
+			context.Background(),
+			&kmmv1beta1.ModuleList{},
+			client.InNamespace(namespace),
+			client.MatchingFields{module.SecretsIndexKey: "some-secret"},
+		)
+
+		clnt.EXPECT().List(
+			context.Background(),
+			&kmmv1beta1.ModuleList{},
+			client.InNamespace(namespace),
+			client.MatchingFields{module.SecretsIndexKey: module.TemplatedReference},
+		)
+
+		p := New(clnt, logr.Discard())
+
+		Expect(
+			p.FindModulesForSecret(&secret),
+		).To(
+			BeEmpty(),
+		)
+	})
 })
 
 var _ = Describe("FindManagedClusterModulesForCluster", func() {
@@ -420,6 +587,52 @@ var _ = Describe("FindManagedClusterModulesForCluster", func() {
 		reqs := p.FindManagedClusterModulesForCluster(&cluster)
 		Expect(reqs).To(Equal([]reconcile.Request{expectedReq}))
 	})
+
+	It("should use the set-based selector", func() {
+		cluster := clusterv1.ManagedCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{"env": "prod"},
+			},
+		}
+
+		matchingMod := hubv1beta1.ManagedClusterModule{
+			ObjectMeta: metav1.ObjectMeta{Name: "matching-mod"},
+			Spec: hubv1beta1.ManagedClusterModuleSpec{
+				LabelSelector: &metav1.LabelSelector{
+					MatchExpressions: []metav1.LabelSelectorRequirement{
+						{Key: "env", Operator: metav1.LabelSelectorOpIn, Values: []string{"prod", "staging"}},
+					},
+				},
+			},
+		}
+
+		mod := hubv1beta1.ManagedClusterModule{
+			ObjectMeta: metav1.ObjectMeta{Name: "mod"},
+			Spec: hubv1beta1.ManagedClusterModuleSpec{
+				LabelSelector: &metav1.LabelSelector{
+					MatchExpressions: []metav1.LabelSelectorRequirement{
+						{Key: "env", Operator: metav1.LabelSelectorOpDoesNotExist},
+					},
+				},
+			},
+		}
+
+		clnt.EXPECT().List(context.Background(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ interface{}, list *hubv1beta1.ManagedClusterModuleList, _ ...interface{}) error {
+				list.Items = []hubv1beta1.ManagedClusterModule{matchingMod, mod}
+				return nil
+			},
+		)
+
+		p := New(clnt, logr.Discard())
+
+		expectedReq := reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: matchingMod.Name},
+		}
+
+		reqs := p.FindManagedClusterModulesForCluster(&cluster)
+		Expect(reqs).To(Equal([]reconcile.Request{expectedReq}))
+	})
 })
 
 var _ = Describe("DeletingPredicate", func() {
@@ -0,0 +1,290 @@
+package joblogs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/types"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/kubernetes-sigs/kernel-module-management/internal/constants"
+)
+
+const (
+	// RetentionEnvVar holds the duration for which failed jobs and their logs are kept.
+	RetentionEnvVar = "FAILED_JOB_RETENTION"
+
+	// DefaultRetention is used when RetentionEnvVar is not set.
+	DefaultRetention = 24 * time.Hour
+
+	// EventReasonJobFailed is the reason of the Events emitted when logs are collected.
+	EventReasonJobFailed = "JobFailed"
+
+	// tailLines is the number of lines kept from the log of each failed container.
+	tailLines = 100
+
+	jobNameLabel = "job-name"
+
+	// JobNameIndexKey is the field index of the name of the Job that created a pod.
+	JobNameIndexKey = "metadata.labels.job-name"
+)
+
+//go:generate mockgen -source=joblogs.go -package=joblogs -destination=mock_joblogs.go
+
+// Collector saves the logs of failed build and signing containers before their pods are deleted.
+type Collector interface {
+	// Collect saves the last lines of the log of each failed container of job in a ConfigMap controlled by owner,
+	// and emits an Event on owner when new failures are saved.
+	// It returns the name of the ConfigMap, or an empty string if no container of job has failed.
+	Collect(ctx context.Context, job *batchv1.Job, owner metav1.Object) (string, error)
+
+	// Delete deletes the ConfigMap holding the logs of job, if any.
+	Delete(ctx context.Context, job *batchv1.Job) error
+}
+
+type collector struct {
+	client   client.Client
+	pods     corev1client.PodsGetter
+	recorder record.EventRecorder
+	scheme   *runtime.Scheme
+}
+
+// NewCollector returns a Collector that lists pods and manages the ConfigMaps through client, and reads the logs of
+// containers through pods.
+// Pods are listed through JobNameIndexKey, which must be registered in the cache of client.
+func NewCollector(client client.Client, pods corev1client.PodsGetter, recorder record.EventRecorder, scheme *runtime.Scheme) Collector {
+	return &collector{
+		client:   client,
+		pods:     pods,
+		recorder: recorder,
+		scheme:   scheme,
+	}
+}
+
+// IndexJobName is a client.IndexerFunc for JobNameIndexKey.
+func IndexJobName(obj client.Object) []string {
+	name, ok := obj.GetLabels()[jobNameLabel]
+	if !ok {
+		return nil
+	}
+
+	return []string{name}
+}
+
+// JobPodsSelector selects the pods created by Jobs, which are the only pods listed by a Collector; it can scope the
+// pod cache of its client.
+func JobPodsSelector() (labels.Selector, error) {
+	req, err := labels.NewRequirement(jobNameLabel, selection.Exists, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not create the requirement on the %s label: %v", jobNameLabel, err)
+	}
+
+	return labels.NewSelector().Add(*req), nil
+}
+
+// ConfigMapName returns the name of the ConfigMap holding the logs of the job named jobName.
+func ConfigMapName(jobName string) string {
+	return jobName + "-logs"
+}
+
+// RetentionFromEnv returns the duration set in RetentionEnvVar, or DefaultRetention if it is not set.
+func RetentionFromEnv() (time.Duration, error) {
+	s := os.Getenv(RetentionEnvVar)
+	if s == "" {
+		return DefaultRetention, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q for %s: %v", s, RetentionEnvVar, err)
+	}
+
+	return d, nil
+}
+
+type failedContainer struct {
+	pod          string
+	container    string
+	restartCount int32
+	// previous is true if the failed run was followed by a restart of the container
+	previous bool
+}
+
+func (c *collector) Collect(ctx context.Context, job *batchv1.Job, owner metav1.Object) (string, error) {
+	pods := v1.PodList{}
+
+	opts := []client.ListOption{
+		client.InNamespace(job.Namespace),
+		client.MatchingFields{JobNameIndexKey: job.Name},
+	}
+
+	// Collect runs on every reconciliation of unfinished jobs, so pods are listed from the cache
+	if err := c.client.List(ctx, &pods, opts...); err != nil {
+		return "", fmt.Errorf("could not list the pods of job %s: %v", job.Name, err)
+	}
+
+	failed := failedContainers(pods.Items)
+
+	cm := v1.ConfigMap{}
+	nsn := types.NamespacedName{Name: ConfigMapName(job.Name), Namespace: job.Namespace}
+
+	exists := true
+
+	if err := c.client.Get(ctx, nsn, &cm); err != nil {
+		if !k8serrors.IsNotFound(err) {
+			return "", fmt.Errorf("could not get ConfigMap %s: %v", nsn, err)
+		}
+
+		exists = false
+	}
+
+	// pods may be deleted before their job, e.g. when its deadline is exceeded; keep what was collected before
+	if len(failed) == 0 {
+		if !exists {
+			return "", nil
+		}
+
+		return nsn.Name, nil
+	}
+
+	collected := collectedContainers(failed)
+
+	if exists && cm.Annotations[constants.JobLogsAnnotation] == collected {
+		return nsn.Name, nil
+	}
+
+	data := make(map[string]string, len(failed))
+
+	for _, fc := range failed {
+		opts := v1.PodLogOptions{
+			Container: fc.container,
+			Previous:  fc.previous,
+			TailLines: pointer.Int64(tailLines),
+		}
+
+		b, err := c.pods.Pods(job.Namespace).GetLogs(fc.pod, &opts).DoRaw(ctx)
+		if err != nil {
+			return "", fmt.Errorf("could not get the logs of container %s in pod %s: %v", fc.container, fc.pod, err)
+		}
+
+		data[fc.container+".log"] = string(b)
+	}
+
+	cm.Data = data
+	metav1.SetMetaDataAnnotation(&cm.ObjectMeta, constants.JobLogsAnnotation, collected)
+
+	if exists {
+		if err := c.client.Update(ctx, &cm); err != nil {
+			return "", fmt.Errorf("could not update ConfigMap %s: %v", nsn, err)
+		}
+	} else {
+		cm.Name = nsn.Name
+		cm.Namespace = nsn.Namespace
+		cm.Labels = job.Labels
+
+		if err := controllerutil.SetControllerReference(owner, &cm, c.scheme); err != nil {
+			return "", fmt.Errorf("could not set the owner of ConfigMap %s: %v", nsn, err)
+		}
+
+		if err := c.client.Create(ctx, &cm); err != nil {
+			return "", fmt.Errorf("could not create ConfigMap %s: %v", nsn, err)
+		}
+	}
+
+	log.FromContext(ctx).Info("Collected the logs of failed containers", "job", job.Name, "configmap", nsn.Name)
+
+	if obj, ok := owner.(runtime.Object); ok {
+		c.recorder.Eventf(
+			obj,
+			v1.EventTypeWarning,
+			EventReasonJobFailed,
+			"Containers of %s job %s failed; their logs are in ConfigMap %s",
+			job.Labels[constants.JobType],
+			job.Name,
+			nsn.Name,
+		)
+	}
+
+	return nsn.Name, nil
+}
+
+func (c *collector) Delete(ctx context.Context, job *batchv1.Job) error {
+	cm := v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ConfigMapName(job.Name),
+			Namespace: job.Namespace,
+		},
+	}
+
+	if err := c.client.Delete(ctx, &cm); err != nil && !k8serrors.IsNotFound(err) {
+		return fmt.Errorf("could not delete ConfigMap %s: %v", cm.Name, err)
+	}
+
+	return nil
+}
+
+// failedContainers returns the init and regular containers of pods whose last run exited with a non-zero code.
+// Pods are processed from the oldest to the newest, so that the newest failure of each container comes last.
+func failedContainers(pods []v1.Pod) []failedContainer {
+	sort.Slice(pods, func(i, j int) bool {
+		return pods[i].CreationTimestamp.Before(&pods[j].CreationTimestamp)
+	})
+
+	failed := make([]failedContainer, 0)
+
+	for _, p := range pods {
+		statuses := make([]v1.ContainerStatus, 0, len(p.Status.InitContainerStatuses)+len(p.Status.ContainerStatuses))
+		statuses = append(statuses, p.Status.InitContainerStatuses...)
+		statuses = append(statuses, p.Status.ContainerStatuses...)
+
+		for _, cs := range statuses {
+			fc := failedContainer{pod: p.Name, container: cs.Name, restartCount: cs.RestartCount}
+
+			switch {
+			case cs.State.Terminated != nil:
+				if cs.State.Terminated.ExitCode == 0 {
+					continue
+				}
+			case cs.LastTerminationState.Terminated != nil:
+				if cs.LastTerminationState.Terminated.ExitCode == 0 {
+					continue
+				}
+
+				fc.previous = true
+			default:
+				continue
+			}
+
+			failed = append(failed, fc)
+		}
+	}
+
+	return failed
+}
+
+// collectedContainers identifies the failed runs of containers, so that their logs are only collected once.
+func collectedContainers(failed []failedContainer) string {
+	ids := make([]string, 0, len(failed))
+
+	for _, fc := range failed {
+		ids = append(ids, fmt.Sprintf("%s/%s/%d", fc.pod, fc.container, fc.restartCount))
+	}
+
+	return strings.Join(ids, ",")
+}
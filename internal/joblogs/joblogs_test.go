@@ -0,0 +1,310 @@
+package joblogs
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/client"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/constants"
+)
+
+var _ = Describe("Collect", func() {
+	const (
+		jobName   = "some-module-build-abcde"
+		namespace = "some-namespace"
+	)
+
+	var (
+		ctrl     *gomock.Controller
+		clnt     *client.MockClient
+		recorder *record.FakeRecorder
+		job      batchv1.Job
+		mod      kmmv1beta1.Module
+	)
+
+	ctx := context.Background()
+	nsn := types.NamespacedName{Name: jobName + "-logs", Namespace: namespace}
+	notFound := k8serrors.NewNotFound(schema.GroupResource{}, nsn.Name)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		clnt = client.NewMockClient(ctrl)
+		recorder = record.NewFakeRecorder(10)
+
+		job = batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      jobName,
+				Namespace: namespace,
+				Labels:    map[string]string{constants.JobType: "build"},
+			},
+		}
+
+		mod = kmmv1beta1.Module{
+			TypeMeta:   metav1.TypeMeta{APIVersion: kmmv1beta1.GroupVersion.String(), Kind: "Module"},
+			ObjectMeta: metav1.ObjectMeta{Name: "some-module", Namespace: namespace},
+		}
+	})
+
+	makePod := func(name string, created time.Time, init, containers []v1.ContainerStatus) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         namespace,
+				Labels:            map[string]string{"job-name": jobName},
+				CreationTimestamp: metav1.NewTime(created),
+			},
+			Status: v1.PodStatus{
+				InitContainerStatuses: init,
+				ContainerStatuses:     containers,
+			},
+		}
+	}
+
+	listPods := func(pods ...*v1.Pod) *gomock.Call {
+		return clnt.
+			EXPECT().
+			List(ctx, &v1.PodList{}, ctrlclient.InNamespace(namespace), ctrlclient.MatchingFields{JobNameIndexKey: jobName}).
+			DoAndReturn(func(_ interface{}, pl *v1.PodList, _ ...ctrlclient.ListOption) error {
+				for _, p := range pods {
+					pl.Items = append(pl.Items, *p)
+				}
+				return nil
+			})
+	}
+
+	terminated := func(exitCode int32) v1.ContainerState {
+		return v1.ContainerState{Terminated: &v1.ContainerStateTerminated{ExitCode: exitCode}}
+	}
+
+	It("should do nothing if no container has failed", func() {
+		pod := makePod("pod", time.Now(), nil, []v1.ContainerStatus{{Name: "kaniko"}})
+		c := NewCollector(clnt, fake.NewSimpleClientset().CoreV1(), recorder, scheme)
+
+		listPods(pod)
+		clnt.EXPECT().Get(ctx, nsn, &v1.ConfigMap{}).Return(notFound)
+
+		name, err := c.Collect(ctx, &job, &mod)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(name).To(BeEmpty())
+		Expect(recorder.Events).To(BeEmpty())
+	})
+
+	It("should keep the logs collected before the pods were deleted", func() {
+		c := NewCollector(clnt, fake.NewSimpleClientset().CoreV1(), recorder, scheme)
+
+		listPods()
+		clnt.EXPECT().Get(ctx, nsn, &v1.ConfigMap{})
+
+		name, err := c.Collect(ctx, &job, &mod)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(name).To(Equal(nsn.Name))
+		Expect(recorder.Events).To(BeEmpty())
+	})
+
+	It("should save the logs of failed containers in a new ConfigMap", func() {
+		pods := []*v1.Pod{
+			makePod(
+				"new-pod",
+				time.Now(),
+				[]v1.ContainerStatus{{Name: "git-clone", State: terminated(0)}},
+				[]v1.ContainerStatus{{Name: "kaniko", RestartCount: 2, LastTerminationState: terminated(1)}},
+			),
+			makePod(
+				"old-pod",
+				time.Now().Add(-time.Hour),
+				nil,
+				[]v1.ContainerStatus{{Name: "kaniko", State: terminated(137)}},
+			),
+		}
+
+		c := NewCollector(clnt, fake.NewSimpleClientset().CoreV1(), recorder, scheme)
+
+		gomock.InOrder(
+			listPods(pods[0], pods[1]),
+			clnt.EXPECT().Get(ctx, nsn, &v1.ConfigMap{}).Return(notFound),
+			clnt.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(
+				func(_ interface{}, cm *v1.ConfigMap, _ ...ctrlclient.CreateOption) error {
+					Expect(cm.Name).To(Equal(nsn.Name))
+					Expect(cm.Namespace).To(Equal(namespace))
+					Expect(cm.Labels).To(Equal(job.Labels))
+					Expect(cm.Annotations).To(HaveKeyWithValue(constants.JobLogsAnnotation, "old-pod/kaniko/0,new-pod/kaniko/2"))
+					Expect(cm.Data).To(Equal(map[string]string{"kaniko.log": "fake logs"}))
+					Expect(metav1.IsControlledBy(cm, &mod)).To(BeTrue())
+					return nil
+				},
+			),
+		)
+
+		name, err := c.Collect(ctx, &job, &mod)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(name).To(Equal(nsn.Name))
+		Expect(recorder.Events).To(Receive(ContainSubstring(EventReasonJobFailed)))
+	})
+
+	It("should not collect the same failures twice", func() {
+		pod := makePod("pod", time.Now(), nil, []v1.ContainerStatus{{Name: "kaniko", State: terminated(1)}})
+		c := NewCollector(clnt, fake.NewSimpleClientset().CoreV1(), recorder, scheme)
+
+		listPods(pod)
+		clnt.
+			EXPECT().
+			Get(ctx, nsn, &v1.ConfigMap{}).
+			DoAndReturn(func(_ interface{}, _ interface{}, cm *v1.ConfigMap, _ ...ctrlclient.GetOption) error {
+				cm.Annotations = map[string]string{constants.JobLogsAnnotation: "pod/kaniko/0"}
+				return nil
+			})
+
+		name, err := c.Collect(ctx, &job, &mod)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(name).To(Equal(nsn.Name))
+		Expect(recorder.Events).To(BeEmpty())
+	})
+
+	It("should update the ConfigMap with new failures", func() {
+		pod := makePod("pod", time.Now(), nil, []v1.ContainerStatus{{Name: "kaniko", RestartCount: 1, LastTerminationState: terminated(1)}})
+		c := NewCollector(clnt, fake.NewSimpleClientset().CoreV1(), recorder, scheme)
+
+		gomock.InOrder(
+			listPods(pod),
+			clnt.
+				EXPECT().
+				Get(ctx, nsn, &v1.ConfigMap{}).
+				DoAndReturn(func(_ interface{}, _ interface{}, cm *v1.ConfigMap, _ ...ctrlclient.GetOption) error {
+					cm.Annotations = map[string]string{constants.JobLogsAnnotation: "pod/kaniko/0"}
+					return nil
+				}),
+			clnt.EXPECT().Update(ctx, gomock.Any()).DoAndReturn(
+				func(_ interface{}, cm *v1.ConfigMap, _ ...ctrlclient.UpdateOption) error {
+					Expect(cm.Annotations).To(HaveKeyWithValue(constants.JobLogsAnnotation, "pod/kaniko/1"))
+					return nil
+				},
+			),
+		)
+
+		_, err := c.Collect(ctx, &job, &mod)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(recorder.Events).To(HaveLen(1))
+	})
+
+	It("should return an error if the pods cannot be listed", func() {
+		c := NewCollector(clnt, fake.NewSimpleClientset().CoreV1(), recorder, scheme)
+
+		listPods().Return(errors.New("some error"))
+
+		_, err := c.Collect(ctx, &job, &mod)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should return an error if the ConfigMap cannot be fetched", func() {
+		c := NewCollector(clnt, fake.NewSimpleClientset().CoreV1(), recorder, scheme)
+
+		listPods()
+		clnt.EXPECT().Get(ctx, nsn, &v1.ConfigMap{}).Return(errors.New("some error"))
+
+		_, err := c.Collect(ctx, &job, &mod)
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("Delete", func() {
+	var (
+		ctrl *gomock.Controller
+		clnt *client.MockClient
+		c    Collector
+	)
+
+	ctx := context.Background()
+
+	job := batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: "some-job", Namespace: "some-namespace"},
+	}
+
+	cm := v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "some-job-logs", Namespace: "some-namespace"},
+	}
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		clnt = client.NewMockClient(ctrl)
+		c = NewCollector(clnt, nil, nil, scheme)
+	})
+
+	It("should delete the ConfigMap", func() {
+		clnt.EXPECT().Delete(ctx, &cm)
+
+		Expect(c.Delete(ctx, &job)).To(Succeed())
+	})
+
+	It("should ignore missing ConfigMaps", func() {
+		clnt.EXPECT().Delete(ctx, &cm).Return(k8serrors.NewNotFound(schema.GroupResource{}, cm.Name))
+
+		Expect(c.Delete(ctx, &job)).To(Succeed())
+	})
+
+	It("should return other errors", func() {
+		clnt.EXPECT().Delete(ctx, &cm).Return(errors.New("some error"))
+
+		Expect(c.Delete(ctx, &job)).NotTo(Succeed())
+	})
+})
+
+var _ = Describe("IndexJobName", func() {
+	It("should index pods by the name of their Job", func() {
+		pod := v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"job-name": "some-job"}},
+		}
+
+		Expect(IndexJobName(&pod)).To(Equal([]string{"some-job"}))
+	})
+
+	It("should not index the pods of no Job", func() {
+		Expect(IndexJobName(&v1.Pod{})).To(BeEmpty())
+	})
+})
+
+var _ = Describe("JobPodsSelector", func() {
+	It("should only select the pods of Jobs", func() {
+		sel, err := JobPodsSelector()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(sel.Matches(labels.Set{"job-name": "some-job"})).To(BeTrue())
+		Expect(sel.Matches(labels.Set{"app": "some-app"})).To(BeFalse())
+	})
+})
+
+var _ = Describe("RetentionFromEnv", func() {
+	It("should return the default retention", func() {
+		GinkgoT().Setenv(RetentionEnvVar, "")
+
+		Expect(RetentionFromEnv()).To(Equal(DefaultRetention))
+	})
+
+	It("should parse the duration", func() {
+		GinkgoT().Setenv(RetentionEnvVar, "90m")
+
+		Expect(RetentionFromEnv()).To(Equal(90 * time.Minute))
+	})
+
+	It("should return an error for invalid durations", func() {
+		GinkgoT().Setenv(RetentionEnvVar, "forever")
+
+		_, err := RetentionFromEnv()
+		Expect(err).To(HaveOccurred())
+	})
+})
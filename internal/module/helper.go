@@ -30,6 +30,17 @@ func AppendToTag(name string, tag string) string {
 	return name + separator + tag
 }
 
+// FailedJobHash returns the FailedJobHash recorded in the status of mod for targetKernel and targetArch, if any.
+func FailedJobHash(mod *kmmv1beta1.Module, targetKernel, targetArch string) string {
+	for _, kvs := range mod.Status.KernelVersions {
+		if kvs.KernelVersion == targetKernel && kvs.Architecture == targetArch {
+			return kvs.FailedJobHash
+		}
+	}
+
+	return ""
+}
+
 // IntermediateImageName returns the image name of the pre-signed module image name
 func IntermediateImageName(name, namespace, targetImage string) string {
 	return AppendToTag(targetImage, namespace+"_"+name+"_kmm_unsigned")
@@ -47,6 +58,8 @@ func ShouldBeSigned(modSpec kmmv1beta1.ModuleSpec, km kmmv1beta1.KernelMapping)
 	return modSpec.ModuleLoader.Container.Sign != nil || km.Sign != nil
 }
 
+// ImageExists returns true if the image exists for the nodes of architecture arch, using the pull secret and TLS
+// options of the Module. An empty arch is the operator's.
 func ImageExists(
 	ctx context.Context,
 	client client.Client,
@@ -54,21 +67,66 @@ func ImageExists(
 	modSpec kmmv1beta1.ModuleSpec,
 	namespace string,
 	km kmmv1beta1.KernelMapping,
-	imageName string) (bool, error) {
-
-	var registryAuthGetter auth.RegistryAuthGetter
-	if modSpec.ImageRepoSecret != nil {
-		registryAuthGetter = auth.NewRegistryAuthGetter(client, types.NamespacedName{
-			Name:      modSpec.ImageRepoSecret.Name,
-			Namespace: namespace,
-		})
-	}
+	imageName string,
+	arch string) (bool, error) {
 
 	tlsOptions := TLSOptions(modSpec, km)
-	exists, err := reg.ImageExists(ctx, imageName, tlsOptions, registryAuthGetter)
+	platform := registry.PlatformForArchitecture(arch)
+	exists, err := reg.ImageExists(ctx, imageName, tlsOptions, registryAuthGetter(client, modSpec, namespace), platform)
 	if err != nil {
 		return false, fmt.Errorf("could not check if the image is available: %v", err)
 	}
 
 	return exists, nil
 }
+
+// ImageLabels returns the labels of the image for the nodes of architecture arch, using the pull secret and TLS
+// options of the Module. An empty arch is the operator's.
+func ImageLabels(
+	ctx context.Context,
+	client client.Client,
+	reg registry.Registry,
+	modSpec kmmv1beta1.ModuleSpec,
+	namespace string,
+	km kmmv1beta1.KernelMapping,
+	imageName string,
+	arch string) (map[string]string, error) {
+
+	tlsOptions := TLSOptions(modSpec, km)
+	platform := registry.PlatformForArchitecture(arch)
+	labels, err := reg.GetImageLabels(ctx, imageName, tlsOptions, registryAuthGetter(client, modSpec, namespace), platform)
+	if err != nil {
+		return nil, fmt.Errorf("could not get the labels of the image: %v", err)
+	}
+
+	return labels, nil
+}
+
+// ImageDigest returns the digest of the image, using the pull secret and TLS options of the Module.
+func ImageDigest(
+	ctx context.Context,
+	client client.Client,
+	reg registry.Registry,
+	modSpec kmmv1beta1.ModuleSpec,
+	namespace string,
+	km kmmv1beta1.KernelMapping,
+	imageName string) (string, error) {
+
+	digest, err := reg.GetDigest(ctx, imageName, TLSOptions(modSpec, km), registryAuthGetter(client, modSpec, namespace))
+	if err != nil {
+		return "", fmt.Errorf("could not get the digest of the image: %v", err)
+	}
+
+	return digest, nil
+}
+
+func registryAuthGetter(client client.Client, modSpec kmmv1beta1.ModuleSpec, namespace string) auth.RegistryAuthGetter {
+	if modSpec.ImageRepoSecret == nil {
+		return nil
+	}
+
+	return auth.NewRegistryAuthGetter(client, types.NamespacedName{
+		Name:      modSpec.ImageRepoSecret.Name,
+		Namespace: namespace,
+	})
+}
@@ -241,10 +241,10 @@ var _ = Describe("ImageExists", func() {
 
 	It("should return true if the image exists", func() {
 		gomock.InOrder(
-			mockRegistry.EXPECT().ImageExists(ctx, imageName, gomock.Any(), nil).Return(true, nil),
+			mockRegistry.EXPECT().ImageExists(ctx, imageName, gomock.Any(), nil, nil).Return(true, nil),
 		)
 
-		exists, err := ImageExists(ctx, clnt, mockRegistry, mod.Spec, namespace, km, imageName)
+		exists, err := ImageExists(ctx, clnt, mockRegistry, mod.Spec, namespace, km, imageName, "")
 
 		Expect(err).ToNot(HaveOccurred())
 		Expect(exists).To(BeTrue())
@@ -252,10 +252,10 @@ var _ = Describe("ImageExists", func() {
 
 	It("should return false if the image does not exist", func() {
 		gomock.InOrder(
-			mockRegistry.EXPECT().ImageExists(ctx, imageName, gomock.Any(), nil).Return(false, nil),
+			mockRegistry.EXPECT().ImageExists(ctx, imageName, gomock.Any(), nil, nil).Return(false, nil),
 		)
 
-		exists, err := ImageExists(ctx, clnt, mockRegistry, mod.Spec, namespace, km, imageName)
+		exists, err := ImageExists(ctx, clnt, mockRegistry, mod.Spec, namespace, km, imageName, "")
 
 		Expect(err).ToNot(HaveOccurred())
 		Expect(exists).To(BeFalse())
@@ -263,10 +263,10 @@ var _ = Describe("ImageExists", func() {
 
 	It("should return an error if the registry call fails", func() {
 		gomock.InOrder(
-			mockRegistry.EXPECT().ImageExists(ctx, imageName, gomock.Any(), nil).Return(false, errors.New("some-error")),
+			mockRegistry.EXPECT().ImageExists(ctx, imageName, gomock.Any(), nil, nil).Return(false, errors.New("some-error")),
 		)
 
-		exists, err := ImageExists(ctx, clnt, mockRegistry, mod.Spec, namespace, km, imageName)
+		exists, err := ImageExists(ctx, clnt, mockRegistry, mod.Spec, namespace, km, imageName, "")
 
 		Expect(err).To(HaveOccurred())
 		Expect(err.Error()).To(ContainSubstring("some-error"))
@@ -283,12 +283,23 @@ var _ = Describe("ImageExists", func() {
 		}
 
 		gomock.InOrder(
-			mockRegistry.EXPECT().ImageExists(ctx, imageName, gomock.Any(), gomock.Not(gomock.Nil())).Return(false, nil),
+			mockRegistry.EXPECT().ImageExists(ctx, imageName, gomock.Any(), gomock.Not(gomock.Nil()), nil).Return(false, nil),
 		)
 
-		exists, err := ImageExists(ctx, clnt, mockRegistry, mod.Spec, namespace, km, imageName)
+		exists, err := ImageExists(ctx, clnt, mockRegistry, mod.Spec, namespace, km, imageName, "")
 
 		Expect(err).ToNot(HaveOccurred())
 		Expect(exists).To(BeFalse())
 	})
+
+	It("should look for the image of the architecture", func() {
+		gomock.InOrder(
+			mockRegistry.EXPECT().ImageExists(ctx, imageName, gomock.Any(), nil, registry.PlatformForArchitecture("arm64")).Return(true, nil),
+		)
+
+		exists, err := ImageExists(ctx, clnt, mockRegistry, mod.Spec, namespace, km, imageName, "arm64")
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exists).To(BeTrue())
+	})
 })
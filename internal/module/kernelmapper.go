@@ -5,11 +5,14 @@ import (
 	"fmt"
 	"reflect"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/a8m/envsubst/parse"
 	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/sign"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 )
 
 const (
@@ -18,37 +21,131 @@ const (
 	kernelVersionPatchIdx = 2
 )
 
+const (
+	// OSReleaseIDLabel and OSReleaseVersionIDLabel are set by Node Feature Discovery from /etc/os-release.
+	OSReleaseIDLabel        = "feature.node.kubernetes.io/system-os_release.ID"
+	OSReleaseVersionIDLabel = "feature.node.kubernetes.io/system-os_release.VERSION_ID"
+)
+
 type NodeOSConfig struct {
-	KernelFullVersion  string `subst:"KERNEL_FULL_VERSION"`
-	KernelVersionMMP   string `subst:"KERNEL_XYZ"`
-	KernelVersionMajor string `subst:"KERNEL_X"`
-	KernelVersionMinor string `subst:"KERNEL_Y"`
-	KernelVersionPatch string `subst:"KERNEL_Z"`
+	KernelFullVersion   string `subst:"KERNEL_FULL_VERSION"`
+	KernelVersionMMP    string `subst:"KERNEL_XYZ"`
+	KernelVersionMajor  string `subst:"KERNEL_X"`
+	KernelVersionMinor  string `subst:"KERNEL_Y"`
+	KernelVersionPatch  string `subst:"KERNEL_Z"`
+	KernelReleaseSuffix string `subst:"KERNEL_RELEASE_SUFFIX"`
+	KernelRT            string `subst:"KERNEL_RT"`
+	Architecture        string `subst:"ARCH"`
+	OSID                string `subst:"OS_ID"`
+	OSVersion           string `subst:"OS_VERSION"`
+}
+
+// BuildArgs returns the non-empty variables of c as build arguments named after them, such as KERNEL_X=5.
+func (c *NodeOSConfig) BuildArgs() []kmmv1beta1.BuildArg {
+	t := reflect.TypeOf(*c)
+	v := reflect.ValueOf(*c)
+
+	args := make([]kmmv1beta1.BuildArg, 0, t.NumField())
+
+	for i := 0; i < t.NumField(); i++ {
+		if value := v.Field(i).String(); value != "" {
+			args = append(args, kmmv1beta1.BuildArg{Name: t.Field(i).Tag.Get("subst"), Value: value})
+		}
+	}
+
+	return args
+}
+
+// ErrNoMapping is returned when no mapping matches a kernel.
+var ErrNoMapping = errors.New("no suitable mapping found")
+
+// kernelArchitectures are the architecture suffixes that distributions append to their kernel versions.
+var kernelArchitectures = sets.NewString("x86_64", "aarch64", "ppc64le", "s390x", "amd64", "arm64")
+
+// KernelTarget identifies the nodes that share a kernel mapping and a module-loader DaemonSet: those with the same
+// kernel version and architecture.
+type KernelTarget struct {
+	KernelVersion string
+	Architecture  string
+}
+
+// buildHelper is implemented by build.Helper, which imports this package.
+type buildHelper interface {
+	GetRelevantBuild(modSpec kmmv1beta1.ModuleSpec, km kmmv1beta1.KernelMapping) *kmmv1beta1.Build
 }
 
 //go:generate mockgen -source=kernelmapper.go -package=module -destination=mock_kernelmapper.go
 
 type KernelMapper interface {
 	FindMappingForKernel(mappings []kmmv1beta1.KernelMapping, kernelVersion string) (*kmmv1beta1.KernelMapping, error)
+	FindMappingForNode(mappings []kmmv1beta1.KernelMapping, kernelVersion string, node *v1.Node) (*kmmv1beta1.KernelMapping, error)
 	GetNodeOSConfig(node *v1.Node) *NodeOSConfig
 	GetNodeOSConfigFromKernelVersion(kernelVersion string) *NodeOSConfig
-	PrepareKernelMapping(mapping *kmmv1beta1.KernelMapping, osConfig *NodeOSConfig) (*kmmv1beta1.KernelMapping, error)
+	PrepareKernelMapping(modSpec kmmv1beta1.ModuleSpec, mapping *kmmv1beta1.KernelMapping, osConfig *NodeOSConfig) (*kmmv1beta1.KernelMapping, error)
 }
 
-type kernelMapper struct{}
+type kernelMapper struct {
+	buildHelper buildHelper
+	signHelper  sign.Helper
+}
 
-func NewKernelMapper() KernelMapper {
-	return &kernelMapper{}
+func NewKernelMapper(buildHelper buildHelper, signHelper sign.Helper) KernelMapper {
+	return &kernelMapper{
+		buildHelper: buildHelper,
+		signHelper:  signHelper,
+	}
 }
 
 // FindMappingForKernel tries to match kernelVersion against mappings. It returns the first mapping that has a Literal
-// field equal to kernelVersion or a Regexp field that matches kernelVersion.
+// field equal to kernelVersion, a Regexp field that matches kernelVersion or a VersionRange that contains
+// kernelVersion. Mappings that exclude kernelVersion are skipped. If no mapping matches, the first fallback mapping
+// is returned.
+// The Architecture and OSImage of the mappings are ignored, as no node is known.
 func (k *kernelMapper) FindMappingForKernel(mappings []kmmv1beta1.KernelMapping, kernelVersion string) (*kmmv1beta1.KernelMapping, error) {
+	return findMapping(mappings, kernelVersion, nil)
+}
+
+// FindMappingForNode works like FindMappingForKernel, but also skips the mappings whose Architecture or OSImage do not
+// match node.
+func (k *kernelMapper) FindMappingForNode(mappings []kmmv1beta1.KernelMapping, kernelVersion string, node *v1.Node) (*kmmv1beta1.KernelMapping, error) {
+	return findMapping(mappings, kernelVersion, node)
+}
+
+func findMapping(mappings []kmmv1beta1.KernelMapping, kernelVersion string, node *v1.Node) (*kmmv1beta1.KernelMapping, error) {
+	var fallback *kmmv1beta1.KernelMapping
+
 	for _, m := range mappings {
+		if excluded(m, kernelVersion) {
+			continue
+		}
+
+		if node != nil {
+			matches, err := matchesNode(m, node)
+			if err != nil {
+				return nil, err
+			}
+			if !matches {
+				continue
+			}
+		}
+
+		if m.Fallback {
+			if fallback == nil {
+				// m is reused by the next iterations
+				fm := m
+				fallback = &fm
+			}
+			continue
+		}
+
 		if m.Literal != "" && m.Literal == kernelVersion {
 			return &m, nil
 		}
 
+		if m.VersionRange != nil && KernelVersionInRange(*m.VersionRange, kernelVersion) {
+			return &m, nil
+		}
+
 		if m.Regexp == "" {
 			continue
 		}
@@ -60,11 +157,74 @@ func (k *kernelMapper) FindMappingForKernel(mappings []kmmv1beta1.KernelMapping,
 		}
 	}
 
-	return nil, errors.New("no suitable mapping found")
+	if fallback != nil {
+		return fallback, nil
+	}
+
+	return nil, ErrNoMapping
+}
+
+func excluded(m kmmv1beta1.KernelMapping, kernelVersion string) bool {
+	for _, e := range m.Exclude {
+		if e == kernelVersion {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchesNode(m kmmv1beta1.KernelMapping, node *v1.Node) (bool, error) {
+	if m.Architecture != "" && NormalizeArchitecture(m.Architecture) != NodeArchitecture(node) {
+		return false, nil
+	}
+
+	if m.OSImage == "" {
+		return true, nil
+	}
+
+	osImage := node.Status.NodeInfo.OSImage
+
+	matches, err := regexp.MatchString(m.OSImage, osImage)
+	if err != nil {
+		return false, fmt.Errorf("could not match regexp %q against OS image %q: %v", m.OSImage, osImage, err)
+	}
+
+	return matches, nil
 }
 
+// NormalizeArchitecture returns the Go name of arch, which is the one used by Kubernetes nodes.
+// The kernel names x86_64 and aarch64 are translated to amd64 and arm64.
+func NormalizeArchitecture(arch string) string {
+	switch arch {
+	case "x86_64":
+		return "amd64"
+	case "aarch64":
+		return "arm64"
+	default:
+		return arch
+	}
+}
+
+// NodeArchitecture returns the architecture reported by node, or the value of its kubernetes.io/arch label.
+func NodeArchitecture(node *v1.Node) string {
+	if arch := node.Status.NodeInfo.Architecture; arch != "" {
+		return arch
+	}
+
+	return node.Labels[v1.LabelArchStable]
+}
+
+// GetNodeOSConfig returns the kernel variables of node, along with its architecture and the OS ID and version
+// published by Node Feature Discovery, if any.
 func (k *kernelMapper) GetNodeOSConfig(node *v1.Node) *NodeOSConfig {
-	return k.GetNodeOSConfigFromKernelVersion(node.Status.NodeInfo.KernelVersion)
+	osConfig := k.GetNodeOSConfigFromKernelVersion(node.Status.NodeInfo.KernelVersion)
+
+	osConfig.Architecture = NodeArchitecture(node)
+	osConfig.OSID = node.Labels[OSReleaseIDLabel]
+	osConfig.OSVersion = node.Labels[OSReleaseVersionIDLabel]
+
+	return osConfig
 }
 
 func (k *kernelMapper) GetNodeOSConfigFromKernelVersion(kernelVersion string) *NodeOSConfig {
@@ -73,26 +233,123 @@ func (k *kernelMapper) GetNodeOSConfigFromKernelVersion(kernelVersion string) *N
 	osConfigFieldsList := regexp.MustCompile("[.,-]").Split(kernelVersion, -1)
 
 	osConfig.KernelFullVersion = kernelVersion
-	osConfig.KernelVersionMMP = strings.Join(osConfigFieldsList[:kernelVersionPatchIdx+1], ".")
-	osConfig.KernelVersionMajor = osConfigFieldsList[kernelVersionMajorIdx]
-	osConfig.KernelVersionMinor = osConfigFieldsList[kernelVersionMinorIdx]
-	osConfig.KernelVersionPatch = osConfigFieldsList[kernelVersionPatchIdx]
+
+	// kernel versions set by users, such as the one of a PreflightValidation, may be truncated
+	if len(osConfigFieldsList) > kernelVersionPatchIdx {
+		osConfig.KernelVersionMMP = strings.Join(osConfigFieldsList[:kernelVersionPatchIdx+1], ".")
+		osConfig.KernelVersionMajor = osConfigFieldsList[kernelVersionMajorIdx]
+		osConfig.KernelVersionMinor = osConfigFieldsList[kernelVersionMinorIdx]
+		osConfig.KernelVersionPatch = osConfigFieldsList[kernelVersionPatchIdx]
+	}
+
+	osConfig.KernelReleaseSuffix = kernelReleaseSuffix(kernelVersion)
+	osConfig.KernelRT = strconv.FormatBool(isRealTimeKernel(kernelVersion))
 
 	return &osConfig
 }
 
-func (k *kernelMapper) PrepareKernelMapping(mapping *kmmv1beta1.KernelMapping, osConfig *NodeOSConfig) (*kmmv1beta1.KernelMapping, error) {
-	osConfigStrings := k.prepareOSConfigList(*osConfig)
+// kernelRelease returns the part of kernelVersion that follows the upstream version, such as 284.11.1.el9_2.x86_64
+// for 5.14.0-284.11.1.el9_2.x86_64.
+func kernelRelease(kernelVersion string) string {
+	_, release, _ := strings.Cut(kernelVersion, "-")
+	return release
+}
 
-	parser := parse.New("mapping", osConfigStrings, &parse.Restrictions{})
+// kernelReleaseSuffix returns the distribution suffix of kernelVersion, which is the last field of its release
+// ignoring the architecture: el9_2 for 5.14.0-284.11.1.el9_2.x86_64, generic for 5.15.0-76-generic.
+// It returns an empty string if that field is a number.
+func kernelReleaseSuffix(kernelVersion string) string {
+	fields := strings.FieldsFunc(kernelRelease(kernelVersion), func(r rune) bool {
+		return r == '.' || r == '-'
+	})
 
-	substContainerImage, err := parser.Parse(mapping.ContainerImage)
-	if err != nil {
-		return nil, fmt.Errorf("failed to substitute the os config into ContainerImage field: %w", err)
+	if n := len(fields); n > 0 && kernelArchitectures.Has(fields[n-1]) {
+		fields = fields[:n-1]
+	}
+
+	if len(fields) == 0 {
+		return ""
+	}
+
+	suffix := fields[len(fields)-1]
+
+	if strings.IndexFunc(suffix, func(r rune) bool { return !isDigit(r) }) == -1 {
+		return ""
+	}
+
+	return suffix
+}
+
+// isRealTimeKernel returns true if the release of kernelVersion contains an rt or realtime segment, like
+// 5.14.0-284.11.1.rt14.296.el9_2.x86_64 or 5.15.0-1032-realtime.
+func isRealTimeKernel(kernelVersion string) bool {
+	for _, s := range kernelVersionSegments(kernelRelease(kernelVersion)) {
+		if s == "rt" || s == "realtime" {
+			return true
+		}
 	}
 
+	return false
+}
+
+// PrepareKernelMapping returns a copy of mapping whose Build and Sign are the complete configurations that apply to
+// it, merged with those of modSpec. The variables of osConfig are substituted into the ContainerImage, the build
+// arguments values, the Dockerfile ConfigMap name, the unsigned image and the files to sign.
+func (k *kernelMapper) PrepareKernelMapping(
+	modSpec kmmv1beta1.ModuleSpec,
+	mapping *kmmv1beta1.KernelMapping,
+	osConfig *NodeOSConfig) (*kmmv1beta1.KernelMapping, error) {
+	osConfigStrings := k.prepareOSConfigList(*osConfig)
+
+	// unknown variables are an error rather than blanked, so that typos do not go unnoticed
+	parser := parse.New("mapping", osConfigStrings, parse.NoUnset)
+
 	substMapping := mapping.DeepCopy()
-	substMapping.ContainerImage = substContainerImage
+
+	// the merged configurations may share fields with modSpec and mapping
+	substMapping.Build = k.buildHelper.GetRelevantBuild(modSpec, *mapping).DeepCopy()
+	substMapping.Sign = k.signHelper.GetRelevantSign(modSpec, *mapping).DeepCopy()
+
+	subst := func(field string, s *string) error {
+		res, err := parser.Parse(*s)
+		if err != nil {
+			return fmt.Errorf("failed to substitute the os config into %s field: %w", field, err)
+		}
+
+		*s = res
+
+		return nil
+	}
+
+	if err := subst("ContainerImage", &substMapping.ContainerImage); err != nil {
+		return nil, err
+	}
+
+	if b := substMapping.Build; b != nil {
+		for i := range b.BuildArgs {
+			if err := subst("Build.BuildArgs", &b.BuildArgs[i].Value); err != nil {
+				return nil, err
+			}
+		}
+
+		if b.DockerfileConfigMap != nil {
+			if err := subst("Build.DockerfileConfigMap", &b.DockerfileConfigMap.Name); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if s := substMapping.Sign; s != nil {
+		if err := subst("Sign.UnsignedImage", &s.UnsignedImage); err != nil {
+			return nil, err
+		}
+
+		for i := range s.FilesToSign {
+			if err := subst("Sign.FilesToSign", &s.FilesToSign[i]); err != nil {
+				return nil, err
+			}
+		}
+	}
 
 	return substMapping, nil
 }
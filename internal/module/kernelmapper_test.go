@@ -1,10 +1,13 @@
 package module
 
 import (
+	"github.com/golang/mock/gomock"
 	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/sign"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 var _ = Describe("FindMappingForKernel", func() {
@@ -13,7 +16,7 @@ var _ = Describe("FindMappingForKernel", func() {
 		selectedImage = "image1"
 	)
 
-	km := NewKernelMapper()
+	km := NewKernelMapper(nil, nil)
 
 	It("should work with one literal mapping", func() {
 		mapping := kmmv1beta1.KernelMapping{
@@ -62,16 +65,150 @@ var _ = Describe("FindMappingForKernel", func() {
 		_, err := km.FindMappingForKernel(mappings, kernelVersion)
 		Expect(err).To(MatchError("no suitable mapping found"))
 	})
+
+	DescribeTable("should match version ranges",
+		func(kernel string, expectedImage string) {
+			mappings := []kmmv1beta1.KernelMapping{
+				{
+					ContainerImage: "range",
+					VersionRange:   &kmmv1beta1.KernelVersionRange{Min: "5.14.0-284", Max: "5.14.0-362"},
+					Exclude:        []string{"5.14.0-300.el9.x86_64"},
+				},
+				{
+					ContainerImage: "fallback",
+					Fallback:       true,
+				},
+				{
+					ContainerImage: "regexp",
+					Regexp:         `^5\.14\.0-362\.`,
+				},
+			}
+
+			m, err := km.FindMappingForKernel(mappings, kernel)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(m.ContainerImage).To(Equal(expectedImage))
+		},
+		Entry("lower bound", "5.14.0-284", "range"),
+		Entry("release within the range", "5.14.0-284.11.1.el9_2.x86_64", "range"),
+		Entry("excluded version", "5.14.0-300.el9.x86_64", "fallback"),
+		Entry("upper bound", "5.14.0-362", "fallback"),
+		Entry("later mapping before the fallback", "5.14.0-362.8.1.el9_3.x86_64", "regexp"),
+		Entry("older kernel", "5.14.0-70.13.1.el9_0.x86_64", "fallback"),
+	)
+
+	It("should not return a fallback mapping that excludes the kernel", func() {
+		mappings := []kmmv1beta1.KernelMapping{
+			{ContainerImage: selectedImage, Fallback: true, Exclude: []string{kernelVersion}},
+		}
+
+		_, err := km.FindMappingForKernel(mappings, kernelVersion)
+		Expect(err).To(MatchError("no suitable mapping found"))
+	})
+})
+
+var _ = Describe("FindMappingForNode", func() {
+	const kernelVersion = "5.15.0-76-generic"
+
+	km := NewKernelMapper(nil, nil)
+
+	mappings := []kmmv1beta1.KernelMapping{
+		{ContainerImage: "ubuntu-arm", Regexp: "^5.15", Architecture: "aarch64", OSImage: "^Ubuntu"},
+		{ContainerImage: "ubuntu", Regexp: "^5.15", OSImage: "^Ubuntu"},
+		{ContainerImage: "x86", Regexp: "^5.15", Architecture: "amd64"},
+		{ContainerImage: "arm-fallback", Fallback: true, Architecture: "arm64"},
+	}
+
+	node := func(arch, osImage string) *v1.Node {
+		return &v1.Node{
+			Status: v1.NodeStatus{
+				NodeInfo: v1.NodeSystemInfo{Architecture: arch, OSImage: osImage},
+			},
+		}
+	}
+
+	DescribeTable("should take the architecture and OS image into account",
+		func(n *v1.Node, expectedImage string) {
+			m, err := km.FindMappingForNode(mappings, kernelVersion, n)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(m.ContainerImage).To(Equal(expectedImage))
+		},
+		Entry("Ubuntu on arm64", node("arm64", "Ubuntu 22.04.2 LTS"), "ubuntu-arm"),
+		Entry("Ubuntu on amd64", node("amd64", "Ubuntu 22.04.2 LTS"), "ubuntu"),
+		Entry("RHEL on amd64", node("amd64", "Red Hat Enterprise Linux 9.2 (Plow)"), "x86"),
+		Entry("RHEL on arm64", node("arm64", "Red Hat Enterprise Linux 9.2 (Plow)"), "arm-fallback"),
+		Entry("architecture from the label", &v1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{v1.LabelArchStable: "amd64"},
+			},
+		}, "x86"),
+	)
+
+	It("should return an error if no mapping matches the node", func() {
+		_, err := km.FindMappingForNode(mappings, kernelVersion, node("s390x", "RHEL"))
+		Expect(err).To(MatchError("no suitable mapping found"))
+	})
+
+	It("should return an error if the OS image regex is invalid", func() {
+		invalid := []kmmv1beta1.KernelMapping{
+			{ContainerImage: "image", Regexp: "^5.15", OSImage: "invalid)"},
+		}
+
+		_, err := km.FindMappingForNode(invalid, kernelVersion, node("amd64", "Ubuntu"))
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should ignore the constraints in FindMappingForKernel", func() {
+		m, err := km.FindMappingForKernel(mappings, kernelVersion)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(m.ContainerImage).To(Equal("ubuntu-arm"))
+	})
+})
+
+var _ = Describe("CompareKernelVersions", func() {
+	DescribeTable("should compare kernel versions",
+		func(a, b string, expected int) {
+			Expect(CompareKernelVersions(a, b)).To(Equal(expected))
+			Expect(CompareKernelVersions(b, a)).To(Equal(-expected))
+		},
+		Entry("equal", "5.14.0-284.11.1.el9_2.x86_64", "5.14.0-284.11.1.el9_2.x86_64", 0),
+		Entry("numeric segments", "5.9.0", "5.14.0", -1),
+		Entry("distro release", "5.14.0-284.11.1.el9_2", "5.14.0-362.8.1.el9_3", -1),
+		Entry("more segments is newer", "5.14.0-284", "5.14.0-284.11.1", -1),
+		Entry("letters within a segment", "5.14.0-284.el9_2", "5.14.0-284.el9_10", -1),
+		Entry("numbers are newer than letters", "5.14.0-rc1", "5.14.0-1", -1),
+		Entry("leading zeroes", "5.14.01", "5.14.1", 0),
+	)
+})
+
+var _ = Describe("KernelVersionInRange", func() {
+	It("should accept open ranges", func() {
+		Expect(KernelVersionInRange(kmmv1beta1.KernelVersionRange{Min: "5.14"}, "6.1.0")).To(BeTrue())
+		Expect(KernelVersionInRange(kmmv1beta1.KernelVersionRange{Max: "5.14"}, "6.1.0")).To(BeFalse())
+	})
 })
 
 var _ = Describe("PrepareKernelMapping", func() {
-	km := NewKernelMapper()
+	var (
+		ctrl            *gomock.Controller
+		mockBuildHelper *MockbuildHelper
+		km              KernelMapper
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		mockBuildHelper = NewMockbuildHelper(ctrl)
+		km = NewKernelMapper(mockBuildHelper, sign.NewSignerHelper())
+	})
+
 	osConfig := NodeOSConfig{
 		KernelFullVersion:  "kernelFullVersion",
 		KernelVersionMMP:   "kernelMMP",
 		KernelVersionMajor: "kernelMajor",
 		KernelVersionMinor: "kernelMinor",
 		KernelVersionPatch: "kernelPatch",
+		Architecture:       "amd64",
+		OSID:               "rhel",
+		OSVersion:          "9.2",
 	}
 
 	It("error input", func() {
@@ -80,19 +217,20 @@ var _ = Describe("PrepareKernelMapping", func() {
 			Literal:        "some literal",
 			Regexp:         "regexp",
 		}
-		_, err := km.PrepareKernelMapping(&mapping, &osConfig)
+		mockBuildHelper.EXPECT().GetRelevantBuild(kmmv1beta1.ModuleSpec{}, mapping).Return(mapping.Build)
+
+		_, err := km.PrepareKernelMapping(kmmv1beta1.ModuleSpec{}, &mapping, &osConfig)
 		Expect(err).To(HaveOccurred())
 	})
 
-	It("should only substitute the ContainerImage field", func() {
+	It("should substitute the image, build and sign fields", func() {
 		const (
-			dockerfile = "RUN echo $MYVAR"
-			literal    = "some literal:${KERNEL_XYZ"
-			regexp     = "some regexp:${KERNEL_XYZ"
+			literal = "some literal:${KERNEL_XYZ"
+			regexp  = "some regexp:${KERNEL_XYZ"
 		)
 
 		mapping := kmmv1beta1.KernelMapping{
-			ContainerImage: "some image:${KERNEL_XYZ}",
+			ContainerImage: "some image:${KERNEL_XYZ}-${ARCH}",
 			Literal:        literal,
 			Regexp:         regexp,
 			Build: &kmmv1beta1.Build{
@@ -100,35 +238,141 @@ var _ = Describe("PrepareKernelMapping", func() {
 					{Name: "name1", Value: "value1"},
 					{Name: "kernel version", Value: "${KERNEL_FULL_VERSION}"},
 				},
-				DockerfileConfigMap: &v1.LocalObjectReference{},
+				DockerfileConfigMap: &v1.LocalObjectReference{Name: "dockerfile-${OS_ID}${OS_VERSION}"},
+			},
+			Sign: &kmmv1beta1.Sign{
+				UnsignedImage: "unsigned:${KERNEL_FULL_VERSION}",
+				FilesToSign:   []string{"/opt/lib/modules/${KERNEL_FULL_VERSION}/mod.ko"},
 			},
 		}
 		expectMapping := kmmv1beta1.KernelMapping{
-			ContainerImage: "some image:kernelMMP",
+			ContainerImage: "some image:kernelMMP-amd64",
 			Literal:        literal,
 			Regexp:         regexp,
 			Build: &kmmv1beta1.Build{
 				BuildArgs: []kmmv1beta1.BuildArg{
 					{Name: "name1", Value: "value1"},
-					{Name: "kernel version", Value: "${KERNEL_FULL_VERSION}"},
+					{Name: "kernel version", Value: "kernelFullVersion"},
 				},
-				DockerfileConfigMap: &v1.LocalObjectReference{},
+				DockerfileConfigMap: &v1.LocalObjectReference{Name: "dockerfile-rhel9.2"},
+			},
+			Sign: &kmmv1beta1.Sign{
+				UnsignedImage: "unsigned:kernelFullVersion",
+				FilesToSign:   []string{"/opt/lib/modules/kernelFullVersion/mod.ko"},
 			},
 		}
 
-		res, err := km.PrepareKernelMapping(&mapping, &osConfig)
+		mockBuildHelper.EXPECT().GetRelevantBuild(kmmv1beta1.ModuleSpec{}, mapping).Return(mapping.Build)
+
+		res, err := km.PrepareKernelMapping(kmmv1beta1.ModuleSpec{}, &mapping, &osConfig)
 		Expect(err).NotTo(HaveOccurred())
 		Expect(*res).To(Equal(expectMapping))
 	})
+
+	It("should substitute the build and sign fields of the Module", func() {
+		modSpec := kmmv1beta1.ModuleSpec{
+			ModuleLoader: kmmv1beta1.ModuleLoaderSpec{
+				Container: kmmv1beta1.ModuleLoaderContainerSpec{
+					Build: &kmmv1beta1.Build{
+						BuildArgs:           []kmmv1beta1.BuildArg{{Name: "kernel", Value: "${KERNEL_XYZ}"}},
+						DockerfileConfigMap: &v1.LocalObjectReference{Name: "dockerfile-${OS_ID}"},
+					},
+					Sign: &kmmv1beta1.Sign{
+						UnsignedImage: "unsigned:${KERNEL_XYZ}",
+						FilesToSign:   []string{"/opt/lib/modules/${KERNEL_X}/mod.ko"},
+					},
+				},
+			},
+		}
+
+		mapping := kmmv1beta1.KernelMapping{
+			ContainerImage: "some image",
+			Sign:           &kmmv1beta1.Sign{FilesToSign: []string{"/opt/lib/modules/${KERNEL_Y}/other.ko"}},
+		}
+
+		mockBuildHelper.EXPECT().GetRelevantBuild(modSpec, mapping).Return(modSpec.ModuleLoader.Container.Build)
+
+		res, err := km.PrepareKernelMapping(modSpec, &mapping, &osConfig)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Build).To(Equal(&kmmv1beta1.Build{
+			BuildArgs:           []kmmv1beta1.BuildArg{{Name: "kernel", Value: "kernelMMP"}},
+			DockerfileConfigMap: &v1.LocalObjectReference{Name: "dockerfile-rhel"},
+		}))
+		Expect(res.Sign.UnsignedImage).To(Equal("unsigned:kernelMMP"))
+		Expect(res.Sign.FilesToSign).To(Equal([]string{"/opt/lib/modules/kernelMajor/mod.ko", "/opt/lib/modules/kernelMinor/other.ko"}))
+
+		// the Module is left as is
+		Expect(modSpec.ModuleLoader.Container.Build.DockerfileConfigMap.Name).To(Equal("dockerfile-${OS_ID}"))
+		Expect(modSpec.ModuleLoader.Container.Sign.FilesToSign).To(Equal([]string{"/opt/lib/modules/${KERNEL_X}/mod.ko"}))
+	})
+
+	It("should not modify the original mapping", func() {
+		mapping := kmmv1beta1.KernelMapping{
+			ContainerImage: "some image",
+			Sign:           &kmmv1beta1.Sign{FilesToSign: []string{"${KERNEL_X}"}},
+		}
+
+		mockBuildHelper.EXPECT().GetRelevantBuild(kmmv1beta1.ModuleSpec{}, mapping).Return(mapping.Build)
+
+		res, err := km.PrepareKernelMapping(kmmv1beta1.ModuleSpec{}, &mapping, &osConfig)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Sign.FilesToSign).To(Equal([]string{"kernelMajor"}))
+		Expect(mapping.Sign.FilesToSign).To(Equal([]string{"${KERNEL_X}"}))
+	})
+
+	It("should return an error if a build argument cannot be substituted", func() {
+		mapping := kmmv1beta1.KernelMapping{
+			ContainerImage: "some image",
+			Build: &kmmv1beta1.Build{
+				BuildArgs: []kmmv1beta1.BuildArg{{Name: "name", Value: "${KERNEL_X"}},
+			},
+		}
+
+		mockBuildHelper.EXPECT().GetRelevantBuild(kmmv1beta1.ModuleSpec{}, mapping).Return(mapping.Build)
+
+		_, err := km.PrepareKernelMapping(kmmv1beta1.ModuleSpec{}, &mapping, &osConfig)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should return an error for unknown variables", func() {
+		mapping := kmmv1beta1.KernelMapping{ContainerImage: "some image:${KERNEL_VERSION}"}
+
+		mockBuildHelper.EXPECT().GetRelevantBuild(kmmv1beta1.ModuleSpec{}, mapping).Return(mapping.Build)
+
+		_, err := km.PrepareKernelMapping(kmmv1beta1.ModuleSpec{}, &mapping, &osConfig)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should keep escaped dollar signs", func() {
+		mapping := kmmv1beta1.KernelMapping{
+			ContainerImage: "some image",
+			Build: &kmmv1beta1.Build{
+				BuildArgs: []kmmv1beta1.BuildArg{{Name: "name", Value: "$${HOME}/${KERNEL_X}"}},
+			},
+		}
+
+		mockBuildHelper.EXPECT().GetRelevantBuild(kmmv1beta1.ModuleSpec{}, mapping).Return(mapping.Build)
+
+		res, err := km.PrepareKernelMapping(kmmv1beta1.ModuleSpec{}, &mapping, &osConfig)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Build.BuildArgs[0].Value).To(Equal("${HOME}/kernelMajor"))
+	})
 })
 
 var _ = Describe("GetNodeOSConfig", func() {
-	km := NewKernelMapper()
+	km := NewKernelMapper(nil, nil)
 
 	It("parsing the node data", func() {
 		node := v1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					OSReleaseIDLabel:        "rhcos",
+					OSReleaseVersionIDLabel: "4.10",
+				},
+			},
 			Status: v1.NodeStatus{
 				NodeInfo: v1.NodeSystemInfo{
+					Architecture:  "amd64",
 					KernelVersion: "4.18.0-305.45.1.el8_4.x86_64",
 					OSImage:       "Red Hat Enterprise Linux CoreOS 410.84.202205191234-0 (Ootpa)",
 				},
@@ -136,11 +380,16 @@ var _ = Describe("GetNodeOSConfig", func() {
 		}
 
 		expectedOSConfig := NodeOSConfig{
-			KernelFullVersion:  "4.18.0-305.45.1.el8_4.x86_64",
-			KernelVersionMMP:   "4.18.0",
-			KernelVersionMajor: "4",
-			KernelVersionMinor: "18",
-			KernelVersionPatch: "0",
+			KernelFullVersion:   "4.18.0-305.45.1.el8_4.x86_64",
+			KernelVersionMMP:    "4.18.0",
+			KernelVersionMajor:  "4",
+			KernelVersionMinor:  "18",
+			KernelVersionPatch:  "0",
+			KernelReleaseSuffix: "el8_4",
+			KernelRT:            "false",
+			Architecture:        "amd64",
+			OSID:                "rhcos",
+			OSVersion:           "4.10",
 		}
 
 		res := km.GetNodeOSConfig(&node)
@@ -149,20 +398,69 @@ var _ = Describe("GetNodeOSConfig", func() {
 })
 
 var _ = Describe("GetNodeOSConfigFromKernelVersion", func() {
-	km := NewKernelMapper()
+	km := NewKernelMapper(nil, nil)
 
 	It("parsing the kernel version", func() {
 		kernelVersion := "4.18.0-305.45.1.el8_4.x86_64"
 
 		expectedOSConfig := NodeOSConfig{
-			KernelFullVersion:  "4.18.0-305.45.1.el8_4.x86_64",
-			KernelVersionMMP:   "4.18.0",
-			KernelVersionMajor: "4",
-			KernelVersionMinor: "18",
-			KernelVersionPatch: "0",
+			KernelFullVersion:   "4.18.0-305.45.1.el8_4.x86_64",
+			KernelVersionMMP:    "4.18.0",
+			KernelVersionMajor:  "4",
+			KernelVersionMinor:  "18",
+			KernelVersionPatch:  "0",
+			KernelReleaseSuffix: "el8_4",
+			KernelRT:            "false",
 		}
 
 		res := km.GetNodeOSConfigFromKernelVersion(kernelVersion)
 		Expect(*res).To(Equal(expectedOSConfig))
 	})
+
+	DescribeTable("should detect the release suffix and real-time kernels",
+		func(kernelVersion, suffix, rt string) {
+			res := km.GetNodeOSConfigFromKernelVersion(kernelVersion)
+			Expect(res.KernelReleaseSuffix).To(Equal(suffix))
+			Expect(res.KernelRT).To(Equal(rt))
+		},
+		Entry(nil, "5.14.0-284.11.1.el9_2.x86_64", "el9_2", "false"),
+		Entry(nil, "5.14.0-284.11.1.rt14.296.el9_2.x86_64", "el9_2", "true"),
+		Entry(nil, "6.2.9-300.fc38.aarch64", "fc38", "false"),
+		Entry(nil, "5.15.0-76-generic", "generic", "false"),
+		Entry(nil, "5.15.0-1032-realtime", "realtime", "true"),
+		Entry(nil, "6.1.0-9-rt-amd64", "rt", "true"),
+		Entry(nil, "5.15.0-1032", "", "false"),
+		Entry(nil, "6.5.0", "", "false"),
+	)
+
+	It("should not parse the parts of truncated versions", func() {
+		res := km.GetNodeOSConfigFromKernelVersion("6.5")
+		Expect(*res).To(Equal(NodeOSConfig{KernelFullVersion: "6.5", KernelRT: "false"}))
+	})
+})
+
+var _ = Describe("NodeOSConfig_BuildArgs", func() {
+	It("should return the non-empty variables", func() {
+		osConfig := NodeOSConfig{
+			KernelFullVersion:  "5.15.0-76-generic",
+			KernelVersionMMP:   "5.15.0",
+			KernelVersionMajor: "5",
+			KernelVersionMinor: "15",
+			KernelVersionPatch: "0",
+			KernelRT:           "false",
+			Architecture:       "arm64",
+			OSID:               "ubuntu",
+		}
+
+		Expect(osConfig.BuildArgs()).To(Equal([]kmmv1beta1.BuildArg{
+			{Name: "KERNEL_FULL_VERSION", Value: "5.15.0-76-generic"},
+			{Name: "KERNEL_XYZ", Value: "5.15.0"},
+			{Name: "KERNEL_X", Value: "5"},
+			{Name: "KERNEL_Y", Value: "15"},
+			{Name: "KERNEL_Z", Value: "0"},
+			{Name: "KERNEL_RT", Value: "false"},
+			{Name: "ARCH", Value: "arm64"},
+			{Name: "OS_ID", Value: "ubuntu"},
+		}))
+	})
 })
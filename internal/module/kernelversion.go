@@ -0,0 +1,110 @@
+package module
+
+import (
+	"strings"
+
+	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
+)
+
+// CompareKernelVersions compares two kernel versions such as 5.14.0-284.11.1.el9_2.x86_64 and returns -1, 0 or 1 if
+// a is respectively older than, equal to or newer than b.
+// Versions are split into numeric and alphabetic segments, ignoring separators. Numeric segments are compared as
+// numbers, alphabetic segments lexically, and a numeric segment is newer than an alphabetic one. When all common
+// segments are equal, the version with more segments is the newer one, so that 5.14.0-284.11.1 is newer than
+// 5.14.0-284.
+func CompareKernelVersions(a, b string) int {
+	sa := kernelVersionSegments(a)
+	sb := kernelVersionSegments(b)
+
+	for i := 0; i < len(sa) && i < len(sb); i++ {
+		if c := compareSegments(sa[i], sb[i]); c != 0 {
+			return c
+		}
+	}
+
+	switch {
+	case len(sa) < len(sb):
+		return -1
+	case len(sa) > len(sb):
+		return 1
+	default:
+		return 0
+	}
+}
+
+// KernelVersionInRange returns true if kernelVersion is newer than or equal to r.Min, if set, and older than r.Max,
+// if set.
+func KernelVersionInRange(r kmmv1beta1.KernelVersionRange, kernelVersion string) bool {
+	if r.Min != "" && CompareKernelVersions(kernelVersion, r.Min) < 0 {
+		return false
+	}
+
+	if r.Max != "" && CompareKernelVersions(kernelVersion, r.Max) >= 0 {
+		return false
+	}
+
+	return true
+}
+
+func kernelVersionSegments(v string) []string {
+	segments := make([]string, 0)
+
+	isSeparator := func(r rune) bool {
+		return !isDigit(r) && !(r >= 'a' && r <= 'z') && !(r >= 'A' && r <= 'Z')
+	}
+
+	for _, field := range strings.FieldsFunc(v, isSeparator) {
+		start := 0
+
+		for i := 1; i < len(field); i++ {
+			if isDigit(rune(field[i])) != isDigit(rune(field[i-1])) {
+				segments = append(segments, field[start:i])
+				start = i
+			}
+		}
+
+		segments = append(segments, field[start:])
+	}
+
+	return segments
+}
+
+func compareSegments(a, b string) int {
+	aNum := isDigit(rune(a[0]))
+	bNum := isDigit(rune(b[0]))
+
+	switch {
+	case aNum && !bNum:
+		return 1
+	case !aNum && bNum:
+		return -1
+	case aNum && bNum:
+		// strip leading zeroes so that numbers of any size can be compared without overflowing
+		a = strings.TrimLeft(a, "0")
+		b = strings.TrimLeft(b, "0")
+
+		if len(a) != len(b) {
+			if len(a) < len(b) {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	return strings.Compare(a, b)
+}
+
+// ValidKernelVersion returns true if v contains at least one number, so that it can be compared with other versions.
+func ValidKernelVersion(v string) bool {
+	for _, s := range kernelVersionSegments(v) {
+		if isDigit(rune(s[0])) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
@@ -12,6 +12,43 @@ import (
 	v1 "k8s.io/api/core/v1"
 )
 
+// MockbuildHelper is a mock of buildHelper interface.
+type MockbuildHelper struct {
+	ctrl     *gomock.Controller
+	recorder *MockbuildHelperMockRecorder
+}
+
+// MockbuildHelperMockRecorder is the mock recorder for MockbuildHelper.
+type MockbuildHelperMockRecorder struct {
+	mock *MockbuildHelper
+}
+
+// NewMockbuildHelper creates a new mock instance.
+func NewMockbuildHelper(ctrl *gomock.Controller) *MockbuildHelper {
+	mock := &MockbuildHelper{ctrl: ctrl}
+	mock.recorder = &MockbuildHelperMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockbuildHelper) EXPECT() *MockbuildHelperMockRecorder {
+	return m.recorder
+}
+
+// GetRelevantBuild mocks base method.
+func (m *MockbuildHelper) GetRelevantBuild(modSpec v1beta1.ModuleSpec, km v1beta1.KernelMapping) *v1beta1.Build {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRelevantBuild", modSpec, km)
+	ret0, _ := ret[0].(*v1beta1.Build)
+	return ret0
+}
+
+// GetRelevantBuild indicates an expected call of GetRelevantBuild.
+func (mr *MockbuildHelperMockRecorder) GetRelevantBuild(modSpec, km interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRelevantBuild", reflect.TypeOf((*MockbuildHelper)(nil).GetRelevantBuild), modSpec, km)
+}
+
 // MockKernelMapper is a mock of KernelMapper interface.
 type MockKernelMapper struct {
 	ctrl     *gomock.Controller
@@ -50,6 +87,21 @@ func (mr *MockKernelMapperMockRecorder) FindMappingForKernel(mappings, kernelVer
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindMappingForKernel", reflect.TypeOf((*MockKernelMapper)(nil).FindMappingForKernel), mappings, kernelVersion)
 }
 
+// FindMappingForNode mocks base method.
+func (m *MockKernelMapper) FindMappingForNode(mappings []v1beta1.KernelMapping, kernelVersion string, node *v1.Node) (*v1beta1.KernelMapping, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindMappingForNode", mappings, kernelVersion, node)
+	ret0, _ := ret[0].(*v1beta1.KernelMapping)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindMappingForNode indicates an expected call of FindMappingForNode.
+func (mr *MockKernelMapperMockRecorder) FindMappingForNode(mappings, kernelVersion, node interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindMappingForNode", reflect.TypeOf((*MockKernelMapper)(nil).FindMappingForNode), mappings, kernelVersion, node)
+}
+
 // GetNodeOSConfig mocks base method.
 func (m *MockKernelMapper) GetNodeOSConfig(node *v1.Node) *NodeOSConfig {
 	m.ctrl.T.Helper()
@@ -79,16 +131,16 @@ func (mr *MockKernelMapperMockRecorder) GetNodeOSConfigFromKernelVersion(kernelV
 }
 
 // PrepareKernelMapping mocks base method.
-func (m *MockKernelMapper) PrepareKernelMapping(mapping *v1beta1.KernelMapping, osConfig *NodeOSConfig) (*v1beta1.KernelMapping, error) {
+func (m *MockKernelMapper) PrepareKernelMapping(modSpec v1beta1.ModuleSpec, mapping *v1beta1.KernelMapping, osConfig *NodeOSConfig) (*v1beta1.KernelMapping, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "PrepareKernelMapping", mapping, osConfig)
+	ret := m.ctrl.Call(m, "PrepareKernelMapping", modSpec, mapping, osConfig)
 	ret0, _ := ret[0].(*v1beta1.KernelMapping)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // PrepareKernelMapping indicates an expected call of PrepareKernelMapping.
-func (mr *MockKernelMapperMockRecorder) PrepareKernelMapping(mapping, osConfig interface{}) *gomock.Call {
+func (mr *MockKernelMapperMockRecorder) PrepareKernelMapping(modSpec, mapping, osConfig interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PrepareKernelMapping", reflect.TypeOf((*MockKernelMapper)(nil).PrepareKernelMapping), mapping, osConfig)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PrepareKernelMapping", reflect.TypeOf((*MockKernelMapper)(nil).PrepareKernelMapping), modSpec, mapping, osConfig)
 }
@@ -0,0 +1,415 @@
+package module
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/constants"
+	"github.com/mitchellh/hashstructure"
+	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// ConfigMapsIndexKey is the field index of the names of the ConfigMaps referenced by a Module.
+	ConfigMapsIndexKey = "spec.referencedConfigMaps"
+	// SecretsIndexKey is the field index of the names of the Secrets referenced by a Module.
+	SecretsIndexKey = "spec.referencedSecrets"
+
+	// TemplatedReference is indexed for the Modules that reference ConfigMaps or Secrets through templated names,
+	// which are only known once the variables of the nodes have been substituted.
+	TemplatedReference = "${}"
+)
+
+// isTemplated returns true if name contains variables that are substituted with the values of the nodes.
+func isTemplated(name string) bool {
+	return strings.Contains(name, "${")
+}
+
+// ReferencedConfigMaps returns the sorted names of all ConfigMaps referenced by modSpec.
+// Templated names are not returned.
+func ReferencedConfigMaps(modSpec kmmv1beta1.ModuleSpec) []string {
+	names, _ := referencedConfigMaps(modSpec)
+	return names.List()
+}
+
+// ReferencedSecrets returns the sorted names of all Secrets referenced by modSpec.
+// Templated names are not returned.
+func ReferencedSecrets(modSpec kmmv1beta1.ModuleSpec) []string {
+	names, _ := referencedSecrets(modSpec)
+	return names.List()
+}
+
+// referencedConfigMaps returns the names of the ConfigMaps referenced by modSpec, and true if some of them are
+// templated.
+func referencedConfigMaps(modSpec kmmv1beta1.ModuleSpec) (sets.String, bool) {
+	names := sets.NewString()
+	templated := false
+
+	add := func(name string) {
+		if isTemplated(name) {
+			templated = true
+		} else if name != "" {
+			names.Insert(name)
+		}
+	}
+
+	addBuild := func(b *kmmv1beta1.Build) {
+		if b == nil {
+			return
+		}
+
+		if b.DockerfileConfigMap != nil {
+			add(b.DockerfileConfigMap.Name)
+		}
+
+		if b.Context != nil {
+			for _, src := range b.Context.ConfigMaps {
+				add(src.Name)
+			}
+		}
+
+		for _, ba := range b.BuildArgs {
+			if ba.ValueFrom != nil && ba.ValueFrom.ConfigMapKeyRef != nil {
+				add(ba.ValueFrom.ConfigMapKeyRef.Name)
+			}
+		}
+	}
+
+	addBuild(modSpec.ModuleLoader.Container.Build)
+
+	for _, km := range modSpec.ModuleLoader.Container.KernelMappings {
+		addBuild(km.Build)
+	}
+
+	if r := modSpec.ModuleLoader.Container.KernelMappingResolver; r != nil && r.ConfigMap != nil {
+		add(r.ConfigMap.Name)
+	}
+
+	return names, templated
+}
+
+// referencedSecrets returns the names of the Secrets referenced by modSpec, and true if some of them are templated.
+func referencedSecrets(modSpec kmmv1beta1.ModuleSpec) (sets.String, bool) {
+	names := sets.NewString()
+	templated := false
+
+	addRef := func(ref *v1.LocalObjectReference) {
+		if ref == nil {
+			return
+		}
+
+		if isTemplated(ref.Name) {
+			templated = true
+		} else if ref.Name != "" {
+			names.Insert(ref.Name)
+		}
+	}
+
+	addBuildAndSign := func(b *kmmv1beta1.Build, s *kmmv1beta1.Sign) {
+		if b != nil {
+			for i := range b.Secrets {
+				addRef(&b.Secrets[i])
+			}
+
+			if b.Context != nil && b.Context.Git != nil {
+				addRef(b.Context.Git.CredentialsSecret)
+			}
+
+			for _, ba := range b.BuildArgs {
+				if ba.ValueFrom != nil && ba.ValueFrom.SecretKeyRef != nil {
+					addRef(&ba.ValueFrom.SecretKeyRef.LocalObjectReference)
+				}
+			}
+		}
+
+		if s != nil {
+			addRef(s.KeySecret)
+			addRef(s.CertSecret)
+		}
+	}
+
+	addRef(modSpec.ImageRepoSecret)
+	addBuildAndSign(modSpec.ModuleLoader.Container.Build, modSpec.ModuleLoader.Container.Sign)
+
+	for _, km := range modSpec.ModuleLoader.Container.KernelMappings {
+		addBuildAndSign(km.Build, km.Sign)
+	}
+
+	return names, templated
+}
+
+// mappingSpec returns a ModuleSpec that only holds the build and signing configuration of km, so that the objects it
+// references can be listed.
+func mappingSpec(km *kmmv1beta1.KernelMapping) kmmv1beta1.ModuleSpec {
+	return kmmv1beta1.ModuleSpec{
+		ModuleLoader: kmmv1beta1.ModuleLoaderSpec{
+			Container: kmmv1beta1.ModuleLoaderContainerSpec{
+				KernelMappings: []kmmv1beta1.KernelMapping{{Build: km.Build, Sign: km.Sign}},
+			},
+		},
+	}
+}
+
+// IndexConfigMaps is a client.IndexerFunc for ConfigMapsIndexKey.
+// Modules referencing templated names are also indexed under TemplatedReference.
+func IndexConfigMaps(obj client.Object) []string {
+	mod, ok := obj.(*kmmv1beta1.Module)
+	if !ok {
+		return nil
+	}
+
+	names, templated := referencedConfigMaps(mod.Spec)
+	if templated {
+		names.Insert(TemplatedReference)
+	}
+
+	return names.List()
+}
+
+// IndexSecrets is a client.IndexerFunc for SecretsIndexKey.
+// Modules referencing templated names are also indexed under TemplatedReference.
+func IndexSecrets(obj client.Object) []string {
+	mod, ok := obj.(*kmmv1beta1.Module)
+	if !ok {
+		return nil
+	}
+
+	names, templated := referencedSecrets(mod.Spec)
+	if templated {
+		names.Insert(TemplatedReference)
+	}
+
+	return names.List()
+}
+
+// InputsVersion returns a version of the spec of mod and of all the ConfigMaps and Secrets referenced by mod and by
+// the prepared mapping km, which changes whenever any of them is updated.
+func InputsVersion(ctx context.Context, clnt client.Client, mod *kmmv1beta1.Module, km *kmmv1beta1.KernelMapping) (string, error) {
+	specHash, err := hashstructure.Hash(mod.Spec, nil)
+	if err != nil {
+		return "", fmt.Errorf("could not hash the spec: %v", err)
+	}
+
+	versions := []string{fmt.Sprintf("%d", specHash)}
+
+	addVersions := func(kind string, names []string, newObj func() client.Object) error {
+		for _, name := range names {
+			obj := newObj()
+			nsn := types.NamespacedName{Name: name, Namespace: mod.Namespace}
+
+			// a missing object has an empty version
+			if err := clnt.Get(ctx, nsn, obj); client.IgnoreNotFound(err) != nil {
+				return fmt.Errorf("could not get %s %s: %v", kind, nsn, err)
+			}
+
+			versions = append(versions, kind+"/"+name+"="+obj.GetResourceVersion())
+		}
+
+		return nil
+	}
+
+	configMaps, _ := referencedConfigMaps(mod.Spec)
+	kmConfigMaps, _ := referencedConfigMaps(mappingSpec(km))
+
+	if err = addVersions("ConfigMap", configMaps.Union(kmConfigMaps).List(), func() client.Object { return &v1.ConfigMap{} }); err != nil {
+		return "", err
+	}
+
+	secrets, _ := referencedSecrets(mod.Spec)
+	kmSecrets, _ := referencedSecrets(mappingSpec(km))
+
+	if err = addVersions("Secret", secrets.Union(kmSecrets).List(), func() client.Object { return &v1.Secret{} }); err != nil {
+		return "", err
+	}
+
+	return strings.Join(versions, ","), nil
+}
+
+//go:generate mockgen -source=references.go -package=module -destination=mock_references.go
+
+type ReferenceValidator interface {
+	ValidateReferences(
+		ctx context.Context,
+		mod *kmmv1beta1.Module,
+		mappings map[KernelTarget]*kmmv1beta1.KernelMapping) ([]kmmv1beta1.InvalidReference, error)
+}
+
+type referenceValidator struct {
+	client client.Client
+}
+
+func NewReferenceValidator(client client.Client) ReferenceValidator {
+	return &referenceValidator{client: client}
+}
+
+// ValidateReferences checks that all ConfigMaps and Secrets referenced by mod and by its prepared mappings exist and
+// have the expected type and keys. It returns one InvalidReference per problem found.
+// Templated names are only checked once substituted in the prepared mappings.
+func (rv *referenceValidator) ValidateReferences(
+	ctx context.Context,
+	mod *kmmv1beta1.Module,
+	mappings map[KernelTarget]*kmmv1beta1.KernelMapping) ([]kmmv1beta1.InvalidReference, error) {
+	// expected keys by referenced object name; a ConfigMap or a Secret may be used for several purposes
+	configMapKeys := make(map[string]sets.String)
+	secretKeys := make(map[string]sets.String)
+	pullSecrets := sets.NewString()
+
+	addKeys := func(m map[string]sets.String, ref *v1.LocalObjectReference, keys ...string) {
+		if ref == nil || ref.Name == "" || isTemplated(ref.Name) {
+			return
+		}
+		if _, ok := m[ref.Name]; !ok {
+			m[ref.Name] = sets.NewString()
+		}
+		m[ref.Name].Insert(keys...)
+	}
+
+	addSecret := func(ref *v1.LocalObjectReference, keys ...string) {
+		addKeys(secretKeys, ref, keys...)
+	}
+
+	addBuildAndSign := func(b *kmmv1beta1.Build, s *kmmv1beta1.Sign) {
+		if b != nil {
+			addKeys(configMapKeys, b.DockerfileConfigMap, constants.DockerfileCMKey)
+			for i := range b.Secrets {
+				addSecret(&b.Secrets[i])
+			}
+
+			if bc := b.Context; bc != nil {
+				for _, src := range bc.ConfigMaps {
+					addKeys(configMapKeys, &v1.LocalObjectReference{Name: src.Name})
+				}
+
+				if bc.Git != nil {
+					addSecret(bc.Git.CredentialsSecret, v1.BasicAuthUsernameKey, v1.BasicAuthPasswordKey)
+				}
+			}
+
+			// optional keys may be missing
+			for _, ba := range b.BuildArgs {
+				if ba.ValueFrom == nil {
+					continue
+				}
+
+				if ref := ba.ValueFrom.ConfigMapKeyRef; ref != nil && (ref.Optional == nil || !*ref.Optional) {
+					addKeys(configMapKeys, &ref.LocalObjectReference, ref.Key)
+				}
+
+				if ref := ba.ValueFrom.SecretKeyRef; ref != nil && (ref.Optional == nil || !*ref.Optional) {
+					addSecret(&ref.LocalObjectReference, ref.Key)
+				}
+			}
+		}
+
+		if s != nil {
+			addSecret(s.KeySecret, constants.PrivateSignDataKey)
+			addSecret(s.CertSecret, constants.PublicSignDataKey)
+		}
+	}
+
+	if irs := mod.Spec.ImageRepoSecret; irs != nil && irs.Name != "" {
+		pullSecrets.Insert(irs.Name)
+		addSecret(irs, v1.DockerConfigJsonKey)
+	}
+
+	addBuildAndSign(mod.Spec.ModuleLoader.Container.Build, mod.Spec.ModuleLoader.Container.Sign)
+
+	for _, km := range mod.Spec.ModuleLoader.Container.KernelMappings {
+		addBuildAndSign(km.Build, km.Sign)
+	}
+
+	for _, km := range mappings {
+		addBuildAndSign(km.Build, km.Sign)
+	}
+
+	if r := mod.Spec.ModuleLoader.Container.KernelMappingResolver; r != nil {
+		addKeys(configMapKeys, r.ConfigMap, constants.KernelMappingsCMKey)
+	}
+
+	invalid := make([]kmmv1beta1.InvalidReference, 0)
+
+	for _, name := range sortedKeys(configMapKeys) {
+		cm := v1.ConfigMap{}
+
+		msg, err := rv.getObject(ctx, name, mod.Namespace, &cm)
+		if err != nil {
+			return nil, err
+		}
+		if msg == "" {
+			for _, k := range configMapKeys[name].List() {
+				if _, ok := cm.Data[k]; !ok {
+					msg = fmt.Sprintf("key %s is missing", k)
+					break
+				}
+			}
+		}
+
+		if msg != "" {
+			invalid = append(invalid, kmmv1beta1.InvalidReference{Kind: "ConfigMap", Name: name, Message: msg})
+		}
+	}
+
+	for _, name := range sortedKeys(secretKeys) {
+		secret := v1.Secret{}
+
+		msg, err := rv.getObject(ctx, name, mod.Namespace, &secret)
+		if err != nil {
+			return nil, err
+		}
+		if msg == "" {
+			msg = validateSecret(&secret, secretKeys[name], pullSecrets.Has(name))
+		}
+
+		if msg != "" {
+			invalid = append(invalid, kmmv1beta1.InvalidReference{Kind: "Secret", Name: name, Message: msg})
+		}
+	}
+
+	return invalid, nil
+}
+
+// getObject fetches obj and returns a non-empty message if it does not exist.
+func (rv *referenceValidator) getObject(ctx context.Context, name, namespace string, obj client.Object) (string, error) {
+	nsn := types.NamespacedName{Name: name, Namespace: namespace}
+
+	if err := rv.client.Get(ctx, nsn, obj); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return "not found", nil
+		}
+
+		return "", fmt.Errorf("could not get %s: %v", nsn, err)
+	}
+
+	return "", nil
+}
+
+func sortedKeys(m map[string]sets.String) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func validateSecret(secret *v1.Secret, keys sets.String, pullSecret bool) string {
+	if pullSecret && secret.Type != v1.SecretTypeDockerConfigJson {
+		return fmt.Sprintf("type is %q, expected %q", secret.Type, v1.SecretTypeDockerConfigJson)
+	}
+
+	for _, k := range keys.List() {
+		if _, ok := secret.Data[k]; !ok {
+			return fmt.Sprintf("key %s is missing", k)
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,452 @@
+package module
+
+import (
+	"context"
+	"errors"
+
+	gomock "github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/client"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/constants"
+)
+
+const namespace = "some-namespace"
+
+func moduleWithReferences() *kmmv1beta1.Module {
+	return &kmmv1beta1.Module{
+		ObjectMeta: metav1.ObjectMeta{Name: "some-module", Namespace: namespace},
+		Spec: kmmv1beta1.ModuleSpec{
+			ImageRepoSecret: &v1.LocalObjectReference{Name: "pull-secret"},
+			ModuleLoader: kmmv1beta1.ModuleLoaderSpec{
+				Container: kmmv1beta1.ModuleLoaderContainerSpec{
+					Build: &kmmv1beta1.Build{
+						DockerfileConfigMap: &v1.LocalObjectReference{Name: "dockerfile"},
+						Secrets:             []v1.LocalObjectReference{{Name: "build-secret"}},
+					},
+					KernelMappings: []kmmv1beta1.KernelMapping{
+						{
+							Build: &kmmv1beta1.Build{
+								DockerfileConfigMap: &v1.LocalObjectReference{Name: "other-dockerfile"},
+							},
+							Sign: &kmmv1beta1.Sign{
+								KeySecret:  &v1.LocalObjectReference{Name: "signing-key"},
+								CertSecret: &v1.LocalObjectReference{Name: "signing-cert"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+var _ = Describe("ReferencedConfigMaps", func() {
+	It("should return the Dockerfile ConfigMaps of the Module and of its mappings", func() {
+		Expect(
+			ReferencedConfigMaps(moduleWithReferences().Spec),
+		).To(
+			Equal([]string{"dockerfile", "other-dockerfile"}),
+		)
+	})
+
+	It("should return the catalog ConfigMap of the kernel mapping resolver", func() {
+		mod := moduleWithReferences()
+		mod.Spec.ModuleLoader.Container.KernelMappingResolver = &kmmv1beta1.KernelMappingResolver{
+			ConfigMap: &v1.LocalObjectReference{Name: "catalog"},
+		}
+
+		Expect(
+			ReferencedConfigMaps(mod.Spec),
+		).To(
+			Equal([]string{"catalog", "dockerfile", "other-dockerfile"}),
+		)
+	})
+
+	It("should return the ConfigMaps of the build context", func() {
+		mod := moduleWithReferences()
+		mod.Spec.ModuleLoader.Container.Build.Context = &kmmv1beta1.BuildContext{
+			ConfigMaps: []kmmv1beta1.ConfigMapBuildSource{{Name: "patches"}},
+		}
+
+		Expect(
+			ReferencedConfigMaps(mod.Spec),
+		).To(
+			Equal([]string{"dockerfile", "other-dockerfile", "patches"}),
+		)
+	})
+
+	It("should return the ConfigMaps of the build arguments", func() {
+		mod := moduleWithReferences()
+		mod.Spec.ModuleLoader.Container.Build.BuildArgs = []kmmv1beta1.BuildArg{
+			{Name: "literal", Value: "value"},
+			{
+				Name: "from-configmap",
+				ValueFrom: &kmmv1beta1.BuildArgSource{
+					ConfigMapKeyRef: &v1.ConfigMapKeySelector{
+						LocalObjectReference: v1.LocalObjectReference{Name: "build-args"},
+						Key:                  "some-key",
+					},
+				},
+			},
+		}
+
+		Expect(
+			ReferencedConfigMaps(mod.Spec),
+		).To(
+			Equal([]string{"build-args", "dockerfile", "other-dockerfile"}),
+		)
+	})
+
+	It("should return nothing for a Module without build", func() {
+		Expect(
+			ReferencedConfigMaps(kmmv1beta1.ModuleSpec{}),
+		).To(
+			BeEmpty(),
+		)
+	})
+	It("should skip templated names", func() {
+		mod := moduleWithReferences()
+		mod.Spec.ModuleLoader.Container.KernelMappings[0].Build.DockerfileConfigMap.Name = "dockerfile-${KERNEL_XYZ}"
+
+		Expect(
+			ReferencedConfigMaps(mod.Spec),
+		).To(
+			Equal([]string{"dockerfile"}),
+		)
+	})
+})
+
+var _ = Describe("ReferencedSecrets", func() {
+	It("should return the pull, build and signing Secrets", func() {
+		Expect(
+			ReferencedSecrets(moduleWithReferences().Spec),
+		).To(
+			Equal([]string{"build-secret", "pull-secret", "signing-cert", "signing-key"}),
+		)
+	})
+
+	It("should return the Git credentials of the build context", func() {
+		mod := moduleWithReferences()
+		mod.Spec.ModuleLoader.Container.KernelMappings[0].Build.Context = &kmmv1beta1.BuildContext{
+			Git: &kmmv1beta1.GitBuildSource{
+				URL:               "https://git.example.com/repo.git",
+				CredentialsSecret: &v1.LocalObjectReference{Name: "git-credentials"},
+			},
+		}
+
+		Expect(
+			ReferencedSecrets(mod.Spec),
+		).To(
+			Equal([]string{"build-secret", "git-credentials", "pull-secret", "signing-cert", "signing-key"}),
+		)
+	})
+	It("should return the Secrets of the build arguments", func() {
+		mod := moduleWithReferences()
+		mod.Spec.ModuleLoader.Container.KernelMappings[0].Build.BuildArgs = []kmmv1beta1.BuildArg{
+			{
+				Name: "from-secret",
+				ValueFrom: &kmmv1beta1.BuildArgSource{
+					SecretKeyRef: &v1.SecretKeySelector{
+						LocalObjectReference: v1.LocalObjectReference{Name: "build-arg-secret"},
+						Key:                  "some-key",
+					},
+				},
+			},
+		}
+
+		Expect(
+			ReferencedSecrets(mod.Spec),
+		).To(
+			Equal([]string{"build-arg-secret", "build-secret", "pull-secret", "signing-cert", "signing-key"}),
+		)
+	})
+})
+
+var _ = Describe("IndexConfigMaps", func() {
+	It("should return nothing for objects that are not Modules", func() {
+		Expect(IndexConfigMaps(&v1.ConfigMap{})).To(BeNil())
+	})
+
+	It("should index Modules referencing templated names under TemplatedReference", func() {
+		mod := moduleWithReferences()
+		mod.Spec.ModuleLoader.Container.KernelMappings[0].Build.DockerfileConfigMap.Name = "dockerfile-${KERNEL_XYZ}"
+
+		Expect(
+			IndexConfigMaps(mod),
+		).To(
+			Equal([]string{TemplatedReference, "dockerfile"}),
+		)
+	})
+})
+
+var _ = Describe("InputsVersion", func() {
+	var (
+		ctrl *gomock.Controller
+		clnt *client.MockClient
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		clnt = client.NewMockClient(ctrl)
+	})
+
+	ctx := context.Background()
+
+	expectGets := func(versions map[string]string) {
+		clnt.EXPECT().Get(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ interface{}, nsn types.NamespacedName, obj ctrlclient.Object, _ ...ctrlclient.GetOption) error {
+				v, ok := versions[nsn.Name]
+				if !ok {
+					return k8serrors.NewNotFound(schema.GroupResource{}, nsn.Name)
+				}
+
+				obj.SetResourceVersion(v)
+				return nil
+			},
+		).AnyTimes()
+	}
+
+	It("should change when the spec or a referenced object changes", func() {
+		mod := moduleWithReferences()
+
+		expectGets(map[string]string{"dockerfile": "1", "signing-key": "2"})
+
+		version, err := InputsVersion(ctx, clnt, mod, &kmmv1beta1.KernelMapping{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(version).To(ContainSubstring("ConfigMap/dockerfile=1"))
+		Expect(version).To(ContainSubstring("ConfigMap/other-dockerfile=,"))
+		Expect(version).To(ContainSubstring("Secret/signing-key=2"))
+
+		Expect(InputsVersion(ctx, clnt, mod, &kmmv1beta1.KernelMapping{})).To(Equal(version))
+
+		mod.Spec.RebuildTrigger = "new"
+
+		Expect(InputsVersion(ctx, clnt, mod, &kmmv1beta1.KernelMapping{})).NotTo(Equal(version))
+	})
+
+	It("should return an error if an object could not be fetched", func() {
+		clnt.EXPECT().Get(ctx, gomock.Any(), gomock.Any()).Return(errors.New("some error"))
+
+		_, err := InputsVersion(ctx, clnt, moduleWithReferences(), &kmmv1beta1.KernelMapping{})
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("ValidateReferences", func() {
+	var (
+		ctrl *gomock.Controller
+		clnt *client.MockClient
+		rv   ReferenceValidator
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		clnt = client.NewMockClient(ctrl)
+		rv = NewReferenceValidator(clnt)
+	})
+
+	ctx := context.Background()
+
+	notFound := k8serrors.NewNotFound(schema.GroupResource{}, "whatever")
+
+	expectConfigMap := func(name string, data map[string]string) *gomock.Call {
+		return clnt.
+			EXPECT().
+			Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, &v1.ConfigMap{}).
+			DoAndReturn(func(_ interface{}, _ interface{}, cm *v1.ConfigMap, _ ...ctrlclient.GetOption) error {
+				cm.Data = data
+				return nil
+			})
+	}
+
+	expectSecret := func(name string, secretType v1.SecretType, data map[string][]byte) *gomock.Call {
+		return clnt.
+			EXPECT().
+			Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, &v1.Secret{}).
+			DoAndReturn(func(_ interface{}, _ interface{}, s *v1.Secret, _ ...ctrlclient.GetOption) error {
+				s.Type = secretType
+				s.Data = data
+				return nil
+			})
+	}
+
+	It("should return nothing if all references are valid", func() {
+		gomock.InOrder(
+			expectConfigMap("dockerfile", map[string]string{constants.DockerfileCMKey: "FROM scratch"}),
+			expectConfigMap("other-dockerfile", map[string]string{constants.DockerfileCMKey: "FROM scratch"}),
+			expectSecret("build-secret", v1.SecretTypeOpaque, nil),
+			expectSecret("pull-secret", v1.SecretTypeDockerConfigJson, map[string][]byte{v1.DockerConfigJsonKey: []byte("{}")}),
+			expectSecret("signing-cert", v1.SecretTypeOpaque, map[string][]byte{constants.PublicSignDataKey: []byte("cert")}),
+			expectSecret("signing-key", v1.SecretTypeOpaque, map[string][]byte{constants.PrivateSignDataKey: []byte("key")}),
+		)
+
+		res, err := rv.ValidateReferences(ctx, moduleWithReferences(), nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(BeEmpty())
+	})
+
+	It("should report missing objects, missing keys and wrong types", func() {
+		gomock.InOrder(
+			expectConfigMap("dockerfile", map[string]string{"some-key": "FROM scratch"}),
+			clnt.EXPECT().Get(ctx, types.NamespacedName{Name: "other-dockerfile", Namespace: namespace}, &v1.ConfigMap{}).Return(notFound),
+			expectSecret("build-secret", v1.SecretTypeOpaque, nil),
+			expectSecret("pull-secret", v1.SecretTypeOpaque, map[string][]byte{v1.DockerConfigJsonKey: []byte("{}")}),
+			expectSecret("signing-cert", v1.SecretTypeOpaque, map[string][]byte{constants.PrivateSignDataKey: []byte("cert")}),
+			clnt.EXPECT().Get(ctx, types.NamespacedName{Name: "signing-key", Namespace: namespace}, &v1.Secret{}).Return(notFound),
+		)
+
+		res, err := rv.ValidateReferences(ctx, moduleWithReferences(), nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(Equal([]kmmv1beta1.InvalidReference{
+			{Kind: "ConfigMap", Name: "dockerfile", Message: "key dockerfile is missing"},
+			{Kind: "ConfigMap", Name: "other-dockerfile", Message: "not found"},
+			{Kind: "Secret", Name: "pull-secret", Message: `type is "Opaque", expected "kubernetes.io/dockerconfigjson"`},
+			{Kind: "Secret", Name: "signing-cert", Message: "key cert is missing"},
+			{Kind: "Secret", Name: "signing-key", Message: "not found"},
+		}))
+	})
+
+	It("should check the key of the kernel mappings catalog", func() {
+		mod := moduleWithReferences()
+		mod.Spec.ImageRepoSecret = nil
+		mod.Spec.ModuleLoader.Container.Build = nil
+		mod.Spec.ModuleLoader.Container.KernelMappings = nil
+		mod.Spec.ModuleLoader.Container.KernelMappingResolver = &kmmv1beta1.KernelMappingResolver{
+			ConfigMap: &v1.LocalObjectReference{Name: "catalog"},
+		}
+
+		expectConfigMap("catalog", map[string]string{constants.DockerfileCMKey: "FROM scratch"})
+
+		res, err := rv.ValidateReferences(ctx, mod, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(Equal([]kmmv1beta1.InvalidReference{
+			{Kind: "ConfigMap", Name: "catalog", Message: "key kernelMappings is missing"},
+		}))
+	})
+
+	It("should check the build context", func() {
+		mod := moduleWithReferences()
+		mod.Spec.ImageRepoSecret = nil
+		mod.Spec.ModuleLoader.Container.KernelMappings = nil
+		mod.Spec.ModuleLoader.Container.Build = &kmmv1beta1.Build{
+			DockerfileConfigMap: &v1.LocalObjectReference{Name: "dockerfile"},
+			Context: &kmmv1beta1.BuildContext{
+				Git: &kmmv1beta1.GitBuildSource{
+					URL:               "https://git.example.com/repo.git",
+					CredentialsSecret: &v1.LocalObjectReference{Name: "git-credentials"},
+				},
+				ConfigMaps: []kmmv1beta1.ConfigMapBuildSource{{Name: "patches"}},
+			},
+		}
+
+		gomock.InOrder(
+			expectConfigMap("dockerfile", map[string]string{constants.DockerfileCMKey: "FROM scratch"}),
+			clnt.EXPECT().Get(ctx, types.NamespacedName{Name: "patches", Namespace: namespace}, &v1.ConfigMap{}).Return(notFound),
+			expectSecret("git-credentials", v1.SecretTypeBasicAuth, map[string][]byte{v1.BasicAuthUsernameKey: []byte("user")}),
+		)
+
+		res, err := rv.ValidateReferences(ctx, mod, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(Equal([]kmmv1beta1.InvalidReference{
+			{Kind: "ConfigMap", Name: "patches", Message: "not found"},
+			{Kind: "Secret", Name: "git-credentials", Message: "key password is missing"},
+		}))
+	})
+
+	It("should check the keys of the build arguments that are not optional", func() {
+		optional := true
+
+		mod := moduleWithReferences()
+		mod.Spec.ImageRepoSecret = nil
+		mod.Spec.ModuleLoader.Container.KernelMappings = nil
+		mod.Spec.ModuleLoader.Container.Build = &kmmv1beta1.Build{
+			BuildArgs: []kmmv1beta1.BuildArg{
+				{
+					Name: "from-configmap",
+					ValueFrom: &kmmv1beta1.BuildArgSource{
+						ConfigMapKeyRef: &v1.ConfigMapKeySelector{
+							LocalObjectReference: v1.LocalObjectReference{Name: "build-args"},
+							Key:                  "some-key",
+						},
+					},
+				},
+				{
+					Name: "optional",
+					ValueFrom: &kmmv1beta1.BuildArgSource{
+						ConfigMapKeyRef: &v1.ConfigMapKeySelector{
+							LocalObjectReference: v1.LocalObjectReference{Name: "optional-build-args"},
+							Key:                  "some-key",
+							Optional:             &optional,
+						},
+					},
+				},
+				{
+					Name: "from-secret",
+					ValueFrom: &kmmv1beta1.BuildArgSource{
+						SecretKeyRef: &v1.SecretKeySelector{
+							LocalObjectReference: v1.LocalObjectReference{Name: "build-arg-secret"},
+							Key:                  "some-key",
+						},
+					},
+				},
+			},
+		}
+
+		gomock.InOrder(
+			expectConfigMap("build-args", map[string]string{"some-key": "value"}),
+			clnt.EXPECT().Get(ctx, types.NamespacedName{Name: "build-arg-secret", Namespace: namespace}, &v1.Secret{}).Return(notFound),
+		)
+
+		res, err := rv.ValidateReferences(ctx, mod, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(Equal([]kmmv1beta1.InvalidReference{
+			{Kind: "Secret", Name: "build-arg-secret", Message: "not found"},
+		}))
+	})
+
+	It("should check the substituted names of the prepared mappings", func() {
+		mod := moduleWithReferences()
+		mod.Spec.ImageRepoSecret = nil
+		mod.Spec.ModuleLoader.Container.Build = nil
+		mod.Spec.ModuleLoader.Container.KernelMappings[0].Build.DockerfileConfigMap.Name = "dockerfile-${KERNEL_XYZ}"
+		mod.Spec.ModuleLoader.Container.KernelMappings[0].Sign = nil
+
+		mappings := map[KernelTarget]*kmmv1beta1.KernelMapping{
+			{KernelVersion: "1.2.3", Architecture: "amd64"}: {
+				Build: &kmmv1beta1.Build{
+					DockerfileConfigMap: &v1.LocalObjectReference{Name: "dockerfile-1.2.3"},
+				},
+			},
+		}
+
+		clnt.
+			EXPECT().
+			Get(ctx, types.NamespacedName{Name: "dockerfile-1.2.3", Namespace: namespace}, &v1.ConfigMap{}).
+			Return(notFound)
+
+		res, err := rv.ValidateReferences(ctx, mod, mappings)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(Equal([]kmmv1beta1.InvalidReference{
+			{Kind: "ConfigMap", Name: "dockerfile-1.2.3", Message: "not found"},
+		}))
+	})
+
+	It("should return an error if an object could not be fetched", func() {
+		clnt.
+			EXPECT().
+			Get(ctx, types.NamespacedName{Name: "dockerfile", Namespace: namespace}, &v1.ConfigMap{}).
+			Return(errors.New("some error"))
+
+		_, err := rv.ValidateReferences(ctx, moduleWithReferences(), nil)
+		Expect(err).To(HaveOccurred())
+	})
+})
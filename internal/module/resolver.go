@@ -0,0 +1,257 @@
+package module
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/constants"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// MappingSourceModule is the source of the mappings found in the KernelMappings of a Module.
+	MappingSourceModule = "KernelMappings"
+
+	defaultResolverCacheTTL = 10 * time.Minute
+	resolverTimeout         = 10 * time.Second
+)
+
+//go:generate mockgen -source=resolver.go -package=module -destination=mock_resolver.go
+
+type MappingResolver interface {
+	ResolveMapping(ctx context.Context, mod *kmmv1beta1.Module, kernelVersion string, node *v1.Node) (*kmmv1beta1.KernelMapping, string, error)
+}
+
+type resolverCacheKey struct {
+	url           string
+	namespace     string
+	module        string
+	kernelVersion string
+	architecture  string
+	osImage       string
+}
+
+type resolverCacheEntry struct {
+	mapping *kmmv1beta1.KernelMapping
+	expires time.Time
+}
+
+type mappingResolver struct {
+	client         client.Client
+	kernelAPI      KernelMapper
+	httpClient     *http.Client
+	insecureClient *http.Client
+	now            func() time.Time
+
+	cacheLock sync.Mutex
+	cache     map[resolverCacheKey]resolverCacheEntry
+}
+
+func NewMappingResolver(client client.Client, kernelAPI KernelMapper) MappingResolver {
+	insecureTransport := http.DefaultTransport.(*http.Transport).Clone()
+	insecureTransport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+
+	return &mappingResolver{
+		client:         client,
+		kernelAPI:      kernelAPI,
+		httpClient:     &http.Client{Timeout: resolverTimeout},
+		insecureClient: &http.Client{Timeout: resolverTimeout, Transport: insecureTransport},
+		now:            time.Now,
+		cache:          make(map[resolverCacheKey]resolverCacheEntry),
+	}
+}
+
+// ResolveMapping returns the mapping of node and where it was found.
+// The KernelMappings of mod are tried first. If none matches, the KernelMappingResolver of mod is queried, if any.
+// ErrNoMapping is returned if no mapping was found.
+func (r *mappingResolver) ResolveMapping(ctx context.Context, mod *kmmv1beta1.Module, kernelVersion string, node *v1.Node) (*kmmv1beta1.KernelMapping, string, error) {
+	m, err := r.kernelAPI.FindMappingForNode(mod.Spec.ModuleLoader.Container.KernelMappings, kernelVersion, node)
+	if err == nil {
+		return m, MappingSourceModule, nil
+	}
+	if !errors.Is(err, ErrNoMapping) {
+		return nil, "", err
+	}
+
+	resolver := mod.Spec.ModuleLoader.Container.KernelMappingResolver
+
+	switch {
+	case resolver == nil:
+		return nil, "", err
+	case resolver.ConfigMap != nil:
+		m, err = r.resolveFromConfigMap(ctx, resolver.ConfigMap.Name, mod.Namespace, kernelVersion, node)
+		return m, "ConfigMap/" + resolver.ConfigMap.Name, err
+	case resolver.HTTP != nil:
+		m, err = r.resolveFromHTTP(ctx, resolver.HTTP, mod, kernelVersion, node)
+		return m, resolver.HTTP.URL, err
+	default:
+		return nil, "", ErrNoMapping
+	}
+}
+
+// resolveFromConfigMap matches the mappings of the catalog ConfigMap.
+// ConfigMaps are read from the cache of the client, so their contents are not cached again.
+func (r *mappingResolver) resolveFromConfigMap(ctx context.Context, name, namespace, kernelVersion string, node *v1.Node) (*kmmv1beta1.KernelMapping, error) {
+	cm := v1.ConfigMap{}
+
+	if err := r.client.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, &cm); err != nil {
+		return nil, fmt.Errorf("could not get the kernel mappings ConfigMap %s: %v", name, err)
+	}
+
+	data, ok := cm.Data[constants.KernelMappingsCMKey]
+	if !ok {
+		return nil, fmt.Errorf("key %s is missing from ConfigMap %s", constants.KernelMappingsCMKey, name)
+	}
+
+	mappings := make([]kmmv1beta1.KernelMapping, 0)
+
+	if err := yaml.NewYAMLOrJSONDecoder(strings.NewReader(data), len(data)).Decode(&mappings); err != nil {
+		return nil, fmt.Errorf("could not decode the kernel mappings of ConfigMap %s: %v", name, err)
+	}
+
+	m, err := r.kernelAPI.FindMappingForNode(mappings, kernelVersion, node)
+	if err != nil {
+		return nil, ErrNoMapping
+	}
+
+	return m, nil
+}
+
+// resolveFromHTTP queries the resolver endpoint.
+// Replies, including the absence of a mapping, are cached for the TTL of the resolver.
+func (r *mappingResolver) resolveFromHTTP(
+	ctx context.Context,
+	resolver *kmmv1beta1.HTTPKernelMappingResolver,
+	mod *kmmv1beta1.Module,
+	kernelVersion string,
+	node *v1.Node) (*kmmv1beta1.KernelMapping, error) {
+
+	key := resolverCacheKey{
+		url:           resolver.URL,
+		namespace:     mod.Namespace,
+		module:        mod.Name,
+		kernelVersion: kernelVersion,
+		architecture:  NodeArchitecture(node),
+		osImage:       node.Status.NodeInfo.OSImage,
+	}
+
+	if m, ok := r.getCached(key); ok {
+		if m == nil {
+			return nil, ErrNoMapping
+		}
+
+		return m.DeepCopy(), nil
+	}
+
+	m, err := r.queryHTTP(ctx, resolver, key)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := defaultResolverCacheTTL
+	if resolver.CacheTTL != nil {
+		ttl = resolver.CacheTTL.Duration
+	}
+
+	r.setCached(key, m, ttl)
+
+	if m == nil {
+		return nil, ErrNoMapping
+	}
+
+	return m.DeepCopy(), nil
+}
+
+// queryHTTP returns the mapping replied by the endpoint, or nil if it has none.
+func (r *mappingResolver) queryHTTP(ctx context.Context, resolver *kmmv1beta1.HTTPKernelMappingResolver, key resolverCacheKey) (*kmmv1beta1.KernelMapping, error) {
+	u, err := url.Parse(resolver.URL)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse the resolver URL %q: %v", resolver.URL, err)
+	}
+
+	q := u.Query()
+	q.Set("kernelVersion", key.kernelVersion)
+	q.Set("architecture", key.architecture)
+	q.Set("osImage", key.osImage)
+	q.Set("namespace", key.namespace)
+	q.Set("module", key.module)
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not create the resolver request: %v", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	httpClient := r.httpClient
+	if resolver.InsecureSkipTLSVerify {
+		httpClient = r.insecureClient
+	}
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not query the resolver %s: %v", resolver.URL, err)
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("resolver %s replied with status %d", resolver.URL, res.StatusCode)
+	}
+
+	m := kmmv1beta1.KernelMapping{}
+
+	if err = json.NewDecoder(res.Body).Decode(&m); err != nil {
+		return nil, fmt.Errorf("could not decode the reply of resolver %s: %v", resolver.URL, err)
+	}
+
+	if m.ContainerImage == "" {
+		return nil, fmt.Errorf("resolver %s replied with an empty containerImage", resolver.URL)
+	}
+
+	return &m, nil
+}
+
+func (r *mappingResolver) getCached(key resolverCacheKey) (*kmmv1beta1.KernelMapping, bool) {
+	r.cacheLock.Lock()
+	defer r.cacheLock.Unlock()
+
+	entry, ok := r.cache[key]
+	if !ok || r.now().After(entry.expires) {
+		return nil, false
+	}
+
+	return entry.mapping, true
+}
+
+func (r *mappingResolver) setCached(key resolverCacheKey, m *kmmv1beta1.KernelMapping, ttl time.Duration) {
+	r.cacheLock.Lock()
+	defer r.cacheLock.Unlock()
+
+	now := r.now()
+
+	// drop expired entries so that the cache does not grow with kernels that are not in the cluster anymore
+	for k, e := range r.cache {
+		if now.After(e.expires) {
+			delete(r.cache, k)
+		}
+	}
+
+	r.cache[key] = resolverCacheEntry{mapping: m, expires: now.Add(ttl)}
+}
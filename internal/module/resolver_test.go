@@ -0,0 +1,232 @@
+package module
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	gomock "github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/client"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/constants"
+)
+
+var _ = Describe("ResolveMapping", func() {
+	const kernelVersion = "5.14.0-284.11.1.el9_2.x86_64"
+
+	var (
+		ctrl *gomock.Controller
+		clnt *client.MockClient
+		mr   *mappingResolver
+		mod  *kmmv1beta1.Module
+		node *v1.Node
+	)
+
+	ctx := context.Background()
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		clnt = client.NewMockClient(ctrl)
+		mr = NewMappingResolver(clnt, NewKernelMapper(nil, nil)).(*mappingResolver)
+
+		mod = &kmmv1beta1.Module{
+			ObjectMeta: metav1.ObjectMeta{Name: "some-module", Namespace: namespace},
+			Spec: kmmv1beta1.ModuleSpec{
+				ModuleLoader: kmmv1beta1.ModuleLoaderSpec{
+					Container: kmmv1beta1.ModuleLoaderContainerSpec{
+						KernelMappings: []kmmv1beta1.KernelMapping{
+							{ContainerImage: "inline", Literal: "5.15.0-76-generic"},
+						},
+					},
+				},
+			},
+		}
+
+		node = &v1.Node{
+			Status: v1.NodeStatus{
+				NodeInfo: v1.NodeSystemInfo{
+					Architecture:  "amd64",
+					KernelVersion: kernelVersion,
+					OSImage:       "Red Hat Enterprise Linux CoreOS 413.92.202303281804-0 (Plow)",
+				},
+			},
+		}
+	})
+
+	It("should prefer the KernelMappings of the Module", func() {
+		m, source, err := mr.ResolveMapping(ctx, mod, "5.15.0-76-generic", node)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(m.ContainerImage).To(Equal("inline"))
+		Expect(source).To(Equal(MappingSourceModule))
+	})
+
+	It("should return ErrNoMapping without a resolver", func() {
+		_, _, err := mr.ResolveMapping(ctx, mod, kernelVersion, node)
+		Expect(err).To(MatchError(ErrNoMapping))
+	})
+
+	Context("ConfigMap", func() {
+		BeforeEach(func() {
+			mod.Spec.ModuleLoader.Container.KernelMappingResolver = &kmmv1beta1.KernelMappingResolver{
+				ConfigMap: &v1.LocalObjectReference{Name: "catalog"},
+			}
+		})
+
+		expectConfigMap := func(data map[string]string) {
+			clnt.
+				EXPECT().
+				Get(ctx, types.NamespacedName{Name: "catalog", Namespace: namespace}, &v1.ConfigMap{}).
+				DoAndReturn(func(_ interface{}, _ interface{}, cm *v1.ConfigMap, _ ...ctrlclient.GetOption) error {
+					cm.Data = data
+					return nil
+				})
+		}
+
+		It("should match the mappings of the catalog", func() {
+			expectConfigMap(map[string]string{
+				constants.KernelMappingsCMKey: `
+- regexp: '^.+\.el9_2\..+$'
+  architecture: aarch64
+  containerImage: arm
+- regexp: '^.+\.el9_2\..+$'
+  containerImage: some-image:${KERNEL_FULL_VERSION}
+  build:
+    dockerfileConfigMap:
+      name: dockerfile
+`,
+			})
+
+			m, source, err := mr.ResolveMapping(ctx, mod, kernelVersion, node)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(source).To(Equal("ConfigMap/catalog"))
+			Expect(m.ContainerImage).To(Equal("some-image:${KERNEL_FULL_VERSION}"))
+			Expect(m.Build.DockerfileConfigMap.Name).To(Equal("dockerfile"))
+		})
+
+		It("should return ErrNoMapping if no mapping of the catalog matches", func() {
+			expectConfigMap(map[string]string{constants.KernelMappingsCMKey: "[{literal: other, containerImage: image}]"})
+
+			_, _, err := mr.ResolveMapping(ctx, mod, kernelVersion, node)
+			Expect(err).To(MatchError(ErrNoMapping))
+		})
+
+		It("should return an error if the key is missing", func() {
+			expectConfigMap(map[string]string{"some-key": "[]"})
+
+			_, _, err := mr.ResolveMapping(ctx, mod, kernelVersion, node)
+			Expect(err).To(HaveOccurred())
+			Expect(err).NotTo(MatchError(ErrNoMapping))
+		})
+
+		It("should return an error if the ConfigMap cannot be fetched", func() {
+			clnt.EXPECT().Get(ctx, gomock.Any(), gomock.Any()).Return(errors.New("some error"))
+
+			_, _, err := mr.ResolveMapping(ctx, mod, kernelVersion, node)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("HTTP", func() {
+		var (
+			requests int
+			status   int
+			server   *httptest.Server
+		)
+
+		BeforeEach(func() {
+			requests = 0
+			status = http.StatusOK
+
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+
+				q := r.URL.Query()
+				if q.Get("kernelVersion") != kernelVersion ||
+					q.Get("architecture") != "amd64" ||
+					q.Get("namespace") != namespace ||
+					q.Get("module") != "some-module" ||
+					q.Get("osImage") == "" {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+
+				if status != http.StatusOK {
+					w.WriteHeader(status)
+					return
+				}
+
+				_ = json.NewEncoder(w).Encode(kmmv1beta1.KernelMapping{
+					ContainerImage: "remote:" + q.Get("kernelVersion"),
+					Sign:           &kmmv1beta1.Sign{FilesToSign: []string{"/opt/mod.ko"}},
+				})
+			}))
+			DeferCleanup(server.Close)
+
+			mod.Spec.ModuleLoader.Container.KernelMappingResolver = &kmmv1beta1.KernelMappingResolver{
+				HTTP: &kmmv1beta1.HTTPKernelMappingResolver{URL: server.URL + "/mappings"},
+			}
+		})
+
+		It("should return the mapping of the endpoint and cache it", func() {
+			for i := 0; i < 2; i++ {
+				m, source, err := mr.ResolveMapping(ctx, mod, kernelVersion, node)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(source).To(Equal(server.URL + "/mappings"))
+				Expect(m.ContainerImage).To(Equal("remote:" + kernelVersion))
+				Expect(m.Sign.FilesToSign).To(Equal([]string{"/opt/mod.ko"}))
+			}
+
+			Expect(requests).To(Equal(1))
+		})
+
+		It("should query the endpoint again once the cache has expired", func() {
+			mod.Spec.ModuleLoader.Container.KernelMappingResolver.HTTP.CacheTTL = &metav1.Duration{Duration: time.Minute}
+
+			now := time.Now()
+			mr.now = func() time.Time { return now }
+
+			_, _, err := mr.ResolveMapping(ctx, mod, kernelVersion, node)
+			Expect(err).NotTo(HaveOccurred())
+
+			now = now.Add(2 * time.Minute)
+
+			_, _, err = mr.ResolveMapping(ctx, mod, kernelVersion, node)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(requests).To(Equal(2))
+			Expect(mr.cache).To(HaveLen(1))
+		})
+
+		It("should cache the absence of a mapping", func() {
+			status = http.StatusNotFound
+
+			for i := 0; i < 2; i++ {
+				_, _, err := mr.ResolveMapping(ctx, mod, kernelVersion, node)
+				Expect(err).To(MatchError(ErrNoMapping))
+			}
+
+			Expect(requests).To(Equal(1))
+		})
+
+		It("should not cache errors", func() {
+			status = http.StatusInternalServerError
+
+			for i := 0; i < 2; i++ {
+				_, _, err := mr.ResolveMapping(ctx, mod, kernelVersion, node)
+				Expect(err).To(HaveOccurred())
+				Expect(err).NotTo(MatchError(ErrNoMapping))
+			}
+
+			Expect(requests).To(Equal(2))
+		})
+	})
+})
@@ -0,0 +1,165 @@
+package module
+
+import (
+	"fmt"
+
+	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+var nodeSelectorOperators = map[v1.NodeSelectorOperator]selection.Operator{
+	v1.NodeSelectorOpIn:           selection.In,
+	v1.NodeSelectorOpNotIn:        selection.NotIn,
+	v1.NodeSelectorOpExists:       selection.Exists,
+	v1.NodeSelectorOpDoesNotExist: selection.DoesNotExist,
+	v1.NodeSelectorOpGt:           selection.GreaterThan,
+	v1.NodeSelectorOpLt:           selection.LessThan,
+}
+
+// Selector combines an equality-based selector with an optional set-based selector.
+// Objects must match both to be selected.
+func Selector(selector map[string]string, labelSelector *metav1.LabelSelector) (labels.Selector, error) {
+	sel := labels.SelectorFromSet(selector)
+
+	if labelSelector == nil {
+		return sel, nil
+	}
+
+	setSelector, err := metav1.LabelSelectorAsSelector(labelSelector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid label selector: %v", err)
+	}
+
+	requirements, _ := setSelector.Requirements()
+
+	return sel.Add(requirements...), nil
+}
+
+// NodeSelector returns the selector matching the nodes targeted by modSpec.
+func NodeSelector(modSpec kmmv1beta1.ModuleSpec) (labels.Selector, error) {
+	return Selector(modSpec.Selector, modSpec.LabelSelector)
+}
+
+// NodeAffinity returns the node affinity equivalent to modSpec's set-based selector, or nil if the Module does not
+// define one.
+// The equality-based selector is expected to be used as the pods' node selector.
+func NodeAffinity(modSpec kmmv1beta1.ModuleSpec) *v1.NodeAffinity {
+	ls := modSpec.LabelSelector
+	if ls == nil || (len(ls.MatchLabels) == 0 && len(ls.MatchExpressions) == 0) {
+		return nil
+	}
+
+	requirements := make([]v1.NodeSelectorRequirement, 0, len(ls.MatchLabels)+len(ls.MatchExpressions))
+
+	for _, k := range sets.StringKeySet(ls.MatchLabels).List() {
+		requirements = append(requirements, v1.NodeSelectorRequirement{
+			Key:      k,
+			Operator: v1.NodeSelectorOpIn,
+			Values:   []string{ls.MatchLabels[k]},
+		})
+	}
+
+	for _, expr := range ls.MatchExpressions {
+		requirements = append(requirements, v1.NodeSelectorRequirement{
+			Key:      expr.Key,
+			Operator: v1.NodeSelectorOperator(expr.Operator),
+			Values:   expr.Values,
+		})
+	}
+
+	return &v1.NodeAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: &v1.NodeSelector{
+			NodeSelectorTerms: []v1.NodeSelectorTerm{
+				{MatchExpressions: requirements},
+			},
+		},
+	}
+}
+
+// Affinity returns the pod affinity that restricts pods to the nodes matching modSpec's set-based selector, or nil if
+// the Module does not define one.
+func Affinity(modSpec kmmv1beta1.ModuleSpec) *v1.Affinity {
+	nodeAffinity := NodeAffinity(modSpec)
+	if nodeAffinity == nil {
+		return nil
+	}
+
+	return &v1.Affinity{NodeAffinity: nodeAffinity}
+}
+
+// MatchesNodeAffinity returns true if node matches the required node affinity of affinity, like the scheduler does:
+// the node must match one of its terms.
+func MatchesNodeAffinity(node *v1.Node, affinity *v1.Affinity) (bool, error) {
+	if affinity == nil || affinity.NodeAffinity == nil || affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		return true, nil
+	}
+
+	for _, term := range affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms {
+		matches, err := matchesNodeSelectorTerm(node, term)
+		if err != nil {
+			return false, err
+		}
+
+		if matches {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// matchesNodeSelectorTerm returns true if node matches all the requirements of term.
+// Empty terms match no node.
+func matchesNodeSelectorTerm(node *v1.Node, term v1.NodeSelectorTerm) (bool, error) {
+	if len(term.MatchExpressions) == 0 && len(term.MatchFields) == 0 {
+		return false, nil
+	}
+
+	sel := labels.NewSelector()
+
+	for _, expr := range term.MatchExpressions {
+		op, ok := nodeSelectorOperators[expr.Operator]
+		if !ok {
+			return false, fmt.Errorf("invalid node selector operator %q", expr.Operator)
+		}
+
+		req, err := labels.NewRequirement(expr.Key, op, expr.Values)
+		if err != nil {
+			return false, fmt.Errorf("invalid node selector requirement: %v", err)
+		}
+
+		sel = sel.Add(*req)
+	}
+
+	if !sel.Matches(labels.Set(node.Labels)) {
+		return false, nil
+	}
+
+	// metadata.name is the only field supported by the scheduler
+	for _, f := range term.MatchFields {
+		if f.Key != "metadata.name" {
+			return false, fmt.Errorf("invalid node selector field %q", f.Key)
+		}
+
+		var matches bool
+
+		switch f.Operator {
+		case v1.NodeSelectorOpIn:
+			matches = len(f.Values) == 1 && node.Name == f.Values[0]
+		case v1.NodeSelectorOpNotIn:
+			matches = len(f.Values) == 1 && node.Name != f.Values[0]
+		default:
+			return false, fmt.Errorf("invalid node selector field operator %q", f.Operator)
+		}
+
+		if !matches {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
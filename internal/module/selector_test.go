@@ -0,0 +1,199 @@
+package module
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
+)
+
+var _ = Describe("Selector", func() {
+	It("should only use the equality-based selector if there is no label selector", func() {
+		sel, err := Selector(map[string]string{"a": "b"}, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sel.String()).To(Equal("a=b"))
+	})
+
+	It("should select everything if no selector is set", func() {
+		sel, err := Selector(nil, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sel.Matches(labels.Set{"a": "b"})).To(BeTrue())
+	})
+
+	DescribeTable("should combine both selectors",
+		func(set labels.Set, expected bool) {
+			ls := &metav1.LabelSelector{
+				MatchLabels: map[string]string{"c": "d"},
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: "e", Operator: metav1.LabelSelectorOpIn, Values: []string{"f", "g"}},
+					{Key: "h", Operator: metav1.LabelSelectorOpDoesNotExist},
+				},
+			}
+
+			sel, err := Selector(map[string]string{"a": "b"}, ls)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sel.Matches(set)).To(Equal(expected))
+		},
+		Entry("all requirements met", labels.Set{"a": "b", "c": "d", "e": "g"}, true),
+		Entry("equality-based selector not met", labels.Set{"c": "d", "e": "g"}, false),
+		Entry("matchLabels not met", labels.Set{"a": "b", "e": "g"}, false),
+		Entry("In expression not met", labels.Set{"a": "b", "c": "d", "e": "x"}, false),
+		Entry("DoesNotExist expression not met", labels.Set{"a": "b", "c": "d", "e": "f", "h": ""}, false),
+	)
+
+	It("should return an error for an invalid label selector", func() {
+		ls := &metav1.LabelSelector{
+			MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: "a", Operator: metav1.LabelSelectorOpIn},
+			},
+		}
+
+		_, err := Selector(nil, ls)
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("NodeAffinity", func() {
+	It("should return nil if there is no label selector", func() {
+		Expect(
+			NodeAffinity(kmmv1beta1.ModuleSpec{Selector: map[string]string{"a": "b"}}),
+		).To(
+			BeNil(),
+		)
+	})
+
+	It("should return nil if the label selector is empty", func() {
+		Expect(
+			NodeAffinity(kmmv1beta1.ModuleSpec{LabelSelector: &metav1.LabelSelector{}}),
+		).To(
+			BeNil(),
+		)
+	})
+
+	It("should convert the label selector to a required node affinity", func() {
+		modSpec := kmmv1beta1.ModuleSpec{
+			LabelSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"c": "d", "a": "b"},
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: "e", Operator: metav1.LabelSelectorOpNotIn, Values: []string{"f"}},
+					{Key: "g", Operator: metav1.LabelSelectorOpExists},
+				},
+			},
+		}
+
+		expected := &v1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &v1.NodeSelector{
+				NodeSelectorTerms: []v1.NodeSelectorTerm{
+					{
+						MatchExpressions: []v1.NodeSelectorRequirement{
+							{Key: "a", Operator: v1.NodeSelectorOpIn, Values: []string{"b"}},
+							{Key: "c", Operator: v1.NodeSelectorOpIn, Values: []string{"d"}},
+							{Key: "e", Operator: v1.NodeSelectorOpNotIn, Values: []string{"f"}},
+							{Key: "g", Operator: v1.NodeSelectorOpExists},
+						},
+					},
+				},
+			},
+		}
+
+		Expect(NodeAffinity(modSpec)).To(Equal(expected))
+	})
+})
+
+var _ = Describe("Affinity", func() {
+	It("should return nil if there is no label selector", func() {
+		Expect(Affinity(kmmv1beta1.ModuleSpec{Selector: map[string]string{"a": "b"}})).To(BeNil())
+	})
+
+	It("should wrap the node affinity", func() {
+		modSpec := kmmv1beta1.ModuleSpec{
+			LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"a": "b"}},
+		}
+
+		Expect(Affinity(modSpec)).To(Equal(&v1.Affinity{NodeAffinity: NodeAffinity(modSpec)}))
+	})
+})
+
+var _ = Describe("MatchesNodeAffinity", func() {
+	node := v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "node",
+			Labels: map[string]string{"a": "b", "count": "3"},
+		},
+	}
+
+	affinity := func(terms ...v1.NodeSelectorTerm) *v1.Affinity {
+		return &v1.Affinity{
+			NodeAffinity: &v1.NodeAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: &v1.NodeSelector{NodeSelectorTerms: terms},
+			},
+		}
+	}
+
+	It("should match every node if there is no required node affinity", func() {
+		Expect(MatchesNodeAffinity(&node, nil)).To(BeTrue())
+		Expect(MatchesNodeAffinity(&node, &v1.Affinity{})).To(BeTrue())
+	})
+
+	It("should not match an empty term", func() {
+		Expect(MatchesNodeAffinity(&node, affinity(v1.NodeSelectorTerm{}))).To(BeFalse())
+	})
+
+	DescribeTable("should evaluate the terms",
+		func(term v1.NodeSelectorTerm, expected bool) {
+			Expect(MatchesNodeAffinity(&node, affinity(term))).To(Equal(expected))
+		},
+		Entry(
+			"In, matching",
+			v1.NodeSelectorTerm{MatchExpressions: []v1.NodeSelectorRequirement{{Key: "a", Operator: v1.NodeSelectorOpIn, Values: []string{"b"}}}},
+			true,
+		),
+		Entry(
+			"NotIn, not matching",
+			v1.NodeSelectorTerm{MatchExpressions: []v1.NodeSelectorRequirement{{Key: "a", Operator: v1.NodeSelectorOpNotIn, Values: []string{"b"}}}},
+			false,
+		),
+		Entry(
+			"DoesNotExist, matching",
+			v1.NodeSelectorTerm{MatchExpressions: []v1.NodeSelectorRequirement{{Key: "c", Operator: v1.NodeSelectorOpDoesNotExist}}},
+			true,
+		),
+		Entry(
+			"Gt, matching",
+			v1.NodeSelectorTerm{MatchExpressions: []v1.NodeSelectorRequirement{{Key: "count", Operator: v1.NodeSelectorOpGt, Values: []string{"2"}}}},
+			true,
+		),
+		Entry(
+			"metadata.name In, matching",
+			v1.NodeSelectorTerm{MatchFields: []v1.NodeSelectorRequirement{{Key: "metadata.name", Operator: v1.NodeSelectorOpIn, Values: []string{"node"}}}},
+			true,
+		),
+		Entry(
+			"matching expression but not field",
+			v1.NodeSelectorTerm{
+				MatchExpressions: []v1.NodeSelectorRequirement{{Key: "a", Operator: v1.NodeSelectorOpExists}},
+				MatchFields:      []v1.NodeSelectorRequirement{{Key: "metadata.name", Operator: v1.NodeSelectorOpIn, Values: []string{"other"}}},
+			},
+			false,
+		),
+	)
+
+	It("should match if any term matches", func() {
+		terms := []v1.NodeSelectorTerm{
+			{MatchExpressions: []v1.NodeSelectorRequirement{{Key: "c", Operator: v1.NodeSelectorOpExists}}},
+			{MatchExpressions: []v1.NodeSelectorRequirement{{Key: "a", Operator: v1.NodeSelectorOpExists}}},
+		}
+
+		Expect(MatchesNodeAffinity(&node, affinity(terms...))).To(BeTrue())
+	})
+
+	It("should return an error for an invalid operator", func() {
+		term := v1.NodeSelectorTerm{MatchExpressions: []v1.NodeSelectorRequirement{{Key: "a", Operator: "Invalid"}}}
+
+		_, err := MatchesNodeAffinity(&node, affinity(term))
+		Expect(err).To(HaveOccurred())
+	})
+})
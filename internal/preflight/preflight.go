@@ -59,8 +59,12 @@ func (p *preflight) PreflightUpgradeCheck(ctx context.Context, pv *kmmv1beta1.Pr
 		return false, fmt.Sprintf("Failed to find kernel mapping in the module %s for kernel version %s", mod.Name, kernelVersion)
 	}
 
-	osConfig := module.NodeOSConfig{KernelFullVersion: kernelVersion}
-	mapping, err = p.kernelAPI.PrepareKernelMapping(mapping, &osConfig)
+	arch := module.NormalizeArchitecture(pv.Spec.Architecture)
+
+	osConfig := p.kernelAPI.GetNodeOSConfigFromKernelVersion(kernelVersion)
+	osConfig.Architecture = arch
+
+	mapping, err = p.kernelAPI.PrepareKernelMapping(mod.Spec, mapping, osConfig)
 	if err != nil {
 		return false, fmt.Sprintf("Failed to substitute template in kernel mapping in the module %s for kernel version %s", mod.Name, kernelVersion)
 	}
@@ -70,7 +74,7 @@ func (p *preflight) PreflightUpgradeCheck(ctx context.Context, pv *kmmv1beta1.Pr
 		log.Info(utils.WarnString("failed to update the stage of Module CR in preflight to image stage"), "module", mod.Name, "error", err)
 	}
 
-	verified, msg := p.helper.verifyImage(ctx, mapping, mod, kernelVersion)
+	verified, msg := p.helper.verifyImage(ctx, mapping, mod, kernelVersion, arch)
 	if verified {
 		return true, msg
 	}
@@ -84,7 +88,7 @@ func (p *preflight) PreflightUpgradeCheck(ctx context.Context, pv *kmmv1beta1.Pr
 			log.Info(utils.WarnString("failed to update the stage of Module CR in preflight to build stage"), "module", mod.Name, "error", err)
 		}
 
-		verified, msg = p.helper.verifyBuild(ctx, pv, mapping, mod)
+		verified, msg = p.helper.verifyBuild(ctx, pv, mapping, mod, osConfig)
 		if !verified {
 			return false, msg
 		}
@@ -104,8 +108,8 @@ func (p *preflight) PreflightUpgradeCheck(ctx context.Context, pv *kmmv1beta1.Pr
 }
 
 type preflightHelperAPI interface {
-	verifyImage(ctx context.Context, mapping *kmmv1beta1.KernelMapping, mod *kmmv1beta1.Module, kernelVersion string) (bool, string)
-	verifyBuild(ctx context.Context, pv *kmmv1beta1.PreflightValidation, mapping *kmmv1beta1.KernelMapping, mod *kmmv1beta1.Module) (bool, string)
+	verifyImage(ctx context.Context, mapping *kmmv1beta1.KernelMapping, mod *kmmv1beta1.Module, kernelVersion, arch string) (bool, string)
+	verifyBuild(ctx context.Context, pv *kmmv1beta1.PreflightValidation, mapping *kmmv1beta1.KernelMapping, mod *kmmv1beta1.Module, osConfig *module.NodeOSConfig) (bool, string)
 	verifySign(ctx context.Context, pv *kmmv1beta1.PreflightValidation, mapping *kmmv1beta1.KernelMapping, mod *kmmv1beta1.Module) (bool, string)
 }
 
@@ -125,15 +129,20 @@ func newPreflightHelper(client client.Client, buildAPI build.Manager, signAPI si
 	}
 }
 
-func (p *preflightHelper) verifyImage(ctx context.Context, mapping *kmmv1beta1.KernelMapping, mod *kmmv1beta1.Module, kernelVersion string) (bool, string) {
+func (p *preflightHelper) verifyImage(ctx context.Context, mapping *kmmv1beta1.KernelMapping, mod *kmmv1beta1.Module, kernelVersion, arch string) (bool, string) {
 	log := ctrlruntime.LoggerFrom(ctx)
 	image := mapping.ContainerImage
-	moduleFileName := mod.Spec.ModuleLoader.Container.Modprobe.ModuleName + ".ko"
 	baseDir := mod.Spec.ModuleLoader.Container.Modprobe.DirName
 
+	// all kernel modules loaded by the Module must be present in the image
+	missingFileNames := make([]string, 0)
+	for _, kernelModule := range kernelModuleNames(mod.Spec.ModuleLoader.Container.Modprobe) {
+		missingFileNames = append(missingFileNames, kernelModule+".ko")
+	}
+
 	tlsOptions := module.TLSOptions(mod.Spec, *mapping)
 	registryAuthGetter := auth.NewRegistryAuthGetterFrom(p.client, mod)
-	digests, repoConfig, err := p.registryAPI.GetLayersDigests(ctx, image, tlsOptions, registryAuthGetter)
+	digests, repoConfig, err := p.registryAPI.GetLayersDigests(ctx, image, tlsOptions, registryAuthGetter, registry.PlatformForArchitecture(arch))
 	if err != nil {
 		log.Info("image layers inaccessible, image probably does not exists", "module name", mod.Name, "image", image)
 		return false, fmt.Sprintf("image %s inaccessible or does not exists", image)
@@ -146,28 +155,60 @@ func (p *preflightHelper) verifyImage(ctx context.Context, mapping *kmmv1beta1.K
 			return false, fmt.Sprintf("image %s, layer %s is inaccessible", image, digests[i])
 		}
 
-		// check kernel module file present in the directory of the kernel lib modules
-		if p.registryAPI.VerifyModuleExists(layer, baseDir, kernelVersion, moduleFileName) {
+		stillMissing := make([]string, 0, len(missingFileNames))
+
+		for _, moduleFileName := range missingFileNames {
+			// check kernel module file present in the directory of the kernel lib modules
+			if p.registryAPI.VerifyModuleExists(layer, baseDir, kernelVersion, moduleFileName) {
+				continue
+			}
+
+			log.V(1).Info("module is not present in the current layer", "image", image, "module file name", moduleFileName, "kernel", kernelVersion, "dir", baseDir)
+			stillMissing = append(stillMissing, moduleFileName)
+		}
+
+		if len(stillMissing) == 0 {
 			return true, fmt.Sprintf(VerificationStatusReasonVerified, "image accessible and verified")
 		}
-		log.V(1).Info("module is not present in the current layer", "image", image, "module file name", moduleFileName, "kernel", kernelVersion, "dir", baseDir)
+
+		missingFileNames = stillMissing
 	}
 
-	log.Info("driver for kernel is not present in the image", "baseDir", baseDir, "kernel", kernelVersion, "moduleFileName", moduleFileName, "image", image)
+	log.Info("driver for kernel is not present in the image", "baseDir", baseDir, "kernel", kernelVersion, "moduleFileNames", missingFileNames, "image", image)
 	return false, fmt.Sprintf("image %s does not contain kernel module for kernel %s on any layer", image, kernelVersion)
 }
 
+// kernelModuleNames returns the names of all the kernel modules loaded by spec.
+func kernelModuleNames(spec kmmv1beta1.ModprobeSpec) []string {
+	if len(spec.Modules) == 0 {
+		return []string{spec.ModuleName}
+	}
+
+	names := make([]string, 0, len(spec.Modules))
+
+	for _, m := range spec.Modules {
+		names = append(names, m.Name)
+	}
+
+	return names
+}
+
 func (p *preflightHelper) verifyBuild(ctx context.Context,
 	pv *kmmv1beta1.PreflightValidation,
 	mapping *kmmv1beta1.KernelMapping,
-	mod *kmmv1beta1.Module) (bool, string) {
+	mod *kmmv1beta1.Module,
+	osConfig *module.NodeOSConfig) (bool, string) {
 	log := ctrlruntime.LoggerFrom(ctx)
 	// at this stage we know that eiher mapping Build or Container build are defined
-	buildRes, err := p.buildAPI.Sync(ctx, *mod, *mapping, pv.Spec.KernelVersion, pv.Spec.PushBuiltImage, pv)
+	buildRes, err := p.buildAPI.Sync(ctx, *mod, *mapping, pv.Spec.KernelVersion, osConfig.Architecture, osConfig, pv.Spec.PushBuiltImage, pv)
 	if err != nil {
 		return false, fmt.Sprintf("Failed to verify build for module %s, kernel version %s, error %s", mod.Name, pv.Spec.KernelVersion, err)
 	}
 
+	if buildRes.Status == build.StatusFailed {
+		return false, fmt.Sprintf("Build failed for module %s, kernel version %s: %s%s", mod.Name, pv.Spec.KernelVersion, buildRes.Reason, logsReference(buildRes.LogsConfigMap))
+	}
+
 	if buildRes.Status == build.StatusCompleted {
 		msg := "build compiles"
 		if pv.Spec.PushBuiltImage {
@@ -191,11 +232,15 @@ func (p *preflightHelper) verifySign(ctx context.Context,
 	}
 
 	// at this stage we know that eiher mapping Sign or Container sign are defined
-	signRes, err := p.signAPI.Sync(ctx, *mod, *mapping, pv.Spec.KernelVersion, previousImage, pv.Spec.PushBuiltImage, pv)
+	signRes, err := p.signAPI.Sync(ctx, *mod, *mapping, pv.Spec.KernelVersion, module.NormalizeArchitecture(pv.Spec.Architecture), previousImage, pv.Spec.PushBuiltImage, pv)
 	if err != nil {
 		return false, fmt.Sprintf("Failed to verify signing for module %s, kernel version %s, error %s", mod.Name, pv.Spec.KernelVersion, err)
 	}
 
+	if signRes.Status == utils.StatusFailed {
+		return false, fmt.Sprintf("Signing failed for module %s, kernel version %s: %s%s", mod.Name, pv.Spec.KernelVersion, signRes.Reason, logsReference(signRes.LogsConfigMap))
+	}
+
 	if signRes.Status == utils.StatusCompleted {
 		msg := "sign completes"
 		if pv.Spec.PushBuiltImage {
@@ -206,3 +251,12 @@ func (p *preflightHelper) verifySign(ctx context.Context,
 	}
 	return false, "Waiting for sign verification"
 }
+
+// logsReference points to the ConfigMap holding the logs of a failed job, if any.
+func logsReference(logsConfigMap string) string {
+	if logsConfigMap == "" {
+		return ""
+	}
+
+	return fmt.Sprintf("; logs are in ConfigMap %s", logsConfigMap)
+}
@@ -113,7 +113,8 @@ var _ = Describe("preflight_PreflightUpgradeCheck", func() {
 
 		gomock.InOrder(
 			mockKernelAPI.EXPECT().FindMappingForKernel(mod.Spec.ModuleLoader.Container.KernelMappings, kernelVersion).Return(&mapping, nil),
-			mockKernelAPI.EXPECT().PrepareKernelMapping(&mapping, gomock.Any()).Return(nil, fmt.Errorf("some error")),
+			mockKernelAPI.EXPECT().GetNodeOSConfigFromKernelVersion(kernelVersion).Return(&module.NodeOSConfig{}),
+			mockKernelAPI.EXPECT().PrepareKernelMapping(mod.Spec, &mapping, gomock.Any()).Return(nil, fmt.Errorf("some error")),
 		)
 
 		res, message := p.PreflightUpgradeCheck(context.Background(), pv, mod)
@@ -134,14 +135,17 @@ var _ = Describe("preflight_PreflightUpgradeCheck", func() {
 			mapping.Sign = &kmmv1beta1.Sign{}
 		}
 
+		osConfig := module.NodeOSConfig{KernelFullVersion: kernelVersion}
+
 		mockKernelAPI.EXPECT().FindMappingForKernel(mod.Spec.ModuleLoader.Container.KernelMappings, kernelVersion).Return(&mapping, nil)
-		mockKernelAPI.EXPECT().PrepareKernelMapping(&mapping, gomock.Any()).Return(&mapping, nil)
+		mockKernelAPI.EXPECT().GetNodeOSConfigFromKernelVersion(kernelVersion).Return(&osConfig)
+		mockKernelAPI.EXPECT().PrepareKernelMapping(mod.Spec, &mapping, &osConfig).Return(&mapping, nil)
 		mockStatusUpdater.EXPECT().PreflightSetVerificationStage(context.Background(), pv, mod.Name, kmmv1beta1.VerificationStageImage).Return(nil)
-		preflightHelper.EXPECT().verifyImage(ctx, &mapping, mod, kernelVersion).Return(imageVerified, "image message")
+		preflightHelper.EXPECT().verifyImage(ctx, &mapping, mod, kernelVersion, "").Return(imageVerified, "image message")
 		if !imageVerified {
 			if buildExists {
 				mockStatusUpdater.EXPECT().PreflightSetVerificationStage(context.Background(), pv, mod.Name, kmmv1beta1.VerificationStageBuild).Return(nil)
-				preflightHelper.EXPECT().verifyBuild(ctx, pv, &mapping, mod).Return(buildVerified, "build message")
+				preflightHelper.EXPECT().verifyBuild(ctx, pv, &mapping, mod, &osConfig).Return(buildVerified, "build message")
 			}
 			if signExists {
 				if buildVerified || !buildExists {
@@ -236,23 +240,33 @@ var _ = Describe("preflightHelper_verifyImage", func() {
 		digestLayer := v1stream.Layer{}
 		gomock.InOrder(
 			mockRegistryAPI.EXPECT().GetLayersDigests(context.Background(), containerImage, gomock.Any(),
-				gomock.Any()).Return(digests, repoConfig, nil),
+				gomock.Any(), nil).Return(digests, repoConfig, nil),
 			mockRegistryAPI.EXPECT().GetLayerByDigest(digests[1], repoConfig).Return(&digestLayer, nil),
 			mockRegistryAPI.EXPECT().VerifyModuleExists(&digestLayer, "/opt", kernelVersion, "simple-kmod.ko").Return(true),
 		)
 
-		res, message := ph.verifyImage(context.Background(), &mapping, mod, kernelVersion)
+		res, message := ph.verifyImage(context.Background(), &mapping, mod, kernelVersion, "")
 
 		Expect(res).To(BeTrue())
 		Expect(message).To(Equal(fmt.Sprintf(VerificationStatusReasonVerified, "image accessible and verified")))
 	})
 
+	It("should check the image of the architecture", func() {
+		mapping := kmmv1beta1.KernelMapping{ContainerImage: containerImage}
+		mockRegistryAPI.EXPECT().GetLayersDigests(context.Background(), containerImage, gomock.Any(),
+			gomock.Any(), registry.PlatformForArchitecture("arm64")).Return(nil, nil, fmt.Errorf("some error"))
+
+		res, _ := ph.verifyImage(context.Background(), &mapping, mod, kernelVersion, "arm64")
+
+		Expect(res).To(BeFalse())
+	})
+
 	It("get layers digest failed", func() {
 		mapping := kmmv1beta1.KernelMapping{ContainerImage: containerImage}
 		mockRegistryAPI.EXPECT().GetLayersDigests(context.Background(), containerImage, gomock.Any(),
-			gomock.Any()).Return(nil, nil, fmt.Errorf("some error"))
+			gomock.Any(), nil).Return(nil, nil, fmt.Errorf("some error"))
 
-		res, message := ph.verifyImage(context.Background(), &mapping, mod, kernelVersion)
+		res, message := ph.verifyImage(context.Background(), &mapping, mod, kernelVersion, "")
 
 		Expect(res).To(BeFalse())
 		Expect(message).To(Equal(fmt.Sprintf("image %s inaccessible or does not exists", containerImage)))
@@ -264,11 +278,11 @@ var _ = Describe("preflightHelper_verifyImage", func() {
 		repoConfig := &registry.RepoPullConfig{}
 		gomock.InOrder(
 			mockRegistryAPI.EXPECT().GetLayersDigests(context.Background(), containerImage, gomock.Any(),
-				gomock.Any()).Return(digests, repoConfig, nil),
+				gomock.Any(), nil).Return(digests, repoConfig, nil),
 			mockRegistryAPI.EXPECT().GetLayerByDigest(digests[1], repoConfig).Return(nil, fmt.Errorf("some error")),
 		)
 
-		res, message := ph.verifyImage(context.Background(), &mapping, mod, kernelVersion)
+		res, message := ph.verifyImage(context.Background(), &mapping, mod, kernelVersion, "")
 
 		Expect(res).To(BeFalse())
 		Expect(message).To(Equal(fmt.Sprintf("image %s, layer %s is inaccessible", containerImage, digests[1])))
@@ -280,17 +294,68 @@ var _ = Describe("preflightHelper_verifyImage", func() {
 		repoConfig := &registry.RepoPullConfig{}
 		digestLayer := v1stream.Layer{}
 		gomock.InOrder(
-			mockRegistryAPI.EXPECT().GetLayersDigests(context.Background(), containerImage, gomock.Any(), gomock.Any()).Return(digests, repoConfig, nil),
+			mockRegistryAPI.EXPECT().GetLayersDigests(context.Background(), containerImage, gomock.Any(), gomock.Any(), nil).Return(digests, repoConfig, nil),
 			mockRegistryAPI.EXPECT().GetLayerByDigest(digests[0], repoConfig).Return(&digestLayer, nil),
 			mockRegistryAPI.EXPECT().VerifyModuleExists(&digestLayer, "/opt", kernelVersion, "simple-kmod.ko").Return(false),
 		)
 
-		res, message := ph.verifyImage(context.Background(), &mapping, mod, kernelVersion)
+		res, message := ph.verifyImage(context.Background(), &mapping, mod, kernelVersion, "")
 
 		Expect(res).To(BeFalse())
 		Expect(message).To(Equal(fmt.Sprintf("image %s does not contain kernel module for kernel %s on any layer", containerImage, kernelVersion)))
 	})
 
+	It("several kernel modules spread over several layers", func() {
+		multiMod := mod.DeepCopy()
+		multiMod.Spec.ModuleLoader.Container.Modprobe.Modules = []kmmv1beta1.ModprobeModule{
+			{Name: "kmod-a"},
+			{Name: "kmod-b"},
+		}
+
+		mapping := kmmv1beta1.KernelMapping{ContainerImage: containerImage}
+		digests := []string{"digest0", "digest1"}
+		repoConfig := &registry.RepoPullConfig{}
+		digestLayer0 := v1stream.Layer{}
+		digestLayer1 := v1stream.Layer{}
+		gomock.InOrder(
+			mockRegistryAPI.EXPECT().GetLayersDigests(context.Background(), containerImage, gomock.Any(),
+				gomock.Any(), nil).Return(digests, repoConfig, nil),
+			mockRegistryAPI.EXPECT().GetLayerByDigest(digests[1], repoConfig).Return(&digestLayer1, nil),
+			mockRegistryAPI.EXPECT().VerifyModuleExists(&digestLayer1, "/opt", kernelVersion, "kmod-a.ko").Return(true),
+			mockRegistryAPI.EXPECT().VerifyModuleExists(&digestLayer1, "/opt", kernelVersion, "kmod-b.ko").Return(false),
+			mockRegistryAPI.EXPECT().GetLayerByDigest(digests[0], repoConfig).Return(&digestLayer0, nil),
+			mockRegistryAPI.EXPECT().VerifyModuleExists(&digestLayer0, "/opt", kernelVersion, "kmod-b.ko").Return(true),
+		)
+
+		res, message := ph.verifyImage(context.Background(), &mapping, multiMod, kernelVersion, "")
+
+		Expect(res).To(BeTrue())
+		Expect(message).To(Equal(fmt.Sprintf(VerificationStatusReasonVerified, "image accessible and verified")))
+	})
+
+	It("one of several kernel modules missing", func() {
+		multiMod := mod.DeepCopy()
+		multiMod.Spec.ModuleLoader.Container.Modprobe.Modules = []kmmv1beta1.ModprobeModule{
+			{Name: "kmod-a"},
+			{Name: "kmod-b"},
+		}
+
+		mapping := kmmv1beta1.KernelMapping{ContainerImage: containerImage}
+		digests := []string{"digest0"}
+		repoConfig := &registry.RepoPullConfig{}
+		digestLayer := v1stream.Layer{}
+		gomock.InOrder(
+			mockRegistryAPI.EXPECT().GetLayersDigests(context.Background(), containerImage, gomock.Any(), gomock.Any(), nil).Return(digests, repoConfig, nil),
+			mockRegistryAPI.EXPECT().GetLayerByDigest(digests[0], repoConfig).Return(&digestLayer, nil),
+			mockRegistryAPI.EXPECT().VerifyModuleExists(&digestLayer, "/opt", kernelVersion, "kmod-a.ko").Return(true),
+			mockRegistryAPI.EXPECT().VerifyModuleExists(&digestLayer, "/opt", kernelVersion, "kmod-b.ko").Return(false),
+		)
+
+		res, message := ph.verifyImage(context.Background(), &mapping, multiMod, kernelVersion, "")
+
+		Expect(res).To(BeFalse())
+		Expect(message).To(Equal(fmt.Sprintf("image %s does not contain kernel module for kernel %s on any layer", containerImage, kernelVersion)))
+	})
 })
 
 var _ = Describe("preflightHelper_verifyBuild", func() {
@@ -301,6 +366,8 @@ var _ = Describe("preflightHelper_verifyBuild", func() {
 		ph           *preflightHelper
 	)
 
+	osConfig := &module.NodeOSConfig{KernelFullVersion: kernelVersion}
+
 	BeforeEach(func() {
 		ctrl = gomock.NewController(GinkgoT())
 		clnt = client.NewMockClient(ctrl)
@@ -320,10 +387,10 @@ var _ = Describe("preflightHelper_verifyBuild", func() {
 		mod.Spec.ModuleLoader.Container.Build = &kmmv1beta1.Build{}
 		mapping := kmmv1beta1.KernelMapping{ContainerImage: containerImage}
 
-		mockBuildAPI.EXPECT().Sync(context.Background(), *mod, mapping, kernelVersion, pv.Spec.PushBuiltImage, pv).
+		mockBuildAPI.EXPECT().Sync(context.Background(), *mod, mapping, kernelVersion, "", osConfig, pv.Spec.PushBuiltImage, pv).
 			Return(build.Result{}, fmt.Errorf("some error"))
 
-		res, msg := ph.verifyBuild(context.Background(), pv, &mapping, mod)
+		res, msg := ph.verifyBuild(context.Background(), pv, &mapping, mod, osConfig)
 		Expect(res).To(BeFalse())
 		Expect(msg).To(Equal(fmt.Sprintf("Failed to verify build for module %s, kernel version %s, error %s", mod.Name, kernelVersion, fmt.Errorf("some error"))))
 	})
@@ -332,10 +399,10 @@ var _ = Describe("preflightHelper_verifyBuild", func() {
 		mod.Spec.ModuleLoader.Container.Build = &kmmv1beta1.Build{}
 		mapping := kmmv1beta1.KernelMapping{ContainerImage: containerImage}
 
-		mockBuildAPI.EXPECT().Sync(context.Background(), *mod, mapping, kernelVersion, pv.Spec.PushBuiltImage, pv).
+		mockBuildAPI.EXPECT().Sync(context.Background(), *mod, mapping, kernelVersion, "", osConfig, pv.Spec.PushBuiltImage, pv).
 			Return(build.Result{Status: build.StatusCompleted}, nil)
 
-		res, msg := ph.verifyBuild(context.Background(), pv, &mapping, mod)
+		res, msg := ph.verifyBuild(context.Background(), pv, &mapping, mod, osConfig)
 		Expect(res).To(BeTrue())
 		Expect(msg).To(Equal(fmt.Sprintf(VerificationStatusReasonVerified, "build compiles")))
 	})
@@ -344,13 +411,29 @@ var _ = Describe("preflightHelper_verifyBuild", func() {
 		mod.Spec.ModuleLoader.Container.Build = &kmmv1beta1.Build{}
 		mapping := kmmv1beta1.KernelMapping{ContainerImage: containerImage}
 
-		mockBuildAPI.EXPECT().Sync(context.Background(), *mod, mapping, kernelVersion, pv.Spec.PushBuiltImage, pv).
+		mockBuildAPI.EXPECT().Sync(context.Background(), *mod, mapping, kernelVersion, "", osConfig, pv.Spec.PushBuiltImage, pv).
 			Return(build.Result{Status: build.StatusInProgress}, nil)
 
-		res, msg := ph.verifyBuild(context.Background(), pv, &mapping, mod)
+		res, msg := ph.verifyBuild(context.Background(), pv, &mapping, mod, osConfig)
 		Expect(res).To(BeFalse())
 		Expect(msg).To(Equal("Waiting for build verification"))
 	})
+
+	It("sync failed definitively", func() {
+		mod.Spec.ModuleLoader.Container.Build = &kmmv1beta1.Build{}
+		mapping := kmmv1beta1.KernelMapping{ContainerImage: containerImage}
+
+		mockBuildAPI.EXPECT().Sync(context.Background(), *mod, mapping, kernelVersion, "", osConfig, pv.Spec.PushBuiltImage, pv).
+			Return(build.Result{Status: build.StatusFailed, Reason: "BackoffLimitExceeded: some message", LogsConfigMap: "some-job-logs"}, nil)
+
+		res, msg := ph.verifyBuild(context.Background(), pv, &mapping, mod, osConfig)
+		Expect(res).To(BeFalse())
+		Expect(msg).To(Equal(fmt.Sprintf(
+			"Build failed for module %s, kernel version %s: BackoffLimitExceeded: some message; logs are in ConfigMap some-job-logs",
+			mod.Name,
+			kernelVersion,
+		)))
+	})
 })
 
 var _ = Describe("preflightHelper_verifySign", func() {
@@ -382,7 +465,7 @@ var _ = Describe("preflightHelper_verifySign", func() {
 
 		previousImage := ""
 
-		mockSignAPI.EXPECT().Sync(context.Background(), *mod, mapping, kernelVersion, previousImage, pv.Spec.PushBuiltImage, pv).
+		mockSignAPI.EXPECT().Sync(context.Background(), *mod, mapping, kernelVersion, "", previousImage, pv.Spec.PushBuiltImage, pv).
 			Return(utils.Result{}, fmt.Errorf("some error"))
 
 		res, msg := ph.verifySign(context.Background(), pv, &mapping, mod)
@@ -396,7 +479,7 @@ var _ = Describe("preflightHelper_verifySign", func() {
 
 		previousImage := ""
 
-		mockSignAPI.EXPECT().Sync(context.Background(), *mod, mapping, kernelVersion, previousImage, pv.Spec.PushBuiltImage, pv).
+		mockSignAPI.EXPECT().Sync(context.Background(), *mod, mapping, kernelVersion, "", previousImage, pv.Spec.PushBuiltImage, pv).
 			Return(utils.Result{Status: utils.StatusCompleted}, nil)
 
 		res, msg := ph.verifySign(context.Background(), pv, &mapping, mod)
@@ -404,13 +487,27 @@ var _ = Describe("preflightHelper_verifySign", func() {
 		Expect(msg).To(Equal(fmt.Sprintf(VerificationStatusReasonVerified, "sign completes")))
 	})
 
+	It("sync failed definitively", func() {
+		mod.Spec.ModuleLoader.Container.Sign = &kmmv1beta1.Sign{}
+		mapping := kmmv1beta1.KernelMapping{ContainerImage: containerImage}
+
+		previousImage := ""
+
+		mockSignAPI.EXPECT().Sync(context.Background(), *mod, mapping, kernelVersion, "", previousImage, pv.Spec.PushBuiltImage, pv).
+			Return(utils.Result{Status: utils.StatusFailed, Reason: "DeadlineExceeded: some message"}, nil)
+
+		res, msg := ph.verifySign(context.Background(), pv, &mapping, mod)
+		Expect(res).To(BeFalse())
+		Expect(msg).To(Equal(fmt.Sprintf("Signing failed for module %s, kernel version %s: DeadlineExceeded: some message", mod.Name, kernelVersion)))
+	})
+
 	It("sync not completed yet", func() {
 		mod.Spec.ModuleLoader.Container.Sign = &kmmv1beta1.Sign{}
 		mapping := kmmv1beta1.KernelMapping{ContainerImage: containerImage}
 
 		previousImage := ""
 
-		mockSignAPI.EXPECT().Sync(context.Background(), *mod, mapping, kernelVersion, previousImage, pv.Spec.PushBuiltImage, pv).
+		mockSignAPI.EXPECT().Sync(context.Background(), *mod, mapping, kernelVersion, "", previousImage, pv.Spec.PushBuiltImage, pv).
 			Return(utils.Result{Status: utils.StatusInProgress}, nil)
 
 		res, msg := ph.verifySign(context.Background(), pv, &mapping, mod)
@@ -86,19 +86,49 @@ func (mr *MockRegistryMockRecorder) ExtractFileToFile(destination, header, tarre
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExtractFileToFile", reflect.TypeOf((*MockRegistry)(nil).ExtractFileToFile), destination, header, tarreader)
 }
 
+// GetDigest mocks base method.
+func (m *MockRegistry) GetDigest(ctx context.Context, image string, tlsOptions *v1beta1.TLSOptions, registryAuthGetter auth.RegistryAuthGetter) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDigest", ctx, image, tlsOptions, registryAuthGetter)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDigest indicates an expected call of GetDigest.
+func (mr *MockRegistryMockRecorder) GetDigest(ctx, image, tlsOptions, registryAuthGetter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDigest", reflect.TypeOf((*MockRegistry)(nil).GetDigest), ctx, image, tlsOptions, registryAuthGetter)
+}
+
 // GetImageByName mocks base method.
-func (m *MockRegistry) GetImageByName(imageName string, auth authn.Authenticator) (v1.Image, error) {
+func (m *MockRegistry) GetImageByName(imageName string, auth authn.Authenticator, platform *v1.Platform) (v1.Image, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetImageByName", imageName, auth)
+	ret := m.ctrl.Call(m, "GetImageByName", imageName, auth, platform)
 	ret0, _ := ret[0].(v1.Image)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetImageByName indicates an expected call of GetImageByName.
-func (mr *MockRegistryMockRecorder) GetImageByName(imageName, auth interface{}) *gomock.Call {
+func (mr *MockRegistryMockRecorder) GetImageByName(imageName, auth, platform interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetImageByName", reflect.TypeOf((*MockRegistry)(nil).GetImageByName), imageName, auth, platform)
+}
+
+// GetImageLabels mocks base method.
+func (m *MockRegistry) GetImageLabels(ctx context.Context, image string, tlsOptions *v1beta1.TLSOptions, registryAuthGetter auth.RegistryAuthGetter, platform *v1.Platform) (map[string]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetImageLabels", ctx, image, tlsOptions, registryAuthGetter, platform)
+	ret0, _ := ret[0].(map[string]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetImageLabels indicates an expected call of GetImageLabels.
+func (mr *MockRegistryMockRecorder) GetImageLabels(ctx, image, tlsOptions, registryAuthGetter, platform interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetImageByName", reflect.TypeOf((*MockRegistry)(nil).GetImageByName), imageName, auth)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetImageLabels", reflect.TypeOf((*MockRegistry)(nil).GetImageLabels), ctx, image, tlsOptions, registryAuthGetter, platform)
 }
 
 // GetLayerByDigest mocks base method.
@@ -132,9 +162,9 @@ func (mr *MockRegistryMockRecorder) GetLayerMediaType(image interface{}) *gomock
 }
 
 // GetLayersDigests mocks base method.
-func (m *MockRegistry) GetLayersDigests(ctx context.Context, image string, tlsOptions *v1beta1.TLSOptions, registryAuthGetter auth.RegistryAuthGetter) ([]string, *RepoPullConfig, error) {
+func (m *MockRegistry) GetLayersDigests(ctx context.Context, image string, tlsOptions *v1beta1.TLSOptions, registryAuthGetter auth.RegistryAuthGetter, platform *v1.Platform) ([]string, *RepoPullConfig, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetLayersDigests", ctx, image, tlsOptions, registryAuthGetter)
+	ret := m.ctrl.Call(m, "GetLayersDigests", ctx, image, tlsOptions, registryAuthGetter, platform)
 	ret0, _ := ret[0].([]string)
 	ret1, _ := ret[1].(*RepoPullConfig)
 	ret2, _ := ret[2].(error)
@@ -142,24 +172,24 @@ func (m *MockRegistry) GetLayersDigests(ctx context.Context, image string, tlsOp
 }
 
 // GetLayersDigests indicates an expected call of GetLayersDigests.
-func (mr *MockRegistryMockRecorder) GetLayersDigests(ctx, image, tlsOptions, registryAuthGetter interface{}) *gomock.Call {
+func (mr *MockRegistryMockRecorder) GetLayersDigests(ctx, image, tlsOptions, registryAuthGetter, platform interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLayersDigests", reflect.TypeOf((*MockRegistry)(nil).GetLayersDigests), ctx, image, tlsOptions, registryAuthGetter)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLayersDigests", reflect.TypeOf((*MockRegistry)(nil).GetLayersDigests), ctx, image, tlsOptions, registryAuthGetter, platform)
 }
 
 // ImageExists mocks base method.
-func (m *MockRegistry) ImageExists(ctx context.Context, image string, tlsOptions *v1beta1.TLSOptions, registryAuthGetter auth.RegistryAuthGetter) (bool, error) {
+func (m *MockRegistry) ImageExists(ctx context.Context, image string, tlsOptions *v1beta1.TLSOptions, registryAuthGetter auth.RegistryAuthGetter, platform *v1.Platform) (bool, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ImageExists", ctx, image, tlsOptions, registryAuthGetter)
+	ret := m.ctrl.Call(m, "ImageExists", ctx, image, tlsOptions, registryAuthGetter, platform)
 	ret0, _ := ret[0].(bool)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ImageExists indicates an expected call of ImageExists.
-func (mr *MockRegistryMockRecorder) ImageExists(ctx, image, tlsOptions, registryAuthGetter interface{}) *gomock.Call {
+func (mr *MockRegistryMockRecorder) ImageExists(ctx, image, tlsOptions, registryAuthGetter, platform interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImageExists", reflect.TypeOf((*MockRegistry)(nil).ImageExists), ctx, image, tlsOptions, registryAuthGetter)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImageExists", reflect.TypeOf((*MockRegistry)(nil).ImageExists), ctx, image, tlsOptions, registryAuthGetter, platform)
 }
 
 // ParseReference mocks base method.
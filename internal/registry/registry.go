@@ -2,6 +2,7 @@ package registry
 
 import (
 	"archive/tar"
+	"bytes"
 	"compress/gzip"
 	"context"
 	"encoding/json"
@@ -34,6 +35,9 @@ const (
 	modulesLocationPath = "lib/modules"
 )
 
+// ErrNoMatchingPlatform is returned when an image exists, but not for the requested platform.
+var ErrNoMatchingPlatform = errors.New("no image for the platform")
+
 type DriverToolkitEntry struct {
 	ImageURL            string `json:"imageURL"`
 	KernelFullVersion   string `json:"kernelFullVersion"`
@@ -48,16 +52,20 @@ type RepoPullConfig struct {
 
 //go:generate mockgen -source=registry.go -package=registry -destination=mock_registry_api.go
 
+// Registry methods reading an image take the platform of the nodes it is meant for, which selects the image from
+// a Docker manifest list or an OCI image index. A nil platform is the platform of the current process.
 type Registry interface {
-	ImageExists(ctx context.Context, image string, tlsOptions *kmmv1beta1.TLSOptions, registryAuthGetter auth.RegistryAuthGetter) (bool, error)
+	ImageExists(ctx context.Context, image string, tlsOptions *kmmv1beta1.TLSOptions, registryAuthGetter auth.RegistryAuthGetter, platform *v1.Platform) (bool, error)
+	GetImageLabels(ctx context.Context, image string, tlsOptions *kmmv1beta1.TLSOptions, registryAuthGetter auth.RegistryAuthGetter, platform *v1.Platform) (map[string]string, error)
+	GetDigest(ctx context.Context, image string, tlsOptions *kmmv1beta1.TLSOptions, registryAuthGetter auth.RegistryAuthGetter) (string, error)
 	VerifyModuleExists(layer v1.Layer, pathPrefix, kernelVersion, moduleFileName string) bool
-	GetLayersDigests(ctx context.Context, image string, tlsOptions *kmmv1beta1.TLSOptions, registryAuthGetter auth.RegistryAuthGetter) ([]string, *RepoPullConfig, error)
+	GetLayersDigests(ctx context.Context, image string, tlsOptions *kmmv1beta1.TLSOptions, registryAuthGetter auth.RegistryAuthGetter, platform *v1.Platform) ([]string, *RepoPullConfig, error)
 	GetLayerByDigest(digest string, pullConfig *RepoPullConfig) (v1.Layer, error)
 	WriteImageByName(imageName string, image v1.Image, auth authn.Authenticator) error
 	WalkFilesInImage(image v1.Image, fn func(filename string, header *tar.Header, tarreader io.Reader, data []interface{}) error, data ...interface{}) error
 	GetLayerMediaType(image v1.Image) (types.MediaType, error)
 	AddLayerToImage(tarfile string, image v1.Image) (v1.Image, error)
-	GetImageByName(imageName string, auth authn.Authenticator) (v1.Image, error)
+	GetImageByName(imageName string, auth authn.Authenticator, platform *v1.Platform) (v1.Image, error)
 	ParseReference(imageName string) (name.Reference, error)
 	ExtractBytesFromTar(size int64, tarreader io.Reader) ([]byte, error)
 	ExtractFileToFile(destination string, header *tar.Header, tarreader io.Reader) error
@@ -70,20 +78,75 @@ func NewRegistry() Registry {
 	return &registry{}
 }
 
-func (r *registry) ImageExists(ctx context.Context, image string, tlsOptions *kmmv1beta1.TLSOptions, registryAuthGetter auth.RegistryAuthGetter) (bool, error) {
-	_, _, err := r.getImageManifest(ctx, image, tlsOptions, registryAuthGetter)
+// PlatformForArchitecture returns the platform of the Linux nodes of architecture arch, or nil if arch is empty.
+func PlatformForArchitecture(arch string) *v1.Platform {
+	if arch == "" {
+		return nil
+	}
+
+	return &v1.Platform{OS: "linux", Architecture: arch}
+}
+
+func (r *registry) ImageExists(ctx context.Context, image string, tlsOptions *kmmv1beta1.TLSOptions, registryAuthGetter auth.RegistryAuthGetter, platform *v1.Platform) (bool, error) {
+	_, _, err := r.getImageManifest(ctx, image, tlsOptions, registryAuthGetter, platform)
 	if err != nil {
 		te := &transport.Error{}
 		if errors.As(err, &te) && te.StatusCode == http.StatusNotFound {
 			return false, nil
 		}
+		// the image of the platform is yet to be built or pushed
+		if errors.Is(err, ErrNoMatchingPlatform) {
+			return false, nil
+		}
 		return false, fmt.Errorf("could not get image %s: %w", image, err)
 	}
 	return true, nil
 }
 
-func (r *registry) GetLayersDigests(ctx context.Context, image string, tlsOptions *kmmv1beta1.TLSOptions, registryAuthGetter auth.RegistryAuthGetter) ([]string, *RepoPullConfig, error) {
-	manifest, pullConfig, err := r.getImageManifest(ctx, image, tlsOptions, registryAuthGetter)
+// GetImageLabels returns the labels set in the config of the image.
+func (r *registry) GetImageLabels(ctx context.Context, image string, tlsOptions *kmmv1beta1.TLSOptions, registryAuthGetter auth.RegistryAuthGetter, platform *v1.Platform) (map[string]string, error) {
+	pullConfig, err := r.getPullOptions(ctx, image, tlsOptions, registryAuthGetter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pull options for image %s: %w", image, err)
+	}
+
+	options := append(pullConfig.authOptions, crane.WithPlatform(platformOrDefault(platform)))
+
+	rawConfig, err := crane.Config(image, options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get crane config from image %s: %w", image, err)
+	}
+
+	configFile, err := v1.ParseConfigFile(bytes.NewReader(rawConfig))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the config of image %s: %w", image, err)
+	}
+
+	// crane only selects the platform in indexes
+	if platform != nil && !platformMatches(configPlatform(configFile), *platform) {
+		return nil, fmt.Errorf("image %s: %w %s", image, ErrNoMatchingPlatform, platform)
+	}
+
+	return configFile.Config.Labels, nil
+}
+
+// GetDigest returns the digest of the manifest, or of the index, that image refers to.
+func (r *registry) GetDigest(ctx context.Context, image string, tlsOptions *kmmv1beta1.TLSOptions, registryAuthGetter auth.RegistryAuthGetter) (string, error) {
+	pullConfig, err := r.getPullOptions(ctx, image, tlsOptions, registryAuthGetter)
+	if err != nil {
+		return "", fmt.Errorf("failed to get pull options for image %s: %w", image, err)
+	}
+
+	digest, err := crane.Digest(image, pullConfig.authOptions...)
+	if err != nil {
+		return "", fmt.Errorf("failed to get the digest of image %s: %w", image, err)
+	}
+
+	return digest, nil
+}
+
+func (r *registry) GetLayersDigests(ctx context.Context, image string, tlsOptions *kmmv1beta1.TLSOptions, registryAuthGetter auth.RegistryAuthGetter, platform *v1.Platform) ([]string, *RepoPullConfig, error) {
+	manifest, pullConfig, err := r.getImageManifest(ctx, image, tlsOptions, registryAuthGetter, platform)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to get manifest from image %s: %w", image, err)
 	}
@@ -111,8 +174,9 @@ func (r *registry) getPullOptions(ctx context.Context, image string, tlsOptions
 	var repo string
 	if hash := strings.Split(image, "@"); len(hash) > 1 {
 		repo = hash[0]
-	} else if tag := strings.Split(image, ":"); len(tag) > 1 {
-		repo = tag[0]
+	} else if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		// the registry host may also contain a port
+		repo = image[:i]
 	}
 
 	if repo == "" {
@@ -153,12 +217,12 @@ func (r *registry) getPullOptions(ctx context.Context, image string, tlsOptions
 	return &RepoPullConfig{repo: repo, authOptions: options}, nil
 }
 
-func (r *registry) getImageManifest(ctx context.Context, image string, tlsOptions *kmmv1beta1.TLSOptions, registryAuthGetter auth.RegistryAuthGetter) ([]byte, *RepoPullConfig, error) {
+func (r *registry) getImageManifest(ctx context.Context, image string, tlsOptions *kmmv1beta1.TLSOptions, registryAuthGetter auth.RegistryAuthGetter, platform *v1.Platform) ([]byte, *RepoPullConfig, error) {
 	pullConfig, err := r.getPullOptions(ctx, image, tlsOptions, registryAuthGetter)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to get pull options for image %s: %w", image, err)
 	}
-	manifest, err := r.getManifestStreamFromImage(image, pullConfig.repo, pullConfig.authOptions)
+	manifest, err := r.getManifestStreamFromImage(image, pullConfig.repo, pullConfig.authOptions, platform)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to get manifest stream from image %s: %w", image, err)
 	}
@@ -166,7 +230,7 @@ func (r *registry) getImageManifest(ctx context.Context, image string, tlsOption
 	return manifest, pullConfig, nil
 }
 
-func (r *registry) getManifestStreamFromImage(image, repo string, options []crane.Option) ([]byte, error) {
+func (r *registry) getManifestStreamFromImage(image, repo string, options []crane.Option, platform *v1.Platform) ([]byte, error) {
 	manifest, err := crane.Manifest(image, options...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get crane manifest from image %s: %w", image, err)
@@ -181,12 +245,16 @@ func (r *registry) getManifestStreamFromImage(image, repo string, options []cran
 	if err != nil {
 		return nil, fmt.Errorf("unmarshalled manifests invalid format: %w", err)
 	}
-	if !mediaTypeFound {
+
+	// mediaType is optional in OCI image indexes, which can be recognized by their list of manifests
+	_, isIndex := release.Object["manifests"]
+
+	if !mediaTypeFound && !isIndex {
 		return nil, fmt.Errorf("mediaType is missing from the image %s manifest", image)
 	}
 
-	if strings.Contains(imageMediaType, "manifest.list") {
-		archDigest, err := r.getImageDigestFromMultiImage(manifest)
+	if isIndex || types.MediaType(imageMediaType).IsIndex() {
+		archDigest, err := r.getImageDigestFromMultiImage(manifest, platformOrDefault(platform))
 		if err != nil {
 			return nil, fmt.Errorf("failed to get arch digets from multi arch image: %w", err)
 		}
@@ -195,6 +263,21 @@ func (r *registry) getManifestStreamFromImage(image, repo string, options []cran
 		if err != nil {
 			return nil, fmt.Errorf("failed to get crane manifest for the arch image: %w", err)
 		}
+	} else if platform != nil {
+		// a single image only says which platform it was built for in its config
+		rawConfig, err := crane.Config(image, options...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get crane config from image %s: %w", image, err)
+		}
+
+		configFile, err := v1.ParseConfigFile(bytes.NewReader(rawConfig))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the config of image %s: %w", image, err)
+		}
+
+		if !platformMatches(configPlatform(configFile), *platform) {
+			return nil, fmt.Errorf("image %s is for %s/%s: %w %s", image, configFile.OS, configFile.Architecture, ErrNoMatchingPlatform, platform)
+		}
 	}
 	return manifest, nil
 }
@@ -248,29 +331,55 @@ func (r *registry) getHeaderStreamFromLayer(layer v1.Layer, headerName string) (
 	return nil, fmt.Errorf("header %s not found in the layer", headerName)
 }
 
-func (r *registry) getImageDigestFromMultiImage(manifestListStream []byte) (string, error) {
-	arch := runtime.GOARCH
+func (r *registry) getImageDigestFromMultiImage(manifestListStream []byte, platform *v1.Platform) (string, error) {
 	manifestList := v1.IndexManifest{}
 
 	if err := json.Unmarshal(manifestListStream, &manifestList); err != nil {
 		return "", fmt.Errorf("failed to unmarshal manifest stream: %w", err)
 	}
 	for _, manifest := range manifestList.Manifests {
-		if manifest.Platform != nil && manifest.Platform.Architecture == arch {
+		if manifest.Platform != nil && platformMatches(*manifest.Platform, *platform) {
 			return manifest.Digest.Algorithm + ":" + manifest.Digest.Hex, nil
 		}
 	}
-	return "", fmt.Errorf("Failed to find manifest for architecture %s", arch)
+	return "", fmt.Errorf("%w %s", ErrNoMatchingPlatform, platform)
+}
+
+// configPlatform returns the platform an image was built for, according to its config.
+func configPlatform(configFile *v1.ConfigFile) v1.Platform {
+	return v1.Platform{OS: configFile.OS, Architecture: configFile.Architecture, Variant: configFile.Variant}
+}
+
+// platformMatches returns true if an image built for candidate can run on target.
+// The OS and variant are only compared when target sets them.
+func platformMatches(candidate, target v1.Platform) bool {
+	if candidate.Architecture != target.Architecture {
+		return false
+	}
+
+	if target.OS != "" && candidate.OS != target.OS {
+		return false
+	}
+
+	return target.Variant == "" || candidate.Variant == target.Variant
+}
+
+func platformOrDefault(platform *v1.Platform) *v1.Platform {
+	if platform != nil {
+		return platform
+	}
+
+	return &v1.Platform{OS: runtime.GOOS, Architecture: runtime.GOARCH}
 }
 
-func (r *registry) GetImageByName(imageName string, auth authn.Authenticator) (v1.Image, error) {
+func (r *registry) GetImageByName(imageName string, auth authn.Authenticator, platform *v1.Platform) (v1.Image, error) {
 
 	ref, err := r.ParseReference(imageName)
 	if err != nil {
 		return nil, err
 	}
 
-	descriptor, err := remote.Get(ref, remote.WithAuth(auth))
+	descriptor, err := remote.Get(ref, remote.WithAuth(auth), remote.WithPlatform(*platformOrDefault(platform)))
 	if err != nil {
 		return nil, fmt.Errorf("could not get image: %w", err)
 	}
@@ -12,6 +12,14 @@ import (
 
 	"github.com/golang/mock/gomock"
 	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/types"
 	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/auth"
 	. "github.com/onsi/ginkgo/v2"
@@ -58,7 +66,7 @@ var _ = Describe("ImageExists", func() {
 
 		It("should fail if the image name isn't valid", func() {
 
-			_, err = reg.ImageExists(ctx, invalidImage, &kmmv1beta1.TLSOptions{}, nil)
+			_, err = reg.ImageExists(ctx, invalidImage, &kmmv1beta1.TLSOptions{}, nil, nil)
 
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("does not contain hash or tag"))
@@ -68,7 +76,7 @@ var _ = Describe("ImageExists", func() {
 
 			mockRegistryAuthGetter.EXPECT().GetKeyChain(ctx).Return(nil, errors.New("some error"))
 
-			_, err = reg.ImageExists(ctx, validImage, &kmmv1beta1.TLSOptions{}, mockRegistryAuthGetter)
+			_, err = reg.ImageExists(ctx, validImage, &kmmv1beta1.TLSOptions{}, mockRegistryAuthGetter, nil)
 
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("cannot get keychain from the registry auth getter"))
@@ -93,7 +101,7 @@ var _ = Describe("ImageExists", func() {
 			u := mustParseURL(server.URL)
 
 			image := fmt.Sprintf("%s/%s/%s:%s", u.Host, validImageOrg, validImageName, validImageTag)
-			_, err = reg.ImageExists(ctx, image, &kmmv1beta1.TLSOptions{}, nil)
+			_, err = reg.ImageExists(ctx, image, &kmmv1beta1.TLSOptions{}, nil, nil)
 
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("failed to get crane manifest from image"))
@@ -108,7 +116,7 @@ var _ = Describe("ImageExists", func() {
 			u := mustParseURL(server.URL)
 
 			image := fmt.Sprintf("%s/%s/%s:%s", u.Host, validImageOrg, validImageName, validImageTag)
-			_, err = reg.ImageExists(ctx, image, &kmmv1beta1.TLSOptions{}, nil)
+			_, err = reg.ImageExists(ctx, image, &kmmv1beta1.TLSOptions{}, nil, nil)
 
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("failed to unmarshal crane manifest"))
@@ -134,7 +142,7 @@ var _ = Describe("ImageExists", func() {
 			u := mustParseURL(server.URL)
 
 			image := fmt.Sprintf("%s/%s/%s:%s", u.Host, validImageOrg, validImageName, validImageTag)
-			_, err = reg.ImageExists(ctx, image, &kmmv1beta1.TLSOptions{}, nil)
+			_, err = reg.ImageExists(ctx, image, &kmmv1beta1.TLSOptions{}, nil, nil)
 
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("mediaType is missing from the image"))
@@ -150,7 +158,7 @@ var _ = Describe("ImageExists", func() {
 		u := mustParseURL(server.URL)
 
 		image := fmt.Sprintf("%s/%s/%s:%s", u.Host, validImageOrg, validImageName, validImageTag)
-		_, err := reg.ImageExists(ctx, image, &kmmv1beta1.TLSOptions{}, nil)
+		_, err := reg.ImageExists(ctx, image, &kmmv1beta1.TLSOptions{}, nil, nil)
 
 		Expect(err).ToNot(HaveOccurred())
 	})
@@ -173,9 +181,9 @@ var _ = Describe("ImageExists", func() {
 		var err error
 		image := fmt.Sprintf("%s/%s/%s:%s", u.Host, validImageOrg, validImageName, validImageTag)
 		if withRegistryAuthGetter {
-			_, err = reg.ImageExists(ctx, image, &kmmv1beta1.TLSOptions{}, mockRegistryAuthGetter)
+			_, err = reg.ImageExists(ctx, image, &kmmv1beta1.TLSOptions{}, mockRegistryAuthGetter, nil)
 		} else {
-			_, err = reg.ImageExists(ctx, image, &kmmv1beta1.TLSOptions{}, nil)
+			_, err = reg.ImageExists(ctx, image, &kmmv1beta1.TLSOptions{}, nil, nil)
 		}
 		Expect(err).ToNot(HaveOccurred())
 	},
@@ -223,7 +231,7 @@ var _ = Describe("GetLayersDigests", func() {
 
 		It("should fail if the image name isn't valid", func() {
 
-			_, err = reg.ImageExists(ctx, invalidImage, &kmmv1beta1.TLSOptions{}, nil)
+			_, err = reg.ImageExists(ctx, invalidImage, &kmmv1beta1.TLSOptions{}, nil, nil)
 
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("does not contain hash or tag"))
@@ -233,7 +241,7 @@ var _ = Describe("GetLayersDigests", func() {
 
 			mockRegistryAuthGetter.EXPECT().GetKeyChain(ctx).Return(nil, errors.New("some error"))
 
-			_, err = reg.ImageExists(ctx, validImage, &kmmv1beta1.TLSOptions{}, mockRegistryAuthGetter)
+			_, err = reg.ImageExists(ctx, validImage, &kmmv1beta1.TLSOptions{}, mockRegistryAuthGetter, nil)
 
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("cannot get keychain from the registry auth getter"))
@@ -258,7 +266,7 @@ var _ = Describe("GetLayersDigests", func() {
 			u := mustParseURL(server.URL)
 
 			image := fmt.Sprintf("%s/%s/%s:%s", u.Host, validImageOrg, validImageName, validImageTag)
-			_, _, err = reg.GetLayersDigests(ctx, image, &kmmv1beta1.TLSOptions{}, nil)
+			_, _, err = reg.GetLayersDigests(ctx, image, &kmmv1beta1.TLSOptions{}, nil, nil)
 
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("failed to get crane manifest from image"))
@@ -273,7 +281,7 @@ var _ = Describe("GetLayersDigests", func() {
 			u := mustParseURL(server.URL)
 
 			image := fmt.Sprintf("%s/%s/%s:%s", u.Host, validImageOrg, validImageName, validImageTag)
-			_, _, err = reg.GetLayersDigests(ctx, image, &kmmv1beta1.TLSOptions{}, nil)
+			_, _, err = reg.GetLayersDigests(ctx, image, &kmmv1beta1.TLSOptions{}, nil, nil)
 
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("failed to unmarshal crane manifest"))
@@ -299,7 +307,7 @@ var _ = Describe("GetLayersDigests", func() {
 			u := mustParseURL(server.URL)
 
 			image := fmt.Sprintf("%s/%s/%s:%s", u.Host, validImageOrg, validImageName, validImageTag)
-			_, _, err = reg.GetLayersDigests(ctx, image, &kmmv1beta1.TLSOptions{}, nil)
+			_, _, err = reg.GetLayersDigests(ctx, image, &kmmv1beta1.TLSOptions{}, nil, nil)
 
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("mediaType is missing from the image"))
@@ -324,9 +332,9 @@ var _ = Describe("GetLayersDigests", func() {
 		var err error
 		image := fmt.Sprintf("%s/%s/%s:%s", u.Host, validImageOrg, validImageName, validImageTag)
 		if withRegistryAuthGetter {
-			_, _, err = reg.GetLayersDigests(ctx, image, &kmmv1beta1.TLSOptions{}, mockRegistryAuthGetter)
+			_, _, err = reg.GetLayersDigests(ctx, image, &kmmv1beta1.TLSOptions{}, mockRegistryAuthGetter, nil)
 		} else {
-			_, _, err = reg.GetLayersDigests(ctx, image, &kmmv1beta1.TLSOptions{}, nil)
+			_, _, err = reg.GetLayersDigests(ctx, image, &kmmv1beta1.TLSOptions{}, nil, nil)
 		}
 		Expect(err).ToNot(HaveOccurred())
 	},
@@ -335,6 +343,228 @@ var _ = Describe("GetLayersDigests", func() {
 	)
 })
 
+var _ = Describe("GetImageLabels", func() {
+	reg := NewRegistry()
+
+	It("should fail if the image name isn't valid", func() {
+		_, err := reg.GetImageLabels(context.TODO(), "non-valid-image-name", &kmmv1beta1.TLSOptions{}, nil, nil)
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("does not contain hash or tag"))
+	})
+
+	It("should return the labels of the image config", func() {
+		server := httptest.NewServer(ggcrregistry.New())
+		defer server.Close()
+		u := mustParseURL(server.URL)
+
+		image := fmt.Sprintf("%s/org/image-name:some-tag", u.Host)
+		labels := map[string]string{"some-label": "some-value"}
+
+		img, err := random.Image(16, 1)
+		Expect(err).ToNot(HaveOccurred())
+		img, err = mutate.Config(img, v1.Config{Labels: labels})
+		Expect(err).ToNot(HaveOccurred())
+		ref, err := name.ParseReference(image)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(remote.Write(ref, img)).To(Succeed())
+
+		res, err := reg.GetImageLabels(context.TODO(), image, &kmmv1beta1.TLSOptions{}, nil, nil)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res).To(Equal(labels))
+	})
+})
+
+var _ = Describe("GetDigest", func() {
+	reg := NewRegistry()
+
+	It("should fail if the image name isn't valid", func() {
+		_, err := reg.GetDigest(context.TODO(), "non-valid-image-name", &kmmv1beta1.TLSOptions{}, nil)
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("does not contain hash or tag"))
+	})
+
+	It("should return the digest of the image the tag refers to", func() {
+		server := httptest.NewServer(ggcrregistry.New())
+		defer server.Close()
+		u := mustParseURL(server.URL)
+
+		image := fmt.Sprintf("%s/org/image-name:some-tag", u.Host)
+
+		img, err := random.Image(16, 1)
+		Expect(err).ToNot(HaveOccurred())
+		ref, err := name.ParseReference(image)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(remote.Write(ref, img)).To(Succeed())
+
+		digest, err := img.Digest()
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(
+			reg.GetDigest(context.TODO(), image, &kmmv1beta1.TLSOptions{}, nil),
+		).To(
+			Equal(digest.String()),
+		)
+	})
+})
+
+// withPlatform returns img with the Linux platform of arch in its config.
+func withPlatform(img v1.Image, arch string) v1.Image {
+	cf, err := img.ConfigFile()
+	Expect(err).ToNot(HaveOccurred())
+
+	cf = cf.DeepCopy()
+	cf.OS = "linux"
+	cf.Architecture = arch
+
+	img, err = mutate.ConfigFile(img, cf)
+	Expect(err).ToNot(HaveOccurred())
+
+	return img
+}
+
+var _ = Describe("multi-platform images", func() {
+	reg := NewRegistry()
+
+	// writeMultiPlatformImage pushes an index of an amd64 and an arm64 image, labelled with their architecture.
+	writeMultiPlatformImage := func(image string, mediaType types.MediaType) map[string]v1.Image {
+		var idx v1.ImageIndex = empty.Index
+		images := make(map[string]v1.Image)
+
+		for _, arch := range []string{"amd64", "arm64"} {
+			img, err := random.Image(16, 1)
+			Expect(err).ToNot(HaveOccurred())
+			img = withPlatform(img, arch)
+			img, err = mutate.Config(img, v1.Config{Labels: map[string]string{"arch": arch}})
+			Expect(err).ToNot(HaveOccurred())
+
+			idx = mutate.AppendManifests(idx, mutate.IndexAddendum{
+				Add: img,
+				Descriptor: v1.Descriptor{
+					Platform: &v1.Platform{OS: "linux", Architecture: arch},
+				},
+			})
+
+			images[arch] = img
+		}
+
+		ref, err := name.ParseReference(image)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(remote.WriteIndex(ref, mutate.IndexMediaType(idx, mediaType))).To(Succeed())
+
+		return images
+	}
+
+	DescribeTable("should resolve the image of the target platform",
+		func(mediaType types.MediaType) {
+			server := httptest.NewServer(ggcrregistry.New())
+			defer server.Close()
+
+			image := fmt.Sprintf("%s/org/image-name:some-tag", mustParseURL(server.URL).Host)
+			images := writeMultiPlatformImage(image, mediaType)
+			platform := PlatformForArchitecture("arm64")
+
+			labels, err := reg.GetImageLabels(context.TODO(), image, &kmmv1beta1.TLSOptions{}, nil, platform)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(labels).To(HaveKeyWithValue("arch", "arm64"))
+
+			digests, _, err := reg.GetLayersDigests(context.TODO(), image, &kmmv1beta1.TLSOptions{}, nil, platform)
+			Expect(err).ToNot(HaveOccurred())
+
+			layers, err := images["arm64"].Layers()
+			Expect(err).ToNot(HaveOccurred())
+			layerDigest, err := layers[0].Digest()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(digests).To(Equal([]string{layerDigest.String()}))
+
+			exists, err := reg.ImageExists(context.TODO(), image, &kmmv1beta1.TLSOptions{}, nil, platform)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(exists).To(BeTrue())
+
+			exists, err = reg.ImageExists(context.TODO(), image, &kmmv1beta1.TLSOptions{}, nil, PlatformForArchitecture("s390x"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(exists).To(BeFalse())
+		},
+		Entry("OCI image index", types.OCIImageIndex),
+		Entry("Docker manifest list", types.DockerManifestList),
+	)
+})
+
+var _ = Describe("single-platform images", func() {
+	reg := NewRegistry()
+
+	It("should only be found for their platform", func() {
+		server := httptest.NewServer(ggcrregistry.New())
+		defer server.Close()
+
+		image := fmt.Sprintf("%s/org/image-name:some-tag", mustParseURL(server.URL).Host)
+
+		img, err := random.Image(16, 1)
+		Expect(err).ToNot(HaveOccurred())
+		img = withPlatform(img, "amd64")
+		ref, err := name.ParseReference(image)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(remote.Write(ref, img)).To(Succeed())
+
+		exists, err := reg.ImageExists(context.TODO(), image, &kmmv1beta1.TLSOptions{}, nil, PlatformForArchitecture("amd64"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exists).To(BeTrue())
+
+		exists, err = reg.ImageExists(context.TODO(), image, &kmmv1beta1.TLSOptions{}, nil, PlatformForArchitecture("arm64"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exists).To(BeFalse())
+
+		_, _, err = reg.GetLayersDigests(context.TODO(), image, &kmmv1beta1.TLSOptions{}, nil, PlatformForArchitecture("arm64"))
+		Expect(err).To(MatchError(ErrNoMatchingPlatform))
+
+		_, err = reg.GetImageLabels(context.TODO(), image, &kmmv1beta1.TLSOptions{}, nil, PlatformForArchitecture("arm64"))
+		Expect(err).To(MatchError(ErrNoMatchingPlatform))
+	})
+
+	It("should be found for any platform if none is given", func() {
+		server := httptest.NewServer(ggcrregistry.New())
+		defer server.Close()
+
+		image := fmt.Sprintf("%s/org/image-name:some-tag", mustParseURL(server.URL).Host)
+
+		img, err := random.Image(16, 1)
+		Expect(err).ToNot(HaveOccurred())
+		img = withPlatform(img, "s390x")
+		ref, err := name.ParseReference(image)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(remote.Write(ref, img)).To(Succeed())
+
+		exists, err := reg.ImageExists(context.TODO(), image, &kmmv1beta1.TLSOptions{}, nil, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exists).To(BeTrue())
+	})
+})
+
+var _ = Describe("platformMatches", func() {
+	DescribeTable("should compare platforms",
+		func(candidate, target v1.Platform, expected bool) {
+			Expect(platformMatches(candidate, target)).To(Equal(expected))
+		},
+		Entry("same platform", v1.Platform{OS: "linux", Architecture: "arm64"}, v1.Platform{OS: "linux", Architecture: "arm64"}, true),
+		Entry("other architecture", v1.Platform{OS: "linux", Architecture: "amd64"}, v1.Platform{OS: "linux", Architecture: "arm64"}, false),
+		Entry("other OS", v1.Platform{OS: "windows", Architecture: "amd64"}, v1.Platform{OS: "linux", Architecture: "amd64"}, false),
+		Entry("any variant", v1.Platform{OS: "linux", Architecture: "arm", Variant: "v7"}, v1.Platform{OS: "linux", Architecture: "arm"}, true),
+		Entry("other variant", v1.Platform{OS: "linux", Architecture: "arm", Variant: "v6"}, v1.Platform{OS: "linux", Architecture: "arm", Variant: "v7"}, false),
+	)
+})
+
+var _ = Describe("PlatformForArchitecture", func() {
+	It("should return nil for an unknown architecture", func() {
+		Expect(PlatformForArchitecture("")).To(BeNil())
+	})
+
+	It("should return a Linux platform", func() {
+		Expect(PlatformForArchitecture("arm64")).To(Equal(&v1.Platform{OS: "linux", Architecture: "arm64"}))
+	})
+})
+
 var _ = Describe("VerifyModuleExists", func() {
 	reg := NewRegistry()
 
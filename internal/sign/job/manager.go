@@ -4,53 +4,163 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/constants"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/joblogs"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/module"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/registry"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/utils"
 )
 
+// failedBeforeReason is the reason of the failure of the jobs that are not run again because they have failed before.
+const failedBeforeReason = "a job with the same inputs has failed and was garbage-collected"
+
+// upToDateTTL is how long a signed image found up to date with its signing inputs is trusted before they are
+// compared again, if the Module and the objects it references have not changed.
+const upToDateTTL = 10 * time.Minute
+
 type signJobManager struct {
-	client    client.Client
-	signer    Signer
-	jobHelper utils.JobHelper
-	registry  registry.Registry
+	client             client.Client
+	signer             Signer
+	jobHelper          utils.JobHelper
+	registry           registry.Registry
+	logs               joblogs.Collector
+	failedJobRetention time.Duration
+	upToDate           *utils.SyncCache
 }
 
+// NewSignJobManager returns a SignManager running signings in jobs.
+// Failed jobs and their logs are kept for failedJobRetention before being garbage collected.
 func NewSignJobManager(
 	client client.Client,
 	signer Signer,
 	jobHelper utils.JobHelper,
-	registry registry.Registry) *signJobManager {
+	registry registry.Registry,
+	logs joblogs.Collector,
+	failedJobRetention time.Duration) *signJobManager {
 	return &signJobManager{
-		client:    client,
-		signer:    signer,
-		jobHelper: jobHelper,
-		registry:  registry,
+		client:             client,
+		signer:             signer,
+		jobHelper:          jobHelper,
+		registry:           registry,
+		logs:               logs,
+		failedJobRetention: failedJobRetention,
+		upToDate:           utils.NewSyncCache(upToDateTTL),
+	}
+}
+
+func (jbm *signJobManager) GarbageCollect(ctx context.Context, modName, namespace string, owner metav1.Object) ([]string, error) {
+	jobs, err := jbm.jobHelper.GetModuleJobs(ctx, modName, namespace, utils.JobTypeSign, owner)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get signing jobs for module %s: %v", modName, err)
+	}
+
+	failedBefore := time.Now().Add(-jbm.failedJobRetention)
+
+	deleteNames := make([]string, 0, len(jobs))
+	for _, job := range jobs {
+		if job.Status.Succeeded != 1 {
+			c := utils.JobFailedCondition(&job)
+			if c == nil || !c.LastTransitionTime.Time.Before(failedBefore) {
+				continue
+			}
+		}
+
+		if err = jbm.jobHelper.DeleteJob(ctx, &job); err != nil {
+			return nil, fmt.Errorf("failed to delete signing job %s: %v", job.Name, err)
+		}
+
+		if err = jbm.logs.Delete(ctx, &job); err != nil {
+			return nil, fmt.Errorf("failed to delete the logs of signing job %s: %v", job.Name, err)
+		}
+
+		deleteNames = append(deleteNames, job.Name)
 	}
+	return deleteNames, nil
 }
 
 func (jbm *signJobManager) ShouldSync(
 	ctx context.Context,
 	mod kmmv1beta1.Module,
-	m kmmv1beta1.KernelMapping) (bool, error) {
+	m kmmv1beta1.KernelMapping,
+	targetKernel string,
+	targetArch string) (bool, error) {
 
 	// if there is no sign specified skip
 	if !module.ShouldBeSigned(mod.Spec, m) {
 		return false, nil
 	}
 
-	exists, err := module.ImageExists(ctx, jbm.client, jbm.registry, mod.Spec, mod.Namespace, m, m.ContainerImage)
+	exists, err := module.ImageExists(ctx, jbm.client, jbm.registry, mod.Spec, mod.Namespace, m, m.ContainerImage, targetArch)
 	if err != nil {
 		return false, fmt.Errorf("failed to check existence of image %s: %w", m.ContainerImage, err)
 	}
+	if !exists {
+		return true, nil
+	}
+
+	// the signing inputs are only compared again once they may have changed
+	inputsVersion, err := module.InputsVersion(ctx, jbm.client, &mod, &m)
+	if err != nil {
+		return false, fmt.Errorf("could not get the version of the signing inputs: %v", err)
+	}
+
+	cacheKey := fmt.Sprintf("%s/%s/%s/%s", mod.Namespace, mod.Name, targetKernel, targetArch)
+	if jbm.upToDate.IsUpToDate(cacheKey, inputsVersion) {
+		return false, nil
+	}
 
-	return !exists, nil
+	labels, err := module.ImageLabels(ctx, jbm.client, jbm.registry, mod.Spec, mod.Namespace, m, m.ContainerImage, targetArch)
+	if err != nil {
+		return false, fmt.Errorf("failed to get the labels of image %s: %w", m.ContainerImage, err)
+	}
+
+	// images that were not signed by us (or by an older version) carry no digest; leave them alone
+	digest, ok := labels[constants.SignDigestLabel]
+	if !ok {
+		jbm.upToDate.SetUpToDate(cacheKey, inputsVersion)
+		return false, nil
+	}
+
+	logger := log.FromContext(ctx)
+
+	imageToSign := ""
+
+	// the signed image inherits the labels of the intermediate image, so a different
+	// build digest means that the intermediate image was rebuilt since it was signed
+	if module.ShouldBeBuilt(mod.Spec, m) {
+		imageToSign = module.IntermediateImageName(mod.Name, mod.Namespace, m.ContainerImage)
+
+		unsignedLabels, err := module.ImageLabels(ctx, jbm.client, jbm.registry, mod.Spec, mod.Namespace, m, imageToSign, targetArch)
+		if err != nil {
+			return false, fmt.Errorf("failed to get the labels of image %s: %w", imageToSign, err)
+		}
+
+		if unsignedLabels[constants.BuildDigestLabel] != labels[constants.BuildDigestLabel] {
+			logger.Info("The unsigned image has been rebuilt since it was signed", "image", imageToSign)
+			return true, nil
+		}
+	}
+
+	jobTemplate, err := jbm.signer.MakeJobTemplate(ctx, mod, m, targetKernel, targetArch, nil, imageToSign, true, &mod)
+	if err != nil {
+		return false, fmt.Errorf("could not make Job template: %v", err)
+	}
+
+	if digest != jobTemplate.Annotations[constants.JobHashAnnotation] {
+		logger.Info("The signing inputs have changed since the image was signed", "image", m.ContainerImage)
+		return true, nil
+	}
+
+	jbm.upToDate.SetUpToDate(cacheKey, inputsVersion)
+
+	return false, nil
 }
 
 func (jbm *signJobManager) Sync(
@@ -58,6 +168,7 @@ func (jbm *signJobManager) Sync(
 	mod kmmv1beta1.Module,
 	m kmmv1beta1.KernelMapping,
 	targetKernel string,
+	targetArch string,
 	imageToSign string,
 	pushImage bool,
 	owner metav1.Object) (utils.Result, error) {
@@ -66,19 +177,25 @@ func (jbm *signJobManager) Sync(
 
 	logger.Info("Signing in-cluster")
 
-	labels := jbm.jobHelper.JobLabels(mod.Name, targetKernel, "sign")
+	labels := jbm.jobHelper.JobLabels(mod.Name, targetKernel, targetArch, utils.JobTypeSign)
 
-	jobTemplate, err := jbm.signer.MakeJobTemplate(ctx, mod, m, targetKernel, labels, imageToSign, pushImage, owner)
+	jobTemplate, err := jbm.signer.MakeJobTemplate(ctx, mod, m, targetKernel, targetArch, labels, imageToSign, pushImage, owner)
 	if err != nil {
 		return utils.Result{}, fmt.Errorf("could not make Job template: %v", err)
 	}
 
-	job, err := jbm.jobHelper.GetModuleJobByKernel(ctx, mod.Name, mod.Namespace, targetKernel, utils.JobTypeSign, owner)
+	job, err := jbm.jobHelper.GetModuleJobByKernel(ctx, mod.Name, mod.Namespace, targetKernel, targetArch, utils.JobTypeSign, owner)
 	if err != nil {
 		if !errors.Is(err, utils.ErrNoMatchingJob) {
 			return utils.Result{}, fmt.Errorf("error getting the signing job: %v", err)
 		}
 
+		// failed jobs are garbage-collected after a while; that does not make their inputs sign
+		if h := module.FailedJobHash(&mod, targetKernel, targetArch); h != "" && h == utils.JobInputsHash(jobTemplate) {
+			logger.Info("A signing job with the same inputs has already failed; not running it again")
+			return utils.Result{Status: utils.StatusFailed, Reason: failedBeforeReason, FailedJobHash: h}, nil
+		}
+
 		logger.Info("Creating job")
 		err = jbm.jobHelper.CreateJob(ctx, jobTemplate)
 		if err != nil {
@@ -99,6 +216,9 @@ func (jbm *signJobManager) Sync(
 		if err != nil {
 			logger.Info(utils.WarnString(fmt.Sprintf("failed to delete signing job %s: %v", job.Name, err)))
 		}
+		if err = jbm.logs.Delete(ctx, job); err != nil {
+			logger.Info(utils.WarnString(fmt.Sprintf("failed to delete the logs of signing job %s: %v", job.Name, err)))
+		}
 		return utils.Result{Status: utils.StatusInProgress, Requeue: true}, nil
 	}
 
@@ -109,5 +229,24 @@ func (jbm *signJobManager) Sync(
 		return utils.Result{}, err
 	}
 
-	return utils.Result{Status: statusmsg, Requeue: inprogress}, nil
+	res := utils.Result{Status: statusmsg, Requeue: inprogress}
+
+	if statusmsg == utils.StatusCompleted {
+		return res, nil
+	}
+
+	// each attempt runs in its own pod, which is kept until the job is deleted, so the logs of all attempts can be
+	// collected, including those of the last one once the job has failed
+	res.LogsConfigMap, err = jbm.logs.Collect(ctx, job, owner)
+	if err != nil {
+		logger.Info(utils.WarnString(fmt.Sprintf("failed to collect the logs of signing job %s: %v", job.Name, err)))
+	}
+
+	if c := utils.JobFailedCondition(job); c != nil {
+		res.Reason = fmt.Sprintf("%s: %s", c.Reason, c.Message)
+		res.FailedJobHash = utils.JobInputsHash(job)
+		logger.Info("The signing job has failed", "name", job.Name, "reason", res.Reason, "logs", res.LogsConfigMap)
+	}
+
+	return res, nil
 }
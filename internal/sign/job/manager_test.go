@@ -3,11 +3,14 @@ package signjob
 import (
 	"context"
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/client"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/constants"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/joblogs"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/registry"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/utils"
 	. "github.com/onsi/ginkgo/v2"
@@ -15,6 +18,8 @@ import (
 	batchv1 "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 )
 
 var _ = Describe("JobManager", func() {
@@ -44,15 +49,15 @@ var _ = Describe("JobManager", func() {
 			mod := kmmv1beta1.Module{}
 			km := kmmv1beta1.KernelMapping{}
 
-			mgr := NewSignJobManager(clnt, nil, nil, reg)
+			mgr := NewSignJobManager(clnt, nil, nil, reg, nil, time.Hour)
 
-			shouldSync, err := mgr.ShouldSync(ctx, mod, km)
+			shouldSync, err := mgr.ShouldSync(ctx, mod, km, kernelVersion, "")
 
 			Expect(err).ToNot(HaveOccurred())
 			Expect(shouldSync).To(BeFalse())
 		})
 
-		It("should return false if image already exists", func() {
+		It("should return false if image already exists without a sign digest", func() {
 			ctx := context.Background()
 
 			km := kmmv1beta1.KernelMapping{
@@ -71,12 +76,14 @@ var _ = Describe("JobManager", func() {
 			}
 
 			gomock.InOrder(
-				reg.EXPECT().ImageExists(ctx, imageName, gomock.Any(), gomock.Any()).Return(true, nil),
+				reg.EXPECT().ImageExists(ctx, imageName, gomock.Any(), gomock.Any(), gomock.Any()).Return(true, nil),
+				clnt.EXPECT().Get(ctx, types.NamespacedName{Name: "pull-push-secret", Namespace: namespace}, &v1.Secret{}),
+				reg.EXPECT().GetImageLabels(ctx, imageName, gomock.Any(), gomock.Any(), gomock.Any()).Return(map[string]string{}, nil),
 			)
 
-			mgr := NewSignJobManager(clnt, nil, nil, reg)
+			mgr := NewSignJobManager(clnt, nil, nil, reg, nil, time.Hour)
 
-			shouldSync, err := mgr.ShouldSync(ctx, mod, km)
+			shouldSync, err := mgr.ShouldSync(ctx, mod, km, kernelVersion, "")
 
 			Expect(err).ToNot(HaveOccurred())
 			Expect(shouldSync).To(BeFalse())
@@ -101,12 +108,12 @@ var _ = Describe("JobManager", func() {
 			}
 
 			gomock.InOrder(
-				reg.EXPECT().ImageExists(ctx, imageName, gomock.Any(), gomock.Any()).Return(false, errors.New("generic-registry-error")),
+				reg.EXPECT().ImageExists(ctx, imageName, gomock.Any(), gomock.Any(), gomock.Any()).Return(false, errors.New("generic-registry-error")),
 			)
 
-			mgr := NewSignJobManager(clnt, nil, nil, reg)
+			mgr := NewSignJobManager(clnt, nil, nil, reg, nil, time.Hour)
 
-			shouldSync, err := mgr.ShouldSync(ctx, mod, km)
+			shouldSync, err := mgr.ShouldSync(ctx, mod, km, kernelVersion, "")
 
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("generic-registry-error"))
@@ -132,12 +139,131 @@ var _ = Describe("JobManager", func() {
 			}
 
 			gomock.InOrder(
-				reg.EXPECT().ImageExists(ctx, imageName, gomock.Any(), gomock.Any()).Return(false, nil),
+				reg.EXPECT().ImageExists(ctx, imageName, gomock.Any(), gomock.Any(), gomock.Any()).Return(false, nil),
 			)
 
-			mgr := NewSignJobManager(clnt, nil, nil, reg)
+			mgr := NewSignJobManager(clnt, nil, nil, reg, nil, time.Hour)
 
-			shouldSync, err := mgr.ShouldSync(ctx, mod, km)
+			shouldSync, err := mgr.ShouldSync(ctx, mod, km, kernelVersion, "")
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(shouldSync).To(BeTrue())
+		})
+
+		DescribeTable("should compare the sign digest of the existing image",
+			func(imageDigest string, expected bool) {
+				ctx := context.Background()
+				signer := NewMockSigner(ctrl)
+
+				km := kmmv1beta1.KernelMapping{
+					Sign:           &kmmv1beta1.Sign{},
+					ContainerImage: imageName,
+				}
+
+				mod := kmmv1beta1.Module{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      moduleName,
+						Namespace: namespace,
+					},
+				}
+
+				job := batchv1.Job{
+					ObjectMeta: metav1.ObjectMeta{
+						Annotations: map[string]string{constants.JobHashAnnotation: "some-hash"},
+					},
+				}
+
+				gomock.InOrder(
+					reg.EXPECT().ImageExists(ctx, imageName, gomock.Any(), gomock.Any(), gomock.Any()).Return(true, nil),
+					reg.EXPECT().
+						GetImageLabels(ctx, imageName, gomock.Any(), gomock.Any(), gomock.Any()).
+						Return(map[string]string{constants.SignDigestLabel: imageDigest}, nil),
+					signer.EXPECT().MakeJobTemplate(ctx, mod, km, kernelVersion, "", nil, "", true, &mod).Return(&job, nil),
+				)
+
+				mgr := NewSignJobManager(clnt, signer, nil, reg, nil, time.Hour)
+
+				shouldSync, err := mgr.ShouldSync(ctx, mod, km, kernelVersion, "")
+
+				Expect(err).ToNot(HaveOccurred())
+				Expect(shouldSync).To(Equal(expected))
+			},
+			Entry("digest is up to date", "some-hash", false),
+			Entry("signing inputs have changed", "some-other-hash", true),
+		)
+
+		It("should not compare the sign digest again while the inputs are unchanged", func() {
+			ctx := context.Background()
+			signer := NewMockSigner(ctrl)
+
+			km := kmmv1beta1.KernelMapping{
+				Sign:           &kmmv1beta1.Sign{},
+				ContainerImage: imageName,
+			}
+
+			mod := kmmv1beta1.Module{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      moduleName,
+					Namespace: namespace,
+				},
+			}
+
+			job := batchv1.Job{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{constants.JobHashAnnotation: "some-hash"},
+				},
+			}
+
+			gomock.InOrder(
+				reg.EXPECT().ImageExists(ctx, imageName, gomock.Any(), gomock.Any(), gomock.Any()).Return(true, nil),
+				reg.EXPECT().
+					GetImageLabels(ctx, imageName, gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(map[string]string{constants.SignDigestLabel: "some-hash"}, nil),
+				signer.EXPECT().MakeJobTemplate(ctx, mod, km, kernelVersion, "", nil, "", true, &mod).Return(&job, nil),
+				reg.EXPECT().ImageExists(ctx, imageName, gomock.Any(), gomock.Any(), gomock.Any()).Return(true, nil),
+			)
+
+			mgr := NewSignJobManager(clnt, signer, nil, reg, nil, time.Hour)
+
+			for i := 0; i < 2; i++ {
+				shouldSync, err := mgr.ShouldSync(ctx, mod, km, kernelVersion, "")
+
+				Expect(err).ToNot(HaveOccurred())
+				Expect(shouldSync).To(BeFalse())
+			}
+		})
+
+		It("should return true if the intermediate image was rebuilt", func() {
+			ctx := context.Background()
+
+			km := kmmv1beta1.KernelMapping{
+				Build:          &kmmv1beta1.Build{},
+				Sign:           &kmmv1beta1.Sign{},
+				ContainerImage: imageName,
+			}
+
+			mod := kmmv1beta1.Module{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      moduleName,
+					Namespace: namespace,
+				},
+			}
+
+			intermediateImage := imageName + ":" + namespace + "_" + moduleName + "_kmm_unsigned"
+
+			gomock.InOrder(
+				reg.EXPECT().ImageExists(ctx, imageName, gomock.Any(), gomock.Any(), gomock.Any()).Return(true, nil),
+				reg.EXPECT().
+					GetImageLabels(ctx, imageName, gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(map[string]string{constants.SignDigestLabel: "some-hash", constants.BuildDigestLabel: "old-build"}, nil),
+				reg.EXPECT().
+					GetImageLabels(ctx, intermediateImage, gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(map[string]string{constants.BuildDigestLabel: "new-build"}, nil),
+			)
+
+			mgr := NewSignJobManager(clnt, nil, nil, reg, nil, time.Hour)
+
+			shouldSync, err := mgr.ShouldSync(ctx, mod, km, kernelVersion, "")
 
 			Expect(err).ToNot(HaveOccurred())
 			Expect(shouldSync).To(BeTrue())
@@ -149,6 +275,7 @@ var _ = Describe("JobManager", func() {
 			ctrl      *gomock.Controller
 			maker     *MockSigner
 			jobhelper *utils.MockJobHelper
+			logs      *joblogs.MockCollector
 		)
 
 		const (
@@ -165,6 +292,7 @@ var _ = Describe("JobManager", func() {
 			ctrl = gomock.NewController(GinkgoT())
 			maker = NewMockSigner(ctrl)
 			jobhelper = utils.NewMockJobHelper(ctrl)
+			logs = joblogs.NewMockCollector(ctrl)
 		})
 
 		km := kmmv1beta1.KernelMapping{
@@ -212,16 +340,20 @@ var _ = Describe("JobManager", func() {
 				}
 
 				gomock.InOrder(
-					jobhelper.EXPECT().JobLabels(mod.Name, kernelVersion, "sign").Return(labels),
-					maker.EXPECT().MakeJobTemplate(ctx, mod, km, kernelVersion, labels, previousImageName, true, &mod).Return(&j, nil),
-					jobhelper.EXPECT().GetModuleJobByKernel(ctx, mod.Name, mod.Namespace, kernelVersion, utils.JobTypeSign, &mod).Return(&newJob, nil),
+					jobhelper.EXPECT().JobLabels(mod.Name, kernelVersion, "", utils.JobTypeSign).Return(labels),
+					maker.EXPECT().MakeJobTemplate(ctx, mod, km, kernelVersion, "", labels, previousImageName, true, &mod).Return(&j, nil),
+					jobhelper.EXPECT().GetModuleJobByKernel(ctx, mod.Name, mod.Namespace, kernelVersion, "", utils.JobTypeSign, &mod).Return(&newJob, nil),
 					jobhelper.EXPECT().IsJobChanged(&j, &newJob).Return(false, nil),
 					jobhelper.EXPECT().GetJobStatus(&newJob).Return(r.Status, r.Requeue, joberr),
 				)
 
-				mgr := NewSignJobManager(nil, maker, jobhelper, nil)
+				if !expectsErr && r.Status != utils.StatusCompleted {
+					logs.EXPECT().Collect(ctx, &newJob, &mod).Return(r.LogsConfigMap, nil)
+				}
 
-				res, err := mgr.Sync(ctx, mod, km, kernelVersion, previousImageName, true, &mod)
+				mgr := NewSignJobManager(nil, maker, jobhelper, nil, logs, time.Hour)
+
+				res, err := mgr.Sync(ctx, mod, km, kernelVersion, "", previousImageName, true, &mod)
 
 				if expectsErr {
 					Expect(err).To(HaveOccurred())
@@ -233,22 +365,43 @@ var _ = Describe("JobManager", func() {
 			Entry("active", batchv1.JobStatus{Active: 1}, utils.Result{Requeue: true, Status: utils.StatusInProgress}, false),
 			Entry("active", batchv1.JobStatus{Active: 1}, utils.Result{Requeue: true, Status: utils.StatusInProgress}, false),
 			Entry("succeeded", batchv1.JobStatus{Succeeded: 1}, utils.Result{Status: utils.StatusCompleted}, false),
-			Entry("failed", batchv1.JobStatus{Failed: 1}, utils.Result{}, true),
+			Entry(
+				"backoff limit exceeded",
+				batchv1.JobStatus{
+					Failed: 1,
+					Conditions: []batchv1.JobCondition{
+						{
+							Type:    batchv1.JobFailed,
+							Status:  v1.ConditionTrue,
+							Reason:  "BackoffLimitExceeded",
+							Message: "Job has reached the specified backoff limit",
+						},
+					},
+				},
+				utils.Result{
+					Status:        utils.StatusFailed,
+					Reason:        "BackoffLimitExceeded: Job has reached the specified backoff limit",
+					LogsConfigMap: "some-job-logs",
+					FailedJobHash: "some hash",
+				},
+				false,
+			),
+			Entry("error", batchv1.JobStatus{}, utils.Result{}, true),
 		)
 
 		It("should return an error if there was an error creating the job template", func() {
 			ctx := context.Background()
 
 			gomock.InOrder(
-				jobhelper.EXPECT().JobLabels(mod.Name, kernelVersion, "sign").Return(labels),
-				maker.EXPECT().MakeJobTemplate(ctx, mod, km, kernelVersion, labels, previousImageName, true, &mod).
+				jobhelper.EXPECT().JobLabels(mod.Name, kernelVersion, "", utils.JobTypeSign).Return(labels),
+				maker.EXPECT().MakeJobTemplate(ctx, mod, km, kernelVersion, "", labels, previousImageName, true, &mod).
 					Return(nil, errors.New("random error")),
 			)
 
-			mgr := NewSignJobManager(nil, maker, jobhelper, nil)
+			mgr := NewSignJobManager(nil, maker, jobhelper, nil, logs, time.Hour)
 
 			Expect(
-				mgr.Sync(ctx, mod, km, kernelVersion, previousImageName, true, &mod),
+				mgr.Sync(ctx, mod, km, kernelVersion, "", previousImageName, true, &mod),
 			).Error().To(
 				HaveOccurred(),
 			)
@@ -268,15 +421,15 @@ var _ = Describe("JobManager", func() {
 			}
 
 			gomock.InOrder(
-				jobhelper.EXPECT().JobLabels(mod.Name, kernelVersion, "sign").Return(labels),
-				maker.EXPECT().MakeJobTemplate(ctx, mod, km, kernelVersion, labels, previousImageName, true, &mod).Return(&j, nil),
-				jobhelper.EXPECT().GetModuleJobByKernel(ctx, mod.Name, mod.Namespace, kernelVersion, utils.JobTypeSign, &mod).Return(nil, errors.New("random error")),
+				jobhelper.EXPECT().JobLabels(mod.Name, kernelVersion, "", utils.JobTypeSign).Return(labels),
+				maker.EXPECT().MakeJobTemplate(ctx, mod, km, kernelVersion, "", labels, previousImageName, true, &mod).Return(&j, nil),
+				jobhelper.EXPECT().GetModuleJobByKernel(ctx, mod.Name, mod.Namespace, kernelVersion, "", utils.JobTypeSign, &mod).Return(nil, errors.New("random error")),
 			)
 
-			mgr := NewSignJobManager(nil, maker, jobhelper, nil)
+			mgr := NewSignJobManager(nil, maker, jobhelper, nil, logs, time.Hour)
 
 			Expect(
-				mgr.Sync(ctx, mod, km, kernelVersion, previousImageName, true, &mod),
+				mgr.Sync(ctx, mod, km, kernelVersion, "", previousImageName, true, &mod),
 			).Error().To(
 				HaveOccurred(),
 			)
@@ -296,16 +449,16 @@ var _ = Describe("JobManager", func() {
 			}
 
 			gomock.InOrder(
-				jobhelper.EXPECT().JobLabels(mod.Name, kernelVersion, "sign").Return(labels),
-				maker.EXPECT().MakeJobTemplate(ctx, mod, km, kernelVersion, labels, previousImageName, true, &mod).Return(&j, nil),
-				jobhelper.EXPECT().GetModuleJobByKernel(ctx, mod.Name, mod.Namespace, kernelVersion, utils.JobTypeSign, &mod).Return(nil, utils.ErrNoMatchingJob),
+				jobhelper.EXPECT().JobLabels(mod.Name, kernelVersion, "", utils.JobTypeSign).Return(labels),
+				maker.EXPECT().MakeJobTemplate(ctx, mod, km, kernelVersion, "", labels, previousImageName, true, &mod).Return(&j, nil),
+				jobhelper.EXPECT().GetModuleJobByKernel(ctx, mod.Name, mod.Namespace, kernelVersion, "", utils.JobTypeSign, &mod).Return(nil, utils.ErrNoMatchingJob),
 				jobhelper.EXPECT().CreateJob(ctx, &j).Return(errors.New("unable to create job")),
 			)
 
-			mgr := NewSignJobManager(nil, maker, jobhelper, nil)
+			mgr := NewSignJobManager(nil, maker, jobhelper, nil, logs, time.Hour)
 
 			Expect(
-				mgr.Sync(ctx, mod, km, kernelVersion, previousImageName, true, &mod),
+				mgr.Sync(ctx, mod, km, kernelVersion, "", previousImageName, true, &mod),
 			).Error().To(
 				HaveOccurred(),
 			)
@@ -326,21 +479,52 @@ var _ = Describe("JobManager", func() {
 			}
 
 			gomock.InOrder(
-				jobhelper.EXPECT().JobLabels(mod.Name, kernelVersion, "sign").Return(labels),
-				maker.EXPECT().MakeJobTemplate(ctx, mod, km, kernelVersion, labels, previousImageName, true, &mod).Return(&j, nil),
-				jobhelper.EXPECT().GetModuleJobByKernel(ctx, mod.Name, mod.Namespace, kernelVersion, utils.JobTypeSign, &mod).Return(nil, utils.ErrNoMatchingJob),
+				jobhelper.EXPECT().JobLabels(mod.Name, kernelVersion, "", utils.JobTypeSign).Return(labels),
+				maker.EXPECT().MakeJobTemplate(ctx, mod, km, kernelVersion, "", labels, previousImageName, true, &mod).Return(&j, nil),
+				jobhelper.EXPECT().GetModuleJobByKernel(ctx, mod.Name, mod.Namespace, kernelVersion, "", utils.JobTypeSign, &mod).Return(nil, utils.ErrNoMatchingJob),
 				jobhelper.EXPECT().CreateJob(ctx, &j).Return(nil),
 			)
 
-			mgr := NewSignJobManager(nil, maker, jobhelper, nil)
+			mgr := NewSignJobManager(nil, maker, jobhelper, nil, logs, time.Hour)
 
 			Expect(
-				mgr.Sync(ctx, mod, km, kernelVersion, previousImageName, true, &mod),
+				mgr.Sync(ctx, mod, km, kernelVersion, "", previousImageName, true, &mod),
 			).To(
 				Equal(utils.Result{Requeue: true, Status: utils.StatusCreated}),
 			)
 		})
 
+		It("should not create the job again if a job with the same inputs has failed", func() {
+			ctx := context.Background()
+
+			j := batchv1.Job{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        jobName,
+					Namespace:   namespace,
+					Annotations: map[string]string{constants.JobHashAnnotation: "some hash"},
+				},
+			}
+
+			failedMod := *mod.DeepCopy()
+			failedMod.Status.KernelVersions = []kmmv1beta1.KernelVersionStatus{
+				{KernelVersion: kernelVersion, FailedJobHash: "some hash"},
+			}
+
+			gomock.InOrder(
+				jobhelper.EXPECT().JobLabels(failedMod.Name, kernelVersion, "", utils.JobTypeSign).Return(labels),
+				maker.EXPECT().MakeJobTemplate(ctx, failedMod, km, kernelVersion, "", labels, previousImageName, true, &failedMod).Return(&j, nil),
+				jobhelper.EXPECT().GetModuleJobByKernel(ctx, failedMod.Name, failedMod.Namespace, kernelVersion, "", utils.JobTypeSign, &failedMod).Return(nil, utils.ErrNoMatchingJob),
+			)
+
+			mgr := NewSignJobManager(nil, maker, jobhelper, nil, logs, time.Hour)
+
+			Expect(
+				mgr.Sync(ctx, failedMod, km, kernelVersion, "", previousImageName, true, &failedMod),
+			).To(
+				Equal(utils.Result{Status: utils.StatusFailed, Reason: failedBeforeReason, FailedJobHash: "some hash"}),
+			)
+		})
+
 		It("should delete the job if it was edited", func() {
 			ctx := context.Background()
 
@@ -357,20 +541,99 @@ var _ = Describe("JobManager", func() {
 			}
 
 			gomock.InOrder(
-				jobhelper.EXPECT().JobLabels(mod.Name, kernelVersion, "sign").Return(labels),
-				maker.EXPECT().MakeJobTemplate(ctx, mod, km, kernelVersion, labels, previousImageName, true, &mod).Return(&newJob, nil),
-				jobhelper.EXPECT().GetModuleJobByKernel(ctx, mod.Name, mod.Namespace, kernelVersion, utils.JobTypeSign, &mod).Return(&newJob, nil),
+				jobhelper.EXPECT().JobLabels(mod.Name, kernelVersion, "", utils.JobTypeSign).Return(labels),
+				maker.EXPECT().MakeJobTemplate(ctx, mod, km, kernelVersion, "", labels, previousImageName, true, &mod).Return(&newJob, nil),
+				jobhelper.EXPECT().GetModuleJobByKernel(ctx, mod.Name, mod.Namespace, kernelVersion, "", utils.JobTypeSign, &mod).Return(&newJob, nil),
 				jobhelper.EXPECT().IsJobChanged(&newJob, &newJob).Return(true, nil),
 				jobhelper.EXPECT().DeleteJob(ctx, &newJob).Return(nil),
+				logs.EXPECT().Delete(ctx, &newJob).Return(nil),
 			)
 
-			mgr := NewSignJobManager(nil, maker, jobhelper, nil)
+			mgr := NewSignJobManager(nil, maker, jobhelper, nil, logs, time.Hour)
 
 			Expect(
-				mgr.Sync(ctx, mod, km, kernelVersion, previousImageName, true, &mod),
+				mgr.Sync(ctx, mod, km, kernelVersion, "", previousImageName, true, &mod),
 			).To(
 				Equal(utils.Result{Requeue: true, Status: utils.StatusInProgress}),
 			)
 		})
 	})
 })
+
+var _ = Describe("GarbageCollect", func() {
+	var (
+		ctrl      *gomock.Controller
+		jobhelper *utils.MockJobHelper
+		logs      *joblogs.MockCollector
+		mgr       *signJobManager
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		jobhelper = utils.NewMockJobHelper(ctrl)
+		logs = joblogs.NewMockCollector(ctrl)
+		mgr = NewSignJobManager(nil, nil, jobhelper, nil, logs, time.Hour)
+	})
+
+	mod := kmmv1beta1.Module{
+		ObjectMeta: metav1.ObjectMeta{Name: "moduleName"},
+	}
+
+	failedSince := func(d time.Duration) batchv1.JobStatus {
+		return batchv1.JobStatus{
+			Failed: 1,
+			Conditions: []batchv1.JobCondition{
+				{
+					Type:               batchv1.JobFailed,
+					Status:             v1.ConditionTrue,
+					LastTransitionTime: metav1.NewTime(time.Now().Add(-d)),
+				},
+			},
+		}
+	}
+
+	DescribeTable("should return the correct error and names of the collected jobs",
+		func(jobStatus1 batchv1.JobStatus, jobStatus2 batchv1.JobStatus, expectedNames []string, expectsErr bool) {
+			job1 := batchv1.Job{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "jobName1",
+				},
+				Status: jobStatus1,
+			}
+			job2 := batchv1.Job{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "jobName2",
+				},
+				Status: jobStatus2,
+			}
+			returnedError := fmt.Errorf("some error")
+			if !expectsErr {
+				returnedError = nil
+			}
+
+			jobhelper.EXPECT().GetModuleJobs(context.Background(), mod.Name, mod.Namespace, utils.JobTypeSign, &mod).Return([]batchv1.Job{job1, job2}, returnedError)
+			for _, j := range []batchv1.Job{job1, job2} {
+				if sets.NewString(expectedNames...).Has(j.Name) {
+					job := j
+					jobhelper.EXPECT().DeleteJob(context.Background(), &job).Return(nil)
+					logs.EXPECT().Delete(context.Background(), &job).Return(nil)
+				}
+			}
+
+			names, err := mgr.GarbageCollect(context.Background(), mod.Name, mod.Namespace, &mod)
+
+			if expectsErr {
+				Expect(err).To(HaveOccurred())
+				Expect(names).To(BeNil())
+			} else {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(names).To(Equal(expectedNames))
+			}
+		},
+		Entry("all jobs succeeded", batchv1.JobStatus{Succeeded: 1}, batchv1.JobStatus{Succeeded: 1}, []string{"jobName1", "jobName2"}, false),
+		Entry("1 job succeeded", batchv1.JobStatus{Succeeded: 1}, batchv1.JobStatus{Active: 1}, []string{"jobName1"}, false),
+		Entry("failed jobs within the retention period", failedSince(time.Minute), batchv1.JobStatus{Active: 1}, []string{}, false),
+		Entry("failed job past the retention period", failedSince(2*time.Hour), failedSince(time.Minute), []string{"jobName1"}, false),
+		Entry("error occured", batchv1.JobStatus{}, batchv1.JobStatus{}, nil, true),
+	)
+})
@@ -17,7 +17,6 @@ import (
 	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/constants"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/module"
-	"github.com/kubernetes-sigs/kernel-module-management/internal/sign"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/utils"
 	"github.com/mitchellh/hashstructure"
 )
@@ -30,6 +29,7 @@ type Signer interface {
 		mod kmmv1beta1.Module,
 		km kmmv1beta1.KernelMapping,
 		targetKernel string,
+		targetArch string,
 		labels map[string]string,
 		imageToSign string,
 		pushImage bool,
@@ -37,28 +37,27 @@ type Signer interface {
 	) (*batchv1.Job, error)
 }
 
+// hashData holds the inputs of a signing.
+// The key Secrets are identified by their version rather than by their data, as the hash is published on the image.
 type hashData struct {
-	PrivateKeyData []byte
-	PublicKeyData  []byte
-	PodTemplate    *v1.PodTemplateSpec
+	PrivateKeyVersion string
+	PublicKeyVersion  string
+	PodTemplate       *v1.PodTemplateSpec
 }
 
 type signer struct {
 	client    client.Client
 	scheme    *runtime.Scheme
-	helper    sign.Helper
 	jobHelper utils.JobHelper
 }
 
 func NewSigner(
 	client client.Client,
 	scheme *runtime.Scheme,
-	helper sign.Helper,
 	jobHelper utils.JobHelper) Signer {
 	return &signer{
 		client:    client,
 		scheme:    scheme,
-		helper:    helper,
 		jobHelper: jobHelper,
 	}
 }
@@ -68,12 +67,14 @@ func (m *signer) MakeJobTemplate(
 	mod kmmv1beta1.Module,
 	km kmmv1beta1.KernelMapping,
 	targetKernel string,
+	targetArch string,
 	labels map[string]string,
 	imageToSign string,
 	pushImage bool,
 	owner metav1.Object) (*batchv1.Job, error) {
 
-	signConfig := m.helper.GetRelevantSign(mod.Spec, km)
+	// km is prepared: its signing configuration is merged with the Module's
+	signConfig := km.Sign
 
 	args := make([]string, 0)
 
@@ -105,6 +106,11 @@ func (m *signer) MakeJobTemplate(
 		args = append(args, "-filestosign", strings.Join(signConfig.FilesToSign, ":"))
 	}
 
+	// the signer would otherwise pick the image of the architecture of the node it runs on
+	if targetArch != "" {
+		args = append(args, "-platform", targetArch)
+	}
+
 	if signConfig.UnsignedImageRegistryTLS.Insecure {
 		args = append(args, "--insecure-pull")
 	}
@@ -138,17 +144,28 @@ func (m *signer) MakeJobTemplate(
 					VolumeMounts: volumeMounts,
 				},
 			},
-			RestartPolicy: v1.RestartPolicyOnFailure,
+			RestartPolicy: v1.RestartPolicyNever,
 			Volumes:       volumes,
-			NodeSelector:  mod.Spec.Selector,
 		},
 	}
 
+	// a new trigger changes the hash of the job, so that the image is signed again
+	if t := mod.Spec.RebuildTrigger; t != "" {
+		specTemplate.Annotations = map[string]string{constants.RebuildTriggerAnnotation: t}
+	}
+
 	specTemplateHash, err := m.getHashAnnotationValue(ctx, signConfig.KeySecret.Name, signConfig.CertSecret.Name, mod.Namespace, &specTemplate)
 	if err != nil {
 		return nil, fmt.Errorf("could not hash job's definitions: %v", err)
 	}
 
+	// stamp the hash of the signing inputs on the image, so that we can detect when it needs to be signed again
+	specTemplate.Spec.Containers[0].Args = append(
+		specTemplate.Spec.Containers[0].Args,
+		"-label",
+		fmt.Sprintf("%s=%d", constants.SignDigestLabel, specTemplateHash),
+	)
+
 	job := &batchv1.Job{
 		ObjectMeta: metav1.ObjectMeta{
 			GenerateName: mod.Name + "-sign-",
@@ -162,6 +179,10 @@ func (m *signer) MakeJobTemplate(
 		},
 	}
 
+	// the nodes the signing runs on do not change the image
+	job.Spec.Template.Spec.NodeSelector = utils.JobNodeSelector(mod.Spec.Selector, targetArch)
+	job.Spec.Template.Spec.Affinity = module.Affinity(mod.Spec)
+
 	if err := controllerutil.SetControllerReference(owner, job, m.scheme); err != nil {
 		return nil, fmt.Errorf("could not set the owner reference: %v", err)
 	}
@@ -170,37 +191,36 @@ func (m *signer) MakeJobTemplate(
 }
 
 func (s *signer) getHashAnnotationValue(ctx context.Context, privateSecret, publicSecret, namespace string, podTemplate *v1.PodTemplateSpec) (uint64, error) {
-	privateKeyData, err := s.getSecretData(ctx, privateSecret, constants.PrivateSignDataKey, namespace)
+	privateKeyVersion, err := s.getSecretVersion(ctx, privateSecret, constants.PrivateSignDataKey, namespace)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get private secret %s for signing: %v", privateSecret, err)
 	}
-	publicKeyData, err := s.getSecretData(ctx, publicSecret, constants.PublicSignDataKey, namespace)
+	publicKeyVersion, err := s.getSecretVersion(ctx, publicSecret, constants.PublicSignDataKey, namespace)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get public secret %s for signing: %v", publicSecret, err)
 	}
 
-	return getHashValue(podTemplate, publicKeyData, privateKeyData)
+	return getHashValue(podTemplate, publicKeyVersion, privateKeyVersion)
 }
 
-func (s *signer) getSecretData(ctx context.Context, secretName, secretDataKey, namespace string) ([]byte, error) {
+func (s *signer) getSecretVersion(ctx context.Context, secretName, secretDataKey, namespace string) (string, error) {
 	secret := v1.Secret{}
 	namespacedName := types.NamespacedName{Name: secretName, Namespace: namespace}
 	err := s.client.Get(ctx, namespacedName, &secret)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get Secret %s: %v", namespacedName, err)
+		return "", fmt.Errorf("failed to get Secret %s: %v", namespacedName, err)
 	}
-	data, ok := secret.Data[secretDataKey]
-	if !ok {
-		return nil, fmt.Errorf("invalid Secret %s format, %s key is missing", namespacedName, secretDataKey)
+	if _, ok := secret.Data[secretDataKey]; !ok {
+		return "", fmt.Errorf("invalid Secret %s format, %s key is missing", namespacedName, secretDataKey)
 	}
-	return data, nil
+	return utils.SecretVersion(&secret), nil
 }
 
-func getHashValue(podTemplate *v1.PodTemplateSpec, publicKeyData, privateKeyData []byte) (uint64, error) {
+func getHashValue(podTemplate *v1.PodTemplateSpec, publicKeyVersion, privateKeyVersion string) (uint64, error) {
 	dataToHash := hashData{
-		PrivateKeyData: privateKeyData,
-		PublicKeyData:  publicKeyData,
-		PodTemplate:    podTemplate,
+		PrivateKeyVersion: privateKeyVersion,
+		PublicKeyVersion:  publicKeyVersion,
+		PodTemplate:       podTemplate,
 	}
 	hashValue, err := hashstructure.Hash(dataToHash, nil)
 	if err != nil {
@@ -20,7 +20,7 @@ import (
 	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/client"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/constants"
-	"github.com/kubernetes-sigs/kernel-module-management/internal/sign"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/module"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/utils"
 )
 
@@ -42,16 +42,14 @@ var _ = Describe("MakeJobTemplate", func() {
 		clnt      *client.MockClient
 		m         Signer
 		mod       kmmv1beta1.Module
-		helper    *sign.MockHelper
 		jobhelper *utils.MockJobHelper
 	)
 
 	BeforeEach(func() {
 		ctrl = gomock.NewController(GinkgoT())
 		clnt = client.NewMockClient(ctrl)
-		helper = sign.NewMockHelper(ctrl)
 		jobhelper = utils.NewMockJobHelper(ctrl)
-		m = NewSigner(clnt, scheme, helper, jobhelper)
+		m = NewSigner(clnt, scheme, jobhelper)
 		mod = kmmv1beta1.Module{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:      moduleName,
@@ -163,8 +161,7 @@ var _ = Describe("MakeJobTemplate", func() {
 								VolumeMounts: []v1.VolumeMount{secretMount, certMount},
 							},
 						},
-						NodeSelector:  nodeSelector,
-						RestartPolicy: v1.RestartPolicyOnFailure,
+						RestartPolicy: v1.RestartPolicyNever,
 
 						Volumes: []v1.Volume{keysecret, certsecret},
 					},
@@ -203,31 +200,43 @@ var _ = Describe("MakeJobTemplate", func() {
 				)
 		}
 
-		hash, err := getHashValue(&expected.Spec.Template, []byte(publicKey), []byte(privateKey))
+		hash, err := getHashValue(&expected.Spec.Template, "public-uid/1", "private-uid/1")
 		Expect(err).NotTo(HaveOccurred())
 		annotations := map[string]string{constants.JobHashAnnotation: fmt.Sprintf("%d", hash)}
 		expected.SetAnnotations(annotations)
+		expected.Spec.Template.Spec.Containers[0].Args = append(
+			expected.Spec.Template.Spec.Containers[0].Args,
+			"-label", fmt.Sprintf("%s=%d", constants.SignDigestLabel, hash),
+		)
 
 		mod := mod.DeepCopy()
 		mod.Spec.Selector = nodeSelector
+		mod.Spec.LabelSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"zone": "a"}}
+
+		// the nodes are not hashed
+		expected.Spec.Template.Spec.NodeSelector = nodeSelector
+		expected.Spec.Template.Spec.Affinity = module.Affinity(mod.Spec)
 
 		gomock.InOrder(
-			helper.EXPECT().GetRelevantSign(mod.Spec, km).Return(km.Sign),
 			clnt.EXPECT().Get(ctx, types.NamespacedName{Name: km.Sign.KeySecret.Name, Namespace: mod.Namespace}, gomock.Any()).DoAndReturn(
 				func(_ interface{}, _ interface{}, secret *v1.Secret, _ ...ctrlclient.GetOption) error {
+					secret.UID = "private-uid"
+					secret.ResourceVersion = "1"
 					secret.Data = privateSignData
 					return nil
 				},
 			),
 			clnt.EXPECT().Get(ctx, types.NamespacedName{Name: km.Sign.CertSecret.Name, Namespace: mod.Namespace}, gomock.Any()).DoAndReturn(
 				func(_ interface{}, _ interface{}, secret *v1.Secret, _ ...ctrlclient.GetOption) error {
+					secret.UID = "public-uid"
+					secret.ResourceVersion = "1"
 					secret.Data = publicSignData
 					return nil
 				},
 			),
 		)
 
-		actual, err := m.MakeJobTemplate(ctx, *mod, km, kernelVersion, labels, unsignedImage, true, mod)
+		actual, err := m.MakeJobTemplate(ctx, *mod, km, kernelVersion, "", labels, unsignedImage, true, mod)
 		Expect(err).NotTo(HaveOccurred())
 
 		Expect(
@@ -259,7 +268,6 @@ var _ = Describe("MakeJobTemplate", func() {
 		}
 
 		gomock.InOrder(
-			helper.EXPECT().GetRelevantSign(mod.Spec, km).Return(km.Sign),
 			clnt.EXPECT().Get(ctx, types.NamespacedName{Name: km.Sign.KeySecret.Name, Namespace: mod.Namespace}, gomock.Any()).DoAndReturn(
 				func(_ interface{}, _ interface{}, secret *v1.Secret, _ ...ctrlclient.GetOption) error {
 					secret.Data = privateSignData
@@ -274,7 +282,7 @@ var _ = Describe("MakeJobTemplate", func() {
 			),
 		)
 
-		actual, err := m.MakeJobTemplate(ctx, mod, km, kernelVersion, labels, "", pushImage, &mod)
+		actual, err := m.MakeJobTemplate(ctx, mod, km, kernelVersion, "", labels, "", pushImage, &mod)
 
 		Expect(err).NotTo(HaveOccurred())
 		Expect(actual.Spec.Template.Spec.Containers[0].Args).To(ContainElement("-unsignedimage"))
@@ -310,6 +318,79 @@ var _ = Describe("MakeJobTemplate", func() {
 		),
 	)
 
+	It("should change the job hash when the rebuild trigger changes", func() {
+		ctx := context.Background()
+		km := kmmv1beta1.KernelMapping{
+			Sign: &kmmv1beta1.Sign{
+				UnsignedImage: signedImage,
+				KeySecret:     &v1.LocalObjectReference{Name: "securebootkey"},
+				CertSecret:    &v1.LocalObjectReference{Name: "securebootcert"},
+			},
+			ContainerImage: unsignedImage,
+		}
+
+		clnt.EXPECT().Get(ctx, types.NamespacedName{Name: km.Sign.KeySecret.Name, Namespace: mod.Namespace}, gomock.Any()).DoAndReturn(
+			func(_ interface{}, _ interface{}, secret *v1.Secret, _ ...ctrlclient.GetOption) error {
+				secret.Data = privateSignData
+				return nil
+			},
+		).Times(2)
+		clnt.EXPECT().Get(ctx, types.NamespacedName{Name: km.Sign.CertSecret.Name, Namespace: mod.Namespace}, gomock.Any()).DoAndReturn(
+			func(_ interface{}, _ interface{}, secret *v1.Secret, _ ...ctrlclient.GetOption) error {
+				secret.Data = publicSignData
+				return nil
+			},
+		).Times(2)
+
+		mod.Spec.RebuildTrigger = "1"
+
+		first, err := m.MakeJobTemplate(ctx, mod, km, kernelVersion, "", labels, "", true, &mod)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(first.Spec.Template.Annotations).To(HaveKeyWithValue(constants.RebuildTriggerAnnotation, "1"))
+
+		mod.Spec.RebuildTrigger = "2"
+
+		second, err := m.MakeJobTemplate(ctx, mod, km, kernelVersion, "", labels, "", true, &mod)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(second.Annotations[constants.JobHashAnnotation]).NotTo(Equal(first.Annotations[constants.JobHashAnnotation]))
+	})
+
+	It("should sign the image of the target architecture on its nodes", func() {
+		ctx := context.Background()
+		km := kmmv1beta1.KernelMapping{
+			Sign: &kmmv1beta1.Sign{
+				UnsignedImage: signedImage,
+				KeySecret:     &v1.LocalObjectReference{Name: "securebootkey"},
+				CertSecret:    &v1.LocalObjectReference{Name: "securebootcert"},
+			},
+			ContainerImage: unsignedImage,
+		}
+
+		gomock.InOrder(
+			clnt.EXPECT().Get(ctx, types.NamespacedName{Name: km.Sign.KeySecret.Name, Namespace: mod.Namespace}, gomock.Any()).DoAndReturn(
+				func(_ interface{}, _ interface{}, secret *v1.Secret, _ ...ctrlclient.GetOption) error {
+					secret.Data = privateSignData
+					return nil
+				},
+			),
+			clnt.EXPECT().Get(ctx, types.NamespacedName{Name: km.Sign.CertSecret.Name, Namespace: mod.Namespace}, gomock.Any()).DoAndReturn(
+				func(_ interface{}, _ interface{}, secret *v1.Secret, _ ...ctrlclient.GetOption) error {
+					secret.Data = publicSignData
+					return nil
+				},
+			),
+		)
+
+		modWithSelector := mod
+		modWithSelector.Spec.Selector = map[string]string{"accelerator": "true"}
+
+		actual, err := m.MakeJobTemplate(ctx, modWithSelector, km, kernelVersion, "arm64", labels, "", true, &mod)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(actual.Spec.Template.Spec.NodeSelector).To(Equal(map[string]string{"accelerator": "true", v1.LabelArchStable: "arm64"}))
+		Expect(modWithSelector.Spec.Selector).NotTo(HaveKey(v1.LabelArchStable))
+		Expect(actual.Spec.Template.Spec.Containers[0].Args).To(ContainElements("-platform", "arm64"))
+	})
+
 	DescribeTable("should set correct kmod-signer TLS flags", func(kmRegistryTLS,
 		unsignedImageRegistryTLS kmmv1beta1.TLSOptions, expectedFlag string) {
 		ctx := context.Background()
@@ -324,7 +405,6 @@ var _ = Describe("MakeJobTemplate", func() {
 		}
 
 		gomock.InOrder(
-			helper.EXPECT().GetRelevantSign(mod.Spec, km).Return(km.Sign),
 			clnt.EXPECT().Get(ctx, types.NamespacedName{Name: km.Sign.KeySecret.Name, Namespace: mod.Namespace}, gomock.Any()).DoAndReturn(
 				func(_ interface{}, _ interface{}, secret *v1.Secret, _ ...ctrlclient.GetOption) error {
 					secret.Data = privateSignData
@@ -339,7 +419,7 @@ var _ = Describe("MakeJobTemplate", func() {
 			),
 		)
 
-		actual, err := m.MakeJobTemplate(ctx, mod, km, kernelVersion, labels, "", true, &mod)
+		actual, err := m.MakeJobTemplate(ctx, mod, km, kernelVersion, "", labels, "", true, &mod)
 
 		Expect(err).NotTo(HaveOccurred())
 		Expect(actual.Spec.Template.Spec.Containers[0].Args).To(ContainElement(expectedFlag))
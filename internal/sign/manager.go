@@ -12,16 +12,21 @@ import (
 //go:generate mockgen -source=manager.go -package=sign -destination=mock_manager.go
 
 type SignManager interface {
+	GarbageCollect(ctx context.Context, modName, namespace string, owner metav1.Object) ([]string, error)
+
 	ShouldSync(
 		ctx context.Context,
 		mod kmmv1beta1.Module,
-		m kmmv1beta1.KernelMapping) (bool, error)
+		m kmmv1beta1.KernelMapping,
+		targetKernel string,
+		targetArch string) (bool, error)
 
 	Sync(
 		ctx context.Context,
 		mod kmmv1beta1.Module,
 		m kmmv1beta1.KernelMapping,
 		targetKernel string,
+		targetArch string,
 		imageToSign string,
 		pushImage bool,
 		owner metav1.Object) (utils.Result, error)
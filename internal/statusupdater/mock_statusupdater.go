@@ -10,6 +10,8 @@ import (
 
 	gomock "github.com/golang/mock/gomock"
 	v1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
+	daemonset "github.com/kubernetes-sigs/kernel-module-management/internal/daemonset"
+	module "github.com/kubernetes-sigs/kernel-module-management/internal/module"
 	v1 "k8s.io/api/apps/v1"
 	v10 "k8s.io/api/core/v1"
 	sets "k8s.io/apimachinery/pkg/util/sets"
@@ -38,18 +40,32 @@ func (m *MockModuleStatusUpdater) EXPECT() *MockModuleStatusUpdaterMockRecorder
 	return m.recorder
 }
 
+// ModuleSetPaused mocks base method.
+func (m *MockModuleStatusUpdater) ModuleSetPaused(ctx context.Context, mod *v1beta1.Module) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ModuleSetPaused", ctx, mod)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ModuleSetPaused indicates an expected call of ModuleSetPaused.
+func (mr *MockModuleStatusUpdaterMockRecorder) ModuleSetPaused(ctx, mod interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ModuleSetPaused", reflect.TypeOf((*MockModuleStatusUpdater)(nil).ModuleSetPaused), ctx, mod)
+}
+
 // ModuleUpdateStatus mocks base method.
-func (m *MockModuleStatusUpdater) ModuleUpdateStatus(ctx context.Context, mod *v1beta1.Module, kernelMappingNodes, targetedNodes []v10.Node, dsByKernelVersion map[string]*v1.DaemonSet) error {
+func (m *MockModuleStatusUpdater) ModuleUpdateStatus(ctx context.Context, mod *v1beta1.Module, kernelMappingNodes, targetedNodes []v10.Node, dsByKey map[daemonset.Key]*v1.DaemonSet, kernelVersionStatuses map[module.KernelTarget]*v1beta1.KernelVersionStatus) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ModuleUpdateStatus", ctx, mod, kernelMappingNodes, targetedNodes, dsByKernelVersion)
+	ret := m.ctrl.Call(m, "ModuleUpdateStatus", ctx, mod, kernelMappingNodes, targetedNodes, dsByKey, kernelVersionStatuses)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // ModuleUpdateStatus indicates an expected call of ModuleUpdateStatus.
-func (mr *MockModuleStatusUpdaterMockRecorder) ModuleUpdateStatus(ctx, mod, kernelMappingNodes, targetedNodes, dsByKernelVersion interface{}) *gomock.Call {
+func (mr *MockModuleStatusUpdaterMockRecorder) ModuleUpdateStatus(ctx, mod, kernelMappingNodes, targetedNodes, dsByKey, kernelVersionStatuses interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ModuleUpdateStatus", reflect.TypeOf((*MockModuleStatusUpdater)(nil).ModuleUpdateStatus), ctx, mod, kernelMappingNodes, targetedNodes, dsByKernelVersion)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ModuleUpdateStatus", reflect.TypeOf((*MockModuleStatusUpdater)(nil).ModuleUpdateStatus), ctx, mod, kernelMappingNodes, targetedNodes, dsByKey, kernelVersionStatuses)
 }
 
 // MockPreflightStatusUpdater is a mock of PreflightStatusUpdater interface.
@@ -3,13 +3,17 @@ package statusupdater
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/daemonset"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/metrics"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/module"
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -19,7 +23,9 @@ import (
 
 type ModuleStatusUpdater interface {
 	ModuleUpdateStatus(ctx context.Context, mod *kmmv1beta1.Module, kernelMappingNodes []v1.Node,
-		targetedNodes []v1.Node, dsByKernelVersion map[string]*appsv1.DaemonSet) error
+		targetedNodes []v1.Node, dsByKey map[daemonset.Key]*appsv1.DaemonSet,
+		kernelVersionStatuses map[module.KernelTarget]*kmmv1beta1.KernelVersionStatus) error
+	ModuleSetPaused(ctx context.Context, mod *kmmv1beta1.Module) error
 }
 
 //go:generate mockgen -source=statusupdater.go -package=statusupdater -destination=mock_statusupdater.go
@@ -59,14 +65,15 @@ func (m *moduleStatusUpdater) ModuleUpdateStatus(ctx context.Context,
 	mod *kmmv1beta1.Module,
 	kernelMappingNodes []v1.Node,
 	targetedNodes []v1.Node,
-	dsByKernelVersion map[string]*appsv1.DaemonSet) error {
+	dsByKey map[daemonset.Key]*appsv1.DaemonSet,
+	kernelVersionStatuses map[module.KernelTarget]*kmmv1beta1.KernelVersionStatus) error {
 
 	nodesMatchingSelectorNumber := int32(len(targetedNodes))
 	numDesired := int32(len(kernelMappingNodes))
 	var numAvailableDevicePlugin int32
 	var numAvailableKernelModule int32
-	for kernelVersion, ds := range dsByKernelVersion {
-		if daemonset.IsDevicePluginKernelVersion(kernelVersion) {
+	for key, ds := range dsByKey {
+		if daemonset.IsDevicePluginKernelVersion(key.KernelVersion) {
 			numAvailableDevicePlugin += ds.Status.NumberAvailable
 		} else {
 			numAvailableKernelModule += ds.Status.NumberAvailable
@@ -80,7 +87,22 @@ func (m *moduleStatusUpdater) ModuleUpdateStatus(ctx context.Context,
 		mod.Status.DevicePlugin.DesiredNumber = numDesired
 		mod.Status.DevicePlugin.AvailableNumber = numAvailableDevicePlugin
 	}
-	m.updateMetrics(ctx, mod, dsByKernelVersion)
+	mod.Status.KernelVersions = kernelVersionsStatus(mod.Status.KernelVersions, kernelVersionStatuses, dsByKey, mod.Spec.ModuleLoader.Container.Version)
+	setModuleConditions(mod)
+	m.updateMetrics(ctx, mod, dsByKey)
+	return m.client.Status().Update(ctx, mod)
+}
+
+// ModuleSetPaused only updates the Paused condition of mod, leaving the rest of its status as it was when the
+// Module was paused.
+func (m *moduleStatusUpdater) ModuleSetPaused(ctx context.Context, mod *kmmv1beta1.Module) error {
+	c := meta.FindStatusCondition(mod.Status.Conditions, kmmv1beta1.ModuleConditionPaused)
+	if c != nil && c.Status == metav1.ConditionTrue && c.ObservedGeneration == mod.Generation {
+		return nil
+	}
+
+	meta.SetStatusCondition(&mod.Status.Conditions, pausedCondition(mod))
+
 	return m.client.Status().Update(ctx, mod)
 }
 
@@ -124,16 +146,186 @@ func (p *preflightStatusUpdater) PreflightSetVerificationStage(ctx context.Conte
 	return p.client.Status().Update(ctx, pv)
 }
 
-func (m *moduleStatusUpdater) updateMetrics(ctx context.Context, mod *kmmv1beta1.Module, dsByKernelVersion map[string]*appsv1.DaemonSet) {
-	for kernelVersion, ds := range dsByKernelVersion {
+func (m *moduleStatusUpdater) updateMetrics(ctx context.Context, mod *kmmv1beta1.Module, dsByKey map[daemonset.Key]*appsv1.DaemonSet) {
+	for key, ds := range dsByKey {
 		stage := metrics.ModuleLoaderStage
-		if daemonset.IsDevicePluginKernelVersion(kernelVersion) {
+		if daemonset.IsDevicePluginKernelVersion(key.KernelVersion) {
 			stage = metrics.DevicePluginStage
+		} else if key.ModuleVersion != mod.Spec.ModuleLoader.Container.Version {
+			// previous versions of the module are being unloaded
+			continue
 		}
 		m.metricsAPI.SetCompletedStage(mod.Name,
 			mod.Namespace,
-			kernelVersion,
+			key.KernelVersion,
 			stage,
 			ds.Status.DesiredNumberScheduled == ds.Status.NumberAvailable)
 	}
 }
+
+// kernelVersionsStatus returns the sorted list of kernel version statuses. Kernel versions in the ModuleLoader stage
+// are promoted to Loaded once their DaemonSet is available on all nodes it targets. LastTransitionTime is only
+// updated when the stage or the error of a kernel version changed since the previous status.
+// Only the DaemonSets of moduleVersion are considered.
+func kernelVersionsStatus(previous []kmmv1beta1.KernelVersionStatus,
+	kernelVersionStatuses map[module.KernelTarget]*kmmv1beta1.KernelVersionStatus,
+	dsByKey map[daemonset.Key]*appsv1.DaemonSet,
+	moduleVersion string) []kmmv1beta1.KernelVersionStatus {
+
+	previousByTarget := make(map[module.KernelTarget]kmmv1beta1.KernelVersionStatus, len(previous))
+	for _, kvs := range previous {
+		previousByTarget[module.KernelTarget{KernelVersion: kvs.KernelVersion, Architecture: kvs.Architecture}] = kvs
+	}
+
+	now := metav1.NewTime(time.Now())
+	kernelVersions := make([]kmmv1beta1.KernelVersionStatus, 0, len(kernelVersionStatuses))
+
+	for target, kvs := range kernelVersionStatuses {
+		newStatus := *kvs
+
+		key := daemonset.Key{
+			KernelVersion: target.KernelVersion,
+			Architecture:  target.Architecture,
+			ModuleVersion: moduleVersion,
+		}
+
+		if newStatus.Stage == kmmv1beta1.KernelVersionStageModuleLoader &&
+			newStatus.LastError == "" &&
+			isDaemonSetAvailable(dsByKey[key]) {
+			newStatus.Stage = kmmv1beta1.KernelVersionStageLoaded
+		}
+
+		if p, ok := previousByTarget[target]; ok && p.Stage == newStatus.Stage && p.LastError == newStatus.LastError {
+			newStatus.LastTransitionTime = p.LastTransitionTime
+		} else {
+			newStatus.LastTransitionTime = now
+		}
+
+		kernelVersions = append(kernelVersions, newStatus)
+	}
+
+	sort.Slice(kernelVersions, func(i, j int) bool {
+		if kernelVersions[i].KernelVersion != kernelVersions[j].KernelVersion {
+			return kernelVersions[i].KernelVersion < kernelVersions[j].KernelVersion
+		}
+
+		return kernelVersions[i].Architecture < kernelVersions[j].Architecture
+	})
+
+	return kernelVersions
+}
+
+// kernelVersionName returns the kernel version of kvs, followed by its architecture if known.
+func kernelVersionName(kvs kmmv1beta1.KernelVersionStatus) string {
+	if kvs.Architecture == "" {
+		return kvs.KernelVersion
+	}
+
+	return fmt.Sprintf("%s (%s)", kvs.KernelVersion, kvs.Architecture)
+}
+
+func setModuleConditions(mod *kmmv1beta1.Module) {
+	failed := make([]string, 0)
+	inProgress := make([]string, 0)
+
+	for _, kvs := range mod.Status.KernelVersions {
+		if kvs.LastError != "" {
+			failed = append(failed, kernelVersionName(kvs))
+		} else if kvs.Stage != kmmv1beta1.KernelVersionStageLoaded {
+			inProgress = append(inProgress, kernelVersionName(kvs))
+		}
+	}
+
+	devicePluginAvailable := mod.Spec.DevicePlugin == nil ||
+		mod.Status.DevicePlugin.AvailableNumber >= mod.Status.DevicePlugin.DesiredNumber
+
+	degraded := metav1.Condition{
+		Type:               kmmv1beta1.ModuleConditionDegraded,
+		Status:             metav1.ConditionFalse,
+		Reason:             "NoErrors",
+		ObservedGeneration: mod.Generation,
+	}
+	if len(failed) > 0 {
+		degraded.Status = metav1.ConditionTrue
+		degraded.Reason = "KernelVersionsFailed"
+		degraded.Message = "Failed kernel versions: " + strings.Join(failed, ", ")
+	}
+
+	progressing := metav1.Condition{
+		Type:               kmmv1beta1.ModuleConditionProgressing,
+		Status:             metav1.ConditionFalse,
+		Reason:             "Completed",
+		ObservedGeneration: mod.Generation,
+	}
+	switch {
+	case len(inProgress) > 0:
+		progressing.Status = metav1.ConditionTrue
+		progressing.Reason = "KernelVersionsInProgress"
+		progressing.Message = "Kernel versions in progress: " + strings.Join(inProgress, ", ")
+	case !devicePluginAvailable:
+		progressing.Status = metav1.ConditionTrue
+		progressing.Reason = "DevicePluginInProgress"
+	}
+
+	loaded := len(mod.Status.KernelVersions) - len(failed) - len(inProgress)
+
+	ready := metav1.Condition{
+		Type:               kmmv1beta1.ModuleConditionReady,
+		Status:             metav1.ConditionFalse,
+		Reason:             "ModuleNotLoaded",
+		Message:            fmt.Sprintf("%d of %d kernel versions loaded", loaded, len(mod.Status.KernelVersions)),
+		ObservedGeneration: mod.Generation,
+	}
+	if len(failed) == 0 && len(inProgress) == 0 && devicePluginAvailable {
+		ready.Status = metav1.ConditionTrue
+		ready.Reason = "ModuleLoaded"
+	}
+
+	meta.SetStatusCondition(&mod.Status.Conditions, ready)
+	meta.SetStatusCondition(&mod.Status.Conditions, progressing)
+	meta.SetStatusCondition(&mod.Status.Conditions, degraded)
+	meta.SetStatusCondition(&mod.Status.Conditions, pausedCondition(mod))
+	meta.SetStatusCondition(&mod.Status.Conditions, referencesValidCondition(mod))
+}
+
+func referencesValidCondition(mod *kmmv1beta1.Module) metav1.Condition {
+	valid := metav1.Condition{
+		Type:               kmmv1beta1.ModuleConditionReferencesValid,
+		Status:             metav1.ConditionTrue,
+		Reason:             "AllReferencesValid",
+		ObservedGeneration: mod.Generation,
+	}
+
+	if len(mod.Status.InvalidReferences) > 0 {
+		invalid := make([]string, 0, len(mod.Status.InvalidReferences))
+		for _, ref := range mod.Status.InvalidReferences {
+			invalid = append(invalid, fmt.Sprintf("%s %s: %s", ref.Kind, ref.Name, ref.Message))
+		}
+
+		valid.Status = metav1.ConditionFalse
+		valid.Reason = "InvalidReferences"
+		valid.Message = strings.Join(invalid, "; ")
+	}
+
+	return valid
+}
+
+func pausedCondition(mod *kmmv1beta1.Module) metav1.Condition {
+	paused := metav1.Condition{
+		Type:               kmmv1beta1.ModuleConditionPaused,
+		Status:             metav1.ConditionFalse,
+		Reason:             "NotPaused",
+		ObservedGeneration: mod.Generation,
+	}
+	if mod.Spec.Paused {
+		paused.Status = metav1.ConditionTrue
+		paused.Reason = "PausedBySpec"
+		paused.Message = "Reconciliation is paused; unset spec.paused to resume it"
+	}
+
+	return paused
+}
+
+func isDaemonSetAvailable(ds *appsv1.DaemonSet) bool {
+	return ds != nil && ds.Status.DesiredNumberScheduled > 0 && ds.Status.NumberAvailable == ds.Status.DesiredNumberScheduled
+}
@@ -3,16 +3,19 @@ package statusupdater
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/client"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/daemonset"
 	"github.com/kubernetes-sigs/kernel-module-management/internal/metrics"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/module"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
 )
@@ -46,15 +49,15 @@ var _ = Describe("module status update", func() {
 	})
 
 	DescribeTable("checking status updater based on module",
-		func(mappingsNodes []v1.Node, targetedNodes []v1.Node, dsMap map[string]*appsv1.DaemonSet, devicePluginPresent bool) {
+		func(mappingsNodes []v1.Node, targetedNodes []v1.Node, dsMap map[daemonset.Key]*appsv1.DaemonSet, devicePluginPresent bool) {
 			if devicePluginPresent {
 				mod.Spec.DevicePlugin = &kmmv1beta1.DevicePluginSpec{}
 			}
 			var moduleLoaderAvailable int32
 			var devicePluginAvailable int32
 
-			for kernelVersion, ds := range dsMap {
-				if daemonset.IsDevicePluginKernelVersion(kernelVersion) {
+			for key, ds := range dsMap {
+				if daemonset.IsDevicePluginKernelVersion(key.KernelVersion) {
 					devicePluginAvailable = ds.Status.NumberAvailable
 					mockMetrics.EXPECT().SetCompletedStage(name,
 						namespace,
@@ -65,7 +68,7 @@ var _ = Describe("module status update", func() {
 					moduleLoaderAvailable += ds.Status.NumberAvailable
 					mockMetrics.EXPECT().SetCompletedStage(name,
 						namespace,
-						kernelVersion,
+						key.KernelVersion,
 						metrics.ModuleLoaderStage,
 						ds.Status.NumberAvailable == ds.Status.DesiredNumberScheduled)
 				}
@@ -74,7 +77,7 @@ var _ = Describe("module status update", func() {
 			clnt.EXPECT().Status().Return(statusWrite)
 			statusWrite.EXPECT().Update(context.Background(), mod).Return(nil)
 
-			res := su.ModuleUpdateStatus(context.Background(), mod, mappingsNodes, targetedNodes, dsMap)
+			res := su.ModuleUpdateStatus(context.Background(), mod, mappingsNodes, targetedNodes, dsMap, nil)
 
 			Expect(res).To(BeNil())
 			Expect(mod.Status.ModuleLoader.NodesMatchingSelectorNumber).To(Equal(int32(len(targetedNodes))))
@@ -117,6 +120,236 @@ var _ = Describe("module status update", func() {
 	)
 })
 
+var _ = Describe("kernelVersionsStatus", func() {
+	const kernelVersion = "1.2.3"
+
+	It("should promote a kernel version to Loaded when its DaemonSet is available", func() {
+		statuses := map[module.KernelTarget]*kmmv1beta1.KernelVersionStatus{
+			{KernelVersion: kernelVersion}: {KernelVersion: kernelVersion, Stage: kmmv1beta1.KernelVersionStageModuleLoader},
+		}
+		dsMap := map[daemonset.Key]*appsv1.DaemonSet{
+			{KernelVersion: kernelVersion}: {
+				Status: appsv1.DaemonSetStatus{DesiredNumberScheduled: 2, NumberAvailable: 2},
+			},
+		}
+
+		res := kernelVersionsStatus(nil, statuses, dsMap, "")
+
+		Expect(res).To(HaveLen(1))
+		Expect(res[0].Stage).To(Equal(kmmv1beta1.KernelVersionStageLoaded))
+	})
+
+	It("should not promote a kernel version when its DaemonSet is not available", func() {
+		statuses := map[module.KernelTarget]*kmmv1beta1.KernelVersionStatus{
+			{KernelVersion: kernelVersion}: {KernelVersion: kernelVersion, Stage: kmmv1beta1.KernelVersionStageModuleLoader},
+		}
+		dsMap := map[daemonset.Key]*appsv1.DaemonSet{
+			{KernelVersion: kernelVersion}: {
+				Status: appsv1.DaemonSetStatus{DesiredNumberScheduled: 2, NumberAvailable: 1},
+			},
+		}
+
+		res := kernelVersionsStatus(nil, statuses, dsMap, "")
+
+		Expect(res).To(HaveLen(1))
+		Expect(res[0].Stage).To(Equal(kmmv1beta1.KernelVersionStageModuleLoader))
+	})
+
+	It("should only consider the DaemonSet of the current module version", func() {
+		statuses := map[module.KernelTarget]*kmmv1beta1.KernelVersionStatus{
+			{KernelVersion: kernelVersion}: {KernelVersion: kernelVersion, Stage: kmmv1beta1.KernelVersionStageModuleLoader},
+		}
+		dsMap := map[daemonset.Key]*appsv1.DaemonSet{
+			{KernelVersion: kernelVersion, ModuleVersion: "v1"}: {
+				Status: appsv1.DaemonSetStatus{DesiredNumberScheduled: 2, NumberAvailable: 2},
+			},
+			{KernelVersion: kernelVersion, ModuleVersion: "v2"}: {
+				Status: appsv1.DaemonSetStatus{DesiredNumberScheduled: 2, NumberAvailable: 0},
+			},
+		}
+
+		res := kernelVersionsStatus(nil, statuses, dsMap, "v2")
+
+		Expect(res).To(HaveLen(1))
+		Expect(res[0].Stage).To(Equal(kmmv1beta1.KernelVersionStageModuleLoader))
+	})
+
+	It("should keep the last transition time when nothing changed", func() {
+		previousTime := metav1.NewTime(metav1.Now().Add(-time.Hour))
+
+		previous := []kmmv1beta1.KernelVersionStatus{
+			{KernelVersion: kernelVersion, Stage: kmmv1beta1.KernelVersionStageBuild, LastTransitionTime: previousTime},
+		}
+		statuses := map[module.KernelTarget]*kmmv1beta1.KernelVersionStatus{
+			{KernelVersion: kernelVersion}: {KernelVersion: kernelVersion, Stage: kmmv1beta1.KernelVersionStageBuild},
+		}
+
+		res := kernelVersionsStatus(previous, statuses, nil, "")
+
+		Expect(res).To(HaveLen(1))
+		Expect(res[0].LastTransitionTime).To(Equal(previousTime))
+
+		statuses[module.KernelTarget{KernelVersion: kernelVersion}].Stage = kmmv1beta1.KernelVersionStageSign
+
+		res = kernelVersionsStatus(previous, statuses, nil, "")
+
+		Expect(res).To(HaveLen(1))
+		Expect(res[0].LastTransitionTime).NotTo(Equal(previousTime))
+	})
+
+	It("should sort kernel versions and drop the ones that are not targeted anymore", func() {
+		previous := []kmmv1beta1.KernelVersionStatus{{KernelVersion: "old"}}
+		statuses := map[module.KernelTarget]*kmmv1beta1.KernelVersionStatus{
+			{KernelVersion: "b"}:                        {KernelVersion: "b"},
+			{KernelVersion: "a", Architecture: "arm64"}: {KernelVersion: "a", Architecture: "arm64"},
+			{KernelVersion: "a", Architecture: "amd64"}: {KernelVersion: "a", Architecture: "amd64"},
+		}
+
+		res := kernelVersionsStatus(previous, statuses, nil, "")
+
+		Expect(res).To(Equal([]kmmv1beta1.KernelVersionStatus{
+			{KernelVersion: "a", Architecture: "amd64", LastTransitionTime: res[0].LastTransitionTime},
+			{KernelVersion: "a", Architecture: "arm64", LastTransitionTime: res[1].LastTransitionTime},
+			{KernelVersion: "b", LastTransitionTime: res[2].LastTransitionTime},
+		}))
+	})
+
+	It("should use the DaemonSet of the kernel version's architecture", func() {
+		statuses := map[module.KernelTarget]*kmmv1beta1.KernelVersionStatus{
+			{KernelVersion: kernelVersion, Architecture: "arm64"}: {
+				KernelVersion: kernelVersion,
+				Architecture:  "arm64",
+				Stage:         kmmv1beta1.KernelVersionStageModuleLoader,
+			},
+		}
+		dsMap := map[daemonset.Key]*appsv1.DaemonSet{
+			{KernelVersion: kernelVersion, Architecture: "amd64"}: {
+				Status: appsv1.DaemonSetStatus{DesiredNumberScheduled: 2, NumberAvailable: 2},
+			},
+			{KernelVersion: kernelVersion, Architecture: "arm64"}: {
+				Status: appsv1.DaemonSetStatus{DesiredNumberScheduled: 2, NumberAvailable: 0},
+			},
+		}
+
+		res := kernelVersionsStatus(nil, statuses, dsMap, "")
+
+		Expect(res).To(HaveLen(1))
+		Expect(res[0].Stage).To(Equal(kmmv1beta1.KernelVersionStageModuleLoader))
+	})
+})
+
+var _ = Describe("setModuleConditions", func() {
+	It("should set Ready when all kernel versions are loaded", func() {
+		mod := kmmv1beta1.Module{
+			Status: kmmv1beta1.ModuleStatus{
+				KernelVersions: []kmmv1beta1.KernelVersionStatus{
+					{KernelVersion: "a", Stage: kmmv1beta1.KernelVersionStageLoaded},
+				},
+			},
+		}
+
+		setModuleConditions(&mod)
+
+		Expect(meta.IsStatusConditionTrue(mod.Status.Conditions, kmmv1beta1.ModuleConditionReady)).To(BeTrue())
+		Expect(meta.IsStatusConditionFalse(mod.Status.Conditions, kmmv1beta1.ModuleConditionProgressing)).To(BeTrue())
+		Expect(meta.IsStatusConditionFalse(mod.Status.Conditions, kmmv1beta1.ModuleConditionDegraded)).To(BeTrue())
+		Expect(meta.IsStatusConditionFalse(mod.Status.Conditions, kmmv1beta1.ModuleConditionPaused)).To(BeTrue())
+		Expect(meta.IsStatusConditionTrue(mod.Status.Conditions, kmmv1beta1.ModuleConditionReferencesValid)).To(BeTrue())
+	})
+
+	It("should set Progressing when a kernel version is still being built", func() {
+		mod := kmmv1beta1.Module{
+			Status: kmmv1beta1.ModuleStatus{
+				KernelVersions: []kmmv1beta1.KernelVersionStatus{
+					{KernelVersion: "a", Stage: kmmv1beta1.KernelVersionStageLoaded},
+					{KernelVersion: "b", Stage: kmmv1beta1.KernelVersionStageBuild},
+				},
+			},
+		}
+
+		setModuleConditions(&mod)
+
+		Expect(meta.IsStatusConditionFalse(mod.Status.Conditions, kmmv1beta1.ModuleConditionReady)).To(BeTrue())
+		Expect(meta.IsStatusConditionTrue(mod.Status.Conditions, kmmv1beta1.ModuleConditionProgressing)).To(BeTrue())
+		Expect(meta.IsStatusConditionFalse(mod.Status.Conditions, kmmv1beta1.ModuleConditionDegraded)).To(BeTrue())
+
+		ready := meta.FindStatusCondition(mod.Status.Conditions, kmmv1beta1.ModuleConditionReady)
+		Expect(ready.Message).To(Equal("1 of 2 kernel versions loaded"))
+	})
+
+	It("should set Degraded when a kernel version failed", func() {
+		mod := kmmv1beta1.Module{
+			Status: kmmv1beta1.ModuleStatus{
+				KernelVersions: []kmmv1beta1.KernelVersionStatus{
+					{KernelVersion: "a", Stage: kmmv1beta1.KernelVersionStageBuild, LastError: "some error"},
+				},
+			},
+		}
+
+		setModuleConditions(&mod)
+
+		Expect(meta.IsStatusConditionFalse(mod.Status.Conditions, kmmv1beta1.ModuleConditionReady)).To(BeTrue())
+		Expect(meta.IsStatusConditionTrue(mod.Status.Conditions, kmmv1beta1.ModuleConditionDegraded)).To(BeTrue())
+	})
+
+	It("should set ReferencesValid to False when some references are invalid", func() {
+		mod := kmmv1beta1.Module{
+			Status: kmmv1beta1.ModuleStatus{
+				InvalidReferences: []kmmv1beta1.InvalidReference{
+					{Kind: "ConfigMap", Name: "dockerfile", Message: "not found"},
+					{Kind: "Secret", Name: "signing-key", Message: "key key is missing"},
+				},
+			},
+		}
+
+		setModuleConditions(&mod)
+
+		c := meta.FindStatusCondition(mod.Status.Conditions, kmmv1beta1.ModuleConditionReferencesValid)
+		Expect(c).NotTo(BeNil())
+		Expect(c.Status).To(Equal(metav1.ConditionFalse))
+		Expect(c.Message).To(Equal("ConfigMap dockerfile: not found; Secret signing-key: key key is missing"))
+	})
+})
+
+var _ = Describe("ModuleSetPaused", func() {
+	var (
+		ctrl *gomock.Controller
+		clnt *client.MockClient
+		su   ModuleStatusUpdater
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		clnt = client.NewMockClient(ctrl)
+		su = NewModuleStatusUpdater(clnt, nil)
+	})
+
+	It("should set the Paused condition", func() {
+		mod := &kmmv1beta1.Module{Spec: kmmv1beta1.ModuleSpec{Paused: true}}
+
+		statusWrite := client.NewMockStatusWriter(ctrl)
+		clnt.EXPECT().Status().Return(statusWrite)
+		statusWrite.EXPECT().Update(context.Background(), mod)
+
+		Expect(su.ModuleSetPaused(context.Background(), mod)).To(Succeed())
+		Expect(meta.IsStatusConditionTrue(mod.Status.Conditions, kmmv1beta1.ModuleConditionPaused)).To(BeTrue())
+	})
+
+	It("should not update the status if the Module is already marked as paused", func() {
+		mod := &kmmv1beta1.Module{
+			ObjectMeta: metav1.ObjectMeta{Generation: 2},
+			Spec:       kmmv1beta1.ModuleSpec{Paused: true},
+			Status: kmmv1beta1.ModuleStatus{
+				Conditions: []metav1.Condition{
+					{Type: kmmv1beta1.ModuleConditionPaused, Status: metav1.ConditionTrue, ObservedGeneration: 2},
+				},
+			},
+		}
+
+		Expect(su.ModuleSetPaused(context.Background(), mod)).To(Succeed())
+	})
+})
+
 var _ = Describe("preflight status updates", func() {
 	const (
 		name       = "preflight-name"
@@ -184,7 +417,7 @@ var _ = Describe("preflight status updates", func() {
 	})
 })
 
-func getDaemonSet(kernelNumber int, dsConfig daemonSetConfig) (string, *appsv1.DaemonSet) {
+func getDaemonSet(kernelNumber int, dsConfig daemonSetConfig) (daemonset.Key, *appsv1.DaemonSet) {
 	kernelVersion := fmt.Sprintf("kernel-version-%d", kernelNumber)
 	if dsConfig.isDevicePlugin {
 		kernelVersion = daemonset.GetDevicePluginKernelVersion()
@@ -195,14 +428,14 @@ func getDaemonSet(kernelNumber int, dsConfig daemonSetConfig) (string, *appsv1.D
 			DesiredNumberScheduled: int32(dsConfig.numberAvailable),
 		},
 	}
-	return kernelVersion, &ds
+	return daemonset.Key{KernelVersion: kernelVersion}, &ds
 }
 
-func prepareDsByKernel(dsConfigs []daemonSetConfig) map[string]*appsv1.DaemonSet {
-	dsMap := make(map[string]*appsv1.DaemonSet)
+func prepareDsByKernel(dsConfigs []daemonSetConfig) map[daemonset.Key]*appsv1.DaemonSet {
+	dsMap := make(map[daemonset.Key]*appsv1.DaemonSet)
 	for i, dsConfig := range dsConfigs {
-		kernelVersion, ds := getDaemonSet(i, dsConfig)
-		dsMap[kernelVersion] = ds
+		key, ds := getDaemonSet(i, dsConfig)
+		dsMap[key] = ds
 	}
 	return dsMap
 }
@@ -0,0 +1,117 @@
+package upgrade
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const mirrorPodAnnotation = "kubernetes.io/config.mirror"
+
+//go:generate mockgen -source=drain.go -package=upgrade -destination=mock_drain.go
+
+type NodeDrainer interface {
+	SetUnschedulable(ctx context.Context, node *v1.Node, unschedulable bool) error
+	Drain(ctx context.Context, nodeName string) (bool, error)
+}
+
+type nodeDrainer struct {
+	client client.Client
+	pods   corev1client.PodsGetter
+}
+
+// NewNodeDrainer returns a NodeDrainer that uses client to update nodes and pods to list and evict pods.
+// Pods are listed directly from the API server using a field selector on the node name, and evicted through the
+// eviction subresource.
+func NewNodeDrainer(client client.Client, pods corev1client.PodsGetter) NodeDrainer {
+	return &nodeDrainer{
+		client: client,
+		pods:   pods,
+	}
+}
+
+func (nd *nodeDrainer) SetUnschedulable(ctx context.Context, node *v1.Node, unschedulable bool) error {
+	if node.Spec.Unschedulable == unschedulable {
+		return nil
+	}
+
+	patchFrom := client.MergeFrom(node.DeepCopy())
+
+	node.Spec.Unschedulable = unschedulable
+
+	if err := nd.client.Patch(ctx, node, patchFrom); err != nil {
+		return fmt.Errorf("could not patch node %s: %v", node.Name, err)
+	}
+
+	return nil
+}
+
+// Drain requests the eviction of all evictable pods running on nodeName.
+// It returns true once none of those pods are left on the node.
+// Evictions that are refused because of a PodDisruptionBudget are retried on the next call.
+func (nd *nodeDrainer) Drain(ctx context.Context, nodeName string) (bool, error) {
+	logger := log.FromContext(ctx).WithValues("node", nodeName)
+
+	podList, err := nd.pods.Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("spec.nodeName", nodeName).String(),
+	})
+	if err != nil {
+		return false, fmt.Errorf("could not list pods on node %s: %v", nodeName, err)
+	}
+
+	drained := true
+
+	for _, pod := range podList.Items {
+		if !isEvictable(&pod) {
+			continue
+		}
+
+		drained = false
+
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+
+		logger.Info("Evicting pod", "pod", pod.Name, "namespace", pod.Namespace)
+
+		eviction := &policyv1.Eviction{
+			ObjectMeta: metav1.ObjectMeta{Name: pod.Name, Namespace: pod.Namespace},
+		}
+
+		err = nd.pods.Pods(pod.Namespace).EvictV1(ctx, eviction)
+		switch {
+		case err == nil || apierrors.IsNotFound(err):
+		case apierrors.IsTooManyRequests(err):
+			logger.Info("Eviction refused; will retry", "pod", pod.Name, "namespace", pod.Namespace, "reason", err.Error())
+		default:
+			return false, fmt.Errorf("could not evict pod %s/%s: %v", pod.Namespace, pod.Name, err)
+		}
+	}
+
+	return drained, nil
+}
+
+// isEvictable returns false for pods that a drain must not or does not need to remove.
+func isEvictable(pod *v1.Pod) bool {
+	if pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+		return false
+	}
+
+	if _, ok := pod.Annotations[mirrorPodAnnotation]; ok {
+		return false
+	}
+
+	if ref := metav1.GetControllerOf(pod); ref != nil && ref.Kind == "DaemonSet" {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,92 @@
+package upgrade
+
+import (
+	"context"
+	"errors"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	mockClient "github.com/kubernetes-sigs/kernel-module-management/internal/client"
+)
+
+var _ = Describe("SetUnschedulable", func() {
+	var (
+		ctrl *gomock.Controller
+		clnt *mockClient.MockClient
+		nd   NodeDrainer
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		clnt = mockClient.NewMockClient(ctrl)
+		nd = NewNodeDrainer(clnt, nil)
+	})
+
+	ctx := context.Background()
+
+	It("should do nothing if the node is already in the expected state", func() {
+		node := v1.Node{Spec: v1.NodeSpec{Unschedulable: true}}
+
+		Expect(nd.SetUnschedulable(ctx, &node, true)).To(Succeed())
+	})
+
+	It("should patch the node", func() {
+		node := v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node"}}
+
+		clnt.EXPECT().Patch(ctx, &node, gomock.Any()).Do(
+			func(_ context.Context, n *v1.Node, _ client.Patch, _ ...client.PatchOption) {
+				Expect(n.Spec.Unschedulable).To(BeTrue())
+			},
+		)
+
+		Expect(nd.SetUnschedulable(ctx, &node, true)).To(Succeed())
+	})
+
+	It("should return an error if the patch failed", func() {
+		node := v1.Node{Spec: v1.NodeSpec{Unschedulable: true}}
+
+		clnt.EXPECT().Patch(ctx, &node, gomock.Any()).Return(errors.New("some error"))
+
+		Expect(nd.SetUnschedulable(ctx, &node, false)).NotTo(Succeed())
+	})
+})
+
+var _ = Describe("isEvictable", func() {
+	isController := true
+
+	DescribeTable("should return the expected value",
+		func(pod v1.Pod, expected bool) {
+			Expect(isEvictable(&pod)).To(Equal(expected))
+		},
+		Entry("regular pod", v1.Pod{}, true),
+		Entry("completed pod", v1.Pod{Status: v1.PodStatus{Phase: v1.PodSucceeded}}, false),
+		Entry(
+			"mirror pod",
+			v1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{mirrorPodAnnotation: ""}}},
+			false,
+		),
+		Entry(
+			"DaemonSet pod",
+			v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					OwnerReferences: []metav1.OwnerReference{{Kind: "DaemonSet", Controller: &isController}},
+				},
+			},
+			false,
+		),
+		Entry(
+			"ReplicaSet pod",
+			v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet", Controller: &isController}},
+				},
+			},
+			true,
+		),
+	)
+})
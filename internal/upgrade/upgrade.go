@@ -0,0 +1,317 @@
+package upgrade
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/kubectl/pkg/util/podutils"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
+)
+
+//go:generate mockgen -source=upgrade.go -package=upgrade -destination=mock_upgrade.go
+
+type Manager interface {
+	Upgrade(ctx context.Context, mod *kmmv1beta1.Module, ds *appsv1.DaemonSet) (bool, error)
+	ReleaseNodes(ctx context.Context, moduleName string, activeDaemonSets sets.String) error
+}
+
+type manager struct {
+	client  client.Client
+	drainer NodeDrainer
+}
+
+func NewManager(client client.Client, drainer NodeDrainer) Manager {
+	return &manager{
+		client:  client,
+		drainer: drainer,
+	}
+}
+
+// Upgrade replaces the outdated pods of ds one node at a time, following mod's UpgradePolicy, which must not be nil.
+// Nodes being upgraded are labeled with the name of ds, so that an upgrade started by a previous reconciliation can
+// be resumed.
+// It returns true while the upgrade is in progress.
+func (m *manager) Upgrade(ctx context.Context, mod *kmmv1beta1.Module, ds *appsv1.DaemonSet) (bool, error) {
+	logger := log.FromContext(ctx).WithValues("daemonset", ds.Name)
+
+	policy := mod.Spec.ModuleLoader.UpgradePolicy
+	nodeLabel := getUpgradeNodeLabel(mod.Name)
+
+	podsByNode, err := m.getPodsByNode(ctx, ds)
+	if err != nil {
+		return false, err
+	}
+
+	upgradingNodes := v1.NodeList{}
+
+	if err = m.client.List(ctx, &upgradingNodes, client.MatchingLabels{nodeLabel: ds.Name}); err != nil {
+		return false, fmt.Errorf("could not list nodes being upgraded: %v", err)
+	}
+
+	maxUnavailable, err := intstr.GetScaledValueFromIntOrPercent(
+		intstr.ValueOrDefault(policy.MaxUnavailable, intstr.FromInt(1)),
+		len(podsByNode),
+		true,
+	)
+	if err != nil {
+		return false, fmt.Errorf("invalid maxUnavailable: %v", err)
+	}
+
+	if maxUnavailable < 1 {
+		maxUnavailable = 1
+	}
+
+	templateGeneration := ds.Annotations[appsv1.DeprecatedTemplateGeneration]
+
+	isOutdated := func(pod *v1.Pod) bool {
+		return pod.Labels[extensionsv1beta1.DaemonSetTemplateGenerationKey] != templateGeneration
+	}
+
+	errs := make([]error, 0)
+	upgrading := make(map[string]bool, len(upgradingNodes.Items))
+	inProgress := 0
+
+	for i := range upgradingNodes.Items {
+		node := &upgradingNodes.Items[i]
+		upgrading[node.Name] = true
+
+		done, err := m.upgradeNode(ctx, mod, ds, node, podsByNode[node.Name], isOutdated)
+		if err != nil {
+			errs = append(errs, err)
+		}
+
+		if !done {
+			inProgress++
+		}
+	}
+
+	unavailable := inProgress
+	outdated := make([]*v1.Pod, 0)
+
+	for nodeName, pod := range podsByNode {
+		if upgrading[nodeName] {
+			continue
+		}
+
+		if !podutils.IsPodReady(pod) {
+			unavailable++
+		}
+
+		if isOutdated(pod) {
+			outdated = append(outdated, pod)
+		}
+	}
+
+	// replace the pods that are already unavailable first, as doing so does not reduce availability
+	sort.Slice(outdated, func(i, j int) bool {
+		iReady := podutils.IsPodReady(outdated[i])
+		jReady := podutils.IsPodReady(outdated[j])
+
+		if iReady != jReady {
+			return !iReady
+		}
+
+		return outdated[i].Spec.NodeName < outdated[j].Spec.NodeName
+	})
+
+	for _, pod := range outdated {
+		if podutils.IsPodReady(pod) {
+			if unavailable >= maxUnavailable {
+				logger.Info("Maximum number of unavailable nodes reached; waiting", "maxUnavailable", maxUnavailable)
+				break
+			}
+
+			unavailable++
+		}
+
+		logger.Info("Starting upgrade", "node", pod.Spec.NodeName)
+
+		node := v1.Node{}
+
+		if err = m.client.Get(ctx, client.ObjectKey{Name: pod.Spec.NodeName}, &node); err != nil {
+			errs = append(errs, fmt.Errorf("could not get node %s: %v", pod.Spec.NodeName, err))
+			continue
+		}
+
+		if err = m.setNodeLabel(ctx, &node, nodeLabel, ds.Name); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if _, err = m.upgradeNode(ctx, mod, ds, &node, pod, isOutdated); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return inProgress > 0 || len(outdated) > 0, utilerrors.NewAggregate(errs)
+}
+
+// ReleaseNodes uncordons and unlabels the nodes being upgraded for moduleName by a DaemonSet that is not in
+// activeDaemonSets.
+// This is needed when the UpgradePolicy, the DaemonSet or the Module is removed while an upgrade is in progress.
+func (m *manager) ReleaseNodes(ctx context.Context, moduleName string, activeDaemonSets sets.String) error {
+	logger := log.FromContext(ctx)
+
+	nodeLabel := getUpgradeNodeLabel(moduleName)
+	nodes := v1.NodeList{}
+
+	if err := m.client.List(ctx, &nodes, client.HasLabels{nodeLabel}); err != nil {
+		return fmt.Errorf("could not list nodes being upgraded: %v", err)
+	}
+
+	errs := make([]error, 0)
+
+	for i := range nodes.Items {
+		node := &nodes.Items[i]
+
+		if activeDaemonSets.Has(node.Labels[nodeLabel]) {
+			continue
+		}
+
+		logger.Info("Upgrade not running anymore; releasing node", "node", node.Name)
+
+		if err := m.releaseNode(ctx, node, nodeLabel, true); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// upgradeNode moves the upgrade of node forward.
+// It returns true once the new pod is ready and the node has been released.
+func (m *manager) upgradeNode(
+	ctx context.Context,
+	mod *kmmv1beta1.Module,
+	ds *appsv1.DaemonSet,
+	node *v1.Node,
+	pod *v1.Pod,
+	isOutdated func(*v1.Pod) bool) (bool, error) {
+
+	logger := log.FromContext(ctx).WithValues("node", node.Name)
+
+	policy := mod.Spec.ModuleLoader.UpgradePolicy
+	cordon := policy.Cordon || policy.Drain
+
+	// the node is not targeted by this DaemonSet anymore
+	if !labels.SelectorFromSet(ds.Spec.Template.Spec.NodeSelector).Matches(labels.Set(node.Labels)) {
+		logger.Info("Node not targeted anymore; stopping its upgrade")
+		return true, m.releaseNode(ctx, node, getUpgradeNodeLabel(mod.Name), cordon)
+	}
+
+	if pod != nil && isOutdated(pod) {
+		if cordon {
+			if err := m.drainer.SetUnschedulable(ctx, node, true); err != nil {
+				return false, fmt.Errorf("could not cordon node %s: %v", node.Name, err)
+			}
+		}
+
+		if policy.Drain {
+			drained, err := m.drainer.Drain(ctx, node.Name)
+			if err != nil {
+				return false, fmt.Errorf("could not drain node %s: %v", node.Name, err)
+			}
+
+			if !drained {
+				logger.Info("Node is being drained")
+				return false, nil
+			}
+		}
+
+		if pod.DeletionTimestamp == nil {
+			logger.Info("Deleting outdated pod", "pod", pod.Name)
+
+			if err := m.client.Delete(ctx, pod); client.IgnoreNotFound(err) != nil {
+				return false, fmt.Errorf("could not delete pod %s: %v", pod.Name, err)
+			}
+		}
+
+		return false, nil
+	}
+
+	if pod == nil || !podutils.IsPodReady(pod) {
+		logger.Info("Waiting for the new pod to be ready")
+		return false, nil
+	}
+
+	logger.Info("Node upgraded")
+
+	return true, m.releaseNode(ctx, node, getUpgradeNodeLabel(mod.Name), cordon)
+}
+
+func (m *manager) releaseNode(ctx context.Context, node *v1.Node, nodeLabel string, uncordon bool) error {
+	if uncordon {
+		if err := m.drainer.SetUnschedulable(ctx, node, false); err != nil {
+			return fmt.Errorf("could not uncordon node %s: %v", node.Name, err)
+		}
+	}
+
+	return m.setNodeLabel(ctx, node, nodeLabel, "")
+}
+
+// setNodeLabel sets nodeLabel to value on node, or removes it if value is empty.
+func (m *manager) setNodeLabel(ctx context.Context, node *v1.Node, nodeLabel, value string) error {
+	patchFrom := client.MergeFrom(node.DeepCopy())
+
+	if value == "" {
+		delete(node.Labels, nodeLabel)
+	} else {
+		if node.Labels == nil {
+			node.Labels = make(map[string]string)
+		}
+
+		node.Labels[nodeLabel] = value
+	}
+
+	if err := m.client.Patch(ctx, node, patchFrom); err != nil {
+		return fmt.Errorf("could not patch node %s: %v", node.Name, err)
+	}
+
+	return nil
+}
+
+// getPodsByNode returns the pods of ds, keyed by node name.
+// If a node has several pods, which happens while an old pod is terminating, the one not being deleted is returned.
+func (m *manager) getPodsByNode(ctx context.Context, ds *appsv1.DaemonSet) (map[string]*v1.Pod, error) {
+	pods := v1.PodList{}
+
+	opts := []client.ListOption{
+		client.InNamespace(ds.Namespace),
+		client.MatchingLabels(ds.Spec.Selector.MatchLabels),
+	}
+
+	if err := m.client.List(ctx, &pods, opts...); err != nil {
+		return nil, fmt.Errorf("could not list pods for DaemonSet %s: %v", ds.Name, err)
+	}
+
+	podsByNode := make(map[string]*v1.Pod, len(pods.Items))
+
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+
+		if pod.Spec.NodeName == "" {
+			continue
+		}
+
+		if existing := podsByNode[pod.Spec.NodeName]; existing == nil || existing.DeletionTimestamp != nil {
+			podsByNode[pod.Spec.NodeName] = pod
+		}
+	}
+
+	return podsByNode, nil
+}
+
+func getUpgradeNodeLabel(moduleName string) string {
+	return fmt.Sprintf("kmm.node.kubernetes.io/%s.upgrading", moduleName)
+}
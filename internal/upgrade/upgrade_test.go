@@ -0,0 +1,286 @@
+package upgrade
+
+import (
+	"context"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
+	mockClient "github.com/kubernetes-sigs/kernel-module-management/internal/client"
+)
+
+const (
+	dsName      = "some-ds"
+	kernelLabel = "kernel-version.full"
+	moduleName  = "some-module"
+	namespace   = "some-namespace"
+)
+
+var _ = Describe("Upgrade", func() {
+	var (
+		ctrl    *gomock.Controller
+		clnt    *mockClient.MockClient
+		drainer *MockNodeDrainer
+		m       Manager
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		clnt = mockClient.NewMockClient(ctrl)
+		drainer = NewMockNodeDrainer(ctrl)
+		m = NewManager(clnt, drainer)
+	})
+
+	ctx := context.Background()
+	nodeLabel := "kmm.node.kubernetes.io/some-module.upgrading"
+
+	ds := appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        dsName,
+			Namespace:   namespace,
+			Annotations: map[string]string{appsv1.DeprecatedTemplateGeneration: "2"},
+		},
+		Spec: appsv1.DaemonSetSpec{
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"app": "module-loader"},
+			},
+			Template: v1.PodTemplateSpec{
+				Spec: v1.PodSpec{
+					NodeSelector: map[string]string{kernelLabel: "1.2.3"},
+				},
+			},
+		},
+	}
+
+	newModule := func(policy kmmv1beta1.UpgradePolicy) *kmmv1beta1.Module {
+		return &kmmv1beta1.Module{
+			ObjectMeta: metav1.ObjectMeta{Name: moduleName, Namespace: namespace},
+			Spec: kmmv1beta1.ModuleSpec{
+				ModuleLoader: kmmv1beta1.ModuleLoaderSpec{UpgradePolicy: &policy},
+			},
+		}
+	}
+
+	newPod := func(nodeName, generation string, ready bool) v1.Pod {
+		status := v1.ConditionFalse
+		if ready {
+			status = v1.ConditionTrue
+		}
+
+		return v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "pod-" + nodeName,
+				Namespace: namespace,
+				Labels:    map[string]string{extensionsv1beta1.DaemonSetTemplateGenerationKey: generation},
+			},
+			Spec: v1.PodSpec{NodeName: nodeName},
+			Status: v1.PodStatus{
+				Conditions: []v1.PodCondition{
+					{Type: v1.PodReady, Status: status},
+				},
+			},
+		}
+	}
+
+	newNode := func(name string, upgrading bool) v1.Node {
+		node := v1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   name,
+				Labels: map[string]string{kernelLabel: "1.2.3"},
+			},
+		}
+
+		if upgrading {
+			node.Labels[nodeLabel] = dsName
+		}
+
+		return node
+	}
+
+	expectLists := func(pods []v1.Pod, upgradingNodes []v1.Node) {
+		gomock.InOrder(
+			clnt.EXPECT().List(ctx, &v1.PodList{}, gomock.Any()).DoAndReturn(
+				func(_ context.Context, list *v1.PodList, _ ...client.ListOption) error {
+					list.Items = pods
+					return nil
+				},
+			),
+			clnt.EXPECT().List(ctx, &v1.NodeList{}, client.MatchingLabels{nodeLabel: dsName}).DoAndReturn(
+				func(_ context.Context, list *v1.NodeList, _ ...client.ListOption) error {
+					list.Items = upgradingNodes
+					return nil
+				},
+			),
+		)
+	}
+
+	expectGetNode := func(name string) {
+		clnt.EXPECT().Get(ctx, client.ObjectKey{Name: name}, &v1.Node{}).DoAndReturn(
+			func(_ context.Context, _ client.ObjectKey, node *v1.Node, _ ...client.GetOption) error {
+				*node = newNode(name, false)
+				return nil
+			},
+		)
+	}
+
+	It("should do nothing if all pods are up to date", func() {
+		expectLists([]v1.Pod{newPod("node-a", "2", true), newPod("node-b", "2", true)}, nil)
+
+		upgrading, err := m.Upgrade(ctx, newModule(kmmv1beta1.UpgradePolicy{}), &ds)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(upgrading).To(BeFalse())
+	})
+
+	It("should only upgrade one node at a time by default", func() {
+		podA := newPod("node-a", "1", true)
+
+		expectLists([]v1.Pod{newPod("node-c", "1", true), podA, newPod("node-b", "1", true)}, nil)
+		expectGetNode("node-a")
+
+		gomock.InOrder(
+			clnt.EXPECT().Patch(ctx, gomock.Any(), gomock.Any()).Do(
+				func(_ context.Context, node *v1.Node, _ client.Patch, _ ...client.PatchOption) {
+					Expect(node.Labels).To(HaveKeyWithValue(nodeLabel, dsName))
+				},
+			),
+			drainer.EXPECT().SetUnschedulable(ctx, gomock.Any(), true),
+			clnt.EXPECT().Delete(ctx, &podA),
+		)
+
+		upgrading, err := m.Upgrade(ctx, newModule(kmmv1beta1.UpgradePolicy{Cordon: true}), &ds)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(upgrading).To(BeTrue())
+	})
+
+	It("should upgrade several nodes if maxUnavailable allows it", func() {
+		maxUnavailable := intstr.FromString("50%")
+
+		podA := newPod("node-a", "1", true)
+		podB := newPod("node-b", "1", true)
+
+		expectLists([]v1.Pod{podA, podB, newPod("node-c", "1", true), newPod("node-d", "1", true)}, nil)
+		expectGetNode("node-a")
+		expectGetNode("node-b")
+		clnt.EXPECT().Patch(ctx, gomock.Any(), gomock.Any()).Times(2)
+		clnt.EXPECT().Delete(ctx, &podA)
+		clnt.EXPECT().Delete(ctx, &podB)
+
+		upgrading, err := m.Upgrade(ctx, newModule(kmmv1beta1.UpgradePolicy{MaxUnavailable: &maxUnavailable}), &ds)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(upgrading).To(BeTrue())
+	})
+
+	It("should replace unavailable pods first", func() {
+		podB := newPod("node-b", "1", false)
+
+		expectLists([]v1.Pod{newPod("node-a", "1", true), podB}, nil)
+		expectGetNode("node-b")
+		clnt.EXPECT().Patch(ctx, gomock.Any(), gomock.Any())
+		clnt.EXPECT().Delete(ctx, &podB)
+
+		upgrading, err := m.Upgrade(ctx, newModule(kmmv1beta1.UpgradePolicy{}), &ds)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(upgrading).To(BeTrue())
+	})
+
+	It("should wait for the node to be drained before deleting the pod", func() {
+		expectLists([]v1.Pod{newPod("node-a", "1", true)}, []v1.Node{newNode("node-a", true)})
+
+		gomock.InOrder(
+			drainer.EXPECT().SetUnschedulable(ctx, gomock.Any(), true),
+			drainer.EXPECT().Drain(ctx, "node-a").Return(false, nil),
+		)
+
+		upgrading, err := m.Upgrade(ctx, newModule(kmmv1beta1.UpgradePolicy{Drain: true}), &ds)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(upgrading).To(BeTrue())
+	})
+
+	It("should not start upgrading other nodes while one is in progress", func() {
+		expectLists(
+			[]v1.Pod{newPod("node-a", "2", false), newPod("node-b", "1", true)},
+			[]v1.Node{newNode("node-a", true)},
+		)
+
+		upgrading, err := m.Upgrade(ctx, newModule(kmmv1beta1.UpgradePolicy{}), &ds)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(upgrading).To(BeTrue())
+	})
+
+	It("should release the node once the new pod is ready", func() {
+		expectLists([]v1.Pod{newPod("node-a", "2", true)}, []v1.Node{newNode("node-a", true)})
+
+		gomock.InOrder(
+			drainer.EXPECT().SetUnschedulable(ctx, gomock.Any(), false),
+			clnt.EXPECT().Patch(ctx, gomock.Any(), gomock.Any()).Do(
+				func(_ context.Context, node *v1.Node, _ client.Patch, _ ...client.PatchOption) {
+					Expect(node.Labels).NotTo(HaveKey(nodeLabel))
+				},
+			),
+		)
+
+		upgrading, err := m.Upgrade(ctx, newModule(kmmv1beta1.UpgradePolicy{Cordon: true}), &ds)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(upgrading).To(BeFalse())
+	})
+
+	It("should release a node that is not targeted by the DaemonSet anymore", func() {
+		node := newNode("node-a", true)
+		node.Labels[kernelLabel] = "4.5.6"
+
+		expectLists(nil, []v1.Node{node})
+
+		gomock.InOrder(
+			drainer.EXPECT().SetUnschedulable(ctx, gomock.Any(), false),
+			clnt.EXPECT().Patch(ctx, gomock.Any(), gomock.Any()),
+		)
+
+		upgrading, err := m.Upgrade(ctx, newModule(kmmv1beta1.UpgradePolicy{Drain: true}), &ds)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(upgrading).To(BeFalse())
+	})
+
+	It("should release the nodes of DaemonSets that are not active anymore", func() {
+		nodeA := newNode("node-a", true)
+		nodeA.Spec.Unschedulable = true
+
+		nodeB := newNode("node-b", true)
+		nodeB.Labels[nodeLabel] = "other-ds"
+
+		clnt.EXPECT().List(ctx, &v1.NodeList{}, client.HasLabels{nodeLabel}).DoAndReturn(
+			func(_ context.Context, list *v1.NodeList, _ ...client.ListOption) error {
+				list.Items = []v1.Node{nodeA, nodeB}
+				return nil
+			},
+		)
+
+		gomock.InOrder(
+			drainer.EXPECT().SetUnschedulable(ctx, gomock.Any(), false).Do(
+				func(_ context.Context, node *v1.Node, _ bool) {
+					Expect(node.Name).To(Equal("node-a"))
+				},
+			),
+			clnt.EXPECT().Patch(ctx, gomock.Any(), gomock.Any()).Do(
+				func(_ context.Context, node *v1.Node, _ client.Patch, _ ...client.PatchOption) {
+					Expect(node.Name).To(Equal("node-a"))
+					Expect(node.Labels).NotTo(HaveKey(nodeLabel))
+				},
+			),
+		)
+
+		Expect(
+			m.ReleaseNodes(ctx, moduleName, sets.NewString("other-ds")),
+		).To(
+			Succeed(),
+		)
+	})
+})
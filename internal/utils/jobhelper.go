@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -29,14 +30,23 @@ var ErrNoMatchingJob = errors.New("no matching job")
 type Result struct {
 	Requeue bool
 	Status  Status
+
+	// Reason explains why the job failed when Status is StatusFailed.
+	Reason string
+
+	// LogsConfigMap is the name of the ConfigMap holding the logs of the failed containers of the job, if any.
+	LogsConfigMap string
+
+	// FailedJobHash identifies the inputs of the job when Status is StatusFailed.
+	FailedJobHash string
 }
 
 //go:generate mockgen -source=jobhelper.go -package=utils -destination=mock_jobhelper.go
 
 type JobHelper interface {
 	IsJobChanged(existingJob *batchv1.Job, newJob *batchv1.Job) (bool, error)
-	JobLabels(modName string, targetKernel string, jobType string) map[string]string
-	GetModuleJobByKernel(ctx context.Context, modName, namespace, targetKernel, jobType string, owner metav1.Object) (*batchv1.Job, error)
+	JobLabels(modName, targetKernel, targetArch, jobType string) map[string]string
+	GetModuleJobByKernel(ctx context.Context, modName, namespace, targetKernel, targetArch, jobType string, owner metav1.Object) (*batchv1.Job, error)
 	GetModuleJobs(ctx context.Context, modName, namespace, jobType string, owner metav1.Object) ([]batchv1.Job, error)
 	DeleteJob(ctx context.Context, job *batchv1.Job) error
 	CreateJob(ctx context.Context, jobTemplate *batchv1.Job) error
@@ -65,12 +75,15 @@ func (jh *jobHelper) IsJobChanged(existingJob *batchv1.Job, newJob *batchv1.Job)
 	return true, nil
 }
 
-func (jh *jobHelper) JobLabels(modName string, targetKernel string, jobType string) map[string]string {
-	return moduleKernelLabels(modName, targetKernel, jobType)
+// JobLabels returns the labels of the job of jobType for the targetKernel and targetArch of a module.
+// Kernels sharing a version across architectures get one job per architecture; targetArch may be empty when the
+// architecture is unknown.
+func (jh *jobHelper) JobLabels(modName, targetKernel, targetArch, jobType string) map[string]string {
+	return moduleKernelLabels(modName, targetKernel, targetArch, jobType)
 }
 
-func (jh *jobHelper) GetModuleJobByKernel(ctx context.Context, modName, namespace, targetKernel, jobType string, owner metav1.Object) (*batchv1.Job, error) {
-	matchLabels := moduleKernelLabels(modName, targetKernel, jobType)
+func (jh *jobHelper) GetModuleJobByKernel(ctx context.Context, modName, namespace, targetKernel, targetArch, jobType string, owner metav1.Object) (*batchv1.Job, error) {
+	matchLabels := moduleKernelLabels(modName, targetKernel, targetArch, jobType)
 	jobs, err := jh.getJobs(ctx, namespace, matchLabels)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get module %s, jobs by kernel %s: %v", modName, targetKernel, err)
@@ -120,17 +133,32 @@ func (jh *jobHelper) CreateJob(ctx context.Context, jobTemplate *batchv1.Job) er
 // whether there was an error or not
 func (jh *jobHelper) GetJobStatus(job *batchv1.Job) (Status, bool, error) {
 	switch {
+	case JobFailedCondition(job) != nil:
+		return StatusFailed, false, nil
 	case job.Status.Succeeded == 1:
 		return StatusCompleted, false, nil
 	case job.Status.Active == 1:
 		return StatusInProgress, true, nil
-	case job.Status.Failed == 1:
-		return StatusFailed, false, fmt.Errorf("job failed")
+	case job.Status.Failed > 0:
+		// the Job controller retries failed pods until the backoff limit or the deadline is reached
+		return StatusInProgress, true, nil
 	default:
 		return StatusFailed, false, fmt.Errorf("unknown status: %v", job.Status)
 	}
 }
 
+// JobFailedCondition returns the Failed condition of job if the latter has exhausted its retries or its deadline,
+// and nil otherwise.
+func JobFailedCondition(job *batchv1.Job) *batchv1.JobCondition {
+	for i, c := range job.Status.Conditions {
+		if c.Type == batchv1.JobFailed && c.Status == v1.ConditionTrue {
+			return &job.Status.Conditions[i]
+		}
+	}
+
+	return nil
+}
+
 func (jh *jobHelper) getJobs(ctx context.Context, namespace string, labels map[string]string) ([]batchv1.Job, error) {
 	jobList := batchv1.JobList{}
 	opts := []client.ListOption{
@@ -144,9 +172,44 @@ func (jh *jobHelper) getJobs(ctx context.Context, namespace string, labels map[s
 	return jobList.Items, nil
 }
 
-func moduleKernelLabels(moduleName, targetKernel, jobType string) map[string]string {
+// SecretVersion identifies the contents of secret without revealing them, so that it can be hashed into values
+// published on images.
+func SecretVersion(secret *v1.Secret) string {
+	return string(secret.UID) + "/" + secret.ResourceVersion
+}
+
+// JobInputsHash identifies the inputs of job: the hash of its image and, for builds, that of its pod policy.
+func JobInputsHash(job *batchv1.Job) string {
+	h := job.Annotations[constants.JobHashAnnotation]
+	if p := job.Annotations[constants.PodPolicyHashAnnotation]; p != "" {
+		h += "-" + p
+	}
+
+	return h
+}
+
+// JobNodeSelector returns a copy of selector that also restricts the job to the nodes of targetArch, so that the image
+// is built or signed for that architecture. targetArch may be empty when the architecture is unknown.
+func JobNodeSelector(selector map[string]string, targetArch string) map[string]string {
+	if targetArch == "" {
+		return selector
+	}
+
+	nodeSelector := make(map[string]string, len(selector)+1)
+	for k, v := range selector {
+		nodeSelector[k] = v
+	}
+	nodeSelector[v1.LabelArchStable] = targetArch
+
+	return nodeSelector
+}
+
+func moduleKernelLabels(moduleName, targetKernel, targetArch, jobType string) map[string]string {
 	labels := moduleLabels(moduleName, jobType)
 	labels[constants.TargetKernelTarget] = targetKernel
+	if targetArch != "" {
+		labels[constants.ArchitectureLabel] = targetArch
+	}
 	return labels
 }
 
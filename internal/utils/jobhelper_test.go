@@ -9,6 +9,7 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
@@ -34,12 +35,38 @@ var _ = Describe("JobLabels", func() {
 			ObjectMeta: metav1.ObjectMeta{Name: "moduleName"},
 		}
 		mgr := NewJobHelper(clnt)
-		labels := mgr.JobLabels(mod.Name, "targetKernel", "jobType")
+		labels := mgr.JobLabels(mod.Name, "targetKernel", "targetArch", "jobType")
 
 		Expect(labels).To(HaveKeyWithValue(constants.ModuleNameLabel, "moduleName"))
 		Expect(labels).To(HaveKeyWithValue(constants.TargetKernelTarget, "targetKernel"))
+		Expect(labels).To(HaveKeyWithValue(constants.ArchitectureLabel, "targetArch"))
 		Expect(labels).To(HaveKeyWithValue(constants.JobType, "jobType"))
 	})
+
+	It("should not set the architecture label if the architecture is unknown", func() {
+		labels := NewJobHelper(clnt).JobLabels("moduleName", "targetKernel", "", "jobType")
+
+		Expect(labels).NotTo(HaveKey(constants.ArchitectureLabel))
+	})
+})
+
+var _ = Describe("JobNodeSelector", func() {
+	It("should return the selector as is if the architecture is unknown", func() {
+		selector := map[string]string{"key": "value"}
+
+		Expect(JobNodeSelector(selector, "")).To(Equal(selector))
+	})
+
+	It("should add the architecture to a copy of the selector", func() {
+		selector := map[string]string{"key": "value"}
+
+		Expect(
+			JobNodeSelector(selector, "arm64"),
+		).To(
+			Equal(map[string]string{"key": "value", v1.LabelArchStable: "arm64"}),
+		)
+		Expect(selector).To(HaveLen(1))
+	})
 })
 
 var _ = Describe("GetModuleJobByKernel", func() {
@@ -86,7 +113,7 @@ var _ = Describe("GetModuleJobByKernel", func() {
 			},
 		)
 
-		job, err := jh.GetModuleJobByKernel(ctx, mod.Name, mod.Namespace, "targetKernel", "jobType", &mod)
+		job, err := jh.GetModuleJobByKernel(ctx, mod.Name, mod.Namespace, "targetKernel", "", "jobType", &mod)
 
 		Expect(job).To(Equal(&j))
 		Expect(err).NotTo(HaveOccurred())
@@ -112,7 +139,7 @@ var _ = Describe("GetModuleJobByKernel", func() {
 
 		clnt.EXPECT().List(ctx, &jobList, opts).Return(errors.New("random error"))
 
-		_, err := jh.GetModuleJobByKernel(ctx, mod.Name, mod.Namespace, "targetKernel", "jobType", &mod)
+		_, err := jh.GetModuleJobByKernel(ctx, mod.Name, mod.Namespace, "targetKernel", "", "jobType", &mod)
 
 		Expect(err).To(HaveOccurred())
 	})
@@ -154,7 +181,7 @@ var _ = Describe("GetModuleJobByKernel", func() {
 			},
 		)
 
-		_, err = jh.GetModuleJobByKernel(ctx, mod.Name, mod.Namespace, "targetKernel", "jobType", &mod)
+		_, err = jh.GetModuleJobByKernel(ctx, mod.Name, mod.Namespace, "targetKernel", "", "jobType", &mod)
 
 		Expect(err).To(HaveOccurred())
 	})
@@ -201,7 +228,7 @@ var _ = Describe("GetModuleJobByKernel", func() {
 			},
 		)
 
-		job, err := jh.GetModuleJobByKernel(ctx, mod.Name, mod.Namespace, "targetKernel", "jobType", &mod)
+		job, err := jh.GetModuleJobByKernel(ctx, mod.Name, mod.Namespace, "targetKernel", "", "jobType", &mod)
 
 		Expect(err).NotTo(HaveOccurred())
 		Expect(job).To(Equal(&j1))
@@ -426,8 +453,20 @@ var _ = Describe("JobStatus", func() {
 		},
 		Entry("succeeded", &batchv1.Job{Status: batchv1.JobStatus{Succeeded: 1}}, StatusCompleted, false, false),
 		Entry("in progress", &batchv1.Job{Status: batchv1.JobStatus{Active: 1}}, StatusInProgress, true, false),
-		Entry("Failed", &batchv1.Job{Status: batchv1.JobStatus{Failed: 1}}, StatusFailed, false, true),
-		Entry("unknown", &batchv1.Job{Status: batchv1.JobStatus{Failed: 2}}, StatusFailed, false, true),
+		Entry("failed pod being retried", &batchv1.Job{Status: batchv1.JobStatus{Failed: 1}}, StatusInProgress, true, false),
+		Entry(
+			"backoff limit exceeded",
+			&batchv1.Job{
+				Status: batchv1.JobStatus{
+					Failed:     1,
+					Conditions: []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: v1.ConditionTrue}},
+				},
+			},
+			StatusFailed,
+			false,
+			false,
+		),
+		Entry("unknown", &batchv1.Job{}, StatusFailed, false, true),
 	)
 })
 
@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"sync"
+	"time"
+)
+
+// SyncCache remembers the images that were found up to date with their build or signing inputs, so that those
+// inputs are not resolved again on every reconciliation.
+// An entry is only valid for the version of the inputs it was recorded for, and for a limited time so that changes
+// in sources outside the cluster, such as a git branch, are eventually detected.
+type SyncCache struct {
+	mu      sync.Mutex
+	entries map[string]syncCacheEntry
+	ttl     time.Duration
+	now     func() time.Time
+}
+
+type syncCacheEntry struct {
+	version string
+	expires time.Time
+}
+
+func NewSyncCache(ttl time.Duration) *SyncCache {
+	return &SyncCache{
+		entries: make(map[string]syncCacheEntry),
+		ttl:     ttl,
+		now:     time.Now,
+	}
+}
+
+// IsUpToDate returns true if key was recorded as up to date for version and the entry has not expired.
+func (c *SyncCache) IsUpToDate(key, version string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		return false
+	}
+
+	if e.version != version || c.now().After(e.expires) {
+		delete(c.entries, key)
+		return false
+	}
+
+	return true
+}
+
+// SetUpToDate records key as up to date for version.
+func (c *SyncCache) SetUpToDate(key, version string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[key] = syncCacheEntry{version: version, expires: c.now().Add(c.ttl)}
+}
@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SyncCache", func() {
+	var (
+		c   *SyncCache
+		now time.Time
+	)
+
+	BeforeEach(func() {
+		now = time.Now()
+		c = NewSyncCache(time.Minute)
+		c.now = func() time.Time { return now }
+	})
+
+	It("should not know a key that was never recorded", func() {
+		Expect(c.IsUpToDate("key", "v1")).To(BeFalse())
+	})
+
+	It("should only be up to date for the recorded version", func() {
+		c.SetUpToDate("key", "v1")
+
+		Expect(c.IsUpToDate("key", "v1")).To(BeTrue())
+		Expect(c.IsUpToDate("other-key", "v1")).To(BeFalse())
+		Expect(c.IsUpToDate("key", "v2")).To(BeFalse())
+		Expect(c.IsUpToDate("key", "v1")).To(BeFalse())
+	})
+
+	It("should expire entries", func() {
+		c.SetUpToDate("key", "v1")
+
+		now = now.Add(2 * time.Minute)
+
+		Expect(c.IsUpToDate("key", "v1")).To(BeFalse())
+	})
+})
@@ -0,0 +1,524 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/build"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/daemonset"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/module"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/sign"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const DefaultModprobeDirName = "/opt"
+
+var sha256Regexp = regexp.MustCompile("^[0-9a-f]{64}$")
+
+// ModuleWebhook validates Module resources and applies defaults to them before they are persisted.
+type ModuleWebhook struct {
+	client      client.Client
+	buildHelper build.Helper
+	signHelper  sign.Helper
+}
+
+var (
+	_ admission.CustomDefaulter = &ModuleWebhook{}
+	_ admission.CustomValidator = &ModuleWebhook{}
+)
+
+func NewModuleWebhook(client client.Client, buildHelper build.Helper, signHelper sign.Helper) *ModuleWebhook {
+	return &ModuleWebhook{
+		client:      client,
+		buildHelper: buildHelper,
+		signHelper:  signHelper,
+	}
+}
+
+func (w *ModuleWebhook) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&kmmv1beta1.Module{}).
+		WithDefaulter(w).
+		WithValidator(w).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-kmm-sigs-x-k8s-io-v1beta1-module,mutating=true,failurePolicy=fail,sideEffects=None,groups=kmm.sigs.x-k8s.io,resources=modules,verbs=create;update,versions=v1beta1,name=mmodule.kb.io,admissionReviewVersions=v1
+
+// Default implements admission.CustomDefaulter.
+func (w *ModuleWebhook) Default(ctx context.Context, obj runtime.Object) error {
+	mod, ok := obj.(*kmmv1beta1.Module)
+	if !ok {
+		return fmt.Errorf("expected a Module but got a %T", obj)
+	}
+
+	log.FromContext(ctx).V(1).Info("Defaulting Module", "name", mod.Name, "namespace", mod.Namespace)
+
+	if mod.Spec.ModuleLoader.Container.Modprobe.DirName == "" {
+		mod.Spec.ModuleLoader.Container.Modprobe.DirName = DefaultModprobeDirName
+	}
+
+	return nil
+}
+
+//+kubebuilder:webhook:path=/validate-kmm-sigs-x-k8s-io-v1beta1-module,mutating=false,failurePolicy=fail,sideEffects=None,groups=kmm.sigs.x-k8s.io,resources=modules,verbs=create;update,versions=v1beta1,name=vmodule.kb.io,admissionReviewVersions=v1
+
+// ValidateCreate implements admission.CustomValidator.
+func (w *ModuleWebhook) ValidateCreate(ctx context.Context, obj runtime.Object) error {
+	mod, ok := obj.(*kmmv1beta1.Module)
+	if !ok {
+		return fmt.Errorf("expected a Module but got a %T", obj)
+	}
+
+	return w.validateModule(ctx, mod)
+}
+
+// ValidateUpdate implements admission.CustomValidator.
+func (w *ModuleWebhook) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) error {
+	mod, ok := newObj.(*kmmv1beta1.Module)
+	if !ok {
+		return fmt.Errorf("expected a Module but got a %T", newObj)
+	}
+
+	return w.validateModule(ctx, mod)
+}
+
+// ValidateDelete implements admission.CustomValidator.
+// Deleting a Module is always allowed.
+func (w *ModuleWebhook) ValidateDelete(ctx context.Context, obj runtime.Object) error {
+	return nil
+}
+
+func (w *ModuleWebhook) validateModule(ctx context.Context, mod *kmmv1beta1.Module) error {
+	containerPath := field.NewPath("spec", "moduleLoader", "container")
+
+	errs := w.validateKernelMappings(mod.Spec, containerPath.Child("kernelMappings"))
+
+	if r := mod.Spec.ModuleLoader.Container.KernelMappingResolver; r != nil {
+		errs = append(errs, validateKernelMappingResolver(*r, containerPath.Child("kernelMappingResolver"))...)
+	} else if len(mod.Spec.ModuleLoader.Container.KernelMappings) == 0 {
+		errs = append(errs, field.Required(containerPath.Child("kernelMappings"), "kernelMappings are required without a kernelMappingResolver"))
+	}
+
+	if b := mod.Spec.ModuleLoader.Container.Build; b != nil {
+		errs = append(errs, validateBuild(b, containerPath.Child("build"))...)
+	}
+
+	errs = append(errs, validateModprobe(mod.Spec.ModuleLoader.Container.Modprobe, field.NewPath("spec", "moduleLoader", "container", "modprobe"))...)
+
+	errs = append(errs, validateUpgradePolicy(mod.Spec.ModuleLoader.UpgradePolicy, field.NewPath("spec", "moduleLoader", "upgradePolicy"))...)
+
+	// the version is used as a node label value
+	if v := mod.Spec.ModuleLoader.Container.Version; v != "" {
+		versionPath := field.NewPath("spec", "moduleLoader", "container", "version")
+
+		for _, msg := range validation.IsValidLabelValue(v) {
+			errs = append(errs, field.Invalid(versionPath, v, msg))
+		}
+
+		// the namespace and name are part of the version node label keys
+		for _, label := range []string{
+			daemonset.GetModuleVersionNodeLabel(mod.Namespace, mod.Name),
+			daemonset.GetModuleLoaderVersionNodeLabel(mod.Namespace, mod.Name),
+		} {
+			for _, msg := range validation.IsQualifiedName(label) {
+				errs = append(
+					errs,
+					field.Invalid(versionPath, v, fmt.Sprintf("namespace and name too long for node label %s: %s", label, msg)),
+				)
+			}
+		}
+	}
+
+	if ls := mod.Spec.LabelSelector; ls != nil {
+		errs = append(
+			errs,
+			metav1validation.ValidateLabelSelector(ls, field.NewPath("spec", "labelSelector"))...,
+		)
+	}
+
+	// a Module without any selector would be loaded on all nodes
+	if len(mod.Spec.Selector) == 0 && (mod.Spec.LabelSelector == nil || len(mod.Spec.LabelSelector.MatchLabels)+len(mod.Spec.LabelSelector.MatchExpressions) == 0) {
+		errs = append(errs, field.Required(field.NewPath("spec", "selector"), "selector or labelSelector is required"))
+	}
+
+	depErrs, err := w.validateDependencies(ctx, mod)
+	if err != nil {
+		return err
+	}
+
+	errs = append(errs, depErrs...)
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(kmmv1beta1.GroupVersion.WithKind("Module").GroupKind(), mod.Name, errs)
+}
+
+// validateDependencies rejects the dependencies of mod that lead back to it through the other Modules of its
+// namespace, as none of the Modules in the cycle could ever be loaded.
+func (w *ModuleWebhook) validateDependencies(ctx context.Context, mod *kmmv1beta1.Module) (field.ErrorList, error) {
+	depsPath := field.NewPath("spec", "dependencies")
+
+	errs := field.ErrorList{}
+
+	for i, dep := range mod.Spec.Dependencies {
+		if dep == mod.Name {
+			errs = append(errs, field.Invalid(depsPath.Index(i), dep, "a Module cannot depend on itself"))
+		}
+	}
+
+	if len(errs) > 0 || len(mod.Spec.Dependencies) == 0 {
+		return errs, nil
+	}
+
+	mods := kmmv1beta1.ModuleList{}
+
+	if err := w.client.List(ctx, &mods, client.InNamespace(mod.Namespace)); err != nil {
+		return nil, fmt.Errorf("could not list the Modules in namespace %s: %v", mod.Namespace, err)
+	}
+
+	deps := make(map[string][]string, len(mods.Items)+1)
+
+	for _, m := range mods.Items {
+		deps[m.Name] = m.Spec.Dependencies
+	}
+
+	deps[mod.Name] = mod.Spec.Dependencies
+
+	for i, dep := range mod.Spec.Dependencies {
+		if path := dependencyPath(deps, dep, mod.Name, make(map[string]bool)); path != nil {
+			cycle := strings.Join(append([]string{mod.Name}, path...), " -> ")
+			errs = append(errs, field.Invalid(depsPath.Index(i), dep, "dependency cycle: "+cycle))
+		}
+	}
+
+	return errs, nil
+}
+
+// dependencyPath returns the Modules from one to the other, both included, if from depends on to directly or
+// through other Modules. It returns nil otherwise.
+func dependencyPath(deps map[string][]string, from, to string, visited map[string]bool) []string {
+	if from == to {
+		return []string{to}
+	}
+
+	if visited[from] {
+		return nil
+	}
+
+	visited[from] = true
+
+	for _, dep := range deps[from] {
+		if path := dependencyPath(deps, dep, to, visited); path != nil {
+			return append([]string{from}, path...)
+		}
+	}
+
+	return nil
+}
+
+func (w *ModuleWebhook) validateKernelMappings(modSpec kmmv1beta1.ModuleSpec, fldPath *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+
+	// the nodes of an architecture and OS image can have their own fallback
+	fallbacks := make(map[string]bool)
+
+	for i, km := range modSpec.ModuleLoader.Container.KernelMappings {
+		kmPath := fldPath.Index(i)
+
+		matchers := 0
+		for _, set := range []bool{km.Literal != "", km.Regexp != "", km.VersionRange != nil, km.Fallback} {
+			if set {
+				matchers++
+			}
+		}
+
+		switch {
+		case matchers > 1:
+			errs = append(errs, field.Invalid(kmPath, km.Literal, "only one of literal, regexp, versionRange or fallback can be set"))
+		case matchers == 0:
+			errs = append(errs, field.Required(kmPath, "one of literal, regexp, versionRange or fallback must be set"))
+		case km.Regexp != "":
+			if _, err := regexp.Compile(km.Regexp); err != nil {
+				errs = append(errs, field.Invalid(kmPath.Child("regexp"), km.Regexp, fmt.Sprintf("invalid regexp: %v", err)))
+			}
+		case km.VersionRange != nil:
+			errs = append(errs, validateVersionRange(*km.VersionRange, kmPath.Child("versionRange"))...)
+		case km.Fallback:
+			key := module.NormalizeArchitecture(km.Architecture) + "/" + km.OSImage
+			if fallbacks[key] {
+				errs = append(
+					errs,
+					field.Invalid(kmPath.Child("fallback"), km.Fallback, "only one mapping can be a fallback for the same architecture and OS image"),
+				)
+			}
+			fallbacks[key] = true
+		}
+
+		if km.OSImage != "" {
+			if _, err := regexp.Compile(km.OSImage); err != nil {
+				errs = append(errs, field.Invalid(kmPath.Child("osImage"), km.OSImage, fmt.Sprintf("invalid regexp: %v", err)))
+			}
+		}
+
+		if km.Build != nil {
+			errs = append(errs, validateBuild(km.Build, kmPath.Child("build"))...)
+		}
+
+		errs = append(errs, w.validateSign(modSpec, km, kmPath)...)
+	}
+
+	return errs
+}
+
+func validateKernelMappingResolver(r kmmv1beta1.KernelMappingResolver, fldPath *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+
+	switch {
+	case r.ConfigMap != nil && r.HTTP != nil:
+		errs = append(errs, field.Invalid(fldPath, r, "only one of configMap or http can be set"))
+	case r.ConfigMap != nil:
+		if r.ConfigMap.Name == "" {
+			errs = append(errs, field.Required(fldPath.Child("configMap", "name"), "the name of the ConfigMap is required"))
+		}
+	case r.HTTP != nil:
+		errs = append(errs, validateHTTPURL(r.HTTP.URL, fldPath.Child("http", "url"))...)
+
+		if ttl := r.HTTP.CacheTTL; ttl != nil && ttl.Duration < 0 {
+			errs = append(errs, field.Invalid(fldPath.Child("http", "cacheTTL"), ttl.Duration.String(), "must not be negative"))
+		}
+	default:
+		errs = append(errs, field.Required(fldPath, "one of configMap or http must be set"))
+	}
+
+	return errs
+}
+
+func validateHTTPURL(s string, fldPath *field.Path) field.ErrorList {
+	u, err := url.Parse(s)
+	if err != nil {
+		return field.ErrorList{field.Invalid(fldPath, s, fmt.Sprintf("invalid URL: %v", err))}
+	}
+
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return field.ErrorList{field.Invalid(fldPath, s, "must be an absolute http or https URL")}
+	}
+
+	return nil
+}
+
+func validateBuild(b *kmmv1beta1.Build, fldPath *field.Path) field.ErrorList {
+	errs := validateBuildContext(b.Context, fldPath.Child("context"))
+
+	for i, ba := range b.BuildArgs {
+		errs = append(errs, validateBuildArg(ba, fldPath.Child("buildArgs").Index(i))...)
+	}
+
+	if kp := b.KanikoParams; kp != nil {
+		if kp.CacheRepo != "" {
+			if _, err := name.NewRepository(kp.CacheRepo); err != nil {
+				errs = append(errs, field.Invalid(fldPath.Child("kanikoParams", "cacheRepo"), kp.CacheRepo, fmt.Sprintf("invalid repository: %v", err)))
+			}
+		}
+
+		if ttl := kp.CacheTTL; ttl != nil && ttl.Duration < 0 {
+			errs = append(errs, field.Invalid(fldPath.Child("kanikoParams", "cacheTTL"), ttl.Duration.String(), "must not be negative"))
+		}
+	}
+
+	return errs
+}
+
+func validateBuildArg(ba kmmv1beta1.BuildArg, fldPath *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+
+	vf := ba.ValueFrom
+	if vf == nil {
+		return errs
+	}
+
+	if ba.Value != "" {
+		errs = append(errs, field.Invalid(fldPath, ba.Name, "only one of value or valueFrom can be set"))
+	}
+
+	switch {
+	case vf.ConfigMapKeyRef != nil && vf.SecretKeyRef != nil:
+		errs = append(errs, field.Invalid(fldPath.Child("valueFrom"), ba.Name, "only one of configMapKeyRef or secretKeyRef can be set"))
+	case vf.ConfigMapKeyRef != nil:
+		if vf.ConfigMapKeyRef.Name == "" {
+			errs = append(errs, field.Required(fldPath.Child("valueFrom", "configMapKeyRef", "name"), "the name of the ConfigMap is required"))
+		}
+	case vf.SecretKeyRef != nil:
+		if vf.SecretKeyRef.Name == "" {
+			errs = append(errs, field.Required(fldPath.Child("valueFrom", "secretKeyRef", "name"), "the name of the Secret is required"))
+		}
+
+		// the value is passed to the build through an environment variable of the same name
+		for _, msg := range validation.IsEnvVarName(ba.Name) {
+			errs = append(errs, field.Invalid(fldPath.Child("name"), ba.Name, msg))
+		}
+	default:
+		errs = append(errs, field.Required(fldPath.Child("valueFrom"), "one of configMapKeyRef or secretKeyRef must be set"))
+	}
+
+	return errs
+}
+
+func validateBuildContext(bc *kmmv1beta1.BuildContext, fldPath *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+
+	if bc == nil {
+		return errs
+	}
+
+	if g := bc.Git; g != nil {
+		errs = append(errs, validateHTTPURL(g.URL, fldPath.Child("git", "url"))...)
+
+		if g.CredentialsSecret != nil && g.CredentialsSecret.Name == "" {
+			errs = append(errs, field.Required(fldPath.Child("git", "credentialsSecret", "name"), "the name of the Secret is required"))
+		}
+	}
+
+	for i, src := range bc.ConfigMaps {
+		cmPath := fldPath.Child("configMaps").Index(i)
+
+		if src.Name == "" {
+			errs = append(errs, field.Required(cmPath.Child("name"), "the name of the ConfigMap is required"))
+		}
+
+		// the ConfigMap is mounted under the build context, next to the Dockerfile
+		if p := src.Path; p != "" {
+			clean := path.Clean(p)
+			if path.IsAbs(p) || clean == "." || clean == "Dockerfile" || clean == ".." || strings.HasPrefix(clean, "../") {
+				errs = append(errs, field.Invalid(cmPath.Child("path"), p, "must be a relative path inside the build context, other than Dockerfile"))
+			}
+		}
+	}
+
+	if a := bc.Archive; a != nil {
+		errs = append(errs, validateHTTPURL(a.URL, fldPath.Child("archive", "url"))...)
+
+		if a.SHA256 != "" && !sha256Regexp.MatchString(a.SHA256) {
+			errs = append(errs, field.Invalid(fldPath.Child("archive", "sha256"), a.SHA256, "must be 64 lowercase hexadecimal characters"))
+		}
+	}
+
+	return errs
+}
+
+func validateVersionRange(r kmmv1beta1.KernelVersionRange, fldPath *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+
+	if r.Min == "" && r.Max == "" {
+		return append(errs, field.Required(fldPath, "at least one of min or max must be set"))
+	}
+
+	if r.Min != "" && !module.ValidKernelVersion(r.Min) {
+		errs = append(errs, field.Invalid(fldPath.Child("min"), r.Min, "not a kernel version"))
+	}
+
+	if r.Max != "" && !module.ValidKernelVersion(r.Max) {
+		errs = append(errs, field.Invalid(fldPath.Child("max"), r.Max, "not a kernel version"))
+	}
+
+	if len(errs) == 0 && r.Min != "" && r.Max != "" && module.CompareKernelVersions(r.Min, r.Max) >= 0 {
+		errs = append(errs, field.Invalid(fldPath, r, "min must be older than max"))
+	}
+
+	return errs
+}
+
+// validateSign validates the signing configuration that results from merging the Module's defaults with the
+// mapping's own settings.
+func (w *ModuleWebhook) validateSign(modSpec kmmv1beta1.ModuleSpec, km kmmv1beta1.KernelMapping, kmPath *field.Path) field.ErrorList {
+	if modSpec.ModuleLoader.Container.Sign == nil && km.Sign == nil {
+		return nil
+	}
+
+	errs := field.ErrorList{}
+	signPath := kmPath.Child("sign")
+
+	signConfig := w.signHelper.GetRelevantSign(modSpec, km)
+
+	if signConfig.KeySecret == nil || signConfig.KeySecret.Name == "" {
+		errs = append(errs, field.Required(signPath.Child("keySecret"), "signing requires a key secret"))
+	}
+
+	if signConfig.CertSecret == nil || signConfig.CertSecret.Name == "" {
+		errs = append(errs, field.Required(signPath.Child("certSecret"), "signing requires a certificate secret"))
+	}
+
+	if signConfig.UnsignedImage == "" && w.buildHelper.GetRelevantBuild(modSpec, km) == nil {
+		errs = append(errs, field.Required(signPath.Child("unsignedImage"), "signing requires either an unsigned image or a build"))
+	}
+
+	return errs
+}
+
+func validateModprobe(modprobe kmmv1beta1.ModprobeSpec, fldPath *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+
+	if modprobe.RawArgs == nil {
+		switch {
+		case modprobe.ModuleName != "" && len(modprobe.Modules) > 0:
+			errs = append(errs, field.Invalid(fldPath.Child("moduleName"), modprobe.ModuleName, "moduleName and modules cannot be set at the same time"))
+		case modprobe.ModuleName == "" && len(modprobe.Modules) == 0:
+			errs = append(errs, field.Required(fldPath, "one of moduleName or modules must be set"))
+		}
+	}
+
+	names := make(map[string]bool, len(modprobe.Modules))
+
+	for i, m := range modprobe.Modules {
+		namePath := fldPath.Child("modules").Index(i).Child("name")
+
+		if m.Name == "" {
+			errs = append(errs, field.Required(namePath, "kernel module name cannot be empty"))
+			continue
+		}
+
+		if names[m.Name] {
+			errs = append(errs, field.Duplicate(namePath, m.Name))
+		}
+
+		names[m.Name] = true
+	}
+
+	return errs
+}
+
+func validateUpgradePolicy(policy *kmmv1beta1.UpgradePolicy, fldPath *field.Path) field.ErrorList {
+	if policy == nil || policy.MaxUnavailable == nil {
+		return nil
+	}
+
+	maxUnavailablePath := fldPath.Child("maxUnavailable")
+
+	value, err := intstr.GetScaledValueFromIntOrPercent(policy.MaxUnavailable, 100, true)
+	if err != nil {
+		return field.ErrorList{field.Invalid(maxUnavailablePath, policy.MaxUnavailable.String(), err.Error())}
+	}
+
+	if value < 0 {
+		return field.ErrorList{field.Invalid(maxUnavailablePath, policy.MaxUnavailable.String(), "must be greater than or equal to 0")}
+	}
+
+	return nil
+}
@@ -0,0 +1,727 @@
+package webhook
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/build"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/client"
+	"github.com/kubernetes-sigs/kernel-module-management/internal/sign"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ = Describe("ModuleWebhook_Default", func() {
+	w := NewModuleWebhook(nil, build.NewHelper(), sign.NewSignerHelper())
+
+	It("should set the default modprobe DirName", func() {
+		mod := &kmmv1beta1.Module{}
+
+		Expect(w.Default(context.Background(), mod)).To(Succeed())
+		Expect(mod.Spec.ModuleLoader.Container.Modprobe.DirName).To(Equal(DefaultModprobeDirName))
+	})
+
+	It("should not override an existing modprobe DirName", func() {
+		mod := &kmmv1beta1.Module{}
+		mod.Spec.ModuleLoader.Container.Modprobe.DirName = "/some/dir"
+
+		Expect(w.Default(context.Background(), mod)).To(Succeed())
+		Expect(mod.Spec.ModuleLoader.Container.Modprobe.DirName).To(Equal("/some/dir"))
+	})
+
+	It("should return an error for another type", func() {
+		Expect(w.Default(context.Background(), &kmmv1beta1.PreflightValidation{})).NotTo(Succeed())
+	})
+})
+
+var _ = Describe("ModuleWebhook_ValidateCreate", func() {
+	w := NewModuleWebhook(nil, build.NewHelper(), sign.NewSignerHelper())
+
+	keySecret := &v1.LocalObjectReference{Name: "key"}
+	certSecret := &v1.LocalObjectReference{Name: "cert"}
+
+	newModule := func(containerSpec kmmv1beta1.ModuleLoaderContainerSpec) *kmmv1beta1.Module {
+		if containerSpec.Modprobe.ModuleName == "" && len(containerSpec.Modprobe.Modules) == 0 {
+			containerSpec.Modprobe.ModuleName = "kmod"
+		}
+
+		if len(containerSpec.KernelMappings) == 0 && containerSpec.KernelMappingResolver == nil {
+			containerSpec.KernelMappingResolver = &kmmv1beta1.KernelMappingResolver{
+				ConfigMap: &v1.LocalObjectReference{Name: "catalog"},
+			}
+		}
+
+		return &kmmv1beta1.Module{
+			ObjectMeta: metav1.ObjectMeta{Name: "name", Namespace: "namespace"},
+			Spec: kmmv1beta1.ModuleSpec{
+				ModuleLoader: kmmv1beta1.ModuleLoaderSpec{Container: containerSpec},
+				Selector:     map[string]string{"some-label": "some-value"},
+			},
+		}
+	}
+
+	DescribeTable("should validate kernel mappings",
+		func(containerSpec kmmv1beta1.ModuleLoaderContainerSpec, expectedError string) {
+			err := w.ValidateCreate(context.Background(), newModule(containerSpec))
+
+			if expectedError == "" {
+				Expect(err).NotTo(HaveOccurred())
+				return
+			}
+
+			Expect(apierrors.IsInvalid(err)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring(expectedError))
+		},
+		Entry(
+			"valid literal",
+			kmmv1beta1.ModuleLoaderContainerSpec{
+				KernelMappings: []kmmv1beta1.KernelMapping{{Literal: "1.2.3", ContainerImage: "image"}},
+			},
+			"",
+		),
+		Entry(
+			"valid regexp",
+			kmmv1beta1.ModuleLoaderContainerSpec{
+				KernelMappings: []kmmv1beta1.KernelMapping{{Regexp: `^.+$`, ContainerImage: "image"}},
+			},
+			"",
+		),
+		Entry(
+			"invalid regexp",
+			kmmv1beta1.ModuleLoaderContainerSpec{
+				KernelMappings: []kmmv1beta1.KernelMapping{{Regexp: "(", ContainerImage: "image"}},
+			},
+			"spec.moduleLoader.container.kernelMappings[0].regexp",
+		),
+		Entry(
+			"literal and regexp",
+			kmmv1beta1.ModuleLoaderContainerSpec{
+				KernelMappings: []kmmv1beta1.KernelMapping{{Literal: "1.2.3", Regexp: `^.+$`, ContainerImage: "image"}},
+			},
+			"only one of literal, regexp, versionRange or fallback can be set",
+		),
+		Entry(
+			"neither literal nor regexp",
+			kmmv1beta1.ModuleLoaderContainerSpec{
+				KernelMappings: []kmmv1beta1.KernelMapping{{ContainerImage: "image"}},
+			},
+			"one of literal, regexp, versionRange or fallback must be set",
+		),
+		Entry(
+			"valid version range",
+			kmmv1beta1.ModuleLoaderContainerSpec{
+				KernelMappings: []kmmv1beta1.KernelMapping{
+					{
+						VersionRange:   &kmmv1beta1.KernelVersionRange{Min: "5.14.0-284", Max: "5.14.0-362"},
+						Exclude:        []string{"5.14.0-300.el9.x86_64"},
+						ContainerImage: "image",
+					},
+					{Fallback: true, ContainerImage: "image"},
+				},
+			},
+			"",
+		),
+		Entry(
+			"empty version range",
+			kmmv1beta1.ModuleLoaderContainerSpec{
+				KernelMappings: []kmmv1beta1.KernelMapping{{VersionRange: &kmmv1beta1.KernelVersionRange{}, ContainerImage: "image"}},
+			},
+			"at least one of min or max must be set",
+		),
+		Entry(
+			"invalid version in range",
+			kmmv1beta1.ModuleLoaderContainerSpec{
+				KernelMappings: []kmmv1beta1.KernelMapping{{VersionRange: &kmmv1beta1.KernelVersionRange{Min: "latest"}, ContainerImage: "image"}},
+			},
+			"spec.moduleLoader.container.kernelMappings[0].versionRange.min",
+		),
+		Entry(
+			"reversed version range",
+			kmmv1beta1.ModuleLoaderContainerSpec{
+				KernelMappings: []kmmv1beta1.KernelMapping{
+					{VersionRange: &kmmv1beta1.KernelVersionRange{Min: "5.14.0-362", Max: "5.14.0-284"}, ContainerImage: "image"},
+				},
+			},
+			"min must be older than max",
+		),
+		Entry(
+			"literal and version range",
+			kmmv1beta1.ModuleLoaderContainerSpec{
+				KernelMappings: []kmmv1beta1.KernelMapping{
+					{Literal: "1.2.3", VersionRange: &kmmv1beta1.KernelVersionRange{Min: "1.2.3"}, ContainerImage: "image"},
+				},
+			},
+			"only one of literal, regexp, versionRange or fallback can be set",
+		),
+		Entry(
+			"invalid OS image regexp",
+			kmmv1beta1.ModuleLoaderContainerSpec{
+				KernelMappings: []kmmv1beta1.KernelMapping{{Regexp: "valid", OSImage: "invalid)", ContainerImage: "image"}},
+			},
+			"spec.moduleLoader.container.kernelMappings[0].osImage",
+		),
+		Entry(
+			"one fallback per architecture",
+			kmmv1beta1.ModuleLoaderContainerSpec{
+				KernelMappings: []kmmv1beta1.KernelMapping{
+					{Fallback: true, Architecture: "amd64", ContainerImage: "image"},
+					{Fallback: true, Architecture: "arm64", ContainerImage: "image"},
+				},
+			},
+			"",
+		),
+		Entry(
+			"several fallbacks for the same architecture",
+			kmmv1beta1.ModuleLoaderContainerSpec{
+				KernelMappings: []kmmv1beta1.KernelMapping{
+					{Fallback: true, Architecture: "arm64", ContainerImage: "image"},
+					{Fallback: true, Architecture: "aarch64", ContainerImage: "image"},
+				},
+			},
+			"only one mapping can be a fallback for the same architecture and OS image",
+		),
+		Entry(
+			"several fallbacks",
+			kmmv1beta1.ModuleLoaderContainerSpec{
+				KernelMappings: []kmmv1beta1.KernelMapping{
+					{Fallback: true, ContainerImage: "image"},
+					{Fallback: true, ContainerImage: "image"},
+				},
+			},
+			"only one mapping can be a fallback for the same architecture and OS image",
+		),
+		Entry(
+			"sign without key secret",
+			kmmv1beta1.ModuleLoaderContainerSpec{
+				KernelMappings: []kmmv1beta1.KernelMapping{
+					{
+						Literal:        "1.2.3",
+						ContainerImage: "image",
+						Sign:           &kmmv1beta1.Sign{UnsignedImage: "unsigned", CertSecret: certSecret},
+					},
+				},
+			},
+			"spec.moduleLoader.container.kernelMappings[0].sign.keySecret",
+		),
+		Entry(
+			"sign without unsigned image or build",
+			kmmv1beta1.ModuleLoaderContainerSpec{
+				KernelMappings: []kmmv1beta1.KernelMapping{
+					{
+						Literal:        "1.2.3",
+						ContainerImage: "image",
+						Sign:           &kmmv1beta1.Sign{KeySecret: keySecret, CertSecret: certSecret},
+					},
+				},
+			},
+			"spec.moduleLoader.container.kernelMappings[0].sign.unsignedImage",
+		),
+		Entry(
+			"sign with a build from the module",
+			kmmv1beta1.ModuleLoaderContainerSpec{
+				Build: &kmmv1beta1.Build{},
+				KernelMappings: []kmmv1beta1.KernelMapping{
+					{
+						Literal:        "1.2.3",
+						ContainerImage: "image",
+						Sign:           &kmmv1beta1.Sign{KeySecret: keySecret, CertSecret: certSecret},
+					},
+				},
+			},
+			"",
+		),
+		Entry(
+			"secrets from the module's sign and unsigned image from the mapping",
+			kmmv1beta1.ModuleLoaderContainerSpec{
+				Sign: &kmmv1beta1.Sign{KeySecret: keySecret, CertSecret: certSecret},
+				KernelMappings: []kmmv1beta1.KernelMapping{
+					{
+						Literal:        "1.2.3",
+						ContainerImage: "image",
+						Sign:           &kmmv1beta1.Sign{UnsignedImage: "unsigned"},
+					},
+				},
+			},
+			"",
+		),
+		Entry(
+			"module sign without unsigned image applied to a mapping without build",
+			kmmv1beta1.ModuleLoaderContainerSpec{
+				Sign: &kmmv1beta1.Sign{KeySecret: keySecret, CertSecret: certSecret},
+				KernelMappings: []kmmv1beta1.KernelMapping{
+					{Literal: "1.2.3", ContainerImage: "image"},
+				},
+			},
+			"signing requires either an unsigned image or a build",
+		),
+	)
+
+	DescribeTable("should validate modprobe",
+		func(modprobe kmmv1beta1.ModprobeSpec, expectedError string) {
+			mod := newModule(kmmv1beta1.ModuleLoaderContainerSpec{})
+			mod.Spec.ModuleLoader.Container.Modprobe = modprobe
+
+			err := w.ValidateCreate(context.Background(), mod)
+
+			if expectedError == "" {
+				Expect(err).NotTo(HaveOccurred())
+				return
+			}
+
+			Expect(apierrors.IsInvalid(err)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring(expectedError))
+		},
+		Entry("module name", kmmv1beta1.ModprobeSpec{ModuleName: "kmod"}, ""),
+		Entry(
+			"several modules",
+			kmmv1beta1.ModprobeSpec{Modules: []kmmv1beta1.ModprobeModule{{Name: "kmod-a"}, {Name: "kmod-b"}}},
+			"",
+		),
+		Entry("raw arguments only", kmmv1beta1.ModprobeSpec{RawArgs: &kmmv1beta1.ModprobeArgs{Load: []string{"kmod"}}}, ""),
+		Entry("nothing to load", kmmv1beta1.ModprobeSpec{}, "one of moduleName or modules must be set"),
+		Entry(
+			"module name and modules",
+			kmmv1beta1.ModprobeSpec{ModuleName: "kmod", Modules: []kmmv1beta1.ModprobeModule{{Name: "kmod-a"}}},
+			"moduleName and modules cannot be set at the same time",
+		),
+		Entry(
+			"module without a name",
+			kmmv1beta1.ModprobeSpec{Modules: []kmmv1beta1.ModprobeModule{{Name: "kmod-a"}, {}}},
+			"spec.moduleLoader.container.modprobe.modules[1].name",
+		),
+		Entry(
+			"duplicate modules",
+			kmmv1beta1.ModprobeSpec{Modules: []kmmv1beta1.ModprobeModule{{Name: "kmod-a"}, {Name: "kmod-a"}}},
+			"Duplicate value",
+		),
+	)
+
+	DescribeTable("should validate the kernel mapping resolver",
+		func(resolver *kmmv1beta1.KernelMappingResolver, expectedError string) {
+			mod := newModule(kmmv1beta1.ModuleLoaderContainerSpec{})
+			mod.Spec.ModuleLoader.Container.KernelMappingResolver = resolver
+
+			err := w.ValidateCreate(context.Background(), mod)
+
+			if expectedError == "" {
+				Expect(err).NotTo(HaveOccurred())
+				return
+			}
+
+			Expect(apierrors.IsInvalid(err)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring(expectedError))
+		},
+		Entry("no mappings and no resolver", nil, "spec.moduleLoader.container.kernelMappings: Required value"),
+		Entry(
+			"ConfigMap",
+			&kmmv1beta1.KernelMappingResolver{ConfigMap: &v1.LocalObjectReference{Name: "catalog"}},
+			"",
+		),
+		Entry(
+			"HTTP",
+			&kmmv1beta1.KernelMappingResolver{
+				HTTP: &kmmv1beta1.HTTPKernelMappingResolver{
+					URL:      "https://resolver.example.com/mappings",
+					CacheTTL: &metav1.Duration{Duration: time.Minute},
+				},
+			},
+			"",
+		),
+		Entry("empty", &kmmv1beta1.KernelMappingResolver{}, "one of configMap or http must be set"),
+		Entry(
+			"both",
+			&kmmv1beta1.KernelMappingResolver{
+				ConfigMap: &v1.LocalObjectReference{Name: "catalog"},
+				HTTP:      &kmmv1beta1.HTTPKernelMappingResolver{URL: "https://resolver.example.com"},
+			},
+			"only one of configMap or http can be set",
+		),
+		Entry(
+			"ConfigMap without name",
+			&kmmv1beta1.KernelMappingResolver{ConfigMap: &v1.LocalObjectReference{}},
+			"spec.moduleLoader.container.kernelMappingResolver.configMap.name",
+		),
+		Entry(
+			"relative URL",
+			&kmmv1beta1.KernelMappingResolver{HTTP: &kmmv1beta1.HTTPKernelMappingResolver{URL: "/mappings"}},
+			"must be an absolute http or https URL",
+		),
+		Entry(
+			"unsupported scheme",
+			&kmmv1beta1.KernelMappingResolver{HTTP: &kmmv1beta1.HTTPKernelMappingResolver{URL: "grpc://resolver:9000"}},
+			"must be an absolute http or https URL",
+		),
+		Entry(
+			"negative TTL",
+			&kmmv1beta1.KernelMappingResolver{
+				HTTP: &kmmv1beta1.HTTPKernelMappingResolver{
+					URL:      "http://resolver",
+					CacheTTL: &metav1.Duration{Duration: -time.Minute},
+				},
+			},
+			"spec.moduleLoader.container.kernelMappingResolver.http.cacheTTL",
+		),
+	)
+
+	DescribeTable("should validate the build context",
+		func(bc *kmmv1beta1.BuildContext, expectedError string) {
+			mod := newModule(kmmv1beta1.ModuleLoaderContainerSpec{
+				Build: &kmmv1beta1.Build{
+					DockerfileConfigMap: &v1.LocalObjectReference{Name: "dockerfile"},
+					Context:             bc,
+				},
+			})
+
+			err := w.ValidateCreate(context.Background(), mod)
+
+			if expectedError == "" {
+				Expect(err).NotTo(HaveOccurred())
+				return
+			}
+
+			Expect(apierrors.IsInvalid(err)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring(expectedError))
+		},
+		Entry(
+			"all sources",
+			&kmmv1beta1.BuildContext{
+				Git: &kmmv1beta1.GitBuildSource{
+					URL:               "https://git.example.com/repo.git",
+					Ref:               "v1.0",
+					CredentialsSecret: &v1.LocalObjectReference{Name: "git-credentials"},
+				},
+				ConfigMaps: []kmmv1beta1.ConfigMapBuildSource{{Name: "patches"}, {Name: "kbuild", Path: "src/kbuild"}},
+				Archive: &kmmv1beta1.ArchiveBuildSource{
+					URL:    "https://bucket.example.com/src.tar.gz",
+					SHA256: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+				},
+			},
+			"",
+		),
+		Entry(
+			"SSH repository",
+			&kmmv1beta1.BuildContext{Git: &kmmv1beta1.GitBuildSource{URL: "git@git.example.com:repo.git"}},
+			"spec.moduleLoader.container.build.context.git.url",
+		),
+		Entry(
+			"ConfigMap without name",
+			&kmmv1beta1.BuildContext{ConfigMaps: []kmmv1beta1.ConfigMapBuildSource{{Path: "patches"}}},
+			"spec.moduleLoader.container.build.context.configMaps[0].name",
+		),
+		Entry(
+			"ConfigMap outside of the build context",
+			&kmmv1beta1.BuildContext{ConfigMaps: []kmmv1beta1.ConfigMapBuildSource{{Name: "patches", Path: "../patches"}}},
+			"spec.moduleLoader.container.build.context.configMaps[0].path",
+		),
+		Entry(
+			"ConfigMap replacing the Dockerfile",
+			&kmmv1beta1.BuildContext{ConfigMaps: []kmmv1beta1.ConfigMapBuildSource{{Name: "patches", Path: "./Dockerfile"}}},
+			"spec.moduleLoader.container.build.context.configMaps[0].path",
+		),
+		Entry(
+			"invalid checksum",
+			&kmmv1beta1.BuildContext{Archive: &kmmv1beta1.ArchiveBuildSource{URL: "https://example.com/src.tar.gz", SHA256: "abc"}},
+			"spec.moduleLoader.container.build.context.archive.sha256",
+		),
+	)
+
+	DescribeTable("should validate the Kaniko cache",
+		func(kp *kmmv1beta1.KanikoParams, expectedError string) {
+			mod := newModule(kmmv1beta1.ModuleLoaderContainerSpec{
+				Build: &kmmv1beta1.Build{
+					DockerfileConfigMap: &v1.LocalObjectReference{Name: "dockerfile"},
+					KanikoParams:        kp,
+				},
+			})
+
+			err := w.ValidateCreate(context.Background(), mod)
+
+			if expectedError == "" {
+				Expect(err).NotTo(HaveOccurred())
+				return
+			}
+
+			Expect(apierrors.IsInvalid(err)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring(expectedError))
+		},
+		Entry(
+			"valid",
+			&kmmv1beta1.KanikoParams{CacheRepo: "registry.example.com/cache", CacheTTL: &metav1.Duration{Duration: time.Hour}},
+			"",
+		),
+		Entry(
+			"invalid repository",
+			&kmmv1beta1.KanikoParams{CacheRepo: "registry.example.com/Cache:latest"},
+			"spec.moduleLoader.container.build.kanikoParams.cacheRepo",
+		),
+		Entry(
+			"negative TTL",
+			&kmmv1beta1.KanikoParams{CacheTTL: &metav1.Duration{Duration: -time.Hour}},
+			"spec.moduleLoader.container.build.kanikoParams.cacheTTL",
+		),
+	)
+
+	DescribeTable("should validate the build arguments",
+		func(ba kmmv1beta1.BuildArg, expectedError string) {
+			mod := newModule(kmmv1beta1.ModuleLoaderContainerSpec{
+				Build: &kmmv1beta1.Build{
+					BuildArgs:           []kmmv1beta1.BuildArg{{Name: "SOME_ARG", Value: "some-value"}, ba},
+					DockerfileConfigMap: &v1.LocalObjectReference{Name: "dockerfile"},
+				},
+			})
+
+			err := w.ValidateCreate(context.Background(), mod)
+
+			if expectedError == "" {
+				Expect(err).NotTo(HaveOccurred())
+				return
+			}
+
+			Expect(apierrors.IsInvalid(err)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring(expectedError))
+		},
+		Entry(
+			"ConfigMap key",
+			kmmv1beta1.BuildArg{
+				Name: "MIRROR_URL",
+				ValueFrom: &kmmv1beta1.BuildArgSource{
+					ConfigMapKeyRef: &v1.ConfigMapKeySelector{LocalObjectReference: v1.LocalObjectReference{Name: "mirrors"}, Key: "url"},
+				},
+			},
+			"",
+		),
+		Entry(
+			"Secret key",
+			kmmv1beta1.BuildArg{
+				Name: "LICENSE_KEY",
+				ValueFrom: &kmmv1beta1.BuildArgSource{
+					SecretKeyRef: &v1.SecretKeySelector{LocalObjectReference: v1.LocalObjectReference{Name: "license"}, Key: "key"},
+				},
+			},
+			"",
+		),
+		Entry(
+			"value and valueFrom",
+			kmmv1beta1.BuildArg{
+				Name:  "MIRROR_URL",
+				Value: "https://mirror.example.com",
+				ValueFrom: &kmmv1beta1.BuildArgSource{
+					ConfigMapKeyRef: &v1.ConfigMapKeySelector{LocalObjectReference: v1.LocalObjectReference{Name: "mirrors"}, Key: "url"},
+				},
+			},
+			"spec.moduleLoader.container.build.buildArgs[1]",
+		),
+		Entry(
+			"empty valueFrom",
+			kmmv1beta1.BuildArg{Name: "MIRROR_URL", ValueFrom: &kmmv1beta1.BuildArgSource{}},
+			"spec.moduleLoader.container.build.buildArgs[1].valueFrom",
+		),
+		Entry(
+			"ConfigMap without name",
+			kmmv1beta1.BuildArg{
+				Name:      "MIRROR_URL",
+				ValueFrom: &kmmv1beta1.BuildArgSource{ConfigMapKeyRef: &v1.ConfigMapKeySelector{Key: "url"}},
+			},
+			"spec.moduleLoader.container.build.buildArgs[1].valueFrom.configMapKeyRef.name",
+		),
+		Entry(
+			"Secret key with an invalid environment variable name",
+			kmmv1beta1.BuildArg{
+				Name: "LICENSE=KEY",
+				ValueFrom: &kmmv1beta1.BuildArgSource{
+					SecretKeyRef: &v1.SecretKeySelector{LocalObjectReference: v1.LocalObjectReference{Name: "license"}, Key: "key"},
+				},
+			},
+			"spec.moduleLoader.container.build.buildArgs[1].name",
+		),
+	)
+
+	It("should validate the build context of kernel mappings", func() {
+		mod := newModule(kmmv1beta1.ModuleLoaderContainerSpec{
+			KernelMappings: []kmmv1beta1.KernelMapping{
+				{
+					Literal: "1.2.3",
+					Build: &kmmv1beta1.Build{
+						DockerfileConfigMap: &v1.LocalObjectReference{Name: "dockerfile"},
+						Context:             &kmmv1beta1.BuildContext{Archive: &kmmv1beta1.ArchiveBuildSource{URL: "s3://bucket/src.tar.gz"}},
+					},
+				},
+			},
+		})
+
+		err := w.ValidateCreate(context.Background(), mod)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("spec.moduleLoader.container.kernelMappings[0].build.context.archive.url"))
+	})
+
+	It("should reject a Module depending on itself", func() {
+		mod := newModule(kmmv1beta1.ModuleLoaderContainerSpec{})
+		mod.Spec.Dependencies = []string{"other", mod.Name}
+
+		err := w.ValidateCreate(context.Background(), mod)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.dependencies[1]"))
+	})
+
+	It("should reject a Module whose dependencies depend on it", func() {
+		ctrl := gomock.NewController(GinkgoT())
+		clnt := client.NewMockClient(ctrl)
+
+		mod := newModule(kmmv1beta1.ModuleLoaderContainerSpec{})
+		mod.Spec.Dependencies = []string{"a", "c"}
+
+		clnt.
+			EXPECT().
+			List(context.Background(), &kmmv1beta1.ModuleList{}, ctrlclient.InNamespace(mod.Namespace)).
+			DoAndReturn(func(_ interface{}, list *kmmv1beta1.ModuleList, _ ...ctrlclient.ListOption) error {
+				list.Items = []kmmv1beta1.Module{
+					{
+						ObjectMeta: metav1.ObjectMeta{Name: "a"},
+						Spec:       kmmv1beta1.ModuleSpec{Dependencies: []string{"b"}},
+					},
+					{
+						ObjectMeta: metav1.ObjectMeta{Name: "b"},
+						Spec:       kmmv1beta1.ModuleSpec{Dependencies: []string{mod.Name}},
+					},
+					{ObjectMeta: metav1.ObjectMeta{Name: "c"}},
+				}
+				return nil
+			})
+
+		err := NewModuleWebhook(clnt, build.NewHelper(), sign.NewSignerHelper()).ValidateCreate(context.Background(), mod)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.dependencies[0]"))
+		Expect(err.Error()).To(ContainSubstring("dependency cycle: name -> a -> b -> name"))
+		Expect(err.Error()).NotTo(ContainSubstring("spec.dependencies[1]"))
+	})
+
+	It("should accept dependencies without cycles", func() {
+		ctrl := gomock.NewController(GinkgoT())
+		clnt := client.NewMockClient(ctrl)
+
+		mod := newModule(kmmv1beta1.ModuleLoaderContainerSpec{})
+		mod.Spec.Dependencies = []string{"a"}
+
+		clnt.
+			EXPECT().
+			List(context.Background(), &kmmv1beta1.ModuleList{}, ctrlclient.InNamespace(mod.Namespace)).
+			DoAndReturn(func(_ interface{}, list *kmmv1beta1.ModuleList, _ ...ctrlclient.ListOption) error {
+				list.Items = []kmmv1beta1.Module{
+					{
+						ObjectMeta: metav1.ObjectMeta{Name: "a"},
+						Spec:       kmmv1beta1.ModuleSpec{Dependencies: []string{"b"}},
+					},
+					{
+						ObjectMeta: metav1.ObjectMeta{Name: "b"},
+						Spec:       kmmv1beta1.ModuleSpec{Dependencies: []string{"a"}},
+					},
+					// the Module being validated replaces its previous version
+					{
+						ObjectMeta: metav1.ObjectMeta{Name: mod.Name},
+						Spec:       kmmv1beta1.ModuleSpec{Dependencies: []string{mod.Name}},
+					},
+				}
+				return nil
+			})
+
+		Expect(
+			NewModuleWebhook(clnt, build.NewHelper(), sign.NewSignerHelper()).ValidateCreate(context.Background(), mod),
+		).To(
+			Succeed(),
+		)
+	})
+
+	It("should reject a version that is not a valid label value", func() {
+		mod := newModule(kmmv1beta1.ModuleLoaderContainerSpec{Version: "not a label value"})
+
+		err := w.ValidateCreate(context.Background(), mod)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.moduleLoader.container.version"))
+	})
+
+	It("should reject a versioned Module whose namespace and name are too long for the node labels", func() {
+		mod := newModule(kmmv1beta1.ModuleLoaderContainerSpec{Version: "v1"})
+		mod.Namespace = strings.Repeat("n", 30)
+		mod.Name = strings.Repeat("m", 20)
+
+		err := w.ValidateCreate(context.Background(), mod)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("namespace and name too long"))
+
+		mod.Spec.ModuleLoader.Container.Version = ""
+
+		Expect(w.ValidateCreate(context.Background(), mod)).To(Succeed())
+	})
+
+	It("should reject an invalid label selector", func() {
+		mod := newModule(kmmv1beta1.ModuleLoaderContainerSpec{})
+		mod.Spec.LabelSelector = &metav1.LabelSelector{
+			MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: "some-key", Operator: metav1.LabelSelectorOpIn},
+			},
+		}
+
+		err := w.ValidateCreate(context.Background(), mod)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.labelSelector.matchExpressions[0].values"))
+	})
+
+	It("should require a selector", func() {
+		mod := newModule(kmmv1beta1.ModuleLoaderContainerSpec{})
+		mod.Spec.Selector = nil
+
+		err := w.ValidateCreate(context.Background(), mod)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.selector"))
+
+		mod.Spec.LabelSelector = &metav1.LabelSelector{
+			MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: "some-key", Operator: metav1.LabelSelectorOpExists},
+			},
+		}
+
+		Expect(w.ValidateCreate(context.Background(), mod)).To(Succeed())
+	})
+
+	DescribeTable("should validate the upgrade policy",
+		func(maxUnavailable intstr.IntOrString, expectedError string) {
+			mod := newModule(kmmv1beta1.ModuleLoaderContainerSpec{})
+			mod.Spec.ModuleLoader.UpgradePolicy = &kmmv1beta1.UpgradePolicy{MaxUnavailable: &maxUnavailable}
+
+			err := w.ValidateCreate(context.Background(), mod)
+
+			if expectedError == "" {
+				Expect(err).NotTo(HaveOccurred())
+				return
+			}
+
+			Expect(apierrors.IsInvalid(err)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring(expectedError))
+		},
+		Entry("absolute number", intstr.FromInt(2), ""),
+		Entry("percentage", intstr.FromString("25%"), ""),
+		Entry("negative number", intstr.FromInt(-1), "spec.moduleLoader.upgradePolicy.maxUnavailable"),
+		Entry("invalid string", intstr.FromString("abc"), "spec.moduleLoader.upgradePolicy.maxUnavailable"),
+	)
+
+	It("should validate the new object on update", func() {
+		oldMod := newModule(kmmv1beta1.ModuleLoaderContainerSpec{})
+		newMod := newModule(kmmv1beta1.ModuleLoaderContainerSpec{
+			KernelMappings: []kmmv1beta1.KernelMapping{{ContainerImage: "image"}},
+		})
+
+		Expect(w.ValidateUpdate(context.Background(), oldMod, newMod)).NotTo(Succeed())
+	})
+
+	It("should always allow deletion", func() {
+		Expect(w.ValidateDelete(context.Background(), &kmmv1beta1.Module{})).To(Succeed())
+	})
+})